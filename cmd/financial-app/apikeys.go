@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"financial-app/pkg/auth"
+	"financial-app/pkg/postgres"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// apiKeysUsage describes the subcommands of the apikeys command
+const apiKeysUsage = `usage:
+  app apikeys create -name NAME -scopes SCOPE[,SCOPE...] [-customer ID]
+  app apikeys list
+  app apikeys revoke -id ID`
+
+// errAPIKeysUsage is returned when the apikeys command is misused
+var errAPIKeysUsage = errors.New(apiKeysUsage)
+
+// runAPIKeys runs the apikeys command, which creates, lists and revokes the
+// API keys. The token of a created key is written to out, and only once.
+func runAPIKeys(args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errAPIKeysUsage
+	}
+
+	logger, _ := zap.NewProduction()
+	defer logger.Sync() // flushes buffer, if any
+	log := logger.Sugar()
+
+	db, err := connectDB()
+	if err != nil {
+		log.Error("failed to connect to database")
+		return err
+	}
+	defer db.Close()
+
+	svc := auth.NewService(postgres.NewAPIKeyRepository(db.DB, log))
+	ctx := context.Background()
+
+	switch args[0] {
+	case "create":
+		return createAPIKey(ctx, svc, log, args[1:], out)
+	case "list":
+		return listAPIKeys(ctx, svc, out)
+	case "revoke":
+		return revokeAPIKey(ctx, svc, args[1:], out)
+	}
+	return errAPIKeysUsage
+}
+
+// createAPIKey creates an API key and writes its token
+func createAPIKey(
+	ctx context.Context, svc auth.Service, log *zap.SugaredLogger, args []string, out io.Writer,
+) error {
+	fs := flag.NewFlagSet("create", flag.ContinueOnError)
+	name := fs.String("name", "", "name of the client using the key")
+	list := fs.String("scopes", "", "comma separated scopes of the key")
+	customerID := fs.String("customer", "", "ID of the customer the key acts for, if any")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	scopes, err := auth.ParseScopes(*list)
+	if err != nil {
+		return err
+	}
+
+	key, token, err := svc.Create(ctx, auth.Key{
+		Name:       *name,
+		Scopes:     scopes,
+		CustomerID: *customerID,
+	})
+	if err != nil {
+		return err
+	}
+
+	// The token is written alone on stdout so that it can be captured by a
+	// script, the details of the key go to stderr with the logs
+	log.Infow("created API key", zap.String("key_id", key.ID), zap.String("prefix", key.Prefix))
+	_, err = fmt.Fprintln(out, token)
+	return err
+}
+
+// listAPIKeys writes a table of the API keys
+func listAPIKeys(ctx context.Context, svc auth.Service, out io.Writer) error {
+	keys, err := svc.LoadAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tPREFIX\tSCOPES\tCUSTOMER\tCREATED AT\tREVOKED AT")
+	for _, key := range keys {
+		scopes := make([]string, 0, len(key.Scopes))
+		for _, scope := range key.Scopes {
+			scopes = append(scopes, string(scope))
+		}
+		customer := "-"
+		if key.CustomerID != "" {
+			customer = key.CustomerID
+		}
+		revokedAt := "-"
+		if key.Revoked() {
+			revokedAt = key.RevokedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			key.ID, key.Name, key.Prefix, strings.Join(scopes, ","), customer,
+			key.CreatedAt.Format(time.RFC3339), revokedAt)
+	}
+	return w.Flush()
+}
+
+// revokeAPIKey revokes an API key for good
+func revokeAPIKey(ctx context.Context, svc auth.Service, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("revoke", flag.ContinueOnError)
+	id := fs.String("id", "", "ID of the key to revoke")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *id == "" {
+		return errAPIKeysUsage
+	}
+
+	if err := svc.Revoke(ctx, *id); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(out, "revoked API key %s\n", *id)
+	return err
+}
@@ -1,8 +1,15 @@
 package main
 
 import (
+	"context"
+	"financial-app/pkg/accounts"
+	"financial-app/pkg/currency"
+	"financial-app/pkg/fx"
+	"financial-app/pkg/holds"
 	"financial-app/pkg/http/rest"
+	"financial-app/pkg/idempotency"
 	"financial-app/pkg/postgres"
+	"financial-app/pkg/transactions"
 	"fmt"
 	"net/http"
 	"os"
@@ -24,6 +31,19 @@ const (
 	defaultDBTable       = "postgres"
 	defaultDBPort        = "5432"
 	defaultSSLMode       = "disable"
+	defaultFXQuoteTTL    = "30"
+	defaultCurrencies    = "EUR,USD"
+
+	defaultIdempotencyRetention       = "86400"
+	defaultIdempotencyCleanupInterval = "3600"
+	defaultBalanceSnapshotInterval    = "86400"
+	defaultApprovalTTL                = "86400"
+	defaultApprovalExpiryInterval     = "60"
+	defaultHoldTTL                    = "604800"
+	defaultHoldExpiryInterval         = "60"
+
+	// balanceSnapshotLag keeps the in-flight transfers out of the snapshots
+	balanceSnapshotLag = time.Minute
 )
 
 // run sets up our application
@@ -35,18 +55,16 @@ func run() error {
 
 	log.Info("setting up financial app")
 
-	// Setup the postgres DB
-	connectionString := fmt.Sprintf(
-		"host=%s port=%s user=%s dbname=%s password=%s sslmode=%s",
-		envString("DB_HOST", defaultDBHost),
-		envString("DB_PORT", defaultDBPort),
-		envString("DB_USERNAME", defaultDBName),
-		envString("DB_TABLE", defaultDBTable),
-		envString("DB_PASSWORD", defaultDBPassword),
-		envString("SSL_MODE", defaultSSLMode),
-	)
+	// Setup the currencies accepted by the API
+	if err := currency.SetSupported(
+		currency.ParseList(envString("SUPPORTED_CURRENCIES", defaultCurrencies)),
+	); err != nil {
+		log.Error("failed to set the supported currencies")
+		return err
+	}
 
-	db, err := sqlx.Connect("postgres", connectionString)
+	// Setup the postgres DB
+	db, err := connectDB()
 	if err != nil {
 		log.Error("failed to connect to database")
 		return err
@@ -56,9 +74,116 @@ func run() error {
 	accountRepo := postgres.NewAccountRepository(db.DB, log)
 	transactionRepo := postgres.NewTransactionRepository(db.DB, log)
 	healthRepo := postgres.NewHealthcheckRepository(db.DB, log)
+	quoteRepo := postgres.NewQuoteRepository(db.DB, log)
+	idempotencyRepo := postgres.NewIdempotencyRepository(db.DB, log)
+	ledgerRepo := postgres.NewLedgerRepository(db.DB, log)
+	apiKeyRepo := postgres.NewAPIKeyRepository(db.DB, log)
+	customerRepo := postgres.NewCustomerRepository(db.DB, log)
+	roleRepo := postgres.NewRoleRepository(db.DB, log)
+	holdRepo := postgres.NewHoldRepository(db.DB, log)
+
+	// Setup the exchange rates
+	rates, err := loadRates(envString("FX_RATES_FILE", ""))
+	if err != nil {
+		log.Error("failed to load the exchange rates")
+		return err
+	}
+
+	// Get the time to live of the fx quotes in seconds
+	quoteTTL, err := strconv.ParseInt(envString("FX_QUOTE_TTL", defaultFXQuoteTTL), 10, 0)
+	if err != nil {
+		log.Error("failed to parse the fx quote time to live")
+		return err
+	}
+
+	// Get the retention window of the idempotency keys in seconds
+	retention, err := strconv.ParseInt(
+		envString("IDEMPOTENCY_RETENTION", defaultIdempotencyRetention), 10, 0)
+	if err != nil {
+		log.Error("failed to parse the idempotency key retention")
+		return err
+	}
+	cleanupInterval, err := strconv.ParseInt(
+		envString("IDEMPOTENCY_CLEANUP_INTERVAL", defaultIdempotencyCleanupInterval), 10, 0)
+	if err != nil {
+		log.Error("failed to parse the idempotency key cleanup interval")
+		return err
+	}
+
+	snapshotInterval, err := strconv.ParseInt(
+		envString("BALANCE_SNAPSHOT_INTERVAL", defaultBalanceSnapshotInterval), 10, 0)
+	if err != nil {
+		log.Error("failed to parse the balance snapshot interval")
+		return err
+	}
+
+	// Get the transfer amounts by currency above which a second principal must
+	// approve, and how long the approvals wait in seconds
+	thresholds, err := transactions.ParseThresholds(envString("APPROVAL_THRESHOLDS", ""))
+	if err != nil {
+		log.Error("failed to parse the approval thresholds")
+		return err
+	}
+	approvalTTL, err := strconv.ParseInt(envString("APPROVAL_TTL", defaultApprovalTTL), 10, 0)
+	if err != nil {
+		log.Error("failed to parse the approval time to live")
+		return err
+	}
+	expiryInterval, err := strconv.ParseInt(
+		envString("APPROVAL_EXPIRY_INTERVAL", defaultApprovalExpiryInterval), 10, 0)
+	if err != nil {
+		log.Error("failed to parse the approval expiry interval")
+		return err
+	}
+
+	// Get how long the holds last in seconds unless placed with an expiry
+	holdTTL, err := strconv.ParseInt(envString("HOLD_TTL", defaultHoldTTL), 10, 0)
+	if err != nil {
+		log.Error("failed to parse the hold time to live")
+		return err
+	}
+	holdExpiryInterval, err := strconv.ParseInt(
+		envString("HOLD_EXPIRY_INTERVAL", defaultHoldExpiryInterval), 10, 0)
+	if err != nil {
+		log.Error("failed to parse the hold expiry interval")
+		return err
+	}
 
 	// Setup the server
-	srv := rest.NewServer(accountRepo, transactionRepo, healthRepo, log)
+	srv := rest.NewServer(
+		accountRepo, transactionRepo, healthRepo, quoteRepo, idempotencyRepo, ledgerRepo,
+		apiKeyRepo, customerRepo, roleRepo, holdRepo, rates,
+		time.Duration(quoteTTL)*time.Second, time.Duration(retention)*time.Second,
+		transactions.ApprovalPolicy{
+			Thresholds: thresholds,
+			TTL:        time.Duration(approvalTTL) * time.Second,
+		},
+		time.Duration(holdTTL)*time.Second,
+		log,
+	)
+
+	// Remove the expired idempotency keys in the background
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go idempotency.RunCleanup(
+		ctx, srv.IdempotencyService, time.Duration(cleanupInterval)*time.Second, log,
+	)
+
+	// Snapshot the balances in the background to keep balance queries fast
+	go accounts.RunSnapshots(
+		ctx, srv.AccountService, time.Duration(snapshotInterval)*time.Second,
+		balanceSnapshotLag, log,
+	)
+
+	// Expire the overdue approvals in the background to release their holds
+	go transactions.RunExpiry(
+		ctx, srv.TransactionService, time.Duration(expiryInterval)*time.Second, log,
+	)
+
+	// Expire the overdue holds in the background to release their amounts
+	go holds.RunExpiry(
+		ctx, srv.HoldService, time.Duration(holdExpiryInterval)*time.Second, log,
+	)
 
 	// Run the server
 	serverConfig, err := loadServerSettings(srv)
@@ -76,6 +201,30 @@ func run() error {
 	return nil
 }
 
+// connectDB connects to the postgres DB set by the environment variables
+func connectDB() (*sqlx.DB, error) {
+	connectionString := fmt.Sprintf(
+		"host=%s port=%s user=%s dbname=%s password=%s sslmode=%s",
+		envString("DB_HOST", defaultDBHost),
+		envString("DB_PORT", defaultDBPort),
+		envString("DB_USERNAME", defaultDBName),
+		envString("DB_TABLE", defaultDBTable),
+		envString("DB_PASSWORD", defaultDBPassword),
+		envString("SSL_MODE", defaultSSLMode),
+	)
+
+	return sqlx.Connect("postgres", connectionString)
+}
+
+// loadRates loads the exchange rate table from the given file. Without a file
+// no rates are quoted and cross-currency transfers are rejected.
+func loadRates(path string) (*fx.StaticProvider, error) {
+	if path == "" {
+		return fx.NewStaticProvider(nil, time.Now().UTC())
+	}
+	return fx.LoadFile(path)
+}
+
 func loadServerSettings(srv *rest.Server) (*http.Server, error) {
 	// Get the timeouts from the enviroment variable
 	rwTimeout, err := strconv.ParseInt(envString("RW_TIMEOUT", defaultRWTimeout), 10, 0)
@@ -111,6 +260,15 @@ func envString(env, fallback string) string {
 }
 
 func main() {
+	// The apikeys command manages the API keys instead of serving the API
+	if len(os.Args) > 1 && os.Args[1] == "apikeys" {
+		if err := runAPIKeys(os.Args[2:], os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	if err := run(); err != nil {
 		zap.S().Error(err)
 		zap.S().Panic("Error starting up financial app")
@@ -3,7 +3,6 @@ module financial-app
 go 1.20
 
 require (
-	github.com/allisson/go-pglock/v3 v3.0.0
 	github.com/gin-contrib/cors v1.4.0
 	github.com/gin-contrib/timeout v0.0.3
 	github.com/gin-gonic/gin v1.9.1
@@ -0,0 +1,3 @@
+ALTER TABLE transactions
+    DROP COLUMN IF EXISTS source_currency,
+    DROP COLUMN IF EXISTS target_currency;
@@ -0,0 +1,7 @@
+ALTER TABLE transactions
+    ADD COLUMN IF NOT EXISTS source_currency TEXT,
+    ADD COLUMN IF NOT EXISTS target_currency TEXT;
+
+UPDATE transactions
+SET source_currency = currency, target_currency = currency
+WHERE source_currency IS NULL OR target_currency IS NULL;
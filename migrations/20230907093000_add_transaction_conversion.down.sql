@@ -0,0 +1,4 @@
+ALTER TABLE transactions
+    DROP COLUMN IF EXISTS target_amount,
+    DROP COLUMN IF EXISTS rate,
+    DROP COLUMN IF EXISTS rate_timestamp;
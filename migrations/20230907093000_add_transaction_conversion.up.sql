@@ -0,0 +1,6 @@
+ALTER TABLE transactions
+    ADD COLUMN IF NOT EXISTS target_amount NUMERIC(8, 2),
+    ADD COLUMN IF NOT EXISTS rate NUMERIC(24, 10),
+    ADD COLUMN IF NOT EXISTS rate_timestamp TIMESTAMPTZ;
+
+UPDATE transactions SET target_amount = amount WHERE target_amount IS NULL;
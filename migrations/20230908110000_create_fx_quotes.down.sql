@@ -0,0 +1,4 @@
+ALTER TABLE transactions
+    DROP COLUMN IF EXISTS quote_id;
+
+DROP TABLE IF EXISTS fx_quotes;
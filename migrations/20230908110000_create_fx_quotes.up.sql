@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS fx_quotes (
+    id uuid PRIMARY KEY,
+    source_currency TEXT NOT NULL,
+    target_currency TEXT NOT NULL,
+    source_amount NUMERIC(8, 2) NOT NULL,
+    target_amount NUMERIC(8, 2) NOT NULL,
+    rate NUMERIC(24, 10) NOT NULL,
+    rate_timestamp TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ
+);
+
+ALTER TABLE transactions
+    ADD COLUMN IF NOT EXISTS quote_id uuid REFERENCES fx_quotes (id);
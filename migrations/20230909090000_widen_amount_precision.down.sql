@@ -0,0 +1,10 @@
+ALTER TABLE fx_quotes
+    ALTER COLUMN source_amount TYPE NUMERIC(8, 2),
+    ALTER COLUMN target_amount TYPE NUMERIC(8, 2);
+
+ALTER TABLE transactions
+    ALTER COLUMN amount TYPE NUMERIC(8, 2),
+    ALTER COLUMN target_amount TYPE NUMERIC(8, 2);
+
+ALTER TABLE accounts
+    ALTER COLUMN balance TYPE NUMERIC(8, 2);
@@ -0,0 +1,10 @@
+ALTER TABLE accounts
+    ALTER COLUMN balance TYPE NUMERIC(19, 4);
+
+ALTER TABLE transactions
+    ALTER COLUMN amount TYPE NUMERIC(19, 4),
+    ALTER COLUMN target_amount TYPE NUMERIC(19, 4);
+
+ALTER TABLE fx_quotes
+    ALTER COLUMN source_amount TYPE NUMERIC(19, 4),
+    ALTER COLUMN target_amount TYPE NUMERIC(19, 4);
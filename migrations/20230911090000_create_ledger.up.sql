@@ -0,0 +1,93 @@
+CREATE TABLE IF NOT EXISTS journal_entries (
+    id uuid PRIMARY KEY,
+    transaction_id uuid UNIQUE,
+    kind TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS postings (
+    id BIGSERIAL PRIMARY KEY,
+    journal_entry_id uuid NOT NULL REFERENCES journal_entries (id),
+    account_id uuid,
+    system_account TEXT,
+    amount NUMERIC(19, 4) NOT NULL CHECK (amount <> 0),
+    currency TEXT NOT NULL,
+    CHECK ((account_id IS NULL) <> (system_account IS NULL))
+);
+
+CREATE INDEX IF NOT EXISTS postings_journal_entry_id_idx ON postings (journal_entry_id);
+CREATE INDEX IF NOT EXISTS postings_account_id_idx ON postings (account_id);
+
+-- Backfill the entries of the existing transfers
+INSERT INTO journal_entries (id, transaction_id, kind)
+SELECT md5('transfer:' || id::text)::uuid, id, 'transfer'
+FROM transactions;
+
+INSERT INTO postings (journal_entry_id, account_id, system_account, amount, currency)
+SELECT md5('transfer:' || id::text)::uuid, source_account_id, NULL, -amount, source_currency
+FROM transactions
+UNION ALL
+SELECT md5('transfer:' || id::text)::uuid, NULL, 'fx_clearing', amount, source_currency
+FROM transactions WHERE source_currency <> target_currency
+UNION ALL
+SELECT md5('transfer:' || id::text)::uuid, NULL, 'fx_clearing', -target_amount, target_currency
+FROM transactions WHERE source_currency <> target_currency
+UNION ALL
+SELECT md5('transfer:' || id::text)::uuid, target_account_id, NULL, target_amount, target_currency
+FROM transactions;
+
+-- Fund the balances not explained by the transfers with opening entries
+CREATE TEMPORARY TABLE opening_balances AS
+SELECT a.id, a.currency, a.balance - COALESCE(SUM(p.amount), 0) AS amount
+FROM accounts a
+LEFT JOIN postings p ON p.account_id = a.id
+GROUP BY a.id, a.currency, a.balance
+HAVING a.balance - COALESCE(SUM(p.amount), 0) <> 0;
+
+INSERT INTO journal_entries (id, kind)
+SELECT md5('opening_balance:' || id::text)::uuid, 'opening_balance'
+FROM opening_balances;
+
+INSERT INTO postings (journal_entry_id, account_id, system_account, amount, currency)
+SELECT md5('opening_balance:' || id::text)::uuid, NULL, 'opening_balance', -amount, currency
+FROM opening_balances
+UNION ALL
+SELECT md5('opening_balance:' || id::text)::uuid, id, NULL, amount, currency
+FROM opening_balances;
+
+DROP TABLE opening_balances;
+
+-- Refuse entries whose postings do not sum up to zero in every currency
+CREATE OR REPLACE FUNCTION check_journal_entry_balanced() RETURNS TRIGGER AS $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM postings
+        WHERE journal_entry_id = NEW.journal_entry_id
+        GROUP BY currency
+        HAVING SUM(amount) <> 0
+    ) THEN
+        RAISE EXCEPTION 'journal entry % is not balanced', NEW.journal_entry_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER postings_balanced
+    AFTER INSERT ON postings
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE PROCEDURE check_journal_entry_balanced();
+
+-- Keep the ledger append-only
+CREATE OR REPLACE FUNCTION reject_ledger_change() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'the ledger is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER journal_entries_append_only
+    BEFORE UPDATE OR DELETE ON journal_entries
+    FOR EACH ROW EXECUTE PROCEDURE reject_ledger_change();
+
+CREATE TRIGGER postings_append_only
+    BEFORE UPDATE OR DELETE ON postings
+    FOR EACH ROW EXECUTE PROCEDURE reject_ledger_change();
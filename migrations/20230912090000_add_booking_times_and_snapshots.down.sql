@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS balance_snapshots;
+
+DROP INDEX IF EXISTS journal_entries_created_at_idx;
+
+ALTER TABLE accounts
+    ALTER COLUMN created_at TYPE TIMESTAMP;
+
+ALTER TABLE transactions
+    DROP COLUMN IF EXISTS created_at,
+    DROP COLUMN IF EXISTS booked_at;
@@ -0,0 +1,27 @@
+ALTER TABLE transactions
+    ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS booked_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP;
+
+ALTER TABLE accounts
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ;
+
+-- Opening balances were booked when their account was created
+ALTER TABLE journal_entries DISABLE TRIGGER journal_entries_append_only;
+
+UPDATE journal_entries e
+SET created_at = a.created_at
+FROM postings p
+JOIN accounts a ON a.id = p.account_id
+WHERE p.journal_entry_id = e.id AND e.kind = 'opening_balance';
+
+ALTER TABLE journal_entries ENABLE TRIGGER journal_entries_append_only;
+
+CREATE INDEX IF NOT EXISTS journal_entries_created_at_idx ON journal_entries (created_at);
+
+CREATE TABLE IF NOT EXISTS balance_snapshots (
+    account_id uuid NOT NULL,
+    as_of TIMESTAMPTZ NOT NULL,
+    balance NUMERIC(19, 4) NOT NULL,
+    currency TEXT NOT NULL,
+    PRIMARY KEY (account_id, as_of)
+);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS transactions_booked_at_id_idx;
@@ -0,0 +1,2 @@
+CREATE INDEX IF NOT EXISTS transactions_booked_at_id_idx
+    ON transactions (booked_at DESC, id DESC);
@@ -0,0 +1,6 @@
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'active';
+
+CREATE INDEX IF NOT EXISTS accounts_created_at_id_idx
+    ON accounts (created_at, id);
+CREATE INDEX IF NOT EXISTS accounts_balance_id_idx
+    ON accounts (balance, id);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS transactions_target_account_id_idx;
+DROP INDEX IF EXISTS transactions_source_account_id_idx;
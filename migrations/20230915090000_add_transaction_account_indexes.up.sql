@@ -0,0 +1,4 @@
+CREATE INDEX IF NOT EXISTS transactions_source_account_id_idx
+    ON transactions (source_account_id);
+CREATE INDEX IF NOT EXISTS transactions_target_account_id_idx
+    ON transactions (target_account_id);
@@ -0,0 +1,5 @@
+ALTER TABLE transactions
+    ADD COLUMN IF NOT EXISTS reversal_of uuid REFERENCES transactions (id);
+
+CREATE INDEX IF NOT EXISTS transactions_reversal_of_idx
+    ON transactions (reversal_of);
@@ -0,0 +1,4 @@
+ALTER TABLE accounts DROP CONSTRAINT IF EXISTS accounts_status_check;
+
+ALTER TABLE accounts DROP COLUMN IF EXISTS status_changed_at;
+ALTER TABLE accounts DROP COLUMN IF EXISTS status_reason;
@@ -0,0 +1,6 @@
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS status_reason TEXT;
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS status_changed_at TIMESTAMPTZ;
+
+ALTER TABLE accounts DROP CONSTRAINT IF EXISTS accounts_status_check;
+ALTER TABLE accounts ADD CONSTRAINT accounts_status_check
+    CHECK (status IN ('active', 'frozen', 'closed'));
@@ -0,0 +1,34 @@
+DROP INDEX IF EXISTS transactions_quote_id_idx;
+
+ALTER TABLE balance_snapshots DROP CONSTRAINT IF EXISTS balance_snapshots_account_fk;
+ALTER TABLE journal_entries DROP CONSTRAINT IF EXISTS journal_entries_transaction_fk;
+ALTER TABLE postings DROP CONSTRAINT IF EXISTS postings_account_fk;
+
+ALTER TABLE transactions
+    DROP CONSTRAINT IF EXISTS transactions_target_account_fk,
+    DROP CONSTRAINT IF EXISTS transactions_source_account_fk;
+
+ALTER TABLE postings DROP CONSTRAINT IF EXISTS postings_currency_format;
+
+ALTER TABLE transactions
+    DROP CONSTRAINT IF EXISTS transactions_distinct_accounts,
+    DROP CONSTRAINT IF EXISTS transactions_target_amount_positive,
+    DROP CONSTRAINT IF EXISTS transactions_amount_positive,
+    DROP CONSTRAINT IF EXISTS transactions_target_currency_format,
+    DROP CONSTRAINT IF EXISTS transactions_source_currency_format,
+    DROP CONSTRAINT IF EXISTS transactions_currency_format;
+
+ALTER TABLE accounts DROP CONSTRAINT IF EXISTS accounts_currency_format;
+
+ALTER TABLE transactions
+    ALTER COLUMN target_amount DROP NOT NULL,
+    ALTER COLUMN target_currency DROP NOT NULL,
+    ALTER COLUMN source_currency DROP NOT NULL,
+    ALTER COLUMN currency DROP NOT NULL,
+    ALTER COLUMN amount DROP NOT NULL,
+    ALTER COLUMN target_account_id DROP NOT NULL,
+    ALTER COLUMN source_account_id DROP NOT NULL;
+
+ALTER TABLE accounts
+    ALTER COLUMN currency DROP NOT NULL,
+    ALTER COLUMN balance DROP NOT NULL;
@@ -0,0 +1,51 @@
+-- Money columns are always set by the application
+ALTER TABLE accounts
+    ALTER COLUMN balance SET NOT NULL,
+    ALTER COLUMN currency SET NOT NULL;
+
+ALTER TABLE transactions
+    ALTER COLUMN source_account_id SET NOT NULL,
+    ALTER COLUMN target_account_id SET NOT NULL,
+    ALTER COLUMN amount SET NOT NULL,
+    ALTER COLUMN currency SET NOT NULL,
+    ALTER COLUMN source_currency SET NOT NULL,
+    ALTER COLUMN target_currency SET NOT NULL,
+    ALTER COLUMN target_amount SET NOT NULL;
+
+-- Currencies are ISO 4217 alphabetic codes
+ALTER TABLE accounts
+    ADD CONSTRAINT accounts_currency_format CHECK (currency ~ '^[A-Z]{3}$');
+
+ALTER TABLE transactions
+    ADD CONSTRAINT transactions_currency_format CHECK (currency ~ '^[A-Z]{3}$'),
+    ADD CONSTRAINT transactions_source_currency_format CHECK (source_currency ~ '^[A-Z]{3}$'),
+    ADD CONSTRAINT transactions_target_currency_format CHECK (target_currency ~ '^[A-Z]{3}$'),
+    ADD CONSTRAINT transactions_amount_positive CHECK (amount > 0),
+    ADD CONSTRAINT transactions_target_amount_positive CHECK (target_amount > 0),
+    ADD CONSTRAINT transactions_distinct_accounts CHECK (source_account_id <> target_account_id);
+
+ALTER TABLE postings
+    ADD CONSTRAINT postings_currency_format CHECK (currency ~ '^[A-Z]{3}$');
+
+-- Accounts and transactions used to be deleted, so the existing rows may
+-- reference missing ones and are not checked. The constraints can be
+-- validated with ALTER TABLE ... VALIDATE CONSTRAINT once they are cleaned up.
+ALTER TABLE transactions
+    ADD CONSTRAINT transactions_source_account_fk
+        FOREIGN KEY (source_account_id) REFERENCES accounts (id) NOT VALID,
+    ADD CONSTRAINT transactions_target_account_fk
+        FOREIGN KEY (target_account_id) REFERENCES accounts (id) NOT VALID;
+
+ALTER TABLE postings
+    ADD CONSTRAINT postings_account_fk
+        FOREIGN KEY (account_id) REFERENCES accounts (id) NOT VALID;
+
+ALTER TABLE journal_entries
+    ADD CONSTRAINT journal_entries_transaction_fk
+        FOREIGN KEY (transaction_id) REFERENCES transactions (id) NOT VALID;
+
+ALTER TABLE balance_snapshots
+    ADD CONSTRAINT balance_snapshots_account_fk
+        FOREIGN KEY (account_id) REFERENCES accounts (id) NOT VALID;
+
+CREATE INDEX IF NOT EXISTS transactions_quote_id_idx ON transactions (quote_id);
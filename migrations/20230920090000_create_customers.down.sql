@@ -0,0 +1,8 @@
+ALTER TABLE api_keys DROP CONSTRAINT IF EXISTS api_keys_customer_fk;
+ALTER TABLE api_keys DROP COLUMN IF EXISTS customer_id;
+
+DROP INDEX IF EXISTS accounts_customer_id_idx;
+ALTER TABLE accounts DROP CONSTRAINT IF EXISTS accounts_customer_fk;
+ALTER TABLE accounts DROP COLUMN IF EXISTS customer_id;
+
+DROP TABLE IF EXISTS customers;
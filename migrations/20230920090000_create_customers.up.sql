@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS customers (
+    id UUID PRIMARY KEY,
+    name TEXT NOT NULL,
+    email TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT customers_email_key UNIQUE (email),
+    CONSTRAINT customers_email_lower CHECK (email = lower(email))
+);
+
+-- Accounts opened before the customers existed have no owner
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS customer_id UUID;
+ALTER TABLE accounts ADD CONSTRAINT accounts_customer_fk
+    FOREIGN KEY (customer_id) REFERENCES customers (id);
+
+CREATE INDEX IF NOT EXISTS accounts_customer_id_idx
+    ON accounts (customer_id, created_at DESC, id DESC);
+
+-- The keys of a customer go away along with it
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS customer_id UUID;
+ALTER TABLE api_keys ADD CONSTRAINT api_keys_customer_fk
+    FOREIGN KEY (customer_id) REFERENCES customers (id) ON DELETE CASCADE;
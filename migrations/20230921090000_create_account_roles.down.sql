@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS account_role_changes;
+DROP TABLE IF EXISTS account_roles;
@@ -0,0 +1,36 @@
+-- The roles of the customers acting on accounts they do not own
+CREATE TABLE IF NOT EXISTS account_roles (
+    account_id UUID NOT NULL,
+    customer_id UUID NOT NULL,
+    role TEXT NOT NULL,
+    granted_by UUID,
+    granted_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT account_roles_pkey PRIMARY KEY (account_id, customer_id),
+    CONSTRAINT account_roles_account_fk
+        FOREIGN KEY (account_id) REFERENCES accounts (id),
+    CONSTRAINT account_roles_customer_fk
+        FOREIGN KEY (customer_id) REFERENCES customers (id) ON DELETE CASCADE,
+    CONSTRAINT account_roles_role_check
+        CHECK (role IN ('view', 'initiate', 'approve'))
+);
+
+CREATE INDEX IF NOT EXISTS account_roles_customer_id_idx
+    ON account_roles (customer_id);
+
+-- Every grant and revocation is kept, even once the customer is removed
+CREATE TABLE IF NOT EXISTS account_role_changes (
+    id UUID PRIMARY KEY,
+    account_id UUID NOT NULL,
+    customer_id UUID NOT NULL,
+    action TEXT NOT NULL,
+    role TEXT NOT NULL,
+    changed_by UUID,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT account_role_changes_account_fk
+        FOREIGN KEY (account_id) REFERENCES accounts (id),
+    CONSTRAINT account_role_changes_action_check
+        CHECK (action IN ('grant', 'revoke'))
+);
+
+CREATE INDEX IF NOT EXISTS account_role_changes_account_id_idx
+    ON account_role_changes (account_id, changed_at, id);
@@ -0,0 +1,17 @@
+DROP INDEX IF EXISTS transactions_pending_expires_at_idx;
+
+-- Without a status the transfers that were never booked would look booked
+DELETE FROM transactions WHERE status <> 'completed';
+
+ALTER TABLE transactions
+    DROP CONSTRAINT IF EXISTS transactions_status_check,
+    DROP COLUMN IF EXISTS expires_at,
+    DROP COLUMN IF EXISTS reviewed_at,
+    DROP COLUMN IF EXISTS reviewed_by,
+    DROP COLUMN IF EXISTS initiator_customer_id,
+    DROP COLUMN IF EXISTS initiated_by,
+    DROP COLUMN IF EXISTS status;
+
+ALTER TABLE accounts
+    DROP CONSTRAINT IF EXISTS accounts_held_within_balance,
+    DROP COLUMN IF EXISTS held;
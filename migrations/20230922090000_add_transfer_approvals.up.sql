@@ -0,0 +1,20 @@
+-- The amounts held on an account by the transfers waiting for an approval are
+-- not available to other transfers
+ALTER TABLE accounts
+    ADD COLUMN IF NOT EXISTS held NUMERIC(19, 4) NOT NULL DEFAULT 0,
+    ADD CONSTRAINT accounts_held_within_balance CHECK (held >= 0 AND held <= balance);
+
+-- The transfers above the approval threshold of their currency wait for the
+-- approval of another principal than their initiator
+ALTER TABLE transactions
+    ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'completed',
+    ADD COLUMN IF NOT EXISTS initiated_by UUID,
+    ADD COLUMN IF NOT EXISTS initiator_customer_id UUID,
+    ADD COLUMN IF NOT EXISTS reviewed_by UUID,
+    ADD COLUMN IF NOT EXISTS reviewed_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ,
+    ADD CONSTRAINT transactions_status_check
+        CHECK (status IN ('completed', 'pending_approval', 'rejected', 'expired'));
+
+CREATE INDEX IF NOT EXISTS transactions_pending_expires_at_idx
+    ON transactions (expires_at) WHERE status = 'pending_approval';
@@ -0,0 +1,18 @@
+DROP INDEX IF EXISTS transactions_status_booked_at_idx;
+
+DROP TRIGGER IF EXISTS transactions_status_transition ON transactions;
+DROP FUNCTION IF EXISTS check_transaction_status_transition();
+
+-- Without these statuses the failed transfers would look booked and the
+-- reversed transactions are completed ones
+DELETE FROM transactions WHERE status IN ('pending', 'failed');
+UPDATE transactions SET status = 'completed' WHERE status = 'reversed';
+
+ALTER TABLE transactions
+    DROP CONSTRAINT IF EXISTS transactions_target_amount_positive,
+    ADD CONSTRAINT transactions_target_amount_positive CHECK (target_amount > 0),
+    DROP CONSTRAINT IF EXISTS transactions_failure_reason_check,
+    DROP CONSTRAINT IF EXISTS transactions_status_check,
+    ADD CONSTRAINT transactions_status_check
+        CHECK (status IN ('completed', 'pending_approval', 'rejected', 'expired')),
+    DROP COLUMN IF EXISTS failure_reason;
@@ -0,0 +1,41 @@
+-- The transfers refused for a business reason are kept as failed
+-- transactions along with the code of the error they were refused with, and
+-- the transactions whose reversals took back all of it are reversed
+ALTER TABLE transactions
+    ADD COLUMN IF NOT EXISTS failure_reason TEXT,
+    DROP CONSTRAINT IF EXISTS transactions_status_check,
+    ADD CONSTRAINT transactions_status_check
+        CHECK (status IN ('pending', 'pending_approval', 'completed', 'failed',
+                          'rejected', 'expired', 'reversed')),
+    ADD CONSTRAINT transactions_failure_reason_check
+        CHECK ((status = 'failed') = (failure_reason IS NOT NULL)),
+    -- A transfer refused before its conversion has no target amount
+    DROP CONSTRAINT IF EXISTS transactions_target_amount_positive,
+    ADD CONSTRAINT transactions_target_amount_positive
+        CHECK (target_amount > 0 OR (status = 'failed' AND target_amount = 0));
+
+UPDATE transactions t SET status = 'reversed'
+WHERE status = 'completed'
+  AND amount <= (SELECT COALESCE(SUM(r.target_amount), 0)
+                 FROM transactions r WHERE r.reversal_of = t.id);
+
+-- A transaction only moves along the transitions of its state machine
+CREATE OR REPLACE FUNCTION check_transaction_status_transition() RETURNS trigger AS $$
+BEGIN
+    IF NEW.status = OLD.status
+        OR (OLD.status = 'pending' AND NEW.status IN ('pending_approval', 'completed', 'failed'))
+        OR (OLD.status = 'pending_approval' AND NEW.status IN ('completed', 'rejected', 'expired'))
+        OR (OLD.status = 'completed' AND NEW.status = 'reversed') THEN
+        RETURN NEW;
+    END IF;
+    RAISE EXCEPTION 'transaction % cannot move from % to %', OLD.id, OLD.status, NEW.status
+        USING ERRCODE = 'check_violation', CONSTRAINT = 'transactions_status_transition';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER transactions_status_transition
+    BEFORE UPDATE OF status ON transactions
+    FOR EACH ROW EXECUTE FUNCTION check_transaction_status_transition();
+
+CREATE INDEX IF NOT EXISTS transactions_status_booked_at_idx
+    ON transactions (status, booked_at DESC, id DESC);
@@ -0,0 +1,11 @@
+-- Release what the active holds held on their accounts
+UPDATE accounts a SET held = a.held - h.amount
+FROM (
+    SELECT account_id, SUM(amount) AS amount
+    FROM holds
+    WHERE status = 'active'
+    GROUP BY account_id
+) h
+WHERE a.id = h.account_id;
+
+DROP TABLE IF EXISTS holds;
@@ -0,0 +1,34 @@
+-- The amounts reserved on the accounts until they are captured, voided or
+-- expire. The amount of an active hold counts in the held amount of its
+-- account, which is not available to transfers.
+CREATE TABLE IF NOT EXISTS holds (
+    id UUID PRIMARY KEY,
+    account_id UUID NOT NULL,
+    amount NUMERIC(19, 4) NOT NULL,
+    currency TEXT NOT NULL,
+    reference TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'active',
+    captured_amount NUMERIC(19, 4),
+    transaction_id UUID,
+    placed_by UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMPTZ NOT NULL,
+    released_at TIMESTAMPTZ,
+    CONSTRAINT holds_account_fk
+        FOREIGN KEY (account_id) REFERENCES accounts (id),
+    CONSTRAINT holds_transaction_fk
+        FOREIGN KEY (transaction_id) REFERENCES transactions (id),
+    CONSTRAINT holds_account_reference_key UNIQUE (account_id, reference),
+    CONSTRAINT holds_amount_positive CHECK (amount > 0),
+    CONSTRAINT holds_currency_format CHECK (currency ~ '^[A-Z]{3}$'),
+    CONSTRAINT holds_status_check
+        CHECK (status IN ('active', 'captured', 'voided', 'expired')),
+    -- A captured hold turned into a transfer of at most its amount
+    CONSTRAINT holds_capture_check
+        CHECK ((status = 'captured') = (transaction_id IS NOT NULL)
+            AND (status = 'captured') = (captured_amount IS NOT NULL)
+            AND captured_amount > 0 AND captured_amount <= amount)
+);
+
+CREATE INDEX IF NOT EXISTS holds_active_expires_at_idx
+    ON holds (expires_at) WHERE status = 'active';
@@ -47,21 +47,103 @@ func (s *instrumentingService) Register(
 	return s.next.Register(ctx, acct)
 }
 
-func (s *instrumentingService) LoadAll(ctx context.Context) []accounts.Account {
+func (s *instrumentingService) LoadAll(
+	ctx context.Context, q accounts.Query,
+) (page accounts.Page, err error) {
 	defer func(begin time.Time) {
 		s.requestCount.With("method", "accounts").Add(1)
 		s.requestLatency.With("method", "accounts").Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-	return s.next.LoadAll(ctx)
+	return s.next.LoadAll(ctx, q)
 }
 
-func (s *instrumentingService) Clean(
+func (s *instrumentingService) Freeze(
+	ctx context.Context, id string, reason string,
+) (account accounts.Account, err error) {
+	defer func(begin time.Time) {
+		s.requestCount.With("method", "freeze").Add(1)
+		s.requestLatency.With("method", "freeze").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return s.next.Freeze(ctx, id, reason)
+}
+
+func (s *instrumentingService) Unfreeze(
+	ctx context.Context, id string, reason string,
+) (account accounts.Account, err error) {
+	defer func(begin time.Time) {
+		s.requestCount.With("method", "unfreeze").Add(1)
+		s.requestLatency.With("method", "unfreeze").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return s.next.Unfreeze(ctx, id, reason)
+}
+
+func (s *instrumentingService) Close(
+	ctx context.Context, id string, reason string,
+) (account accounts.Account, err error) {
+	defer func(begin time.Time) {
+		s.requestCount.With("method", "close").Add(1)
+		s.requestLatency.With("method", "close").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return s.next.Close(ctx, id, reason)
+}
+
+func (s *instrumentingService) BalanceAsOf(
+	ctx context.Context, id string, asOf time.Time,
+) (balance accounts.Balance, err error) {
+	defer func(begin time.Time) {
+		s.requestCount.With("method", "balance_as_of").Add(1)
+		s.requestLatency.With("method", "balance_as_of").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return s.next.BalanceAsOf(ctx, id, asOf)
+}
+
+func (s *instrumentingService) SnapshotBalances(
+	ctx context.Context, asOf time.Time,
+) (n int64, err error) {
+	defer func(begin time.Time) {
+		s.requestCount.With("method", "snapshot_balances").Add(1)
+		s.requestLatency.With("method", "snapshot_balances").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return s.next.SnapshotBalances(ctx, asOf)
+}
+
+func (s *instrumentingService) Roles(
 	ctx context.Context, id string,
+) (grants []accounts.Grant, err error) {
+	defer func(begin time.Time) {
+		s.requestCount.With("method", "roles").Add(1)
+		s.requestLatency.With("method", "roles").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return s.next.Roles(ctx, id)
+}
+
+func (s *instrumentingService) GrantRole(
+	ctx context.Context, id string, customerID string, role accounts.Role,
+) (grant accounts.Grant, err error) {
+	defer func(begin time.Time) {
+		s.requestCount.With("method", "grant_role").Add(1)
+		s.requestLatency.With("method", "grant_role").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return s.next.GrantRole(ctx, id, customerID, role)
+}
+
+func (s *instrumentingService) RevokeRole(
+	ctx context.Context, id string, customerID string,
 ) (err error) {
 	defer func(begin time.Time) {
-		s.requestCount.With("method", "clean").Add(1)
-		s.requestLatency.With("method", "clean").Observe(time.Since(begin).Seconds())
+		s.requestCount.With("method", "revoke_role").Add(1)
+		s.requestLatency.With("method", "revoke_role").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+	return s.next.RevokeRole(ctx, id, customerID)
+}
+
+func (s *instrumentingService) RoleChanges(
+	ctx context.Context, id string,
+) (changes []accounts.RoleChange, err error) {
+	defer func(begin time.Time) {
+		s.requestCount.With("method", "role_changes").Add(1)
+		s.requestLatency.With("method", "role_changes").Observe(time.Since(begin).Seconds())
 	}(time.Now())
-	return s.next.Clean(ctx, id)
+	return s.next.RoleChanges(ctx, id)
 }
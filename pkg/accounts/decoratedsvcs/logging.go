@@ -39,7 +39,7 @@ func (s *loggingService) Register(
 		s.logger.Infow(
 			"account store",
 			log.String("account_id", string(acct.ID)),
-			log.Float64("balance", acct.Balance),
+			log.Stringer("balance", acct.Balance),
 			log.String("currency", string(acct.Currency)),
 			log.String("created_at", acct.CreatedAt.String()),
 			log.Duration("took", time.Since(begin)),
@@ -49,26 +49,160 @@ func (s *loggingService) Register(
 	return s.next.Register(ctx, acct)
 }
 
-func (s *loggingService) LoadAll(ctx context.Context) []accounts.Account {
+func (s *loggingService) LoadAll(
+	ctx context.Context, q accounts.Query,
+) (page accounts.Page, err error) {
 	defer func(begin time.Time) {
 		s.logger.Infow(
 			"accounts",
+			log.String("currency", q.Currency),
+			log.String("status", q.Status),
+			log.String("sort", q.Sort),
+			log.Int("limit", q.Limit),
+			log.Int("count", len(page.Accounts)),
 			log.Duration("took", time.Since(begin)),
+			log.Error(err),
+		)
+	}(time.Now())
+	return s.next.LoadAll(ctx, q)
+}
+
+func (s *loggingService) Freeze(
+	ctx context.Context, id string, reason string,
+) (account accounts.Account, err error) {
+	defer func(begin time.Time) {
+		s.logger.Infow(
+			"freeze",
+			log.String("account_id", id),
+			log.String("reason", reason),
+			log.String("status", account.Status),
+			log.Duration("took", time.Since(begin)),
+			log.Error(err),
+		)
+	}(time.Now())
+	return s.next.Freeze(ctx, id, reason)
+}
+
+func (s *loggingService) Unfreeze(
+	ctx context.Context, id string, reason string,
+) (account accounts.Account, err error) {
+	defer func(begin time.Time) {
+		s.logger.Infow(
+			"unfreeze",
+			log.String("account_id", id),
+			log.String("reason", reason),
+			log.String("status", account.Status),
+			log.Duration("took", time.Since(begin)),
+			log.Error(err),
+		)
+	}(time.Now())
+	return s.next.Unfreeze(ctx, id, reason)
+}
+
+func (s *loggingService) Close(
+	ctx context.Context, id string, reason string,
+) (account accounts.Account, err error) {
+	defer func(begin time.Time) {
+		s.logger.Infow(
+			"close",
+			log.String("account_id", id),
+			log.String("reason", reason),
+			log.String("status", account.Status),
+			log.Duration("took", time.Since(begin)),
+			log.Error(err),
 		)
 	}(time.Now())
-	return s.next.LoadAll(ctx)
+	return s.next.Close(ctx, id, reason)
 }
 
-func (s *loggingService) Clean(
+func (s *loggingService) BalanceAsOf(
+	ctx context.Context, id string, asOf time.Time,
+) (balance accounts.Balance, err error) {
+	defer func(begin time.Time) {
+		s.logger.Infow(
+			"balance as of",
+			log.String("account_id", string(id)),
+			log.Time("as_of", asOf),
+			log.Duration("took", time.Since(begin)),
+			log.Error(err),
+		)
+	}(time.Now())
+	return s.next.BalanceAsOf(ctx, id, asOf)
+}
+
+func (s *loggingService) SnapshotBalances(
+	ctx context.Context, asOf time.Time,
+) (n int64, err error) {
+	defer func(begin time.Time) {
+		s.logger.Infow(
+			"snapshot balances",
+			log.Time("as_of", asOf),
+			log.Int64("snapshots", n),
+			log.Duration("took", time.Since(begin)),
+			log.Error(err),
+		)
+	}(time.Now())
+	return s.next.SnapshotBalances(ctx, asOf)
+}
+
+func (s *loggingService) Roles(
 	ctx context.Context, id string,
+) (grants []accounts.Grant, err error) {
+	defer func(begin time.Time) {
+		s.logger.Infow(
+			"roles",
+			log.String("account_id", id),
+			log.Int("count", len(grants)),
+			log.Duration("took", time.Since(begin)),
+			log.Error(err),
+		)
+	}(time.Now())
+	return s.next.Roles(ctx, id)
+}
+
+func (s *loggingService) GrantRole(
+	ctx context.Context, id string, customerID string, role accounts.Role,
+) (grant accounts.Grant, err error) {
+	defer func(begin time.Time) {
+		s.logger.Infow(
+			"grant role",
+			log.String("account_id", id),
+			log.String("customer_id", customerID),
+			log.String("role", string(role)),
+			log.String("granted_by", grant.GrantedBy),
+			log.Duration("took", time.Since(begin)),
+			log.Error(err),
+		)
+	}(time.Now())
+	return s.next.GrantRole(ctx, id, customerID, role)
+}
+
+func (s *loggingService) RevokeRole(
+	ctx context.Context, id string, customerID string,
 ) (err error) {
 	defer func(begin time.Time) {
 		s.logger.Infow(
-			"clean",
-			log.String("account_id", string(id)),
+			"revoke role",
+			log.String("account_id", id),
+			log.String("customer_id", customerID),
+			log.Duration("took", time.Since(begin)),
+			log.Error(err),
+		)
+	}(time.Now())
+	return s.next.RevokeRole(ctx, id, customerID)
+}
+
+func (s *loggingService) RoleChanges(
+	ctx context.Context, id string,
+) (changes []accounts.RoleChange, err error) {
+	defer func(begin time.Time) {
+		s.logger.Infow(
+			"role changes",
+			log.String("account_id", id),
+			log.Int("count", len(changes)),
 			log.Duration("took", time.Since(begin)),
 			log.Error(err),
 		)
 	}(time.Now())
-	return s.next.Clean(ctx, id)
+	return s.next.RoleChanges(ctx, id)
 }
@@ -1,39 +1,219 @@
 package accounts
 
 import (
-	"errors"
+	"financial-app/pkg/errs"
 	"strings"
 )
 
 // ErrEmptyAccountList is used when the given account list is empty
-var ErrEmptyAccountList = errors.New("account list is empty")
+var ErrEmptyAccountList = errs.New(errs.ErrInvalid, "empty_account_list", "account list is empty")
+
+// ErrInvalidAsOf is used when the as_of time of a balance query is not valid
+var ErrInvalidAsOf = errs.New(errs.ErrInvalid, "invalid_as_of", "as_of must be an RFC 3339 timestamp")
+
+// ErrListingAccounts is used when the accounts could not be listed
+var ErrListingAccounts = errs.New(errs.ErrInternal, "listing_accounts_failed", "could not list the accounts")
+
+// ErrInvalidSort is used when the sort key of a listing is not supported
+var ErrInvalidSort = errs.New(errs.ErrInvalid, "invalid_sort",
+	"sort must be one of created_at, -created_at, balance, -balance")
+
+// ErrInvalidFilter is used when a query parameter of a listing is not valid
+func ErrInvalidFilter(name string) *errs.Error {
+	return errs.New(errs.ErrInvalid, "invalid_filter", "invalid value of the query parameter "+name)
+}
 
 // ErrPostingAccount is used when an account could not be created
-func ErrPostingAccount(id string) error {
-	return errors.New("could not create a new account by ID " + id)
+func ErrPostingAccount(id string) *errs.Error {
+	return errs.New(errs.ErrInternal, "posting_account_failed", "could not create a new account by ID "+id)
 }
 
 // ErrScanAccount is used when an account could not be scanned
-func ErrScanAccounts(ids []string) error {
-	return errors.New("could not scan the accounts by IDs " + strings.Join(ids, ","))
+func ErrScanAccounts(ids []string) *errs.Error {
+	return errs.New(errs.ErrInternal, "scanning_accounts_failed",
+		"could not scan the accounts by IDs "+strings.Join(ids, ","))
 }
 
 // ErrQueryingAccounts is used when one of the accounts could not be queried
-func ErrQueryingAccounts(ids []string) error {
-	return errors.New("could not query the requested accounts by IDs " + strings.Join(ids, ","))
+func ErrQueryingAccounts(ids []string) *errs.Error {
+	return errs.New(errs.ErrInternal, "querying_accounts_failed",
+		"could not query the requested accounts by IDs "+strings.Join(ids, ","))
 }
 
 // ErrFetchingAccounts is used when one of the accounts could not be fetched
-func ErrFetchingAccounts(ids []string) error {
-	return errors.New("could not fetch the requested accounts by IDs " + strings.Join(ids, ","))
+func ErrFetchingAccounts(ids []string) *errs.Error {
+	return errs.New(errs.ErrInternal, "fetching_accounts_failed",
+		"could not fetch the requested accounts by IDs "+strings.Join(ids, ","))
+}
+
+// ErrDuplicateAccount is used when an account ID is already taken
+var ErrDuplicateAccount = errs.New(errs.ErrConflict, "duplicate_account",
+	"an account with the same ID already exists")
+
+// ErrNegativeBalance is used when an account balance would become negative
+var ErrNegativeBalance = errs.New(errs.ErrUnprocessable, "negative_balance",
+	"account balance cannot be negative")
+
+// ErrInvalidCurrencyCode is used when a currency is not an ISO 4217 alphabetic code
+var ErrInvalidCurrencyCode = errs.New(errs.ErrUnprocessable, "invalid_currency_code",
+	"currency must be a three letter ISO 4217 code")
+
+// ErrAccountNotFound is used when an account does not exist
+var ErrAccountNotFound = errs.New(errs.ErrNotFound, "account_not_found", "account not found")
+
+// ErrFetchingAccount is used when an account could not be found. It matches
+// ErrAccountNotFound.
+func ErrFetchingAccount(id string) *errs.Error {
+	return ErrAccountNotFound.WithMessage("could not fetch account by ID " + id)
+}
+
+// ErrUpdatingStatus is used when the status of an account could not be updated
+func ErrUpdatingStatus(id string) *errs.Error {
+	return errs.New(errs.ErrInternal, "updating_status_failed",
+		"could not update the status of account by ID "+id)
+}
+
+// ErrNonZeroBalance is used when an account with a balance is to be closed
+var ErrNonZeroBalance = errs.New(errs.ErrConflict, "non_zero_balance",
+	"only accounts with a zero balance can be closed")
+
+// ErrInvalidTransition is used when an account cannot move to another status
+var ErrInvalidTransition = errs.New(errs.ErrConflict, "invalid_status_transition",
+	"invalid account status transition")
+
+// TransitionError is used when an account cannot move from its status to the
+// requested one. It wraps ErrInvalidTransition.
+type TransitionError struct {
+	AccountID string
+	From      string
+	To        string
+}
+
+func (e *TransitionError) Error() string {
+	return "the account " + e.AccountID + " cannot go from " + e.From + " to " + e.To
+}
+
+// Unwrap returns ErrInvalidTransition
+func (e *TransitionError) Unwrap() error {
+	return ErrInvalidTransition
+}
+
+// ErrAccountInactive is used when a frozen or closed account is to send or
+// receive a transfer
+var ErrAccountInactive = errs.New(errs.ErrConflict, "account_inactive", "account is not active")
+
+// InactiveAccountError is used when a transfer could not be performed because
+// one of its accounts is frozen or closed. It wraps ErrAccountInactive.
+type InactiveAccountError struct {
+	AccountID string
+	Status    string
+}
+
+func (e *InactiveAccountError) Error() string {
+	return "the account " + e.AccountID + " is " + e.Status
+}
+
+// Unwrap returns ErrAccountInactive
+func (e *InactiveAccountError) Unwrap() error {
+	return ErrAccountInactive
+}
+
+// ErrFetchingBalance is used when the balance of an account could not be computed
+func ErrFetchingBalance(id string) *errs.Error {
+	return errs.New(errs.ErrInternal, "fetching_balance_failed",
+		"could not fetch the balance of account by ID "+id)
+}
+
+// ErrUnknownCustomer is used when an account is registered for a customer that
+// does not exist
+var ErrUnknownCustomer = errs.New(errs.ErrUnprocessable, "unknown_customer",
+	"the customer of the account does not exist")
+
+// ErrOtherCustomer is used when a customer registers an account for another
+// customer
+var ErrOtherCustomer = errs.New(errs.ErrForbidden, "account_for_other_customer",
+	"customers may only register accounts for themselves")
+
+// ErrOpeningBalance is used when a customer registers an account with a
+// balance, which only the operator may credit
+var ErrOpeningBalance = errs.New(errs.ErrForbidden, "opening_balance_not_allowed",
+	"customers may only register accounts with a zero balance")
+
+// ErrNotAccountOwner is used when a customer acts on an account it does not own
+var ErrNotAccountOwner = errs.New(errs.ErrForbidden, "not_account_owner",
+	"the account does not belong to the customer")
+
+// ErrUnfreezeNotAllowed is used when a customer unfreezes an account, which
+// only the operator may do so that freezing stays an operator control
+var ErrUnfreezeNotAllowed = errs.New(errs.ErrForbidden, "unfreeze_not_allowed",
+	"only the operator may unfreeze an account")
+
+// OwnerError is used when a customer acts on the account of someone else. It
+// wraps ErrNotAccountOwner.
+type OwnerError struct {
+	AccountID  string
+	CustomerID string
+}
+
+func (e *OwnerError) Error() string {
+	return "the account " + e.AccountID + " does not belong to the customer " + e.CustomerID
+}
+
+// Unwrap returns ErrNotAccountOwner
+func (e *OwnerError) Unwrap() error {
+	return ErrNotAccountOwner
+}
+
+// ErrInsufficientRole is used when a customer acts on an account with a role
+// lacking the rights to do so
+var ErrInsufficientRole = errs.New(errs.ErrForbidden, "insufficient_account_role",
+	"the role of the customer on the account does not allow it")
+
+// RoleError is used when the role of a customer on an account does not
+// include the required one. It wraps ErrInsufficientRole.
+type RoleError struct {
+	AccountID  string
+	CustomerID string
+	Role       Role
+}
+
+func (e *RoleError) Error() string {
+	return "the customer " + e.CustomerID + " needs the " + string(e.Role) +
+		" role on the account " + e.AccountID
+}
+
+// Unwrap returns ErrInsufficientRole
+func (e *RoleError) Unwrap() error {
+	return ErrInsufficientRole
+}
+
+// ErrInvalidRole is used when a role is not one of view, initiate or approve
+var ErrInvalidRole = errs.New(errs.ErrInvalid, "invalid_role",
+	"role must be one of view, initiate, approve")
+
+// ErrOwnerRole is used when a role is granted to the owner of the account,
+// who already holds every right
+var ErrOwnerRole = errs.New(errs.ErrConflict, "owner_role",
+	"the owner of the account holds every right")
+
+// ErrRoleNotFound is used when a customer has no role on an account
+var ErrRoleNotFound = errs.New(errs.ErrNotFound, "role_not_found", "role not found")
+
+// ErrFetchingRole is used when the role of a customer on an account could not
+// be found. It matches ErrRoleNotFound.
+func ErrFetchingRole(accountID, customerID string) *errs.Error {
+	return ErrRoleNotFound.WithMessage("could not fetch the role of customer " +
+		customerID + " on account " + accountID)
 }
 
-// ErrFetchingAccount is used when an account could not be found
-func ErrFetchingAccount(id string) error {
-	return errors.New("could not fetch account by ID " + id)
+// ErrQueryingRoles is used when the roles of an account could not be queried
+func ErrQueryingRoles(accountID string) *errs.Error {
+	return errs.New(errs.ErrInternal, "querying_roles_failed",
+		"could not query the roles on account by ID "+accountID)
 }
 
-// ErrDeletingAccount is used when an account could not be removed
-func ErrDeletingAccount(id string) error {
-	return errors.New("could not delete account by ID " + id)
+// ErrChangingRole is used when a role could not be granted or revoked
+func ErrChangingRole(accountID, customerID string) *errs.Error {
+	return errs.New(errs.ErrInternal, "changing_role_failed",
+		"could not change the role of customer "+customerID+" on account "+accountID)
 }
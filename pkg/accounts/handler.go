@@ -1,17 +1,25 @@
 package accounts
 
 import (
+	"context"
+	"encoding/json"
+	"financial-app/pkg/currency"
+	"financial-app/pkg/errs"
+	"financial-app/pkg/http/rest/httperr"
+	"financial-app/pkg/money"
+	"financial-app/pkg/pagination"
 	"net/http"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
+	uuid "github.com/satori/go.uuid"
 	"go.uber.org/zap"
 )
 
-var (
-	accountIDRequired    = "account id required"
-	currencyNotSupported = "currency is not supported"
-)
+var accountIDRequired = errs.New(errs.ErrInvalid, "account_id_required", "account id required")
 
 type AccountHandler struct {
 	Service Service
@@ -22,9 +30,17 @@ type AccountHandler struct {
 // Router sets up all the routes for account service
 func (h *AccountHandler) Router(routerGroup *gin.RouterGroup) {
 	routerGroup.GET("accounts/:id", h.load)
+	routerGroup.GET("accounts/:id/balance", h.balance)
 	routerGroup.GET("accounts", h.loadAll)
 	routerGroup.POST("accounts", h.register)
+	routerGroup.POST("accounts/:id/freeze", h.changeStatus(h.Service.Freeze))
+	routerGroup.POST("accounts/:id/unfreeze", h.changeStatus(h.Service.Unfreeze))
+	routerGroup.POST("accounts/:id/close", h.changeStatus(h.Service.Close))
 	routerGroup.DELETE("accounts/:id", h.clean)
+	routerGroup.GET("accounts/:id/roles", h.roles)
+	routerGroup.GET("accounts/:id/roles/changes", h.roleChanges)
+	routerGroup.PUT("accounts/:id/roles/:customer_id", h.grantRole)
+	routerGroup.DELETE("accounts/:id/roles/:customer_id", h.revokeRole)
 }
 
 // load retrieves an account by ID
@@ -33,9 +49,7 @@ func (h *AccountHandler) load(context *gin.Context) {
 	if id == "" {
 		h.Logger.Error("no account id found")
 
-		context.JSON(http.StatusBadRequest, gin.H{
-			"error": accountIDRequired,
-		})
+		httperr.Respond(context, accountIDRequired)
 		return
 	}
 
@@ -43,42 +57,173 @@ func (h *AccountHandler) load(context *gin.Context) {
 	if err != nil {
 		h.Logger.Error(err)
 
-		context.JSON(http.StatusNotFound, gin.H{
-			"error": err.Error(),
-		})
+		httperr.Respond(context, err)
 		return
 	}
 
 	context.JSON(http.StatusOK, acct)
 }
 
-// loadAll retrieves all the registered accounts
+// balance retrieves the balance of an account as of the as_of time, which
+// defaults to now
+func (h *AccountHandler) balance(context *gin.Context) {
+	id := context.Param("id")
+	if id == "" {
+		h.Logger.Error("no account id found")
+
+		httperr.Respond(context, accountIDRequired)
+		return
+	}
+
+	asOf := time.Now().UTC()
+	if s := context.Query("as_of"); s != "" {
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			h.Logger.Error(err)
+
+			httperr.Respond(context, ErrInvalidAsOf)
+			return
+		}
+		asOf = t
+	}
+
+	balance, err := h.Service.BalanceAsOf(context, id, asOf)
+	if err != nil {
+		h.Logger.Error(err)
+
+		httperr.Respond(context, err)
+		return
+	}
+
+	context.JSON(http.StatusOK, balance)
+}
+
+// decimalPattern matches the balances stored as sort keys in cursors
+var decimalPattern = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+
+// QueryFromRequest builds an account query from the query parameters of a
+// listing request
+func QueryFromRequest(context *gin.Context) (Query, error) {
+	var (
+		q   Query
+		err error
+	)
+
+	if q.Limit, err = pagination.ParseLimit(context.Query("limit")); err != nil {
+		return Query{}, err
+	}
+
+	// The newest accounts come first unless another order is requested
+	sort := context.DefaultQuery("sort", "-"+SortCreatedAt)
+	q.Descending = strings.HasPrefix(sort, "-")
+	q.Sort = strings.TrimPrefix(sort, "-")
+	if q.Sort != SortCreatedAt && q.Sort != SortBalance {
+		return Query{}, ErrInvalidSort
+	}
+
+	if s := context.Query("cursor"); s != "" {
+		c, err := pagination.Decode(s)
+		if err != nil {
+			return Query{}, err
+		}
+		// The cursor must have been issued for the same sort key
+		switch q.Sort {
+		case SortCreatedAt:
+			if _, err := time.Parse(time.RFC3339Nano, c.Key); err != nil {
+				return Query{}, pagination.ErrInvalidCursor
+			}
+		case SortBalance:
+			if !decimalPattern.MatchString(c.Key) {
+				return Query{}, pagination.ErrInvalidCursor
+			}
+		}
+		q.After = &c
+	}
+
+	q.Currency = context.Query("currency")
+	if q.Currency != "" && !currency.IsSupported(q.Currency) {
+		return Query{}, currency.ErrNotSupported
+	}
+
+	q.Status = context.Query("status")
+	if q.Status != "" && !ValidStatus(q.Status) {
+		return Query{}, ErrInvalidFilter("status")
+	}
+
+	q.CustomerID = context.Query("customer_id")
+	if q.CustomerID != "" {
+		if _, err := uuid.FromString(q.CustomerID); err != nil {
+			return Query{}, ErrInvalidFilter("customer_id")
+		}
+	}
+
+	for _, f := range []struct {
+		name    string
+		balance **money.Money
+	}{{"min_balance", &q.MinBalance}, {"max_balance", &q.MaxBalance}} {
+		if s := context.Query(f.name); s != "" {
+			balance, err := money.Parse(s, q.Currency)
+			if err != nil {
+				return Query{}, ErrInvalidFilter(f.name)
+			}
+			*f.balance = &balance
+		}
+	}
+
+	return q, nil
+}
+
+// loadAll retrieves a page of the registered accounts
 func (h *AccountHandler) loadAll(context *gin.Context) {
-	accounts := h.Service.LoadAll(context)
+	q, err := QueryFromRequest(context)
+	if err != nil {
+		h.Logger.Error(err)
 
-	context.JSON(http.StatusOK, accounts)
+		httperr.Respond(context, err)
+		return
+	}
+
+	page, err := h.Service.LoadAll(context, q)
+	if err != nil {
+		h.Logger.Error(err)
+
+		httperr.Respond(context, err)
+		return
+	}
+
+	context.JSON(http.StatusOK, page)
 }
 
-// validCurrency validates if the given currency is supported
-func validCurrency(fl validator.FieldLevel) bool {
-	if currency, ok := fl.Field().Interface().(string); ok {
-		return IsSupportedCurrency(currency)
+// validBalance validates if the given balance is a non-negative amount
+// of the request currency
+func validBalance(fl validator.FieldLevel) bool {
+	currency := fl.Parent().FieldByName("Currency").String()
+	balance, err := money.Parse(fl.Field().String(), currency)
+	if err != nil {
+		return false
 	}
-	return false
+	return !balance.IsNegative()
 }
 
 // storeRequest
 type storeRequest struct {
-	Balance  float64 `json:"balance" validate:"required"`
-	Currency string  `json:"currency" validate:"currency"`
+	Balance    json.Number `json:"balance" validate:"required,balance"`
+	Currency   string      `json:"currency" validate:"currency"`
+	CustomerID string      `json:"customer_id" validate:"omitempty,uuid"`
 }
 
-func accountRequestFromAccountDomain(p storeRequest) Account {
-	return Account{
-		ID:       nextAccountID(), // Generate a new uuid
-		Balance:  p.Balance,
-		Currency: p.Currency,
+func accountRequestFromAccountDomain(p storeRequest) (Account, error) {
+	balance, err := money.Parse(p.Balance.String(), p.Currency)
+	if err != nil {
+		return Account{}, err
 	}
+
+	return Account{
+		ID:         nextAccountID(), // Generate a new uuid
+		Balance:    balance,
+		Currency:   p.Currency,
+		CustomerID: p.CustomerID,
+	}, nil
 }
 
 // register registers a new account
@@ -87,22 +232,20 @@ func (h *AccountHandler) register(context *gin.Context) {
 	if err := context.ShouldBindJSON(&storeReq); err != nil {
 		h.Logger.Error(err)
 
-		context.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		httperr.Respond(context, errs.Invalid(err))
 		return
 	}
 
 	validate := validator.New()
-	validate.RegisterValidation("currency", validCurrency)
+	validate.RegisterTagNameFunc(httperr.JSONFieldName)
+	validate.RegisterValidation("currency", currency.Validate)
+	validate.RegisterValidation("balance", validBalance)
 
 	err := validate.Var(storeReq.Currency, "currency")
 	if err != nil {
 		h.Logger.Error(err)
 
-		context.JSON(http.StatusBadRequest, gin.H{
-			"error": currencyNotSupported,
-		})
+		httperr.Respond(context, currency.ErrNotSupported)
 		return
 	}
 
@@ -110,50 +253,200 @@ func (h *AccountHandler) register(context *gin.Context) {
 	if err != nil {
 		h.Logger.Error(err)
 
-		context.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		httperr.Respond(context, errs.Invalid(err))
 		return
 	}
 
-	acct := accountRequestFromAccountDomain(storeReq)
+	acct, err := accountRequestFromAccountDomain(storeReq)
+	if err != nil {
+		h.Logger.Error(err)
+
+		httperr.Respond(context, errs.Invalid(err))
+		return
+	}
 
 	account, err := h.Service.Register(context, acct)
 	if err != nil {
 		h.Logger.Error(err)
 
-		context.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
+		httperr.Respond(context, err)
 		return
 	}
 
 	context.JSON(http.StatusCreated, account)
 }
 
-// clean deletes an account by ID
+// statusRequest
+type statusRequest struct {
+	Reason string `json:"reason" validate:"required,max=500"`
+}
+
+// statusChanger is one of the service methods changing the status of an account
+type statusChanger func(ctx context.Context, id string, reason string) (Account, error)
+
+// changeStatus returns a handler moving an account to another status with the
+// reason given in the request body
+func (h *AccountHandler) changeStatus(change statusChanger) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		id := context.Param("id")
+		if id == "" {
+			h.Logger.Error("no account id found")
+
+			httperr.Respond(context, accountIDRequired)
+			return
+		}
+
+		var statusReq statusRequest
+		if err := context.ShouldBindJSON(&statusReq); err != nil {
+			h.Logger.Error(err)
+
+			httperr.Respond(context, errs.Invalid(err))
+			return
+		}
+		validate := validator.New()
+		validate.RegisterTagNameFunc(httperr.JSONFieldName)
+		if err := validate.Struct(statusReq); err != nil {
+			h.Logger.Error(err)
+
+			httperr.Respond(context, errs.Invalid(err))
+			return
+		}
+
+		account, err := change(context, id, statusReq.Reason)
+		if err != nil {
+			h.Logger.Error(err)
+
+			httperr.Respond(context, err)
+			return
+		}
+
+		context.JSON(http.StatusOK, account)
+	}
+}
+
+// clean closes an account by ID. Accounts are never deleted so that the
+// transactions referencing them keep making sense.
 func (h *AccountHandler) clean(context *gin.Context) {
 	id := context.Param("id")
 	if id == "" {
 		h.Logger.Error("no account id found")
 
-		context.JSON(http.StatusBadRequest, gin.H{
-			"error": accountIDRequired,
-		})
+		httperr.Respond(context, accountIDRequired)
+		return
+	}
+
+	account, err := h.Service.Close(context, id, context.Query("reason"))
+	if err != nil {
+		h.Logger.Error(err)
+
+		httperr.Respond(context, err)
+		return
+	}
+
+	context.JSON(http.StatusOK, account)
+}
+
+// roles retrieves the roles of the customers on an account
+func (h *AccountHandler) roles(context *gin.Context) {
+	id := context.Param("id")
+	if id == "" {
+		h.Logger.Error("no account id found")
+
+		httperr.Respond(context, accountIDRequired)
+		return
+	}
+
+	grants, err := h.Service.Roles(context, id)
+	if err != nil {
+		h.Logger.Error(err)
+
+		httperr.Respond(context, err)
+		return
+	}
+
+	context.JSON(http.StatusOK, grants)
+}
+
+// roleRequest
+type roleRequest struct {
+	Role Role `json:"role" validate:"required"`
+}
+
+// grantRole gives the customer of the path the role of the request body
+func (h *AccountHandler) grantRole(context *gin.Context) {
+	id := context.Param("id")
+	if id == "" {
+		h.Logger.Error("no account id found")
+
+		httperr.Respond(context, accountIDRequired)
+		return
+	}
+
+	var roleReq roleRequest
+	if err := context.ShouldBindJSON(&roleReq); err != nil {
+		h.Logger.Error(err)
+
+		httperr.Respond(context, errs.Invalid(err))
+		return
+	}
+	validate := validator.New()
+	validate.RegisterTagNameFunc(httperr.JSONFieldName)
+	if err := validate.Struct(roleReq); err != nil {
+		h.Logger.Error(err)
+
+		httperr.Respond(context, errs.Invalid(err))
+		return
+	}
+
+	grant, err := h.Service.GrantRole(context, id, context.Param("customer_id"), roleReq.Role)
+	if err != nil {
+		h.Logger.Error(err)
+
+		httperr.Respond(context, err)
+		return
+	}
+
+	context.JSON(http.StatusOK, grant)
+}
+
+// revokeRole takes back the role of the customer of the path
+func (h *AccountHandler) revokeRole(context *gin.Context) {
+	id := context.Param("id")
+	if id == "" {
+		h.Logger.Error("no account id found")
+
+		httperr.Respond(context, accountIDRequired)
+		return
+	}
+
+	if err := h.Service.RevokeRole(context, id, context.Param("customer_id")); err != nil {
+		h.Logger.Error(err)
+
+		httperr.Respond(context, err)
+		return
+	}
+
+	context.Status(http.StatusNoContent)
+}
+
+// roleChanges retrieves the audit of the roles of an account, the oldest
+// change first
+func (h *AccountHandler) roleChanges(context *gin.Context) {
+	id := context.Param("id")
+	if id == "" {
+		h.Logger.Error("no account id found")
+
+		httperr.Respond(context, accountIDRequired)
 		return
 	}
 
-	err := h.Service.Clean(context, id)
+	changes, err := h.Service.RoleChanges(context, id)
 	if err != nil {
 		h.Logger.Error(err)
 
-		context.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
+		httperr.Respond(context, err)
 		return
 	}
 
-	context.JSON(http.StatusOK, gin.H{
-		id: "Deleted",
-	})
+	context.JSON(http.StatusOK, changes)
 }
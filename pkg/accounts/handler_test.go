@@ -5,9 +5,16 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"financial-app/pkg/currency"
+	"financial-app/pkg/errs"
+	"financial-app/pkg/http/rest/httperr"
+	"financial-app/pkg/money"
+	"financial-app/pkg/pagination"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -20,9 +27,9 @@ type MockService struct {
 	mock.Mock
 }
 
-func (m *MockService) LoadAll(ctx context.Context) []Account {
-	args := m.Called(ctx)
-	return args.Get(0).([]Account)
+func (m *MockService) LoadAll(ctx context.Context, q Query) (Page, error) {
+	args := m.Called(ctx, q)
+	return args.Get(0).(Page), args.Error(1)
 }
 
 func (m *MockService) Load(ctx context.Context, id string) (Account, error) {
@@ -35,50 +42,166 @@ func (m *MockService) Register(ctx context.Context, acct Account) (Account, erro
 	return args.Get(0).(Account), args.Error(1)
 }
 
-func (m *MockService) Clean(ctx context.Context, id string) error {
+func (m *MockService) Freeze(ctx context.Context, id string, reason string) (Account, error) {
+	args := m.Called(ctx, id, reason)
+	return args.Get(0).(Account), args.Error(1)
+}
+
+func (m *MockService) Unfreeze(ctx context.Context, id string, reason string) (Account, error) {
+	args := m.Called(ctx, id, reason)
+	return args.Get(0).(Account), args.Error(1)
+}
+
+func (m *MockService) Close(ctx context.Context, id string, reason string) (Account, error) {
+	args := m.Called(ctx, id, reason)
+	return args.Get(0).(Account), args.Error(1)
+}
+
+func (m *MockService) BalanceAsOf(ctx context.Context, id string, asOf time.Time) (Balance, error) {
+	args := m.Called(ctx, id, asOf)
+	return args.Get(0).(Balance), args.Error(1)
+}
+
+func (m *MockService) SnapshotBalances(ctx context.Context, asOf time.Time) (int64, error) {
+	args := m.Called(ctx, asOf)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockService) Roles(ctx context.Context, id string) ([]Grant, error) {
 	args := m.Called(ctx, id)
+	return args.Get(0).([]Grant), args.Error(1)
+}
+
+func (m *MockService) GrantRole(
+	ctx context.Context, id string, customerID string, role Role,
+) (Grant, error) {
+	args := m.Called(ctx, id, customerID, role)
+	return args.Get(0).(Grant), args.Error(1)
+}
+
+func (m *MockService) RevokeRole(ctx context.Context, id string, customerID string) error {
+	args := m.Called(ctx, id, customerID)
 	return args.Error(0)
 }
 
-func TestAccountHandler_LoadAll(t *testing.T) {
-	// Create a mock service and an AccountHandler instance using the mock service
-	logger, _ := zap.NewDevelopment()
-	mockService := new(MockService)
-	handler := &AccountHandler{Service: mockService, Logger: logger.Sugar()}
+func (m *MockService) RoleChanges(ctx context.Context, id string) ([]RoleChange, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).([]RoleChange), args.Error(1)
+}
 
-	// Create a router and register the accounts route
-	r := gin.Default()
-	r.GET("/accounts", handler.loadAll)
+func TestAccountHandler_LoadAll(t *testing.T) {
+	minBalance := money.New(10000, "EUR")
+	cursor := pagination.Cursor{Key: "100.00", ID: "account-id-2"}
+	defaultQuery := Query{Sort: SortCreatedAt, Descending: true, Limit: pagination.DefaultLimit}
 
 	// Define test cases
 	testCases := []struct {
 		Name             string
-		ExpectedResponse []Account
+		URL              string
+		ExpectedQuery    Query
+		ExpectedResponse Page
+		ServiceError     error
 		ExpectedCode     int
 	}{
 		{
-			Name: "Accounts Found",
-			ExpectedResponse: []Account{
-				{ID: "account-id-1", Balance: 100, Currency: "EUR"},
-				{ID: "account-id-2", Balance: 200, Currency: "EUR"},
+			Name:          "Accounts Found",
+			URL:           "/accounts",
+			ExpectedQuery: defaultQuery,
+			ExpectedResponse: Page{
+				Accounts: []Account{
+					{ID: "account-id-1", Balance: money.New(10000, "EUR"), Currency: "EUR", Status: StatusActive},
+					{ID: "account-id-2", Balance: money.New(20000, "EUR"), Currency: "EUR", Status: StatusActive},
+				},
+				NextCursor: "next",
 			},
 			ExpectedCode: http.StatusOK,
 		},
 		{
 			Name:             "No Accounts",
-			ExpectedResponse: nil,
+			URL:              "/accounts",
+			ExpectedQuery:    defaultQuery,
+			ExpectedResponse: Page{Accounts: []Account{}},
+			ExpectedCode:     http.StatusOK,
+		},
+		{
+			Name: "Filtered And Sorted",
+			URL: "/accounts?sort=balance&limit=10&cursor=" + cursor.Encode() +
+				"&currency=EUR&status=active&min_balance=100",
+			ExpectedQuery: Query{
+				Currency:   "EUR",
+				Status:     StatusActive,
+				MinBalance: &minBalance,
+				Sort:       SortBalance,
+				After:      &cursor,
+				Limit:      10,
+			},
+			ExpectedResponse: Page{Accounts: []Account{}},
 			ExpectedCode:     http.StatusOK,
 		},
+		{
+			Name: "Customer Filter",
+			URL:  "/accounts?customer_id=5d7e6e6c-52b4-4a3e-9d1c-3f7b1d2c9a10",
+			ExpectedQuery: Query{
+				CustomerID: "5d7e6e6c-52b4-4a3e-9d1c-3f7b1d2c9a10",
+				Sort:       SortCreatedAt,
+				Descending: true,
+				Limit:      pagination.DefaultLimit,
+			},
+			ExpectedResponse: Page{Accounts: []Account{}},
+			ExpectedCode:     http.StatusOK,
+		},
+		{
+			Name:         "Invalid Customer Filter",
+			URL:          "/accounts?customer_id=1111",
+			ExpectedCode: http.StatusBadRequest,
+		},
+		{
+			Name:         "Invalid Sort",
+			URL:          "/accounts?sort=id",
+			ExpectedCode: http.StatusBadRequest,
+		},
+		{
+			Name:         "Cursor Of Another Sort",
+			URL:          "/accounts?sort=-created_at&cursor=" + cursor.Encode(),
+			ExpectedCode: http.StatusBadRequest,
+		},
+		{
+			Name:         "Invalid Status",
+			URL:          "/accounts?status=dormant",
+			ExpectedCode: http.StatusBadRequest,
+		},
+		{
+			Name:         "Invalid Balance",
+			URL:          "/accounts?max_balance=ten",
+			ExpectedCode: http.StatusBadRequest,
+		},
+		{
+			Name:          "Service Error",
+			URL:           "/accounts",
+			ExpectedQuery: defaultQuery,
+			ServiceError:  ErrListingAccounts,
+			ExpectedCode:  http.StatusInternalServerError,
+		},
 	}
 
 	// Iterate through test cases and run the tests
 	for _, tc := range testCases {
 		t.Run(tc.Name, func(t *testing.T) {
+			// Create a mock service and an AccountHandler instance using the mock service
+			logger, _ := zap.NewDevelopment()
+			mockService := new(MockService)
+			handler := &AccountHandler{Service: mockService, Logger: logger.Sugar()}
+
+			// Create a router and register the accounts route
+			r := gin.Default()
+			r.GET("/accounts", handler.loadAll)
+
 			// Set up expected behavior for the mock service method
-			mockService.On("LoadAll", mock.Anything).Return(tc.ExpectedResponse)
+			mockService.On("LoadAll", mock.Anything, tc.ExpectedQuery).
+				Return(tc.ExpectedResponse, tc.ServiceError)
 
 			// Create a new HTTP request
-			req, _ := http.NewRequest("GET", "/accounts", nil)
+			req, _ := http.NewRequest("GET", tc.URL, nil)
 			rr := httptest.NewRecorder()
 
 			// Serve the request using the router
@@ -87,12 +210,11 @@ func TestAccountHandler_LoadAll(t *testing.T) {
 			// Perform assertions
 			assert.Equal(t, tc.ExpectedCode, rr.Code) // Check HTTP response status code
 
-			// If accounts are expected, assert the accounts in the response body
-			if tc.ExpectedResponse != nil {
-				var accounts []Account
-				err := json.NewDecoder(rr.Body).Decode(&accounts)
+			// If accounts are expected, assert the page in the response body
+			if tc.ExpectedCode == http.StatusOK {
+				expected, err := json.Marshal(tc.ExpectedResponse)
 				assert.NoError(t, err)
-				assert.Equal(t, tc.ExpectedResponse, accounts)
+				assert.JSONEq(t, string(expected), rr.Body.String())
 			}
 		})
 	}
@@ -119,23 +241,51 @@ func TestAccountHandler_Register(t *testing.T) {
 		{
 			Name: "Valid Registration",
 			Request: storeRequest{
-				Balance:  100,
+				Balance:  "100",
 				Currency: "USD",
 			},
 			ExpectedError: nil,
 			ExpectedCode:  http.StatusCreated,
 			ExpectedResponse: Account{
-				Balance:  100,
+				Balance:  money.New(10000, "USD"),
 				Currency: "USD",
 			},
 		},
 		{
 			Name: "Invalid Currency",
 			Request: storeRequest{
-				Balance:  200,
+				Balance:  "200",
 				Currency: "XYZ",
 			},
-			ExpectedError:    errors.New(currencyNotSupported),
+			ExpectedError:    currency.ErrNotSupported,
+			ExpectedCode:     http.StatusBadRequest,
+			ExpectedResponse: Account{},
+		},
+		{
+			Name: "Too Many Decimals",
+			Request: storeRequest{
+				Balance:  "10.001",
+				Currency: "EUR",
+			},
+			ExpectedCode:     http.StatusBadRequest,
+			ExpectedResponse: Account{},
+		},
+		{
+			Name: "Negative Balance",
+			Request: storeRequest{
+				Balance:  "-10",
+				Currency: "EUR",
+			},
+			ExpectedCode:     http.StatusBadRequest,
+			ExpectedResponse: Account{},
+		},
+		{
+			Name: "Invalid Customer",
+			Request: storeRequest{
+				Balance:    "10",
+				Currency:   "EUR",
+				CustomerID: "1111",
+			},
 			ExpectedCode:     http.StatusBadRequest,
 			ExpectedResponse: Account{},
 		},
@@ -161,20 +311,69 @@ func TestAccountHandler_Register(t *testing.T) {
 
 			// If a response is expected, assert the response in the body
 			if tc.ExpectedError != nil {
-				// Convert the JSON response to a map
-				var response map[string]string
+				// Convert the JSON response to a problem
+				var response httperr.Problem
 				err := json.Unmarshal(rr.Body.Bytes(), &response)
-				// Grab the value & whether or not it exists
-				errorMsg, exists := response["error"]
+				// Grab the detail of the problem
+				errorMsg := response.Detail
 				// Make some assertions on the correctness of the response.
 				assert.Nil(t, err)
-				assert.True(t, exists)
 				assert.Equal(t, tc.ExpectedError.Error(), errorMsg)
 			}
 		})
 	}
 }
 
+func TestAccountHandler_RegisterServiceErrors(t *testing.T) {
+	testCases := []struct {
+		Name              string
+		ServiceError      error
+		ExpectedCode      int
+		ExpectedErrorCode string
+		ExpectedDetail    string
+	}{
+		{Name: "Duplicate Account", ServiceError: ErrDuplicateAccount, ExpectedCode: http.StatusConflict,
+			ExpectedErrorCode: "duplicate_account", ExpectedDetail: ErrDuplicateAccount.Error()},
+		{Name: "Invalid Currency Code", ServiceError: ErrInvalidCurrencyCode,
+			ExpectedCode: http.StatusUnprocessableEntity, ExpectedErrorCode: "invalid_currency_code",
+			ExpectedDetail: ErrInvalidCurrencyCode.Error()},
+		// The internal message of a server error is not sent to the client
+		{Name: "Unexpected Error", ServiceError: ErrPostingAccount("account-id"),
+			ExpectedCode: http.StatusInternalServerError, ExpectedErrorCode: "posting_account_failed",
+			ExpectedDetail: "the server could not complete the request"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			logger, _ := zap.NewDevelopment()
+			mockService := new(MockService)
+			handler := &AccountHandler{Service: mockService, Logger: logger.Sugar()}
+
+			r := gin.Default()
+			r.POST("/accounts", handler.register)
+
+			mockService.On("Register", mock.Anything, mock.Anything).
+				Return(Account{}, tc.ServiceError)
+
+			requestBody, _ := json.Marshal(storeRequest{Balance: "100", Currency: "EUR"})
+			req, _ := http.NewRequest("POST", "/accounts", bytes.NewReader(requestBody))
+			rr := httptest.NewRecorder()
+
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.ExpectedCode, rr.Code)
+			assert.Equal(t, httperr.ContentType, rr.Header().Get("Content-Type"))
+			var response httperr.Problem
+			assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+			assert.Equal(t, tc.ExpectedCode, response.Status)
+			assert.Equal(t, tc.ExpectedErrorCode, response.Code)
+			assert.Equal(t, "/problems/"+tc.ExpectedErrorCode, response.Type)
+			assert.Equal(t, "/accounts", response.Instance)
+			assert.Equal(t, tc.ExpectedDetail, response.Detail)
+		})
+	}
+}
+
 func TestAccountHandler_Load(t *testing.T) {
 	// Create a mock service and an AccountHandler instance using the mock service
 	logger, _ := zap.NewDevelopment()
@@ -187,10 +386,12 @@ func TestAccountHandler_Load(t *testing.T) {
 
 	// Define test cases
 	testCases := []struct {
-		Name          string
-		AccountID     string
-		ExpectedError error
-		ExpectedCode  int
+		Name              string
+		AccountID         string
+		ExpectedError     error
+		ExpectedCode      int
+		ExpectedMessage   string
+		ExpectedErrorCode string
 	}{
 		{
 			Name:          "Account Found",
@@ -199,10 +400,21 @@ func TestAccountHandler_Load(t *testing.T) {
 			ExpectedCode:  http.StatusOK,
 		},
 		{
-			Name:          "Account Not Found",
-			AccountID:     "non-existent-account-id",
-			ExpectedError: ErrFetchingAccount("non-existent-account-id"),
-			ExpectedCode:  http.StatusNotFound,
+			Name:              "Account Not Found",
+			AccountID:         "non-existent-account-id",
+			ExpectedError:     ErrFetchingAccount("non-existent-account-id"),
+			ExpectedCode:      http.StatusNotFound,
+			ExpectedMessage:   "could not fetch account by ID non-existent-account-id",
+			ExpectedErrorCode: "account_not_found",
+		},
+		{
+			Name:      "Storage Error",
+			AccountID: "unreachable-account-id",
+			ExpectedError: ErrQueryingAccounts([]string{"unreachable-account-id"}).
+				Wrap(errors.New("connection refused")),
+			ExpectedCode:      http.StatusInternalServerError,
+			ExpectedMessage:   "the server could not complete the request",
+			ExpectedErrorCode: "querying_accounts_failed",
 		},
 	}
 
@@ -225,15 +437,16 @@ func TestAccountHandler_Load(t *testing.T) {
 
 			// If an error is expected, assert the error message
 			if tc.ExpectedError != nil {
-				// Convert the JSON response to a map
-				var response map[string]string
+				// Convert the JSON response to a problem
+				var response httperr.Problem
 				err := json.Unmarshal(rr.Body.Bytes(), &response)
-				// Grab the value & whether or not it exists
-				errorMsg, exists := response["error"]
+				// Grab the detail of the problem
+				errorMsg := response.Detail
 				// Make some assertions on the correctness of the response.
+				// The cause of a server error is not sent to the client.
 				assert.Nil(t, err)
-				assert.True(t, exists)
-				assert.Equal(t, tc.ExpectedError.Error(), errorMsg)
+				assert.Equal(t, tc.ExpectedMessage, errorMsg)
+				assert.Equal(t, tc.ExpectedErrorCode, response.Code)
 			}
 		})
 	}
@@ -253,31 +466,55 @@ func TestAccountHandler_Clean(t *testing.T) {
 	testCases := []struct {
 		Name          string
 		AccountID     string
+		Reason        string
 		ExpectedError error
 		ExpectedCode  int
 	}{
 		{
-			Name:          "Account Deleted",
-			AccountID:     "valid-account-id",
-			ExpectedError: nil,
-			ExpectedCode:  http.StatusOK,
+			Name:         "Account Closed",
+			AccountID:    "valid-account-id",
+			Reason:       "customer request",
+			ExpectedCode: http.StatusOK,
+		},
+		{
+			Name:          "Account Not Found",
+			AccountID:     "unknown-account-id",
+			ExpectedError: ErrFetchingAccount("unknown-account-id"),
+			ExpectedCode:  http.StatusNotFound,
+		},
+		{
+			Name:          "Account With Balance",
+			AccountID:     "funded-account-id",
+			ExpectedError: ErrNonZeroBalance,
+			ExpectedCode:  http.StatusConflict,
 		},
 		{
-			Name:          "Account Not Deleted",
-			AccountID:     "account-id-not-deleted",
-			ExpectedError: ErrDeletingAccount("account-id-not-deleted"),
+			Name:          "Account Not Closed",
+			AccountID:     "account-id-not-closed",
+			ExpectedError: ErrUpdatingStatus("account-id-not-closed"),
 			ExpectedCode:  http.StatusInternalServerError,
 		},
 	}
 
+	// The internal message of a server error is not sent to the client
+	detail := func(err error) string {
+		if errors.Is(err, errs.ErrInternal) {
+			return "the server could not complete the request"
+		}
+		return err.Error()
+	}
+
 	// Iterate through test cases and run the tests
 	for _, tc := range testCases {
 		t.Run(tc.Name, func(t *testing.T) {
 			// Set up expected behavior for the mock service method
-			mockService.On("Clean", mock.Anything, tc.AccountID).Return(tc.ExpectedError)
+			closed := Account{ID: tc.AccountID, Status: StatusClosed, StatusReason: tc.Reason}
+			mockService.On("Close", mock.Anything, tc.AccountID, tc.Reason).
+				Return(closed, tc.ExpectedError)
 
 			// Create a new HTTP request
-			req, _ := http.NewRequest("DELETE", "/accounts/"+tc.AccountID, nil)
+			req, _ := http.NewRequest("DELETE", "/accounts/"+tc.AccountID+"?reason="+
+				url.QueryEscape(tc.Reason), nil)
 			rr := httptest.NewRecorder()
 
 			// Serve the request using the router
@@ -286,18 +523,359 @@ func TestAccountHandler_Clean(t *testing.T) {
 			// Perform assertions
 			assert.Equal(t, tc.ExpectedCode, rr.Code) // Check HTTP response status code
 
+			if tc.ExpectedError == nil {
+				var response Account
+				assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+				assert.Equal(t, StatusClosed, response.Status)
+				assert.Equal(t, tc.Reason, response.StatusReason)
+				return
+			}
+
 			// If an error is expected, assert the error message
-			if tc.ExpectedError != nil {
-				// Convert the JSON response to a map
-				var response map[string]string
-				err := json.Unmarshal(rr.Body.Bytes(), &response)
-				// Grab the value & whether or not it exists
-				errorMsg, exists := response["error"]
-				// Make some assertions on the correctness of the response.
-				assert.Nil(t, err)
-				assert.True(t, exists)
-				assert.Equal(t, tc.ExpectedError.Error(), errorMsg)
+			var response httperr.Problem
+			err := json.Unmarshal(rr.Body.Bytes(), &response)
+			assert.Nil(t, err)
+			assert.Equal(t, detail(tc.ExpectedError), response.Detail)
+		})
+	}
+}
+
+func TestAccountHandler_ChangeStatus(t *testing.T) {
+	testCases := []struct {
+		Name          string
+		Path          string
+		Method        string
+		Body          string
+		ServiceError  error
+		ExpectedCode  int
+		ExpectedCalls int
+	}{
+		{
+			Name:          "Freeze",
+			Path:          "freeze",
+			Method:        "Freeze",
+			Body:          `{"reason": "suspicious activity"}`,
+			ExpectedCode:  http.StatusOK,
+			ExpectedCalls: 1,
+		},
+		{
+			Name:          "Unfreeze",
+			Path:          "unfreeze",
+			Method:        "Unfreeze",
+			Body:          `{"reason": "suspicious activity"}`,
+			ExpectedCode:  http.StatusOK,
+			ExpectedCalls: 1,
+		},
+		{
+			Name:          "Close",
+			Path:          "close",
+			Method:        "Close",
+			Body:          `{"reason": "suspicious activity"}`,
+			ExpectedCode:  http.StatusOK,
+			ExpectedCalls: 1,
+		},
+		{
+			Name:         "Missing Reason",
+			Path:         "freeze",
+			Method:       "Freeze",
+			Body:         `{}`,
+			ExpectedCode: http.StatusBadRequest,
+		},
+		{
+			Name:         "Missing Body",
+			Path:         "freeze",
+			Method:       "Freeze",
+			ExpectedCode: http.StatusBadRequest,
+		},
+		{
+			Name:   "Invalid Transition",
+			Path:   "unfreeze",
+			Method: "Unfreeze",
+			Body:   `{"reason": "suspicious activity"}`,
+			ServiceError: &TransitionError{
+				AccountID: "account-id", From: StatusClosed, To: StatusActive,
+			},
+			ExpectedCode:  http.StatusConflict,
+			ExpectedCalls: 1,
+		},
+		{
+			Name:          "Account Not Found",
+			Path:          "freeze",
+			Method:        "Freeze",
+			Body:          `{"reason": "suspicious activity"}`,
+			ServiceError:  ErrFetchingAccount("account-id"),
+			ExpectedCode:  http.StatusNotFound,
+			ExpectedCalls: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			logger, _ := zap.NewDevelopment()
+			mockService := new(MockService)
+			handler := &AccountHandler{Service: mockService, Logger: logger.Sugar()}
+
+			r := gin.Default()
+			handler.Router(r.Group(""))
+
+			mockService.On(tc.Method, mock.Anything, "account-id", "suspicious activity").
+				Return(Account{ID: "account-id"}, tc.ServiceError)
+
+			req, _ := http.NewRequest(
+				"POST", "/accounts/account-id/"+tc.Path, bytes.NewBufferString(tc.Body),
+			)
+			rr := httptest.NewRecorder()
+
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.ExpectedCode, rr.Code)
+			mockService.AssertNumberOfCalls(t, tc.Method, tc.ExpectedCalls)
+		})
+	}
+}
+
+func TestAccountHandler_ChangeStatusValidationErrors(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	handler := &AccountHandler{Service: new(MockService), Logger: logger.Sugar()}
+
+	r := gin.Default()
+	handler.Router(r.Group(""))
+
+	req, _ := http.NewRequest("POST", "/accounts/account-id/freeze", bytes.NewBufferString(`{}`))
+	rr := httptest.NewRecorder()
+
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, httperr.ContentType, rr.Header().Get("Content-Type"))
+
+	var response httperr.Problem
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, errs.CodeInvalidRequest, response.Code)
+	assert.Equal(t, "/accounts/account-id/freeze", response.Instance)
+	assert.Equal(t, []httperr.FieldError{
+		{Field: "reason", Code: "required", Detail: "reason failed the required validation"},
+	}, response.Errors)
+}
+
+func TestAccountHandler_Balance(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	mockService := new(MockService)
+	handler := &AccountHandler{Service: mockService, Logger: logger.Sugar()}
+
+	r := gin.Default()
+	r.GET("/accounts/:id/balance", handler.balance)
+
+	asOf := time.Date(2023, 9, 30, 23, 59, 59, 0, time.UTC)
+	mockService.On("BalanceAsOf", mock.Anything, "1111", asOf).Return(Balance{
+		AccountID: "1111",
+		Balance:   money.New(5000, "EUR"),
+		Currency:  "EUR",
+		AsOf:      asOf,
+	}, nil)
+	mockService.On("BalanceAsOf", mock.Anything, "2222", mock.Anything).
+		Return(Balance{}, ErrFetchingAccount("2222"))
+
+	testCases := []struct {
+		Name             string
+		URL              string
+		ExpectedCode     int
+		ExpectedResponse string
+	}{
+		{
+			Name:         "As Of Month End",
+			URL:          "/accounts/1111/balance?as_of=2023-09-30T23:59:59Z",
+			ExpectedCode: http.StatusOK,
+			ExpectedResponse: `{"account_id":"1111","balance":"50.00","currency":"EUR",` +
+				`"as_of":"2023-09-30T23:59:59Z"}`,
+		},
+		{
+			Name:         "Invalid As Of",
+			URL:          "/accounts/1111/balance?as_of=yesterday",
+			ExpectedCode: http.StatusBadRequest,
+			ExpectedResponse: `{"type":"/problems/invalid_as_of","title":"Bad Request","status":400,` +
+				`"detail":"` + ErrInvalidAsOf.Error() + `","instance":"/accounts/1111/balance",` +
+				`"code":"invalid_as_of"}`,
+		},
+		{
+			Name:         "Unknown Account",
+			URL:          "/accounts/2222/balance",
+			ExpectedCode: http.StatusNotFound,
+			ExpectedResponse: `{"type":"/problems/account_not_found","title":"Not Found","status":404,` +
+				`"detail":"` + ErrFetchingAccount("2222").Error() + `","instance":"/accounts/2222/balance",` +
+				`"code":"account_not_found"}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", tc.URL, nil)
+			rr := httptest.NewRecorder()
+
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.ExpectedCode, rr.Code)
+			assert.JSONEq(t, tc.ExpectedResponse, rr.Body.String())
+		})
+	}
+}
+
+func TestAccountHandler_GrantRole(t *testing.T) {
+	customerID := "5d7e6e6c-52b4-4a3e-9d1c-3f7b1d2c9a10"
+	grantedAt := time.Date(2023, 9, 21, 9, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		Name              string
+		Body              string
+		ServiceError      error
+		ExpectedCode      int
+		ExpectedErrorCode string
+	}{
+		{
+			Name:         "Role Granted",
+			Body:         `{"role": "initiate"}`,
+			ExpectedCode: http.StatusOK,
+		},
+		{
+			Name:              "Missing Role",
+			Body:              `{}`,
+			ExpectedCode:      http.StatusBadRequest,
+			ExpectedErrorCode: errs.CodeInvalidRequest,
+		},
+		{
+			Name:              "Invalid Role",
+			Body:              `{"role": "admin"}`,
+			ServiceError:      ErrInvalidRole,
+			ExpectedCode:      http.StatusBadRequest,
+			ExpectedErrorCode: "invalid_role",
+		},
+		{
+			Name:              "Not Account Owner",
+			Body:              `{"role": "view"}`,
+			ServiceError:      &OwnerError{AccountID: "account-id", CustomerID: customerID},
+			ExpectedCode:      http.StatusForbidden,
+			ExpectedErrorCode: "not_account_owner",
+		},
+		{
+			Name:              "Owner Role",
+			Body:              `{"role": "approve"}`,
+			ServiceError:      ErrOwnerRole,
+			ExpectedCode:      http.StatusConflict,
+			ExpectedErrorCode: "owner_role",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			logger, _ := zap.NewDevelopment()
+			mockService := new(MockService)
+			handler := &AccountHandler{Service: mockService, Logger: logger.Sugar()}
+
+			r := gin.Default()
+			handler.Router(r.Group(""))
+
+			grant := Grant{
+				AccountID:  "account-id",
+				CustomerID: customerID,
+				Role:       RoleInitiate,
+				GrantedBy:  "key-id",
+				GrantedAt:  grantedAt,
 			}
+			mockService.On("GrantRole", mock.Anything, "account-id", customerID, mock.Anything).
+				Return(grant, tc.ServiceError)
+
+			req, _ := http.NewRequest("PUT", "/accounts/account-id/roles/"+customerID,
+				bytes.NewBufferString(tc.Body))
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.ExpectedCode, rr.Code)
+			if tc.ExpectedCode == http.StatusOK {
+				mockService.AssertCalled(t, "GrantRole", mock.Anything, "account-id",
+					customerID, RoleInitiate)
+				expected, _ := json.Marshal(grant)
+				assert.JSONEq(t, string(expected), rr.Body.String())
+				return
+			}
+
+			var response httperr.Problem
+			assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+			assert.Equal(t, tc.ExpectedErrorCode, response.Code)
+		})
+	}
+}
+
+func TestAccountHandler_RevokeRole(t *testing.T) {
+	customerID := "5d7e6e6c-52b4-4a3e-9d1c-3f7b1d2c9a10"
+
+	testCases := []struct {
+		Name         string
+		ServiceError error
+		ExpectedCode int
+	}{
+		{
+			Name:         "Role Revoked",
+			ExpectedCode: http.StatusNoContent,
+		},
+		{
+			Name:         "Role Not Found",
+			ServiceError: ErrFetchingRole("account-id", customerID),
+			ExpectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			logger, _ := zap.NewDevelopment()
+			mockService := new(MockService)
+			handler := &AccountHandler{Service: mockService, Logger: logger.Sugar()}
+
+			r := gin.Default()
+			handler.Router(r.Group(""))
+
+			mockService.On("RevokeRole", mock.Anything, "account-id", customerID).
+				Return(tc.ServiceError)
+
+			req, _ := http.NewRequest("DELETE", "/accounts/account-id/roles/"+customerID, nil)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.ExpectedCode, rr.Code)
 		})
 	}
 }
+
+func TestAccountHandler_Roles(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	mockService := new(MockService)
+	handler := &AccountHandler{Service: mockService, Logger: logger.Sugar()}
+
+	r := gin.Default()
+	handler.Router(r.Group(""))
+
+	changedAt := time.Date(2023, 9, 21, 9, 0, 0, 0, time.UTC)
+	grants := []Grant{{AccountID: "account-id", CustomerID: "customer-id", Role: RoleView,
+		GrantedAt: changedAt}}
+	changes := []RoleChange{
+		{ID: "change-1", AccountID: "account-id", CustomerID: "customer-id",
+			Action: ActionGrant, Role: RoleView, ChangedBy: "key-id", ChangedAt: changedAt},
+	}
+	mockService.On("Roles", mock.Anything, "account-id").Return(grants, nil)
+	mockService.On("RoleChanges", mock.Anything, "account-id").Return(changes, nil)
+
+	req, _ := http.NewRequest("GET", "/accounts/account-id/roles", nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	expected, _ := json.Marshal(grants)
+	assert.JSONEq(t, string(expected), rr.Body.String())
+
+	req, _ = http.NewRequest("GET", "/accounts/account-id/roles/changes", nil)
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	expected, _ = json.Marshal(changes)
+	assert.JSONEq(t, string(expected), rr.Body.String())
+}
@@ -1,12 +1,67 @@
 package accounts
 
-import "context"
+import (
+	"context"
+	"financial-app/pkg/money"
+	"financial-app/pkg/pagination"
+	"time"
+)
+
+// Sort keys of the account listing
+const (
+	SortCreatedAt = "created_at"
+	SortBalance   = "balance"
+)
+
+// Query selects accounts ordered by a sort key and then by ID. Empty fields
+// do not filter.
+type Query struct {
+	Currency   string
+	Status     string
+	CustomerID string
+	// AccessibleTo keeps the accounts a customer owns or holds a role on
+	AccessibleTo string
+	// MinBalance and MaxBalance bound the balance, both inclusive
+	MinBalance *money.Money
+	MaxBalance *money.Money
+	// Sort is either SortCreatedAt or SortBalance
+	Sort       string
+	Descending bool
+	// After continues the listing after the given cursor
+	After *pagination.Cursor
+	Limit int
+}
 
 // AccountRepository provides access an account store
 type AccountRepository interface {
 	Store(ctx context.Context, acct *Account) (*Account, error)
 	Find(ctx context.Context, id string) (*Account, error)
 	FindByIDs(ctx context.Context, ids []string) (map[string]*Account, error)
-	FindAll(ctx context.Context) []*Account
-	Delete(ctx context.Context, id string) error
+	// Query returns at most q.Limit accounts matching the query
+	Query(ctx context.Context, q Query) ([]*Account, error)
+	// UpdateStatus atomically checks that the account may move to the status
+	// of the change and updates it
+	UpdateStatus(ctx context.Context, id string, change StatusChange) (*Account, error)
+	// BalanceAsOf computes the balance of an account at the given time from
+	// the latest balance snapshot before it and the postings booked since
+	BalanceAsOf(ctx context.Context, id string, asOf time.Time) (money.Money, error)
+	// SnapshotBalances stores the balance of every account at the given time
+	// and returns how many snapshots were taken
+	SnapshotBalances(ctx context.Context, asOf time.Time) (int64, error)
+}
+
+// RoleRepository provides access to the roles of customers on accounts
+type RoleRepository interface {
+	// Find returns the role of a customer on an account
+	Find(ctx context.Context, accountID, customerID string) (*Grant, error)
+	// FindAll returns the roles on an account, ordered by customer
+	FindAll(ctx context.Context, accountID string) ([]*Grant, error)
+	// Grant stores the role of a customer on an account, replacing the role
+	// it held, and records the change atomically
+	Grant(ctx context.Context, grant *Grant, change *RoleChange) (*Grant, error)
+	// Revoke removes the role of the customer of the change and records the
+	// change atomically, filling in the revoked role
+	Revoke(ctx context.Context, change *RoleChange) error
+	// Changes returns the role changes of an account, the oldest first
+	Changes(ctx context.Context, accountID string) ([]*RoleChange, error)
 }
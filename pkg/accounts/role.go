@@ -0,0 +1,109 @@
+package accounts
+
+import (
+	"context"
+	"errors"
+	"financial-app/pkg/auth"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// Role grants a customer rights on an account it does not own
+type Role string
+
+// Roles of the customers on accounts. Each role includes the rights of the
+// previous ones: view reads the account, initiate also sends transfers from
+// it and approve also approves the transfers initiated by others. The owner
+// of an account holds every right without a role.
+const (
+	RoleView     Role = "view"
+	RoleInitiate Role = "initiate"
+	RoleApprove  Role = "approve"
+)
+
+// roleRanks orders the roles from the weakest to the strongest
+var roleRanks = map[Role]int{
+	RoleView:     1,
+	RoleInitiate: 2,
+	RoleApprove:  3,
+}
+
+// ValidRole returns true if the given role is a known role
+func ValidRole(role Role) bool {
+	_, ok := roleRanks[role]
+	return ok
+}
+
+// Includes returns true if the role grants the rights of the other role
+func (r Role) Includes(other Role) bool {
+	return ValidRole(r) && roleRanks[r] >= roleRanks[other]
+}
+
+// Grant is a read model for the role of a customer on an account
+type Grant struct {
+	AccountID  string `json:"account_id"`
+	CustomerID string `json:"customer_id"`
+	Role       Role   `json:"role"`
+	// GrantedBy is the API key which granted the role, if any
+	GrantedBy string    `json:"granted_by,omitempty"`
+	GrantedAt time.Time `json:"granted_at"`
+}
+
+// Actions of the role changes
+const (
+	ActionGrant  = "grant"
+	ActionRevoke = "revoke"
+)
+
+// RoleChange is a read model for the audit of the roles of an account. A
+// revocation holds the role that was revoked.
+type RoleChange struct {
+	ID         string `json:"id"`
+	AccountID  string `json:"account_id"`
+	CustomerID string `json:"customer_id"`
+	Action     string `json:"action"`
+	Role       Role   `json:"role"`
+	// ChangedBy is the API key which changed the role, if any
+	ChangedBy string    `json:"changed_by,omitempty"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// CheckRole returns an error unless the caller of the request holds the given
+// role on the account. The API keys issued for a customer act with the role of
+// this customer, whereas the other keys act for the operator of the app.
+func CheckRole(
+	ctx context.Context, roles RoleRepository, acct *Account, role Role,
+) error {
+	key, ok := auth.FromContext(ctx)
+	if !ok || key.CustomerID == "" || acct.CustomerID == key.CustomerID {
+		return nil
+	}
+
+	grant, err := roles.Find(ctx, acct.ID, key.CustomerID)
+	if errors.Is(err, ErrRoleNotFound) {
+		return &OwnerError{AccountID: acct.ID, CustomerID: key.CustomerID}
+	}
+	if err != nil {
+		return err
+	}
+	if !grant.Role.Includes(role) {
+		return &RoleError{AccountID: acct.ID, CustomerID: key.CustomerID, Role: role}
+	}
+	return nil
+}
+
+// CheckOwner returns an error unless the caller of the request owns the
+// account or acts for the operator of the app
+func CheckOwner(ctx context.Context, acct *Account) error {
+	key, ok := auth.FromContext(ctx)
+	if !ok || key.CustomerID == "" || acct.CustomerID == key.CustomerID {
+		return nil
+	}
+	return &OwnerError{AccountID: acct.ID, CustomerID: key.CustomerID}
+}
+
+// nextRoleChangeID generates a new role change ID.
+func nextRoleChangeID() string {
+	return uuid.NewV4().String()
+}
@@ -2,23 +2,44 @@ package accounts
 
 import (
 	"context"
+	"financial-app/pkg/auth"
+	"financial-app/pkg/money"
+	"financial-app/pkg/pagination"
 	"time"
 
 	uuid "github.com/satori/go.uuid"
 )
 
-// Constants for all supported currencies
-const (
-	USD = "USD"
-	EUR = "EUR"
-)
-
 // Account is a read model for account views
 type Account struct {
-	ID        string    `json:"id"`
-	Balance   float64   `json:"balance"`
-	Currency  string    `json:"currency"`
-	CreatedAt time.Time `json:"created_at"`
+	ID      string      `json:"id"`
+	Balance money.Money `json:"balance"`
+	// AvailableBalance is the balance less the amounts held on the account by
+	// the holds and the transfers waiting for an approval
+	AvailableBalance money.Money `json:"available_balance"`
+	Currency         string      `json:"currency"`
+	Status           string      `json:"status"`
+	// StatusReason and StatusChangedAt describe the latest status change
+	StatusReason    string     `json:"status_reason,omitempty"`
+	StatusChangedAt *time.Time `json:"status_changed_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	// CustomerID is the customer owning the account, if any
+	CustomerID string `json:"customer_id,omitempty"`
+}
+
+// Page is a read model for a page of accounts. NextCursor is empty on the
+// last page.
+type Page struct {
+	Accounts   []Account `json:"accounts"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+}
+
+// Balance is a read model for point-in-time balance views
+type Balance struct {
+	AccountID string      `json:"account_id"`
+	Balance   money.Money `json:"balance"`
+	Currency  string      `json:"currency"`
+	AsOf      time.Time   `json:"as_of"`
 }
 
 // Service is the interface that provides account methods
@@ -29,11 +50,37 @@ type Service interface {
 	// Register registers a new account
 	Register(ctx context.Context, acct Account) (Account, error)
 
-	// LoadAll returns a list of accounts have been registered
-	LoadAll(ctx context.Context) []Account
+	// LoadAll returns a page of the registered accounts matching the query
+	LoadAll(ctx context.Context, q Query) (Page, error)
+
+	// Freeze stops an active account from sending and receiving transfers
+	Freeze(ctx context.Context, id string, reason string) (Account, error)
+
+	// Unfreeze makes a frozen account active again. Only the operator
+	// unfreezes accounts.
+	Unfreeze(ctx context.Context, id string, reason string) (Account, error)
+
+	// Close closes an active account with a zero balance for good
+	Close(ctx context.Context, id string, reason string) (Account, error)
+
+	// BalanceAsOf returns the balance of an account at the given time
+	BalanceAsOf(ctx context.Context, id string, asOf time.Time) (Balance, error)
+
+	// SnapshotBalances takes a snapshot of the balances at the given time
+	SnapshotBalances(ctx context.Context, asOf time.Time) (int64, error)
+
+	// Roles returns the roles of the customers on an account
+	Roles(ctx context.Context, id string) ([]Grant, error)
+
+	// GrantRole gives a customer a role on an account, replacing the role it
+	// held if any
+	GrantRole(ctx context.Context, id string, customerID string, role Role) (Grant, error)
 
-	// Clean deletes an account
-	Clean(ctx context.Context, id string) error
+	// RevokeRole takes back the role of a customer on an account
+	RevokeRole(ctx context.Context, id string, customerID string) error
+
+	// RoleChanges returns who granted and revoked the roles on an account
+	RoleChanges(ctx context.Context, id string) ([]RoleChange, error)
 }
 
 func (s *service) Load(
@@ -44,12 +91,29 @@ func (s *service) Load(
 	if err != nil {
 		return Account{}, err
 	}
+	if err := CheckRole(ctx, s.roles, account, RoleView); err != nil {
+		return Account{}, err
+	}
 	return *account, nil
 }
 
 func (s *service) Register(
 	ctx context.Context, acct Account,
 ) (Account, error) {
+	// Customers open empty accounts for themselves, whereas the operator
+	// opens accounts for anyone with an opening balance
+	if key, ok := auth.FromContext(ctx); ok && key.CustomerID != "" {
+		if acct.CustomerID == "" {
+			acct.CustomerID = key.CustomerID
+		}
+		if acct.CustomerID != key.CustomerID {
+			return Account{}, ErrOtherCustomer
+		}
+		if !acct.Balance.IsZero() {
+			return Account{}, ErrOpeningBalance
+		}
+	}
+
 	// Store the new account to the repository
 	account, err := s.accounts.Store(ctx, &acct)
 	if err != nil {
@@ -58,31 +122,252 @@ func (s *service) Register(
 	return *account, nil
 }
 
-func (s *service) LoadAll(ctx context.Context) []Account {
-	var accounts []Account
-	for _, a := range s.accounts.FindAll(ctx) {
-		accounts = append(accounts, *a)
+func (s *service) LoadAll(ctx context.Context, q Query) (Page, error) {
+	// Customers only see the accounts they own or hold a role on
+	if key, ok := auth.FromContext(ctx); ok && key.CustomerID != "" {
+		q.AccessibleTo = key.CustomerID
+	}
+
+	// Fetch one more account to find out if there is a next page
+	limit := q.Limit
+	q.Limit++
+	found, err := s.accounts.Query(ctx, q)
+	if err != nil {
+		return Page{}, err
+	}
+
+	page := Page{Accounts: make([]Account, 0, len(found))}
+	for i, a := range found {
+		if i == limit {
+			last := page.Accounts[limit-1]
+			page.NextCursor = pagination.Cursor{
+				Key: sortKey(last, q.Sort),
+				ID:  last.ID,
+			}.Encode()
+			break
+		}
+		page.Accounts = append(page.Accounts, *a)
+	}
+	return page, nil
+}
+
+// sortKey returns the value of the sort key of an account as stored in cursors
+func sortKey(acct Account, sort string) string {
+	if sort == SortBalance {
+		return acct.Balance.String()
 	}
-	return accounts
+	return acct.CreatedAt.Format(time.RFC3339Nano)
 }
 
-func (s *service) Clean(ctx context.Context, id string) error {
-	if err := s.accounts.Delete(ctx, id); err != nil {
+func (s *service) Freeze(
+	ctx context.Context, id string, reason string,
+) (Account, error) {
+	return s.changeStatus(ctx, id, StatusFrozen, reason)
+}
+
+func (s *service) Unfreeze(
+	ctx context.Context, id string, reason string,
+) (Account, error) {
+	// A frozen account stays frozen until the operator unfreezes it, even
+	// for its owner
+	if key, ok := auth.FromContext(ctx); ok && key.CustomerID != "" {
+		return Account{}, ErrUnfreezeNotAllowed
+	}
+	return s.changeStatus(ctx, id, StatusActive, reason)
+}
+
+func (s *service) Close(
+	ctx context.Context, id string, reason string,
+) (Account, error) {
+	return s.changeStatus(ctx, id, StatusClosed, reason)
+}
+
+// changeStatus moves an account to another status on behalf of its owner or
+// the operator, if the transition is allowed
+func (s *service) changeStatus(
+	ctx context.Context, id string, status string, reason string,
+) (Account, error) {
+	// Only the owner and the operator change the status of an account. Reject
+	// early if the transition is not allowed: the repository checks it again
+	// under lock when updating the status.
+	account, err := s.loadOwned(ctx, id)
+	if err != nil {
+		return Account{}, err
+	}
+	if err := CheckTransition(account, status); err != nil {
+		return Account{}, err
+	}
+
+	account, err = s.accounts.UpdateStatus(ctx, id, StatusChange{
+		Status:    status,
+		Reason:    reason,
+		ChangedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return Account{}, err
+	}
+	return *account, nil
+}
+
+func (s *service) BalanceAsOf(
+	ctx context.Context, id string, asOf time.Time,
+) (Balance, error) {
+	account, err := s.accounts.Find(ctx, id)
+	if err != nil {
+		return Balance{}, err
+	}
+	if err := CheckRole(ctx, s.roles, account, RoleView); err != nil {
+		return Balance{}, err
+	}
+
+	balance, err := s.accounts.BalanceAsOf(ctx, id, asOf)
+	if err != nil {
+		return Balance{}, err
+	}
+	return Balance{
+		AccountID: id,
+		Balance:   balance,
+		Currency:  balance.Currency(),
+		AsOf:      asOf,
+	}, nil
+}
+
+func (s *service) SnapshotBalances(
+	ctx context.Context, asOf time.Time,
+) (int64, error) {
+	return s.accounts.SnapshotBalances(ctx, asOf)
+}
+
+func (s *service) Roles(ctx context.Context, id string) ([]Grant, error) {
+	if _, err := s.loadOwned(ctx, id); err != nil {
+		return nil, err
+	}
+
+	found, err := s.roles.FindAll(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	grants := make([]Grant, 0, len(found))
+	for _, g := range found {
+		grants = append(grants, *g)
+	}
+	return grants, nil
+}
+
+func (s *service) GrantRole(
+	ctx context.Context, id string, customerID string, role Role,
+) (Grant, error) {
+	if !ValidRole(role) {
+		return Grant{}, ErrInvalidRole
+	}
+	if _, err := uuid.FromString(customerID); err != nil {
+		return Grant{}, ErrUnknownCustomer
+	}
+
+	account, err := s.loadOwned(ctx, id)
+	if err != nil {
+		return Grant{}, err
+	}
+	if account.CustomerID == customerID {
+		return Grant{}, ErrOwnerRole
+	}
+
+	// The grant and its audit are stored together
+	now := time.Now().UTC()
+	keyID := callerKeyID(ctx)
+	grant, err := s.roles.Grant(ctx, &Grant{
+		AccountID:  id,
+		CustomerID: customerID,
+		Role:       role,
+		GrantedBy:  keyID,
+		GrantedAt:  now,
+	}, &RoleChange{
+		ID:         nextRoleChangeID(),
+		AccountID:  id,
+		CustomerID: customerID,
+		Action:     ActionGrant,
+		Role:       role,
+		ChangedBy:  keyID,
+		ChangedAt:  now,
+	})
+	if err != nil {
+		return Grant{}, err
+	}
+	return *grant, nil
+}
+
+func (s *service) RevokeRole(
+	ctx context.Context, id string, customerID string,
+) error {
+	if _, err := uuid.FromString(customerID); err != nil {
+		return ErrFetchingRole(id, customerID)
+	}
+	if _, err := s.loadOwned(ctx, id); err != nil {
 		return err
 	}
-	return nil
+
+	return s.roles.Revoke(ctx, &RoleChange{
+		ID:         nextRoleChangeID(),
+		AccountID:  id,
+		CustomerID: customerID,
+		Action:     ActionRevoke,
+		ChangedBy:  callerKeyID(ctx),
+		ChangedAt:  time.Now().UTC(),
+	})
+}
+
+func (s *service) RoleChanges(
+	ctx context.Context, id string,
+) ([]RoleChange, error) {
+	if _, err := s.loadOwned(ctx, id); err != nil {
+		return nil, err
+	}
+
+	found, err := s.roles.Changes(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]RoleChange, 0, len(found))
+	for _, c := range found {
+		changes = append(changes, *c)
+	}
+	return changes, nil
+}
+
+// loadOwned returns an account whose roles the caller of the request may
+// manage, that is the owner of the account or the operator of the app
+func (s *service) loadOwned(ctx context.Context, id string) (*Account, error) {
+	account, err := s.accounts.Find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckOwner(ctx, account); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// callerKeyID returns the ID of the API key of the request, if any
+func callerKeyID(ctx context.Context) string {
+	key, _ := auth.FromContext(ctx)
+	return key.ID
 }
 
 type service struct {
 	accounts AccountRepository
+	roles    RoleRepository
 }
 
 // NewService creates an account service with necessary dependencies
 func NewService(
 	accounts AccountRepository,
+	roles RoleRepository,
 ) Service {
 	return &service{
 		accounts: accounts,
+		roles:    roles,
 	}
 }
 
@@ -90,12 +375,3 @@ func NewService(
 func nextAccountID() string {
 	return uuid.NewV4().String()
 }
-
-// IsSupportedCurrency returns true if the currency is supported
-func IsSupportedCurrency(c string) bool {
-	switch c {
-	case USD, EUR:
-		return true
-	}
-	return false
-}
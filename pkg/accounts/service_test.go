@@ -2,6 +2,11 @@ package accounts
 
 import (
 	"context"
+	"errors"
+	"financial-app/pkg/auth"
+	"financial-app/pkg/money"
+	"financial-app/pkg/pagination"
+	"sort"
 	"testing"
 	"time"
 
@@ -40,24 +45,135 @@ func (m *mockAccountRepository) Store(
 	return acct, nil
 }
 
-func (m *mockAccountRepository) FindAll(
-	ctx context.Context,
-) []*Account {
+func (m *mockAccountRepository) Query(
+	ctx context.Context, q Query,
+) ([]*Account, error) {
+	// The accounts are listed in ascending order of the sort key and then of ID
+	less := func(a, b *Account) bool {
+		switch {
+		case q.Sort == SortBalance && a.Balance != b.Balance:
+			return a.Balance.Amount() < b.Balance.Amount()
+		case q.Sort == SortCreatedAt && !a.CreatedAt.Equal(b.CreatedAt):
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.ID < b.ID
+	}
+
 	accounts := make([]*Account, 0, len(m.Accounts))
-	for _, txn := range m.Accounts {
-		accounts = append(accounts, txn)
+	for _, acct := range m.Accounts {
+		if q.Currency != "" && acct.Currency != q.Currency {
+			continue
+		}
+		// The roles are left out, only the owned accounts are accessible
+		if q.AccessibleTo != "" && acct.CustomerID != q.AccessibleTo {
+			continue
+		}
+		if q.After != nil && !less(m.Accounts[q.After.ID], acct) {
+			continue
+		}
+		accounts = append(accounts, acct)
+	}
+
+	sort.Slice(accounts, func(i, j int) bool { return less(accounts[i], accounts[j]) })
+	if len(accounts) > q.Limit {
+		accounts = accounts[:q.Limit]
 	}
-	return accounts
+	return accounts, nil
 }
 
-func (m *mockAccountRepository) Delete(
-	ctx context.Context, id string,
-) error {
-	if _, ok := m.Accounts[id]; ok {
-		delete(m.Accounts, id)
-		return nil
+func (m *mockAccountRepository) UpdateStatus(
+	ctx context.Context, id string, change StatusChange,
+) (*Account, error) {
+	acct, ok := m.Accounts[id]
+	if !ok {
+		return nil, ErrFetchingAccount(id)
+	}
+	if err := CheckTransition(acct, change.Status); err != nil {
+		return nil, err
+	}
+	acct.Status = change.Status
+	acct.StatusReason = change.Reason
+	acct.StatusChangedAt = &change.ChangedAt
+	return acct, nil
+}
+
+func (m *mockAccountRepository) BalanceAsOf(
+	ctx context.Context, id string, asOf time.Time,
+) (money.Money, error) {
+	acct, ok := m.Accounts[id]
+	if !ok {
+		return money.Money{}, ErrFetchingAccount(id)
+	}
+	// The accounts do not move after their creation in these tests
+	if asOf.Before(acct.CreatedAt) {
+		return money.Zero(acct.Currency), nil
+	}
+	return acct.Balance, nil
+}
+
+func (m *mockAccountRepository) SnapshotBalances(
+	ctx context.Context, asOf time.Time,
+) (int64, error) {
+	return int64(len(m.Accounts)), nil
+}
+
+type mockRoleRepository struct {
+	// Grants are keyed by account and customer IDs
+	Grants map[string]*Grant
+	Audit  []*RoleChange
+}
+
+func (m *mockRoleRepository) Find(
+	ctx context.Context, accountID, customerID string,
+) (*Grant, error) {
+	if grant, ok := m.Grants[accountID+"/"+customerID]; ok {
+		return grant, nil
+	}
+	return nil, ErrFetchingRole(accountID, customerID)
+}
+
+func (m *mockRoleRepository) FindAll(
+	ctx context.Context, accountID string,
+) ([]*Grant, error) {
+	grants := make([]*Grant, 0)
+	for _, grant := range m.Grants {
+		if grant.AccountID == accountID {
+			grants = append(grants, grant)
+		}
+	}
+	sort.Slice(grants, func(i, j int) bool { return grants[i].CustomerID < grants[j].CustomerID })
+	return grants, nil
+}
+
+func (m *mockRoleRepository) Grant(
+	ctx context.Context, grant *Grant, change *RoleChange,
+) (*Grant, error) {
+	m.Grants[grant.AccountID+"/"+grant.CustomerID] = grant
+	m.Audit = append(m.Audit, change)
+	return grant, nil
+}
+
+func (m *mockRoleRepository) Revoke(ctx context.Context, change *RoleChange) error {
+	grant, ok := m.Grants[change.AccountID+"/"+change.CustomerID]
+	if !ok {
+		return ErrFetchingRole(change.AccountID, change.CustomerID)
+	}
+	delete(m.Grants, change.AccountID+"/"+change.CustomerID)
+	change.Role = grant.Role
+	m.Audit = append(m.Audit, change)
+	return nil
+}
+
+func (m *mockRoleRepository) Changes(
+	ctx context.Context, accountID string,
+) ([]*RoleChange, error) {
+	changes := make([]*RoleChange, 0)
+	for _, change := range m.Audit {
+		if change.AccountID == accountID {
+			changes = append(changes, change)
+		}
 	}
-	return ErrDeletingAccount(id)
+	return changes, nil
 }
 
 func TestService_LoadAccount(t *testing.T) {
@@ -65,7 +181,7 @@ func TestService_LoadAccount(t *testing.T) {
 
 	expectedAccount := Account{
 		ID:        accountID,
-		Balance:   1000.0,
+		Balance:   money.New(100000, "USD"),
 		Currency:  "USD",
 		CreatedAt: time.Now(),
 	}
@@ -78,7 +194,7 @@ func TestService_LoadAccount(t *testing.T) {
 		Accounts: mockAccount,
 	}
 
-	service := NewService(mockAccountRepository)
+	service := NewService(mockAccountRepository, nil)
 
 	loadedAccount, err := service.Load(context.Background(), accountID)
 
@@ -91,7 +207,7 @@ func TestService_Store(t *testing.T) {
 
 	expectedAccount := Account{
 		ID:        accountID,
-		Balance:   1000.0,
+		Balance:   money.New(100000, "USD"),
 		Currency:  "USD",
 		CreatedAt: time.Now(),
 	}
@@ -104,7 +220,7 @@ func TestService_Store(t *testing.T) {
 		Accounts: mockAccounts,
 	}
 
-	service := NewService(mockAccountRepository)
+	service := NewService(mockAccountRepository, nil)
 
 	newAccount, err := service.Register(context.Background(), expectedAccount)
 
@@ -125,56 +241,370 @@ func TestService_Store(t *testing.T) {
 	)
 }
 
+func TestService_RegisterForCustomer(t *testing.T) {
+	customerID := "5d7e6e6c-52b4-4a3e-9d1c-3f7b1d2c9a10"
+	testCases := []struct {
+		Name               string
+		Key                auth.Key
+		CustomerID         string
+		Balance            money.Money
+		ExpectedCustomerID string
+		ExpectedError      error
+	}{
+		{
+			Name:               "Operator Key",
+			Key:                auth.Key{ID: "key"},
+			CustomerID:         customerID,
+			Balance:            money.New(100000, "USD"),
+			ExpectedCustomerID: customerID,
+		},
+		{
+			Name:               "Customer Key",
+			Key:                auth.Key{ID: "key", CustomerID: customerID},
+			Balance:            money.Zero("USD"),
+			ExpectedCustomerID: customerID,
+		},
+		{
+			Name:          "Other Customer",
+			Key:           auth.Key{ID: "key", CustomerID: customerID},
+			CustomerID:    "a7c6a8f5-3b8e-4a55-8b5b-7a2d0f0b9e21",
+			Balance:       money.Zero("USD"),
+			ExpectedError: ErrOtherCustomer,
+		},
+		{
+			Name:          "Opening Balance",
+			Key:           auth.Key{ID: "key", CustomerID: customerID},
+			Balance:       money.New(100000, "USD"),
+			ExpectedError: ErrOpeningBalance,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			mockAccountRepository := &mockAccountRepository{Accounts: make(map[string]*Account)}
+			service := NewService(mockAccountRepository, nil)
+
+			registered, err := service.Register(
+				auth.NewContext(context.Background(), tc.Key),
+				Account{ID: "1111", CustomerID: tc.CustomerID, Balance: tc.Balance, Currency: "USD"},
+			)
+
+			if tc.ExpectedError != nil {
+				assert.Equal(t, tc.ExpectedError, err)
+				assert.Empty(t, mockAccountRepository.Accounts)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.ExpectedCustomerID, registered.CustomerID)
+			assert.Contains(t, mockAccountRepository.Accounts, "1111")
+		})
+	}
+}
+
 func TestService_Accounts(t *testing.T) {
-	accountID1 := "1111"
-	accountID2 := "2222"
+	createdAt := time.Date(2023, 9, 14, 9, 0, 0, 0, time.UTC)
 
-	expectedAccount1 := Account{
-		ID:        accountID1,
-		Balance:   1000.0,
+	account1 := Account{
+		ID:        "1111",
+		Balance:   money.New(300000, "USD"),
 		Currency:  "USD",
-		CreatedAt: time.Now(),
+		CreatedAt: createdAt,
 	}
 
-	expectedAccount2 := Account{
-		ID:        accountID2,
-		Balance:   2000.0,
+	account2 := Account{
+		ID:        "2222",
+		Balance:   money.New(200000, "EUR"),
 		Currency:  "EUR",
-		CreatedAt: time.Now(),
+		CreatedAt: createdAt.Add(time.Minute),
 	}
 
-	mockAccounts := map[string]*Account{
-		accountID1: &expectedAccount1,
-		accountID2: &expectedAccount2,
+	account3 := Account{
+		ID:        "3333",
+		Balance:   money.New(100000, "EUR"),
+		Currency:  "EUR",
+		CreatedAt: createdAt.Add(2 * time.Minute),
 	}
 
 	mockAccountRepository := &mockAccountRepository{
-		Accounts: mockAccounts,
+		Accounts: map[string]*Account{
+			account1.ID: &account1,
+			account2.ID: &account2,
+			account3.ID: &account3,
+		},
+	}
+
+	service := NewService(mockAccountRepository, nil)
+
+	testCases := []struct {
+		Name     string
+		Query    Query
+		Expected [][]Account
+	}{
+		{
+			Name:     "By Creation Time",
+			Query:    Query{Sort: SortCreatedAt, Limit: 2},
+			Expected: [][]Account{{account1, account2}, {account3}},
+		},
+		{
+			Name:     "By Balance",
+			Query:    Query{Sort: SortBalance, Limit: 2},
+			Expected: [][]Account{{account3, account2}, {account1}},
+		},
+		{
+			Name:     "By Currency",
+			Query:    Query{Sort: SortCreatedAt, Currency: "EUR", Limit: 1},
+			Expected: [][]Account{{account2}, {account3}},
+		},
 	}
 
-	service := NewService(mockAccountRepository)
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			q := tc.Query
+			for i, expected := range tc.Expected {
+				page, err := service.LoadAll(context.Background(), q)
+
+				assert.NoError(t, err)
+				assert.Equal(t, expected, page.Accounts, "Page %d should match", i)
+
+				// Every page but the last one points to the next one
+				if i == len(tc.Expected)-1 {
+					assert.Empty(t, page.NextCursor, "Last page should not have a next cursor")
+					break
+				}
+				cursor, err := pagination.Decode(page.NextCursor)
+				assert.NoError(t, err)
+				q.After = &cursor
+			}
+		})
+	}
+}
 
-	accounts := service.LoadAll(context.Background())
+func TestService_Lifecycle(t *testing.T) {
+	mockAccountRepository := &mockAccountRepository{
+		Accounts: map[string]*Account{
+			"1111": {ID: "1111", Balance: money.Zero("EUR"), Currency: "EUR", Status: StatusActive},
+			"2222": {ID: "2222", Balance: money.New(100, "EUR"), Currency: "EUR", Status: StatusActive},
+		},
+	}
 
-	assert.Len(t, accounts, 2, "Number of returned accounts should be 2")
-	assert.Contains(t, accounts, expectedAccount1, "Account 1 should be present")
-	assert.Contains(t, accounts, expectedAccount2, "Account 2 should be present")
+	service := NewService(mockAccountRepository, nil)
+	ctx := context.Background()
+
+	frozen, err := service.Freeze(ctx, "1111", "suspicious activity")
+	assert.NoError(t, err)
+	assert.Equal(t, StatusFrozen, frozen.Status)
+	assert.Equal(t, "suspicious activity", frozen.StatusReason)
+	assert.NotNil(t, frozen.StatusChangedAt)
+
+	// Frozen accounts can neither be frozen again nor closed
+	_, err = service.Freeze(ctx, "1111", "again")
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+	_, err = service.Close(ctx, "1111", "customer request")
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+
+	active, err := service.Unfreeze(ctx, "1111", "cleared")
+	assert.NoError(t, err)
+	assert.Equal(t, StatusActive, active.Status)
+
+	closed, err := service.Close(ctx, "1111", "customer request")
+	assert.NoError(t, err)
+	assert.Equal(t, StatusClosed, closed.Status)
+
+	// Closed accounts stay closed
+	_, err = service.Unfreeze(ctx, "1111", "reopen")
+	assert.Equal(t, &TransitionError{AccountID: "1111", From: StatusClosed, To: StatusActive}, err)
+
+	// Only accounts with a zero balance can be closed
+	_, err = service.Close(ctx, "2222", "customer request")
+	assert.Equal(t, ErrNonZeroBalance, err)
+	assert.Equal(t, StatusActive, mockAccountRepository.Accounts["2222"].Status)
+
+	_, err = service.Freeze(ctx, "3333", "unknown")
+	assert.Equal(t, ErrFetchingAccount("3333"), err)
+
+	// Only the owner and the operator change the status of an account
+	mockAccountRepository.Accounts["2222"].CustomerID = "owner"
+	other := auth.NewContext(ctx, auth.Key{ID: "key", CustomerID: "other"})
+	_, err = service.Freeze(other, "2222", "not mine")
+	assert.Equal(t, &OwnerError{AccountID: "2222", CustomerID: "other"}, err)
+	assert.Equal(t, StatusActive, mockAccountRepository.Accounts["2222"].Status)
+
+	owner := auth.NewContext(ctx, auth.Key{ID: "key", CustomerID: "owner"})
+	frozen, err = service.Freeze(owner, "2222", "card lost")
+	assert.NoError(t, err)
+	assert.Equal(t, StatusFrozen, frozen.Status)
+
+	// Only the operator unfreezes an account, even the one its owner froze
+	_, err = service.Unfreeze(owner, "2222", "card found")
+	assert.Equal(t, ErrUnfreezeNotAllowed, err)
+	assert.Equal(t, StatusFrozen, mockAccountRepository.Accounts["2222"].Status)
+	active, err = service.Unfreeze(ctx, "2222", "card found")
+	assert.NoError(t, err)
+	assert.Equal(t, StatusActive, active.Status)
 }
 
-func TestService_Clean(t *testing.T) {
-	mockAccountID := "1111"
+func TestService_BalanceAsOf(t *testing.T) {
+	createdAt := time.Date(2023, 9, 1, 0, 0, 0, 0, time.UTC)
 
 	mockAccountRepository := &mockAccountRepository{
 		Accounts: map[string]*Account{
-			mockAccountID: {},
+			"1111": {
+				ID:        "1111",
+				Balance:   money.New(100000, "USD"),
+				Currency:  "USD",
+				CreatedAt: createdAt,
+			},
 		},
 	}
 
-	service := NewService(mockAccountRepository)
+	service := NewService(mockAccountRepository, nil)
+
+	testCases := []struct {
+		Name          string
+		ID            string
+		AsOf          time.Time
+		Expected      Balance
+		ExpectedError error
+	}{
+		{
+			Name: "After Creation",
+			ID:   "1111",
+			AsOf: createdAt.Add(time.Hour),
+			Expected: Balance{
+				AccountID: "1111",
+				Balance:   money.New(100000, "USD"),
+				Currency:  "USD",
+				AsOf:      createdAt.Add(time.Hour),
+			},
+		},
+		{
+			Name: "Before Creation",
+			ID:   "1111",
+			AsOf: createdAt.Add(-time.Hour),
+			Expected: Balance{
+				AccountID: "1111",
+				Balance:   money.Zero("USD"),
+				Currency:  "USD",
+				AsOf:      createdAt.Add(-time.Hour),
+			},
+		},
+		{
+			Name:          "Unknown Account",
+			ID:            "2222",
+			AsOf:          createdAt,
+			ExpectedError: ErrFetchingAccount("2222"),
+		},
+	}
 
-	err := service.Clean(context.Background(), mockAccountID)
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			balance, err := service.BalanceAsOf(context.Background(), tc.ID, tc.AsOf)
 
-	assert.NoError(t, err, "Error should be nil")
-	_, exists := mockAccountRepository.Accounts[mockAccountID]
-	assert.False(t, exists, "Account should be deleted")
+			assert.Equal(t, tc.ExpectedError, err)
+			assert.Equal(t, tc.Expected, balance)
+		})
+	}
+}
+
+func TestService_Roles(t *testing.T) {
+	ownerID := "5d7e6e6c-52b4-4a3e-9d1c-3f7b1d2c9a10"
+	delegateID := "a7c6a8f5-3b8e-4a55-8b5b-7a2d0f0b9e21"
+	strangerID := "0b7e8f6a-2d4c-4f1e-9a3b-5c6d7e8f9a0b"
+
+	mockAccountRepository := &mockAccountRepository{
+		Accounts: map[string]*Account{
+			"1111": {ID: "1111", Balance: money.New(10000, "EUR"), Currency: "EUR",
+				Status: StatusActive, CustomerID: ownerID},
+			"2222": {ID: "2222", Balance: money.New(10000, "EUR"), Currency: "EUR",
+				Status: StatusActive, CustomerID: strangerID},
+		},
+	}
+	mockRoleRepository := &mockRoleRepository{Grants: make(map[string]*Grant)}
+	service := NewService(mockAccountRepository, mockRoleRepository)
+
+	owner := auth.NewContext(context.Background(), auth.Key{ID: "owner-key", CustomerID: ownerID})
+	delegate := auth.NewContext(context.Background(), auth.Key{ID: "delegate-key", CustomerID: delegateID})
+	stranger := auth.NewContext(context.Background(), auth.Key{ID: "stranger-key", CustomerID: strangerID})
+
+	// Without a role, a customer cannot read the account
+	_, err := service.Load(delegate, "1111")
+	assert.True(t, errors.Is(err, ErrNotAccountOwner))
+
+	// The owner grants the roles of the other customers
+	grant, err := service.GrantRole(owner, "1111", delegateID, RoleView)
+	assert.NoError(t, err)
+	assert.Equal(t, "owner-key", grant.GrantedBy)
+	assert.False(t, grant.GrantedAt.IsZero())
+
+	_, err = service.GrantRole(owner, "1111", ownerID, RoleApprove)
+	assert.Equal(t, ErrOwnerRole, err)
+	_, err = service.GrantRole(owner, "1111", delegateID, Role("admin"))
+	assert.Equal(t, ErrInvalidRole, err)
+	_, err = service.GrantRole(owner, "1111", "1234", RoleView)
+	assert.Equal(t, ErrUnknownCustomer, err)
+
+	// Neither a delegate nor another customer manage the roles
+	_, err = service.GrantRole(delegate, "1111", delegateID, RoleApprove)
+	assert.True(t, errors.Is(err, ErrNotAccountOwner))
+	_, err = service.Roles(stranger, "1111")
+	assert.True(t, errors.Is(err, ErrNotAccountOwner))
+
+	// A viewer reads the account, its balance and finds it in the listing
+	acct, err := service.Load(delegate, "1111")
+	assert.NoError(t, err)
+	assert.Equal(t, "1111", acct.ID)
+	_, err = service.BalanceAsOf(delegate, "1111", time.Now())
+	assert.NoError(t, err)
+	_, err = service.Load(delegate, "2222")
+	assert.True(t, errors.Is(err, ErrNotAccountOwner))
+
+	page, err := service.LoadAll(owner, Query{Sort: SortCreatedAt, Limit: 10})
+	assert.NoError(t, err)
+	assert.Len(t, page.Accounts, 1)
+	assert.Equal(t, "1111", page.Accounts[0].ID)
+
+	// Granting another role replaces the previous one
+	_, err = service.GrantRole(owner, "1111", delegateID, RoleInitiate)
+	assert.NoError(t, err)
+	grants, err := service.Roles(owner, "1111")
+	assert.NoError(t, err)
+	assert.Len(t, grants, 1)
+	assert.Equal(t, RoleInitiate, grants[0].Role)
+
+	// The operator manages the roles of any account
+	assert.NoError(t, service.RevokeRole(context.Background(), "1111", delegateID))
+	_, err = service.Load(delegate, "1111")
+	assert.True(t, errors.Is(err, ErrNotAccountOwner))
+
+	err = service.RevokeRole(owner, "1111", delegateID)
+	assert.True(t, errors.Is(err, ErrRoleNotFound))
+
+	// Every change is audited along with who made it
+	changes, err := service.RoleChanges(owner, "1111")
+	assert.NoError(t, err)
+	assert.Len(t, changes, 3)
+	for i, expected := range []struct {
+		Action    string
+		Role      Role
+		ChangedBy string
+	}{
+		{ActionGrant, RoleView, "owner-key"},
+		{ActionGrant, RoleInitiate, "owner-key"},
+		{ActionRevoke, RoleInitiate, ""},
+	} {
+		assert.Equal(t, expected.Action, changes[i].Action)
+		assert.Equal(t, expected.Role, changes[i].Role)
+		assert.Equal(t, expected.ChangedBy, changes[i].ChangedBy)
+		assert.Equal(t, delegateID, changes[i].CustomerID)
+		assert.NotEmpty(t, changes[i].ID)
+	}
+}
+
+func TestRole_Includes(t *testing.T) {
+	assert.True(t, RoleApprove.Includes(RoleInitiate))
+	assert.True(t, RoleInitiate.Includes(RoleView))
+	assert.True(t, RoleView.Includes(RoleView))
+	assert.False(t, RoleView.Includes(RoleInitiate))
+	assert.False(t, RoleInitiate.Includes(RoleApprove))
+	assert.False(t, Role("admin").Includes(RoleView))
 }
@@ -0,0 +1,30 @@
+package accounts
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// RunSnapshots takes a snapshot of the balances at every interval until the
+// context is done. Snapshots are taken as of the given lag in the past so
+// that the transfers still in flight are included.
+func RunSnapshots(
+	ctx context.Context, svc Service, interval, lag time.Duration, logger *zap.SugaredLogger,
+) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			asOf := time.Now().UTC().Add(-lag)
+			if _, err := svc.SnapshotBalances(ctx, asOf); err != nil {
+				logger.Errorf("failed to take the balance snapshots: %v", err)
+			}
+		}
+	}
+}
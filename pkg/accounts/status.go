@@ -0,0 +1,52 @@
+package accounts
+
+import "time"
+
+// Statuses of an account. Active accounts can send and receive transfers,
+// frozen ones neither until they are unfrozen, and closed ones never again.
+const (
+	StatusActive = "active"
+	StatusFrozen = "frozen"
+	StatusClosed = "closed"
+)
+
+// StatusChange moves an account to another status for the given reason
+type StatusChange struct {
+	Status    string
+	Reason    string
+	ChangedAt time.Time
+}
+
+// ValidStatus returns true if the given status is a known account status
+func ValidStatus(status string) bool {
+	switch status {
+	case StatusActive, StatusFrozen, StatusClosed:
+		return true
+	}
+	return false
+}
+
+// CheckTransition returns an error unless the account may move to the given
+// status. Active accounts can be frozen and unfrozen, and closed once their
+// balance is zero.
+func CheckTransition(acct *Account, status string) error {
+	switch {
+	case acct.Status == StatusActive && status == StatusFrozen,
+		acct.Status == StatusFrozen && status == StatusActive:
+		return nil
+	case acct.Status == StatusActive && status == StatusClosed:
+		if !acct.Balance.IsZero() {
+			return ErrNonZeroBalance
+		}
+		return nil
+	}
+	return &TransitionError{AccountID: acct.ID, From: acct.Status, To: status}
+}
+
+// CheckActive returns an error unless the account can send and receive transfers
+func CheckActive(acct *Account) error {
+	if acct.Status != StatusActive {
+		return &InactiveAccountError{AccountID: acct.ID, Status: acct.Status}
+	}
+	return nil
+}
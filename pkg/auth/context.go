@@ -0,0 +1,17 @@
+package auth
+
+import "context"
+
+// contextKey is the key of the authenticated API key in a context
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying the authenticated API key
+func NewContext(ctx context.Context, key Key) context.Context {
+	return context.WithValue(ctx, contextKey{}, key)
+}
+
+// FromContext returns the authenticated API key of a context, if any
+func FromContext(ctx context.Context) (Key, bool) {
+	key, ok := ctx.Value(contextKey{}).(Key)
+	return key, ok
+}
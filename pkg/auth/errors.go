@@ -0,0 +1,68 @@
+package auth
+
+import "financial-app/pkg/errs"
+
+// ErrMissingKey is used when a request does not carry an API key
+var ErrMissingKey = errs.New(errs.ErrUnauthenticated, "missing_api_key",
+	"an API key is required")
+
+// ErrInvalidKey is used when an API key is unknown or revoked. Both cases
+// are reported alike so as not to reveal which keys exist.
+var ErrInvalidKey = errs.New(errs.ErrUnauthenticated, "invalid_api_key",
+	"the API key is not valid")
+
+// ErrInsufficientScope is used when an API key lacks the scope of a request
+var ErrInsufficientScope = errs.New(errs.ErrForbidden, "insufficient_scope",
+	"the API key is not allowed to perform this request")
+
+// ErrKeyNotFound is used when an API key does not exist
+var ErrKeyNotFound = errs.New(errs.ErrNotFound, "api_key_not_found", "API key not found")
+
+// ErrNameRequired is used when an API key is created without a name
+var ErrNameRequired = errs.New(errs.ErrInvalid, "api_key_name_required",
+	"the name of the API key is required")
+
+// ErrScopesRequired is used when an API key is created without any scope
+var ErrScopesRequired = errs.New(errs.ErrInvalid, "api_key_scopes_required",
+	"the API key requires at least one scope")
+
+// ErrInvalidCustomerID is used when the customer of an API key is not a UUID
+var ErrInvalidCustomerID = errs.New(errs.ErrInvalid, "invalid_customer_id",
+	"the customer of the API key must be a UUID")
+
+// ErrUnknownCustomer is used when an API key is created for a customer that
+// does not exist
+var ErrUnknownCustomer = errs.New(errs.ErrUnprocessable, "unknown_customer",
+	"the customer of the API key does not exist")
+
+// ErrMissingScope is used when an API key lacks the given scope
+func ErrMissingScope(scope Scope) *errs.Error {
+	return ErrInsufficientScope.WithMessage(
+		"the API key lacks the " + string(scope) + " scope")
+}
+
+// ErrUnknownScope is used when a scope is not a known scope
+func ErrUnknownScope(scope Scope) *errs.Error {
+	return errs.New(errs.ErrInvalid, "unknown_scope", "unknown scope "+string(scope))
+}
+
+// ErrFetchingKey is used when an API key does not exist
+func ErrFetchingKey(id string) *errs.Error {
+	return ErrKeyNotFound.WithMessage("could not fetch API key by ID " + id)
+}
+
+// ErrPostingKey is used when an API key could not be stored
+func ErrPostingKey(id string) *errs.Error {
+	return errs.New(errs.ErrInternal, "posting_api_key_failed",
+		"could not store API key by ID "+id)
+}
+
+// ErrQueryingKeys is used when the API keys could not be queried
+var ErrQueryingKeys = errs.New(errs.ErrInternal, "querying_api_keys_failed",
+	"could not query the API keys")
+
+// ErrRevokingKey is used when an API key could not be revoked
+func ErrRevokingKey(id string) *errs.Error {
+	return errs.New(errs.ErrInternal, "revoking_api_key_failed",
+		"could not revoke API key by ID "+id)
+}
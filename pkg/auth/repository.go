@@ -0,0 +1,19 @@
+package auth
+
+import (
+	"context"
+	"time"
+)
+
+// Repository provides access an API key store
+type Repository interface {
+	Store(ctx context.Context, key *Key) (*Key, error)
+	// FindByHash returns the key whose token has the given hash, or
+	// ErrInvalidKey if there is none
+	FindByHash(ctx context.Context, hash string) (*Key, error)
+	// FindAll returns every key, the most recently created first
+	FindAll(ctx context.Context) ([]Key, error)
+	// Revoke revokes the key at the given time, or returns ErrFetchingKey if
+	// there is no such key
+	Revoke(ctx context.Context, id string, at time.Time) error
+}
@@ -0,0 +1,44 @@
+package auth
+
+import "strings"
+
+// Scope grants an API key access to a group of routes
+type Scope string
+
+// Scopes of the API keys. Write scopes do not imply the read ones.
+const (
+	ScopeAccountsRead      Scope = "accounts:read"
+	ScopeAccountsWrite     Scope = "accounts:write"
+	ScopeTransactionsRead  Scope = "transactions:read"
+	ScopeTransactionsWrite Scope = "transactions:write"
+	ScopeLedgerRead        Scope = "ledger:read"
+	ScopeCustomersRead     Scope = "customers:read"
+	ScopeCustomersWrite    Scope = "customers:write"
+)
+
+// ValidScope returns true if the given scope is a known scope
+func ValidScope(scope Scope) bool {
+	switch scope {
+	case ScopeAccountsRead, ScopeAccountsWrite, ScopeTransactionsRead,
+		ScopeTransactionsWrite, ScopeLedgerRead, ScopeCustomersRead, ScopeCustomersWrite:
+		return true
+	}
+	return false
+}
+
+// ParseScopes parses a comma separated list of scopes, such as
+// "accounts:read,transactions:write"
+func ParseScopes(list string) ([]Scope, error) {
+	var scopes []Scope
+	for _, s := range strings.Split(list, ",") {
+		scope := Scope(strings.TrimSpace(s))
+		if scope == "" {
+			continue
+		}
+		if !ValidScope(scope) {
+			return nil, ErrUnknownScope(scope)
+		}
+		scopes = append(scopes, scope)
+	}
+	return scopes, nil
+}
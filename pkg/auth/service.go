@@ -0,0 +1,177 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// tokenPrefix marks the tokens of the API keys so that they are easy to spot,
+// e.g. in a secret scanner
+const tokenPrefix = "fa_"
+
+// tokenBytes is the number of random bytes of a token
+const tokenBytes = 32
+
+// displayLength is the number of leading characters of a token kept to
+// recognise a key without revealing it
+const displayLength = 10
+
+// Key is an API key. Only the hash of its token is stored, so the token is
+// shown once when the key is created and cannot be retrieved afterwards.
+type Key struct {
+	ID     string
+	Name   string
+	Prefix string
+	Hash   string
+	Scopes []Scope
+	// CustomerID is the customer the key acts for. A key without a customer
+	// acts for the operator of the app.
+	CustomerID string
+	CreatedAt  time.Time
+	RevokedAt  *time.Time
+}
+
+// Revoked returns true if the key has been revoked
+func (k Key) Revoked() bool {
+	return k.RevokedAt != nil
+}
+
+// Authorize returns ErrInsufficientScope unless the key has the given scope
+func (k Key) Authorize(scope Scope) error {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return nil
+		}
+	}
+	return ErrMissingScope(scope)
+}
+
+// Service is the interface that provides API key methods
+type Service interface {
+	// Create issues a key with the name, scopes and customer of the given one,
+	// and returns it along with its token
+	Create(ctx context.Context, key Key) (Key, string, error)
+
+	// Authenticate returns the active key of a token
+	Authenticate(ctx context.Context, token string) (Key, error)
+
+	// LoadAll returns every key, including the revoked ones
+	LoadAll(ctx context.Context) ([]Key, error)
+
+	// Revoke revokes a key for good
+	Revoke(ctx context.Context, id string) error
+}
+
+// HashToken returns the hash of a token as it is stored. The tokens are
+// random, so a fast hash is enough to keep them secret.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *service) Create(
+	ctx context.Context, key Key,
+) (Key, string, error) {
+	name := strings.TrimSpace(key.Name)
+	if name == "" {
+		return Key{}, "", ErrNameRequired
+	}
+	if len(key.Scopes) == 0 {
+		return Key{}, "", ErrScopesRequired
+	}
+	for _, scope := range key.Scopes {
+		if !ValidScope(scope) {
+			return Key{}, "", ErrUnknownScope(scope)
+		}
+	}
+	if key.CustomerID != "" {
+		if _, err := uuid.FromString(key.CustomerID); err != nil {
+			return Key{}, "", ErrInvalidCustomerID
+		}
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		return Key{}, "", err
+	}
+
+	stored, err := s.keys.Store(ctx, &Key{
+		ID:         nextKeyID(),
+		Name:       name,
+		Prefix:     token[:displayLength],
+		Hash:       HashToken(token),
+		Scopes:     key.Scopes,
+		CustomerID: key.CustomerID,
+		CreatedAt:  time.Now().UTC(),
+	})
+	if err != nil {
+		return Key{}, "", err
+	}
+
+	return *stored, token, nil
+}
+
+func (s *service) Authenticate(ctx context.Context, token string) (Key, error) {
+	if token == "" {
+		return Key{}, ErrMissingKey
+	}
+	if !strings.HasPrefix(token, tokenPrefix) {
+		return Key{}, ErrInvalidKey
+	}
+
+	key, err := s.keys.FindByHash(ctx, HashToken(token))
+	if err != nil {
+		return Key{}, err
+	}
+	if key.Revoked() {
+		return Key{}, ErrInvalidKey
+	}
+
+	return *key, nil
+}
+
+func (s *service) LoadAll(ctx context.Context) ([]Key, error) {
+	return s.keys.FindAll(ctx)
+}
+
+func (s *service) Revoke(ctx context.Context, id string) error {
+	if _, err := uuid.FromString(id); err != nil {
+		return ErrFetchingKey(id)
+	}
+	return s.keys.Revoke(ctx, id, time.Now().UTC())
+}
+
+type service struct {
+	keys Repository
+}
+
+// NewService creates an API key service with necessary dependencies
+func NewService(
+	keys Repository,
+) Service {
+	return &service{
+		keys: keys,
+	}
+}
+
+// nextKeyID generates a new API key ID.
+func nextKeyID() string {
+	return uuid.NewV4().String()
+}
+
+// generateToken returns a new random token
+func generateToken() (string, error) {
+	b := make([]byte, tokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("could not generate an API key: %w", err)
+	}
+	return tokenPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
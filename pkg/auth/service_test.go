@@ -0,0 +1,226 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"financial-app/pkg/errs"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockRepository struct {
+	Keys map[string]*Key
+}
+
+func (m *mockRepository) Store(ctx context.Context, key *Key) (*Key, error) {
+	stored := *key
+	m.Keys[key.ID] = &stored
+	return key, nil
+}
+
+func (m *mockRepository) FindByHash(ctx context.Context, hash string) (*Key, error) {
+	for _, key := range m.Keys {
+		if key.Hash == hash {
+			found := *key
+			return &found, nil
+		}
+	}
+	return nil, ErrInvalidKey
+}
+
+func (m *mockRepository) FindAll(ctx context.Context) ([]Key, error) {
+	keys := []Key{}
+	for _, key := range m.Keys {
+		keys = append(keys, *key)
+	}
+	return keys, nil
+}
+
+func (m *mockRepository) Revoke(ctx context.Context, id string, at time.Time) error {
+	key, ok := m.Keys[id]
+	if !ok {
+		return ErrFetchingKey(id)
+	}
+	if key.RevokedAt == nil {
+		key.RevokedAt = &at
+	}
+	return nil
+}
+
+func TestService_Create(t *testing.T) {
+	mockRepository := &mockRepository{Keys: make(map[string]*Key)}
+	service := NewService(mockRepository)
+
+	customerID := "5d7e6e6c-52b4-4a3e-9d1c-3f7b1d2c9a10"
+	key, token, err := service.Create(context.Background(), Key{
+		Name:       " ci ",
+		Scopes:     []Scope{ScopeAccountsRead, ScopeTransactionsWrite},
+		CustomerID: customerID,
+	})
+
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(token, tokenPrefix))
+	assert.Equal(t, "ci", key.Name)
+	assert.Equal(t, token[:displayLength], key.Prefix)
+	assert.Equal(t, HashToken(token), key.Hash)
+	assert.Equal(t, []Scope{ScopeAccountsRead, ScopeTransactionsWrite}, key.Scopes)
+	assert.Equal(t, customerID, key.CustomerID)
+	assert.False(t, key.Revoked())
+	assert.NotEqual(t, token, mockRepository.Keys[key.ID].Hash,
+		"The token should not be stored")
+
+	_, other, err := service.Create(context.Background(),
+		Key{Name: "ci", Scopes: []Scope{ScopeAccountsRead}})
+	assert.NoError(t, err)
+	assert.NotEqual(t, token, other, "Tokens should be random")
+}
+
+func TestService_CreateErrors(t *testing.T) {
+	testCases := []struct {
+		Name          string
+		Key           Key
+		ExpectedError error
+	}{
+		{
+			Name:          "Name Required",
+			Key:           Key{Name: " ", Scopes: []Scope{ScopeAccountsRead}},
+			ExpectedError: ErrNameRequired,
+		},
+		{
+			Name:          "Scopes Required",
+			Key:           Key{Name: "ci"},
+			ExpectedError: ErrScopesRequired,
+		},
+		{
+			Name:          "Unknown Scope",
+			Key:           Key{Name: "ci", Scopes: []Scope{ScopeAccountsRead, "accounts:admin"}},
+			ExpectedError: ErrUnknownScope("accounts:admin"),
+		},
+		{
+			Name:          "Invalid Customer",
+			Key:           Key{Name: "ci", Scopes: []Scope{ScopeAccountsRead}, CustomerID: "1111"},
+			ExpectedError: ErrInvalidCustomerID,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			mockRepository := &mockRepository{Keys: make(map[string]*Key)}
+			service := NewService(mockRepository)
+
+			_, token, err := service.Create(context.Background(), tc.Key)
+
+			assert.Equal(t, tc.ExpectedError, err)
+			assert.Empty(t, token)
+			assert.Empty(t, mockRepository.Keys)
+		})
+	}
+}
+
+func TestService_Authenticate(t *testing.T) {
+	mockRepository := &mockRepository{Keys: make(map[string]*Key)}
+	service := NewService(mockRepository)
+
+	key, token, err := service.Create(context.Background(),
+		Key{Name: "ci", Scopes: []Scope{ScopeAccountsRead}})
+	assert.NoError(t, err)
+	revoked, revokedToken, err := service.Create(context.Background(),
+		Key{Name: "old", Scopes: []Scope{ScopeAccountsRead}})
+	assert.NoError(t, err)
+	assert.NoError(t, service.Revoke(context.Background(), revoked.ID))
+
+	testCases := []struct {
+		Name          string
+		Token         string
+		ExpectedKey   Key
+		ExpectedError error
+	}{
+		{
+			Name:        "Valid Key",
+			Token:       token,
+			ExpectedKey: key,
+		},
+		{
+			Name:          "Missing Key",
+			Token:         "",
+			ExpectedError: ErrMissingKey,
+		},
+		{
+			Name:          "Malformed Key",
+			Token:         "not-a-key",
+			ExpectedError: ErrInvalidKey,
+		},
+		{
+			Name:          "Unknown Key",
+			Token:         tokenPrefix + "unknown",
+			ExpectedError: ErrInvalidKey,
+		},
+		{
+			Name:          "Revoked Key",
+			Token:         revokedToken,
+			ExpectedError: ErrInvalidKey,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			key, err := service.Authenticate(context.Background(), tc.Token)
+
+			assert.Equal(t, tc.ExpectedError, err)
+			assert.Equal(t, tc.ExpectedKey, key)
+			if err != nil {
+				assert.True(t, errors.Is(err, errs.ErrUnauthenticated))
+			}
+		})
+	}
+}
+
+func TestService_Revoke(t *testing.T) {
+	mockRepository := &mockRepository{Keys: make(map[string]*Key)}
+	service := NewService(mockRepository)
+
+	key, _, err := service.Create(context.Background(),
+		Key{Name: "ci", Scopes: []Scope{ScopeAccountsRead}})
+	assert.NoError(t, err)
+
+	assert.NoError(t, service.Revoke(context.Background(), key.ID))
+	revokedAt := mockRepository.Keys[key.ID].RevokedAt
+	assert.NotNil(t, revokedAt)
+
+	assert.NoError(t, service.Revoke(context.Background(), key.ID),
+		"Revoking a revoked key should succeed")
+	assert.Equal(t, revokedAt, mockRepository.Keys[key.ID].RevokedAt)
+
+	err = service.Revoke(context.Background(), "1111")
+	assert.True(t, errors.Is(err, ErrKeyNotFound))
+	err = service.Revoke(context.Background(), "5d7e6e6c-52b4-4a3e-9d1c-3f7b1d2c9a10")
+	assert.True(t, errors.Is(err, ErrKeyNotFound))
+}
+
+func TestKey_Authorize(t *testing.T) {
+	key := Key{Scopes: []Scope{ScopeAccountsRead, ScopeTransactionsWrite}}
+
+	assert.NoError(t, key.Authorize(ScopeAccountsRead))
+	assert.NoError(t, key.Authorize(ScopeTransactionsWrite))
+
+	err := key.Authorize(ScopeAccountsWrite)
+	assert.True(t, errors.Is(err, ErrInsufficientScope))
+	assert.True(t, errors.Is(err, errs.ErrForbidden))
+	assert.Equal(t, "the API key lacks the accounts:write scope", err.Error())
+}
+
+func TestParseScopes(t *testing.T) {
+	scopes, err := ParseScopes("accounts:read, transactions:write,")
+	assert.NoError(t, err)
+	assert.Equal(t, []Scope{ScopeAccountsRead, ScopeTransactionsWrite}, scopes)
+
+	scopes, err = ParseScopes("")
+	assert.NoError(t, err)
+	assert.Empty(t, scopes)
+
+	_, err = ParseScopes("accounts:read,accounts:admin")
+	assert.Equal(t, ErrUnknownScope("accounts:admin"), err)
+}
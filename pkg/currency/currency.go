@@ -0,0 +1,117 @@
+package currency
+
+import (
+	"financial-app/pkg/errs"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// defaultMinorUnits is the number of minor unit digits of unknown currencies
+const defaultMinorUnits = 2
+
+// DefaultSupported is the allow-list used unless the operator configures one
+var DefaultSupported = []string{"EUR", "USD"}
+
+// ErrUnknownCurrency is used when a code is not an ISO 4217 currency
+var ErrUnknownCurrency = errs.New(errs.ErrInvalid, "unknown_currency", "currency is not an ISO 4217 currency")
+
+// ErrNotSupported is used when a currency is not in the allow-list
+var ErrNotSupported = errs.New(errs.ErrInvalid, "currency_not_supported", "currency is not supported")
+
+// Currency is an ISO 4217 currency
+type Currency struct {
+	// Code is the alphabetic code such as "EUR"
+	Code string
+	// Numeric is the numeric code such as "978"
+	Numeric string
+	// MinorUnits is the number of decimals of the currency, e.g. 0 for JPY
+	MinorUnits int
+}
+
+var (
+	mu        sync.RWMutex
+	supported = allowList(DefaultSupported)
+)
+
+// Lookup returns the ISO 4217 currency with the given alphabetic code
+func Lookup(code string) (Currency, bool) {
+	c, ok := iso4217[code]
+	return c, ok
+}
+
+// MinorUnits returns the number of decimals of a currency. Unknown currencies
+// fall back to two decimals.
+func MinorUnits(code string) int {
+	if c, ok := iso4217[code]; ok {
+		return c.MinorUnits
+	}
+	return defaultMinorUnits
+}
+
+// SetSupported replaces the allow-list of the currencies accepted by the API
+func SetSupported(codes []string) error {
+	for _, code := range codes {
+		if _, ok := iso4217[code]; !ok {
+			return ErrUnknownCurrency
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	supported = allowList(codes)
+
+	return nil
+}
+
+// ParseList splits a comma separated list of codes such as "EUR, USD"
+func ParseList(s string) []string {
+	var codes []string
+	for _, code := range strings.Split(s, ",") {
+		if code = strings.ToUpper(strings.TrimSpace(code)); code != "" {
+			codes = append(codes, code)
+		}
+	}
+	return codes
+}
+
+// Supported returns the allowed currency codes in alphabetical order
+func Supported() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	codes := make([]string, 0, len(supported))
+	for code := range supported {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	return codes
+}
+
+// IsSupported returns true if the currency is in the allow-list
+func IsSupported(code string) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	return supported[code]
+}
+
+// Validate validates if the given field is a supported currency
+func Validate(fl validator.FieldLevel) bool {
+	if code, ok := fl.Field().Interface().(string); ok {
+		return IsSupported(code)
+	}
+	return false
+}
+
+// allowList returns a set of the given codes
+func allowList(codes []string) map[string]bool {
+	set := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		set[code] = true
+	}
+	return set
+}
@@ -0,0 +1,55 @@
+package currency
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookup(t *testing.T) {
+	testCases := []struct {
+		Code       string
+		Numeric    string
+		MinorUnits int
+	}{
+		{Code: "EUR", Numeric: "978", MinorUnits: 2},
+		{Code: "JPY", Numeric: "392", MinorUnits: 0},
+		{Code: "KWD", Numeric: "414", MinorUnits: 3},
+		{Code: "CLF", Numeric: "990", MinorUnits: 4},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Code, func(t *testing.T) {
+			c, ok := Lookup(tc.Code)
+
+			assert.True(t, ok)
+			assert.Equal(t, Currency{Code: tc.Code, Numeric: tc.Numeric, MinorUnits: tc.MinorUnits}, c)
+			assert.Equal(t, tc.MinorUnits, MinorUnits(tc.Code))
+		})
+	}
+
+	_, ok := Lookup("XYZ")
+	assert.False(t, ok)
+	assert.Equal(t, defaultMinorUnits, MinorUnits("XYZ"))
+}
+
+func TestSetSupported(t *testing.T) {
+	t.Cleanup(func() { _ = SetSupported(DefaultSupported) })
+
+	assert.True(t, IsSupported("EUR"))
+	assert.False(t, IsSupported("JPY"))
+
+	assert.NoError(t, SetSupported([]string{"JPY", "KWD"}))
+	assert.Equal(t, []string{"JPY", "KWD"}, Supported())
+	assert.True(t, IsSupported("JPY"))
+	assert.False(t, IsSupported("EUR"))
+
+	assert.Equal(t, ErrUnknownCurrency, SetSupported([]string{"EUR", "XYZ"}))
+	assert.Equal(t, []string{"JPY", "KWD"}, Supported(),
+		"Allow-list should not change on error")
+}
+
+func TestParseList(t *testing.T) {
+	assert.Equal(t, []string{"EUR", "USD", "JPY"}, ParseList(" eur,USD,, JPY "))
+	assert.Empty(t, ParseList(""))
+}
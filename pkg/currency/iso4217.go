@@ -0,0 +1,173 @@
+package currency
+
+// iso4217 holds the active ISO 4217 currencies by their alphabetic code.
+// Precious metals, funds without minor units and testing codes are omitted.
+var iso4217 = map[string]Currency{
+	"AED": {Code: "AED", Numeric: "784", MinorUnits: 2},
+	"AFN": {Code: "AFN", Numeric: "971", MinorUnits: 2},
+	"ALL": {Code: "ALL", Numeric: "008", MinorUnits: 2},
+	"AMD": {Code: "AMD", Numeric: "051", MinorUnits: 2},
+	"ANG": {Code: "ANG", Numeric: "532", MinorUnits: 2},
+	"AOA": {Code: "AOA", Numeric: "973", MinorUnits: 2},
+	"ARS": {Code: "ARS", Numeric: "032", MinorUnits: 2},
+	"AUD": {Code: "AUD", Numeric: "036", MinorUnits: 2},
+	"AWG": {Code: "AWG", Numeric: "533", MinorUnits: 2},
+	"AZN": {Code: "AZN", Numeric: "944", MinorUnits: 2},
+	"BAM": {Code: "BAM", Numeric: "977", MinorUnits: 2},
+	"BBD": {Code: "BBD", Numeric: "052", MinorUnits: 2},
+	"BDT": {Code: "BDT", Numeric: "050", MinorUnits: 2},
+	"BGN": {Code: "BGN", Numeric: "975", MinorUnits: 2},
+	"BHD": {Code: "BHD", Numeric: "048", MinorUnits: 3},
+	"BIF": {Code: "BIF", Numeric: "108", MinorUnits: 0},
+	"BMD": {Code: "BMD", Numeric: "060", MinorUnits: 2},
+	"BND": {Code: "BND", Numeric: "096", MinorUnits: 2},
+	"BOB": {Code: "BOB", Numeric: "068", MinorUnits: 2},
+	"BOV": {Code: "BOV", Numeric: "984", MinorUnits: 2},
+	"BRL": {Code: "BRL", Numeric: "986", MinorUnits: 2},
+	"BSD": {Code: "BSD", Numeric: "044", MinorUnits: 2},
+	"BTN": {Code: "BTN", Numeric: "064", MinorUnits: 2},
+	"BWP": {Code: "BWP", Numeric: "072", MinorUnits: 2},
+	"BYN": {Code: "BYN", Numeric: "933", MinorUnits: 2},
+	"BZD": {Code: "BZD", Numeric: "084", MinorUnits: 2},
+	"CAD": {Code: "CAD", Numeric: "124", MinorUnits: 2},
+	"CDF": {Code: "CDF", Numeric: "976", MinorUnits: 2},
+	"CHE": {Code: "CHE", Numeric: "947", MinorUnits: 2},
+	"CHF": {Code: "CHF", Numeric: "756", MinorUnits: 2},
+	"CHW": {Code: "CHW", Numeric: "948", MinorUnits: 2},
+	"CLF": {Code: "CLF", Numeric: "990", MinorUnits: 4},
+	"CLP": {Code: "CLP", Numeric: "152", MinorUnits: 0},
+	"CNY": {Code: "CNY", Numeric: "156", MinorUnits: 2},
+	"COP": {Code: "COP", Numeric: "170", MinorUnits: 2},
+	"COU": {Code: "COU", Numeric: "970", MinorUnits: 2},
+	"CRC": {Code: "CRC", Numeric: "188", MinorUnits: 2},
+	"CUC": {Code: "CUC", Numeric: "931", MinorUnits: 2},
+	"CUP": {Code: "CUP", Numeric: "192", MinorUnits: 2},
+	"CVE": {Code: "CVE", Numeric: "132", MinorUnits: 2},
+	"CZK": {Code: "CZK", Numeric: "203", MinorUnits: 2},
+	"DJF": {Code: "DJF", Numeric: "262", MinorUnits: 0},
+	"DKK": {Code: "DKK", Numeric: "208", MinorUnits: 2},
+	"DOP": {Code: "DOP", Numeric: "214", MinorUnits: 2},
+	"DZD": {Code: "DZD", Numeric: "012", MinorUnits: 2},
+	"EGP": {Code: "EGP", Numeric: "818", MinorUnits: 2},
+	"ERN": {Code: "ERN", Numeric: "232", MinorUnits: 2},
+	"ETB": {Code: "ETB", Numeric: "230", MinorUnits: 2},
+	"EUR": {Code: "EUR", Numeric: "978", MinorUnits: 2},
+	"FJD": {Code: "FJD", Numeric: "242", MinorUnits: 2},
+	"FKP": {Code: "FKP", Numeric: "238", MinorUnits: 2},
+	"GBP": {Code: "GBP", Numeric: "826", MinorUnits: 2},
+	"GEL": {Code: "GEL", Numeric: "981", MinorUnits: 2},
+	"GHS": {Code: "GHS", Numeric: "936", MinorUnits: 2},
+	"GIP": {Code: "GIP", Numeric: "292", MinorUnits: 2},
+	"GMD": {Code: "GMD", Numeric: "270", MinorUnits: 2},
+	"GNF": {Code: "GNF", Numeric: "324", MinorUnits: 0},
+	"GTQ": {Code: "GTQ", Numeric: "320", MinorUnits: 2},
+	"GYD": {Code: "GYD", Numeric: "328", MinorUnits: 2},
+	"HKD": {Code: "HKD", Numeric: "344", MinorUnits: 2},
+	"HNL": {Code: "HNL", Numeric: "340", MinorUnits: 2},
+	"HTG": {Code: "HTG", Numeric: "332", MinorUnits: 2},
+	"HUF": {Code: "HUF", Numeric: "348", MinorUnits: 2},
+	"IDR": {Code: "IDR", Numeric: "360", MinorUnits: 2},
+	"ILS": {Code: "ILS", Numeric: "376", MinorUnits: 2},
+	"INR": {Code: "INR", Numeric: "356", MinorUnits: 2},
+	"IQD": {Code: "IQD", Numeric: "368", MinorUnits: 3},
+	"IRR": {Code: "IRR", Numeric: "364", MinorUnits: 2},
+	"ISK": {Code: "ISK", Numeric: "352", MinorUnits: 0},
+	"JMD": {Code: "JMD", Numeric: "388", MinorUnits: 2},
+	"JOD": {Code: "JOD", Numeric: "400", MinorUnits: 3},
+	"JPY": {Code: "JPY", Numeric: "392", MinorUnits: 0},
+	"KES": {Code: "KES", Numeric: "404", MinorUnits: 2},
+	"KGS": {Code: "KGS", Numeric: "417", MinorUnits: 2},
+	"KHR": {Code: "KHR", Numeric: "116", MinorUnits: 2},
+	"KMF": {Code: "KMF", Numeric: "174", MinorUnits: 0},
+	"KPW": {Code: "KPW", Numeric: "408", MinorUnits: 2},
+	"KRW": {Code: "KRW", Numeric: "410", MinorUnits: 0},
+	"KWD": {Code: "KWD", Numeric: "414", MinorUnits: 3},
+	"KYD": {Code: "KYD", Numeric: "136", MinorUnits: 2},
+	"KZT": {Code: "KZT", Numeric: "398", MinorUnits: 2},
+	"LAK": {Code: "LAK", Numeric: "418", MinorUnits: 2},
+	"LBP": {Code: "LBP", Numeric: "422", MinorUnits: 2},
+	"LKR": {Code: "LKR", Numeric: "144", MinorUnits: 2},
+	"LRD": {Code: "LRD", Numeric: "430", MinorUnits: 2},
+	"LSL": {Code: "LSL", Numeric: "426", MinorUnits: 2},
+	"LYD": {Code: "LYD", Numeric: "434", MinorUnits: 3},
+	"MAD": {Code: "MAD", Numeric: "504", MinorUnits: 2},
+	"MDL": {Code: "MDL", Numeric: "498", MinorUnits: 2},
+	"MGA": {Code: "MGA", Numeric: "969", MinorUnits: 2},
+	"MKD": {Code: "MKD", Numeric: "807", MinorUnits: 2},
+	"MMK": {Code: "MMK", Numeric: "104", MinorUnits: 2},
+	"MNT": {Code: "MNT", Numeric: "496", MinorUnits: 2},
+	"MOP": {Code: "MOP", Numeric: "446", MinorUnits: 2},
+	"MRU": {Code: "MRU", Numeric: "929", MinorUnits: 2},
+	"MUR": {Code: "MUR", Numeric: "480", MinorUnits: 2},
+	"MVR": {Code: "MVR", Numeric: "462", MinorUnits: 2},
+	"MWK": {Code: "MWK", Numeric: "454", MinorUnits: 2},
+	"MXN": {Code: "MXN", Numeric: "484", MinorUnits: 2},
+	"MXV": {Code: "MXV", Numeric: "979", MinorUnits: 2},
+	"MYR": {Code: "MYR", Numeric: "458", MinorUnits: 2},
+	"MZN": {Code: "MZN", Numeric: "943", MinorUnits: 2},
+	"NAD": {Code: "NAD", Numeric: "516", MinorUnits: 2},
+	"NGN": {Code: "NGN", Numeric: "566", MinorUnits: 2},
+	"NIO": {Code: "NIO", Numeric: "558", MinorUnits: 2},
+	"NOK": {Code: "NOK", Numeric: "578", MinorUnits: 2},
+	"NPR": {Code: "NPR", Numeric: "524", MinorUnits: 2},
+	"NZD": {Code: "NZD", Numeric: "554", MinorUnits: 2},
+	"OMR": {Code: "OMR", Numeric: "512", MinorUnits: 3},
+	"PAB": {Code: "PAB", Numeric: "590", MinorUnits: 2},
+	"PEN": {Code: "PEN", Numeric: "604", MinorUnits: 2},
+	"PGK": {Code: "PGK", Numeric: "598", MinorUnits: 2},
+	"PHP": {Code: "PHP", Numeric: "608", MinorUnits: 2},
+	"PKR": {Code: "PKR", Numeric: "586", MinorUnits: 2},
+	"PLN": {Code: "PLN", Numeric: "985", MinorUnits: 2},
+	"PYG": {Code: "PYG", Numeric: "600", MinorUnits: 0},
+	"QAR": {Code: "QAR", Numeric: "634", MinorUnits: 2},
+	"RON": {Code: "RON", Numeric: "946", MinorUnits: 2},
+	"RSD": {Code: "RSD", Numeric: "941", MinorUnits: 2},
+	"RUB": {Code: "RUB", Numeric: "643", MinorUnits: 2},
+	"RWF": {Code: "RWF", Numeric: "646", MinorUnits: 0},
+	"SAR": {Code: "SAR", Numeric: "682", MinorUnits: 2},
+	"SBD": {Code: "SBD", Numeric: "090", MinorUnits: 2},
+	"SCR": {Code: "SCR", Numeric: "690", MinorUnits: 2},
+	"SDG": {Code: "SDG", Numeric: "938", MinorUnits: 2},
+	"SEK": {Code: "SEK", Numeric: "752", MinorUnits: 2},
+	"SGD": {Code: "SGD", Numeric: "702", MinorUnits: 2},
+	"SHP": {Code: "SHP", Numeric: "654", MinorUnits: 2},
+	"SLE": {Code: "SLE", Numeric: "925", MinorUnits: 2},
+	"SLL": {Code: "SLL", Numeric: "694", MinorUnits: 2},
+	"SOS": {Code: "SOS", Numeric: "706", MinorUnits: 2},
+	"SRD": {Code: "SRD", Numeric: "968", MinorUnits: 2},
+	"SSP": {Code: "SSP", Numeric: "728", MinorUnits: 2},
+	"STN": {Code: "STN", Numeric: "930", MinorUnits: 2},
+	"SVC": {Code: "SVC", Numeric: "222", MinorUnits: 2},
+	"SYP": {Code: "SYP", Numeric: "760", MinorUnits: 2},
+	"SZL": {Code: "SZL", Numeric: "748", MinorUnits: 2},
+	"THB": {Code: "THB", Numeric: "764", MinorUnits: 2},
+	"TJS": {Code: "TJS", Numeric: "972", MinorUnits: 2},
+	"TMT": {Code: "TMT", Numeric: "934", MinorUnits: 2},
+	"TND": {Code: "TND", Numeric: "788", MinorUnits: 3},
+	"TOP": {Code: "TOP", Numeric: "776", MinorUnits: 2},
+	"TRY": {Code: "TRY", Numeric: "949", MinorUnits: 2},
+	"TTD": {Code: "TTD", Numeric: "780", MinorUnits: 2},
+	"TWD": {Code: "TWD", Numeric: "901", MinorUnits: 2},
+	"TZS": {Code: "TZS", Numeric: "834", MinorUnits: 2},
+	"UAH": {Code: "UAH", Numeric: "980", MinorUnits: 2},
+	"UGX": {Code: "UGX", Numeric: "800", MinorUnits: 0},
+	"USD": {Code: "USD", Numeric: "840", MinorUnits: 2},
+	"USN": {Code: "USN", Numeric: "997", MinorUnits: 2},
+	"UYI": {Code: "UYI", Numeric: "940", MinorUnits: 0},
+	"UYU": {Code: "UYU", Numeric: "858", MinorUnits: 2},
+	"UYW": {Code: "UYW", Numeric: "927", MinorUnits: 4},
+	"UZS": {Code: "UZS", Numeric: "860", MinorUnits: 2},
+	"VED": {Code: "VED", Numeric: "926", MinorUnits: 2},
+	"VES": {Code: "VES", Numeric: "928", MinorUnits: 2},
+	"VND": {Code: "VND", Numeric: "704", MinorUnits: 0},
+	"VUV": {Code: "VUV", Numeric: "548", MinorUnits: 0},
+	"WST": {Code: "WST", Numeric: "882", MinorUnits: 2},
+	"XAF": {Code: "XAF", Numeric: "950", MinorUnits: 0},
+	"XCD": {Code: "XCD", Numeric: "951", MinorUnits: 2},
+	"XOF": {Code: "XOF", Numeric: "952", MinorUnits: 0},
+	"XPF": {Code: "XPF", Numeric: "953", MinorUnits: 0},
+	"YER": {Code: "YER", Numeric: "886", MinorUnits: 2},
+	"ZAR": {Code: "ZAR", Numeric: "710", MinorUnits: 2},
+	"ZMW": {Code: "ZMW", Numeric: "967", MinorUnits: 2},
+	"ZWL": {Code: "ZWL", Numeric: "932", MinorUnits: 2},
+}
@@ -0,0 +1,82 @@
+package decoratedsvcs
+
+import (
+	"context"
+	"financial-app/pkg/accounts"
+	"financial-app/pkg/customers"
+	"time"
+
+	"github.com/go-kit/kit/metrics"
+)
+
+type instrumentingService struct {
+	requestCount   metrics.Counter
+	requestLatency metrics.Histogram
+	next           customers.Service
+}
+
+// NewInstrumentingService returns an instance of an instrumenting Service.
+func NewInstrumentingService(
+	counter metrics.Counter, latency metrics.Histogram, s customers.Service,
+) customers.Service {
+	return &instrumentingService{
+		requestCount:   counter,
+		requestLatency: latency,
+		next:           s,
+	}
+}
+
+func (s *instrumentingService) Load(
+	ctx context.Context, id string,
+) (customer customers.Customer, err error) {
+	defer func(begin time.Time) {
+		s.requestCount.With("method", "load").Add(1)
+		s.requestLatency.With("method", "load").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return s.next.Load(ctx, id)
+}
+
+func (s *instrumentingService) Register(
+	ctx context.Context, c customers.Customer,
+) (customer customers.Customer, err error) {
+	defer func(begin time.Time) {
+		s.requestCount.With("method", "register").Add(1)
+		s.requestLatency.With("method", "register").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return s.next.Register(ctx, c)
+}
+
+func (s *instrumentingService) Update(
+	ctx context.Context, c customers.Customer,
+) (customer customers.Customer, err error) {
+	defer func(begin time.Time) {
+		s.requestCount.With("method", "update").Add(1)
+		s.requestLatency.With("method", "update").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return s.next.Update(ctx, c)
+}
+
+func (s *instrumentingService) Remove(
+	ctx context.Context, id string,
+) (err error) {
+	defer func(begin time.Time) {
+		s.requestCount.With("method", "remove").Add(1)
+		s.requestLatency.With("method", "remove").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return s.next.Remove(ctx, id)
+}
+
+func (s *instrumentingService) Accounts(
+	ctx context.Context, id string, q accounts.Query,
+) (page accounts.Page, err error) {
+	defer func(begin time.Time) {
+		s.requestCount.With("method", "accounts").Add(1)
+		s.requestLatency.With("method", "accounts").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return s.next.Accounts(ctx, id, q)
+}
@@ -0,0 +1,92 @@
+package decoratedsvcs
+
+import (
+	"context"
+	"financial-app/pkg/accounts"
+	"financial-app/pkg/customers"
+	"time"
+
+	log "go.uber.org/zap"
+)
+
+type loggingService struct {
+	logger *log.SugaredLogger
+	next   customers.Service
+}
+
+// NewLoggingService returns a new instance of a logging Service.
+func NewLoggingService(logger *log.SugaredLogger, s customers.Service) customers.Service {
+	return &loggingService{logger, s}
+}
+
+func (s *loggingService) Load(
+	ctx context.Context, id string,
+) (customer customers.Customer, err error) {
+	defer func(begin time.Time) {
+		s.logger.Infow(
+			"load",
+			log.String("customer_id", id),
+			log.Duration("took", time.Since(begin)),
+			log.Error(err),
+		)
+	}(time.Now())
+	return s.next.Load(ctx, id)
+}
+
+func (s *loggingService) Register(
+	ctx context.Context, c customers.Customer,
+) (customer customers.Customer, err error) {
+	defer func(begin time.Time) {
+		s.logger.Infow(
+			"customer store",
+			log.String("customer_id", c.ID),
+			log.Duration("took", time.Since(begin)),
+			log.Error(err),
+		)
+	}(time.Now())
+	return s.next.Register(ctx, c)
+}
+
+func (s *loggingService) Update(
+	ctx context.Context, c customers.Customer,
+) (customer customers.Customer, err error) {
+	defer func(begin time.Time) {
+		s.logger.Infow(
+			"customer update",
+			log.String("customer_id", c.ID),
+			log.Duration("took", time.Since(begin)),
+			log.Error(err),
+		)
+	}(time.Now())
+	return s.next.Update(ctx, c)
+}
+
+func (s *loggingService) Remove(
+	ctx context.Context, id string,
+) (err error) {
+	defer func(begin time.Time) {
+		s.logger.Infow(
+			"customer remove",
+			log.String("customer_id", id),
+			log.Duration("took", time.Since(begin)),
+			log.Error(err),
+		)
+	}(time.Now())
+	return s.next.Remove(ctx, id)
+}
+
+func (s *loggingService) Accounts(
+	ctx context.Context, id string, q accounts.Query,
+) (page accounts.Page, err error) {
+	defer func(begin time.Time) {
+		s.logger.Infow(
+			"customer accounts",
+			log.String("customer_id", id),
+			log.Int("limit", q.Limit),
+			log.Int("count", len(page.Accounts)),
+			log.Duration("took", time.Since(begin)),
+			log.Error(err),
+		)
+	}(time.Now())
+	return s.next.Accounts(ctx, id, q)
+}
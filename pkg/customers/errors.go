@@ -0,0 +1,50 @@
+package customers
+
+import "financial-app/pkg/errs"
+
+// ErrCustomerNotFound is used when a customer does not exist
+var ErrCustomerNotFound = errs.New(errs.ErrNotFound, "customer_not_found", "customer not found")
+
+// ErrFetchingCustomer is used when a customer could not be found. It matches
+// ErrCustomerNotFound.
+func ErrFetchingCustomer(id string) *errs.Error {
+	return ErrCustomerNotFound.WithMessage("could not fetch customer by ID " + id)
+}
+
+// ErrDuplicateEmail is used when the email of a customer is already taken
+var ErrDuplicateEmail = errs.New(errs.ErrConflict, "duplicate_customer_email",
+	"a customer with the same email already exists")
+
+// ErrCustomerHasAccounts is used when a customer owning accounts is to be
+// removed
+var ErrCustomerHasAccounts = errs.New(errs.ErrConflict, "customer_has_accounts",
+	"customers owning accounts cannot be removed")
+
+// ErrRegisteringForCustomer is used when a key bound to a customer registers
+// a customer, which only the operator onboards
+var ErrRegisteringForCustomer = errs.New(errs.ErrForbidden, "customer_registration_not_allowed",
+	"only the operator may register customers")
+
+// ErrPostingCustomer is used when a customer could not be created
+func ErrPostingCustomer(id string) *errs.Error {
+	return errs.New(errs.ErrInternal, "posting_customer_failed",
+		"could not create a new customer by ID "+id)
+}
+
+// ErrQueryingCustomer is used when a customer could not be queried
+func ErrQueryingCustomer(id string) *errs.Error {
+	return errs.New(errs.ErrInternal, "querying_customer_failed",
+		"could not query the customer by ID "+id)
+}
+
+// ErrUpdatingCustomer is used when a customer could not be updated
+func ErrUpdatingCustomer(id string) *errs.Error {
+	return errs.New(errs.ErrInternal, "updating_customer_failed",
+		"could not update the customer by ID "+id)
+}
+
+// ErrDeletingCustomer is used when a customer could not be removed
+func ErrDeletingCustomer(id string) *errs.Error {
+	return errs.New(errs.ErrInternal, "deleting_customer_failed",
+		"could not remove the customer by ID "+id)
+}
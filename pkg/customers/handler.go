@@ -0,0 +1,182 @@
+package customers
+
+import (
+	"financial-app/pkg/accounts"
+	"financial-app/pkg/errs"
+	"financial-app/pkg/http/rest/httperr"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+var customerIDRequired = errs.New(errs.ErrInvalid, "customer_id_required", "customer id required")
+
+type CustomerHandler struct {
+	Service Service
+
+	Logger *zap.SugaredLogger
+}
+
+// Router sets up all the routes for customer service
+func (h *CustomerHandler) Router(routerGroup *gin.RouterGroup) {
+	routerGroup.GET("customers/:id", h.load)
+	routerGroup.POST("customers", h.register)
+	routerGroup.PUT("customers/:id", h.update)
+	routerGroup.DELETE("customers/:id", h.remove)
+	routerGroup.GET("customers/:id/accounts", h.accounts)
+}
+
+// customerRequest
+type customerRequest struct {
+	Name  string `json:"name" validate:"required,max=200"`
+	Email string `json:"email" validate:"required,email,max=254"`
+}
+
+// bindCustomerRequest decodes and validates the customer of a request body
+func bindCustomerRequest(context *gin.Context) (customerRequest, error) {
+	var req customerRequest
+	if err := context.ShouldBindJSON(&req); err != nil {
+		return customerRequest{}, errs.Invalid(err)
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	req.Email = strings.TrimSpace(req.Email)
+
+	validate := validator.New()
+	validate.RegisterTagNameFunc(httperr.JSONFieldName)
+	if err := validate.Struct(req); err != nil {
+		return customerRequest{}, errs.Invalid(err)
+	}
+	return req, nil
+}
+
+// load retrieves a customer by ID
+func (h *CustomerHandler) load(context *gin.Context) {
+	id := context.Param("id")
+	if id == "" {
+		h.Logger.Error("no customer id found")
+
+		httperr.Respond(context, customerIDRequired)
+		return
+	}
+
+	customer, err := h.Service.Load(context, id)
+	if err != nil {
+		h.Logger.Error(err)
+
+		httperr.Respond(context, err)
+		return
+	}
+
+	context.JSON(http.StatusOK, customer)
+}
+
+// register registers a new customer
+func (h *CustomerHandler) register(context *gin.Context) {
+	req, err := bindCustomerRequest(context)
+	if err != nil {
+		h.Logger.Error(err)
+
+		httperr.Respond(context, err)
+		return
+	}
+
+	customer, err := h.Service.Register(context, Customer{
+		ID:    nextCustomerID(), // Generate a new uuid
+		Name:  req.Name,
+		Email: req.Email,
+	})
+	if err != nil {
+		h.Logger.Error(err)
+
+		httperr.Respond(context, err)
+		return
+	}
+
+	context.JSON(http.StatusCreated, customer)
+}
+
+// update replaces the name and email of a customer
+func (h *CustomerHandler) update(context *gin.Context) {
+	id := context.Param("id")
+	if id == "" {
+		h.Logger.Error("no customer id found")
+
+		httperr.Respond(context, customerIDRequired)
+		return
+	}
+
+	req, err := bindCustomerRequest(context)
+	if err != nil {
+		h.Logger.Error(err)
+
+		httperr.Respond(context, err)
+		return
+	}
+
+	customer, err := h.Service.Update(context, Customer{
+		ID:    id,
+		Name:  req.Name,
+		Email: req.Email,
+	})
+	if err != nil {
+		h.Logger.Error(err)
+
+		httperr.Respond(context, err)
+		return
+	}
+
+	context.JSON(http.StatusOK, customer)
+}
+
+// remove removes a customer that does not own any account
+func (h *CustomerHandler) remove(context *gin.Context) {
+	id := context.Param("id")
+	if id == "" {
+		h.Logger.Error("no customer id found")
+
+		httperr.Respond(context, customerIDRequired)
+		return
+	}
+
+	if err := h.Service.Remove(context, id); err != nil {
+		h.Logger.Error(err)
+
+		httperr.Respond(context, err)
+		return
+	}
+
+	context.Status(http.StatusNoContent)
+}
+
+// accounts retrieves a page of the accounts of a customer. It takes the same
+// query parameters as the account listing.
+func (h *CustomerHandler) accounts(context *gin.Context) {
+	id := context.Param("id")
+	if id == "" {
+		h.Logger.Error("no customer id found")
+
+		httperr.Respond(context, customerIDRequired)
+		return
+	}
+
+	q, err := accounts.QueryFromRequest(context)
+	if err != nil {
+		h.Logger.Error(err)
+
+		httperr.Respond(context, err)
+		return
+	}
+
+	page, err := h.Service.Accounts(context, id, q)
+	if err != nil {
+		h.Logger.Error(err)
+
+		httperr.Respond(context, err)
+		return
+	}
+
+	context.JSON(http.StatusOK, page)
+}
@@ -0,0 +1,260 @@
+package customers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"financial-app/pkg/accounts"
+	"financial-app/pkg/http/rest/httperr"
+	"financial-app/pkg/pagination"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+// MockService is a mock implementation of the Service interface for testing.
+type MockService struct {
+	mock.Mock
+}
+
+func (m *MockService) Load(ctx context.Context, id string) (Customer, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(Customer), args.Error(1)
+}
+
+func (m *MockService) Register(ctx context.Context, customer Customer) (Customer, error) {
+	args := m.Called(ctx, customer)
+	return args.Get(0).(Customer), args.Error(1)
+}
+
+func (m *MockService) Update(ctx context.Context, customer Customer) (Customer, error) {
+	args := m.Called(ctx, customer)
+	return args.Get(0).(Customer), args.Error(1)
+}
+
+func (m *MockService) Remove(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockService) Accounts(
+	ctx context.Context, id string, q accounts.Query,
+) (accounts.Page, error) {
+	args := m.Called(ctx, id, q)
+	return args.Get(0).(accounts.Page), args.Error(1)
+}
+
+// setupRouter returns a router serving the customer routes with the mock service
+func setupRouter(mockService *MockService) *gin.Engine {
+	logger, _ := zap.NewDevelopment()
+	handler := &CustomerHandler{Service: mockService, Logger: logger.Sugar()}
+
+	r := gin.Default()
+	handler.Router(&r.RouterGroup)
+	return r
+}
+
+func TestCustomerHandler_Register(t *testing.T) {
+	testCases := []struct {
+		Name              string
+		Body              string
+		ServiceError      error
+		ExpectedCode      int
+		ExpectedErrorCode string
+		ExpectedFields    []string
+	}{
+		{
+			Name:         "Valid Registration",
+			Body:         `{"name": " Ada Lovelace ", "email": "ada@example.com"}`,
+			ExpectedCode: http.StatusCreated,
+		},
+		{
+			Name:              "Missing Fields",
+			Body:              `{"name": " "}`,
+			ExpectedCode:      http.StatusBadRequest,
+			ExpectedErrorCode: "invalid_request",
+			ExpectedFields:    []string{"name", "email"},
+		},
+		{
+			Name:              "Invalid Email",
+			Body:              `{"name": "Ada Lovelace", "email": "ada"}`,
+			ExpectedCode:      http.StatusBadRequest,
+			ExpectedErrorCode: "invalid_request",
+			ExpectedFields:    []string{"email"},
+		},
+		{
+			Name:              "Duplicate Email",
+			Body:              `{"name": "Ada Lovelace", "email": "ada@example.com"}`,
+			ServiceError:      ErrDuplicateEmail,
+			ExpectedCode:      http.StatusConflict,
+			ExpectedErrorCode: "duplicate_customer_email",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			mockService := new(MockService)
+			r := setupRouter(mockService)
+
+			var registered Customer
+			mockService.On("Register", mock.Anything, mock.Anything).
+				Run(func(args mock.Arguments) { registered = args.Get(1).(Customer) }).
+				Return(Customer{ID: customerID, Name: "Ada Lovelace", Email: "ada@example.com"},
+					tc.ServiceError)
+
+			req, _ := http.NewRequest("POST", "/customers", bytes.NewBufferString(tc.Body))
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.ExpectedCode, rr.Code)
+			if tc.ExpectedCode == http.StatusCreated {
+				assert.NotEmpty(t, registered.ID)
+				assert.Equal(t, "Ada Lovelace", registered.Name)
+				return
+			}
+
+			var response httperr.Problem
+			assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+			assert.Equal(t, tc.ExpectedErrorCode, response.Code)
+			fields := make([]string, 0, len(response.Errors))
+			for _, fe := range response.Errors {
+				fields = append(fields, fe.Field)
+			}
+			if tc.ExpectedFields != nil {
+				assert.Equal(t, tc.ExpectedFields, fields)
+			}
+		})
+	}
+}
+
+func TestCustomerHandler_Load(t *testing.T) {
+	mockService := new(MockService)
+	r := setupRouter(mockService)
+
+	customer := Customer{ID: customerID, Name: "Ada Lovelace", Email: "ada@example.com"}
+	mockService.On("Load", mock.Anything, customerID).Return(customer, nil)
+	mockService.On("Load", mock.Anything, "1111").
+		Return(Customer{}, ErrFetchingCustomer("1111"))
+
+	req, _ := http.NewRequest("GET", "/customers/"+customerID, nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	expected, _ := json.Marshal(customer)
+	assert.JSONEq(t, string(expected), rr.Body.String())
+
+	req, _ = http.NewRequest("GET", "/customers/1111", nil)
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	var response httperr.Problem
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, "customer_not_found", response.Code)
+	assert.Equal(t, "could not fetch customer by ID 1111", response.Detail)
+}
+
+func TestCustomerHandler_Update(t *testing.T) {
+	mockService := new(MockService)
+	r := setupRouter(mockService)
+
+	updated := Customer{ID: customerID, Name: "Ada King", Email: "ada@example.com"}
+	mockService.On("Update", mock.Anything, Customer{
+		ID:    customerID,
+		Name:  "Ada King",
+		Email: "ada@example.com",
+	}).Return(updated, nil)
+
+	req, _ := http.NewRequest("PUT", "/customers/"+customerID,
+		bytes.NewBufferString(`{"name": "Ada King", "email": "ada@example.com"}`))
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	expected, _ := json.Marshal(updated)
+	assert.JSONEq(t, string(expected), rr.Body.String())
+
+	// The customer is replaced as a whole
+	req, _ = http.NewRequest("PUT", "/customers/"+customerID,
+		bytes.NewBufferString(`{"name": "Ada King"}`))
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	mockService.AssertNumberOfCalls(t, "Update", 1)
+}
+
+func TestCustomerHandler_Remove(t *testing.T) {
+	testCases := []struct {
+		Name         string
+		ServiceError error
+		ExpectedCode int
+	}{
+		{
+			Name:         "Removed",
+			ExpectedCode: http.StatusNoContent,
+		},
+		{
+			Name:         "Customer Has Accounts",
+			ServiceError: ErrCustomerHasAccounts,
+			ExpectedCode: http.StatusConflict,
+		},
+		{
+			Name:         "Customer Not Found",
+			ServiceError: ErrFetchingCustomer(customerID),
+			ExpectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			mockService := new(MockService)
+			r := setupRouter(mockService)
+			mockService.On("Remove", mock.Anything, customerID).Return(tc.ServiceError)
+
+			req, _ := http.NewRequest("DELETE", "/customers/"+customerID, nil)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.ExpectedCode, rr.Code)
+			if tc.ServiceError == nil {
+				assert.Empty(t, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestCustomerHandler_Accounts(t *testing.T) {
+	mockService := new(MockService)
+	r := setupRouter(mockService)
+
+	page := accounts.Page{Accounts: []accounts.Account{{ID: "1111", CustomerID: customerID}}}
+	mockService.On("Accounts", mock.Anything, customerID, accounts.Query{
+		Status:     accounts.StatusActive,
+		Sort:       accounts.SortCreatedAt,
+		Descending: true,
+		Limit:      pagination.DefaultLimit,
+	}).Return(page, nil)
+
+	req, _ := http.NewRequest("GET", "/customers/"+customerID+"/accounts?status=active", nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	expected, _ := json.Marshal(page)
+	assert.JSONEq(t, string(expected), rr.Body.String())
+
+	// The query parameters are validated like the account listing ones
+	req, _ = http.NewRequest("GET", "/customers/"+customerID+"/accounts?sort=id", nil)
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	mockService.AssertNumberOfCalls(t, "Accounts", 1)
+}
@@ -0,0 +1,14 @@
+package customers
+
+import "context"
+
+// Repository provides access a customer store
+type Repository interface {
+	Store(ctx context.Context, customer *Customer) (*Customer, error)
+	Find(ctx context.Context, id string) (*Customer, error)
+	// Update saves the name and email of the customer
+	Update(ctx context.Context, customer *Customer) (*Customer, error)
+	// Delete removes the customer, or returns ErrCustomerHasAccounts if it
+	// still owns accounts
+	Delete(ctx context.Context, id string) error
+}
@@ -0,0 +1,149 @@
+package customers
+
+import (
+	"context"
+	"financial-app/pkg/accounts"
+	"financial-app/pkg/auth"
+	"strings"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// Customer is a read model for the views of the account holders
+type Customer struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Service is the interface that provides customer methods
+type Service interface {
+	// Load returns a read model of a customer
+	Load(ctx context.Context, id string) (Customer, error)
+
+	// Register registers a new customer
+	Register(ctx context.Context, customer Customer) (Customer, error)
+
+	// Update changes the name and email of a customer
+	Update(ctx context.Context, customer Customer) (Customer, error)
+
+	// Remove removes a customer that does not own any account
+	Remove(ctx context.Context, id string) error
+
+	// Accounts returns a page of the accounts of a customer matching the query
+	Accounts(ctx context.Context, id string, q accounts.Query) (accounts.Page, error)
+}
+
+func (s *service) Load(
+	ctx context.Context, id string,
+) (Customer, error) {
+	customer, err := s.find(ctx, id)
+	if err != nil {
+		return Customer{}, err
+	}
+	return *customer, nil
+}
+
+func (s *service) Register(
+	ctx context.Context, customer Customer,
+) (Customer, error) {
+	// Only the operator onboards customers
+	if key, ok := auth.FromContext(ctx); ok && key.CustomerID != "" {
+		return Customer{}, ErrRegisteringForCustomer
+	}
+
+	customer.Email = normalizeEmail(customer.Email)
+	customer.CreatedAt = time.Now().UTC()
+	customer.UpdatedAt = customer.CreatedAt
+
+	stored, err := s.customers.Store(ctx, &customer)
+	if err != nil {
+		return Customer{}, err
+	}
+	return *stored, nil
+}
+
+func (s *service) Update(
+	ctx context.Context, customer Customer,
+) (Customer, error) {
+	found, err := s.find(ctx, customer.ID)
+	if err != nil {
+		return Customer{}, err
+	}
+
+	found.Name = customer.Name
+	found.Email = normalizeEmail(customer.Email)
+	found.UpdatedAt = time.Now().UTC()
+
+	updated, err := s.customers.Update(ctx, found)
+	if err != nil {
+		return Customer{}, err
+	}
+	return *updated, nil
+}
+
+func (s *service) Remove(ctx context.Context, id string) error {
+	if _, err := uuid.FromString(id); err != nil || !accessible(ctx, id) {
+		return ErrFetchingCustomer(id)
+	}
+	return s.customers.Delete(ctx, id)
+}
+
+func (s *service) Accounts(
+	ctx context.Context, id string, q accounts.Query,
+) (accounts.Page, error) {
+	// An unknown customer is not found rather than owning no account
+	if _, err := s.find(ctx, id); err != nil {
+		return accounts.Page{}, err
+	}
+
+	q.CustomerID = id
+	return s.accounts.LoadAll(ctx, q)
+}
+
+// find returns a customer by ID. IDs that are not UUIDs cannot exist, and
+// neither do the other customers for the keys bound to a customer.
+func (s *service) find(ctx context.Context, id string) (*Customer, error) {
+	if _, err := uuid.FromString(id); err != nil || !accessible(ctx, id) {
+		return nil, ErrFetchingCustomer(id)
+	}
+	return s.customers.Find(ctx, id)
+}
+
+// accessible returns true if the caller of the request may act on the
+// customer: the operator on every customer and a customer on itself only
+func accessible(ctx context.Context, id string) bool {
+	key, ok := auth.FromContext(ctx)
+	return !ok || key.CustomerID == "" || key.CustomerID == id
+}
+
+// normalizeEmail returns an email address as it is stored, so that the same
+// address is not registered twice with another case
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+type service struct {
+	customers Repository
+	accounts  accounts.Service
+}
+
+// NewService creates a customer service with necessary dependencies. The
+// accounts of the customers are listed through the account service.
+func NewService(
+	customers Repository,
+	accounts accounts.Service,
+) Service {
+	return &service{
+		customers: customers,
+		accounts:  accounts,
+	}
+}
+
+// nextCustomerID generates a new customer ID.
+func nextCustomerID() string {
+	return uuid.NewV4().String()
+}
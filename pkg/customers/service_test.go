@@ -0,0 +1,221 @@
+package customers
+
+import (
+	"context"
+	"errors"
+	"financial-app/pkg/accounts"
+	"financial-app/pkg/auth"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const customerID = "5d7e6e6c-52b4-4a3e-9d1c-3f7b1d2c9a10"
+
+type mockRepository struct {
+	Customers map[string]*Customer
+	// Owners are the customers owning accounts
+	Owners map[string]bool
+}
+
+func (m *mockRepository) Store(
+	ctx context.Context, customer *Customer,
+) (*Customer, error) {
+	for _, c := range m.Customers {
+		if c.Email == customer.Email {
+			return nil, ErrDuplicateEmail
+		}
+	}
+	stored := *customer
+	m.Customers[customer.ID] = &stored
+	return customer, nil
+}
+
+func (m *mockRepository) Find(
+	ctx context.Context, id string,
+) (*Customer, error) {
+	if c, ok := m.Customers[id]; ok {
+		found := *c
+		return &found, nil
+	}
+	return nil, ErrFetchingCustomer(id)
+}
+
+func (m *mockRepository) Update(
+	ctx context.Context, customer *Customer,
+) (*Customer, error) {
+	if _, ok := m.Customers[customer.ID]; !ok {
+		return nil, ErrFetchingCustomer(customer.ID)
+	}
+	stored := *customer
+	m.Customers[customer.ID] = &stored
+	return customer, nil
+}
+
+func (m *mockRepository) Delete(ctx context.Context, id string) error {
+	if _, ok := m.Customers[id]; !ok {
+		return ErrFetchingCustomer(id)
+	}
+	if m.Owners[id] {
+		return ErrCustomerHasAccounts
+	}
+	delete(m.Customers, id)
+	return nil
+}
+
+// mockAccountService returns its page for any query and keeps the last query
+type mockAccountService struct {
+	accounts.Service
+	Page  accounts.Page
+	Query accounts.Query
+}
+
+func (m *mockAccountService) LoadAll(
+	ctx context.Context, q accounts.Query,
+) (accounts.Page, error) {
+	m.Query = q
+	return m.Page, nil
+}
+
+func TestService_Register(t *testing.T) {
+	mockRepository := &mockRepository{Customers: make(map[string]*Customer)}
+	service := NewService(mockRepository, nil)
+
+	customer, err := service.Register(context.Background(), Customer{
+		ID:    customerID,
+		Name:  "Ada Lovelace",
+		Email: " Ada@Example.com",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "ada@example.com", customer.Email)
+	assert.False(t, customer.CreatedAt.IsZero())
+	assert.Equal(t, customer.CreatedAt, customer.UpdatedAt)
+	assert.Contains(t, mockRepository.Customers, customerID)
+
+	// The email is taken whatever its case
+	_, err = service.Register(context.Background(), Customer{
+		ID:    nextCustomerID(),
+		Name:  "Ada",
+		Email: "ADA@example.com",
+	})
+	assert.Equal(t, ErrDuplicateEmail, err)
+}
+
+func TestService_Load(t *testing.T) {
+	mockRepository := &mockRepository{Customers: map[string]*Customer{
+		customerID: {ID: customerID, Name: "Ada Lovelace", Email: "ada@example.com"},
+	}}
+	service := NewService(mockRepository, nil)
+
+	customer, err := service.Load(context.Background(), customerID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Ada Lovelace", customer.Name)
+
+	for _, id := range []string{"1111", nextCustomerID()} {
+		_, err = service.Load(context.Background(), id)
+		assert.Equal(t, ErrFetchingCustomer(id), err)
+		assert.True(t, errors.Is(err, ErrCustomerNotFound))
+	}
+}
+
+func TestService_Update(t *testing.T) {
+	mockRepository := &mockRepository{Customers: map[string]*Customer{
+		customerID: {ID: customerID, Name: "Ada Lovelace", Email: "ada@example.com"},
+	}}
+	service := NewService(mockRepository, nil)
+
+	customer, err := service.Update(context.Background(), Customer{
+		ID:    customerID,
+		Name:  "Ada King",
+		Email: "ADA.KING@example.com",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Ada King", customer.Name)
+	assert.Equal(t, "ada.king@example.com", customer.Email)
+	assert.False(t, customer.UpdatedAt.IsZero())
+	assert.Equal(t, "Ada King", mockRepository.Customers[customerID].Name)
+
+	_, err = service.Update(context.Background(), Customer{ID: "1111", Name: "Ada"})
+	assert.True(t, errors.Is(err, ErrCustomerNotFound))
+}
+
+func TestService_Remove(t *testing.T) {
+	ownerID := nextCustomerID()
+	mockRepository := &mockRepository{
+		Customers: map[string]*Customer{
+			customerID: {ID: customerID},
+			ownerID:    {ID: ownerID},
+		},
+		Owners: map[string]bool{ownerID: true},
+	}
+	service := NewService(mockRepository, nil)
+
+	assert.NoError(t, service.Remove(context.Background(), customerID))
+	assert.NotContains(t, mockRepository.Customers, customerID)
+
+	err := service.Remove(context.Background(), ownerID)
+	assert.Equal(t, ErrCustomerHasAccounts, err)
+	assert.Contains(t, mockRepository.Customers, ownerID)
+
+	err = service.Remove(context.Background(), "1111")
+	assert.True(t, errors.Is(err, ErrCustomerNotFound))
+}
+
+func TestService_Accounts(t *testing.T) {
+	mockRepository := &mockRepository{Customers: map[string]*Customer{
+		customerID: {ID: customerID},
+	}}
+	page := accounts.Page{Accounts: []accounts.Account{{ID: "1111", CustomerID: customerID}}}
+	mockAccountService := &mockAccountService{Page: page}
+	service := NewService(mockRepository, mockAccountService)
+
+	found, err := service.Accounts(context.Background(), customerID,
+		accounts.Query{Status: accounts.StatusActive, Limit: 10})
+
+	assert.NoError(t, err)
+	assert.Equal(t, page, found)
+	assert.Equal(t, accounts.Query{
+		Status:     accounts.StatusActive,
+		CustomerID: customerID,
+		Limit:      10,
+	}, mockAccountService.Query)
+
+	// The accounts of an unknown customer are not listed
+	id := nextCustomerID()
+	_, err = service.Accounts(context.Background(), id, accounts.Query{Limit: 10})
+	assert.Equal(t, ErrFetchingCustomer(id), err)
+}
+
+func TestService_OtherCustomer(t *testing.T) {
+	otherID := nextCustomerID()
+	mockRepository := &mockRepository{Customers: map[string]*Customer{
+		customerID: {ID: customerID, Name: "Ada Lovelace", Email: "ada@example.com"},
+		otherID:    {ID: otherID, Name: "Charles Babbage", Email: "charles@example.com"},
+	}}
+	service := NewService(mockRepository, &mockAccountService{})
+	ctx := auth.NewContext(context.Background(), auth.Key{ID: "key", CustomerID: customerID})
+
+	// A customer key acts on its own customer
+	customer, err := service.Load(ctx, customerID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Ada Lovelace", customer.Name)
+
+	// The other customers are not found
+	_, err = service.Load(ctx, otherID)
+	assert.Equal(t, ErrFetchingCustomer(otherID), err)
+	_, err = service.Update(ctx, Customer{ID: otherID, Name: "Ada", Email: "ada@example.org"})
+	assert.Equal(t, ErrFetchingCustomer(otherID), err)
+	_, err = service.Accounts(ctx, otherID, accounts.Query{Limit: 10})
+	assert.Equal(t, ErrFetchingCustomer(otherID), err)
+	err = service.Remove(ctx, otherID)
+	assert.Equal(t, ErrFetchingCustomer(otherID), err)
+	assert.Equal(t, "Charles Babbage", mockRepository.Customers[otherID].Name)
+
+	// Nor does it onboard new customers
+	newID := nextCustomerID()
+	_, err = service.Register(ctx, Customer{ID: newID, Name: "Ada", Email: "ada@example.net"})
+	assert.Equal(t, ErrRegisteringForCustomer, err)
+	assert.NotContains(t, mockRepository.Customers, newID)
+}
@@ -0,0 +1,99 @@
+package errs
+
+import "errors"
+
+// Kinds of errors. Every domain error belongs to one of them, which decides
+// the HTTP status it is reported with.
+var (
+	// ErrInvalid is used when a request is malformed
+	ErrInvalid = errors.New("invalid request")
+	// ErrUnauthenticated is used when a request does not carry valid credentials
+	ErrUnauthenticated = errors.New("unauthenticated")
+	// ErrForbidden is used when the credentials of a request do not grant it
+	ErrForbidden = errors.New("forbidden")
+	// ErrNotFound is used when a resource does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrConflict is used when a request conflicts with the state of a resource
+	ErrConflict = errors.New("conflict")
+	// ErrGone is used when a resource is no longer available
+	ErrGone = errors.New("gone")
+	// ErrUnprocessable is used when a well-formed request cannot be processed
+	ErrUnprocessable = errors.New("unprocessable")
+	// ErrNotAllowed is used when an operation is not supported by a resource
+	ErrNotAllowed = errors.New("not allowed")
+	// ErrTooLarge is used when a request body exceeds the size the server accepts
+	ErrTooLarge = errors.New("too large")
+	// ErrTimeout is used when a request took too long to be processed
+	ErrTimeout = errors.New("timeout")
+	// ErrUnavailable is used when a dependency such as the database is down
+	ErrUnavailable = errors.New("unavailable")
+	// ErrInternal is used when a request failed because of the server
+	ErrInternal = errors.New("internal error")
+)
+
+// CodeInvalidRequest is the code of the errors returned by Invalid
+const CodeInvalidRequest = "invalid_request"
+
+// Error is a domain error of a kind, identified by a stable machine-readable
+// code. It may wrap the error that caused it.
+type Error struct {
+	// Kind is one of the kind sentinels such as ErrNotFound
+	Kind error
+	// Code is a stable snake_case identifier such as "account_not_found"
+	Code string
+	// Message is the human-readable description of the error
+	Message string
+	// Err is the cause of the error, if any
+	Err error
+}
+
+// New returns an error of the given kind, code and message
+func New(kind error, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+// Invalid returns an ErrInvalid error caused by err, such as a request that
+// could not be decoded
+func Invalid(err error) error {
+	return &Error{Kind: ErrInvalid, Code: CodeInvalidRequest, Err: err}
+}
+
+// Error returns the message followed by the cause, if any
+func (e *Error) Error() string {
+	switch {
+	case e.Err == nil:
+		return e.Message
+	case e.Message == "":
+		return e.Err.Error()
+	}
+	return e.Message + ": " + e.Err.Error()
+}
+
+// Unwrap returns the cause of the error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the target is the kind of the error or an error with
+// the same code
+func (e *Error) Is(target error) bool {
+	if target == e.Kind {
+		return true
+	}
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// WithMessage returns a copy of the error with another message
+func (e *Error) WithMessage(message string) *Error {
+	c := *e
+	c.Message = message
+	return &c
+}
+
+// Wrap returns a copy of the error caused by err
+func (e *Error) Wrap(err error) *Error {
+	c := *e
+	c.Err = err
+	return &c
+}
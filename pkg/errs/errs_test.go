@@ -0,0 +1,42 @@
+package errs
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var errAccountNotFound = New(ErrNotFound, "account_not_found", "account not found")
+
+func TestError_Error(t *testing.T) {
+	cause := errors.New("connection refused")
+
+	assert.Equal(t, "account not found", errAccountNotFound.Error())
+	assert.Equal(t, "account not found: connection refused", errAccountNotFound.Wrap(cause).Error())
+	assert.Equal(t, "connection refused", Invalid(cause).Error())
+}
+
+func TestError_Is(t *testing.T) {
+	cause := errors.New("connection refused")
+	err := errAccountNotFound.WithMessage("could not fetch account by ID 1111").Wrap(cause)
+
+	assert.True(t, errors.Is(err, ErrNotFound))
+	assert.True(t, errors.Is(err, errAccountNotFound))
+	assert.True(t, errors.Is(err, cause))
+	assert.False(t, errors.Is(err, ErrConflict))
+	assert.False(t, errors.Is(err, New(ErrNotFound, "quote_not_found", "quote not found")))
+
+	// The copies leave the original error untouched
+	assert.Equal(t, "account not found", errAccountNotFound.Error())
+	assert.Nil(t, errAccountNotFound.Unwrap())
+}
+
+func TestError_As(t *testing.T) {
+	err := Invalid(errors.New("unexpected EOF"))
+
+	var e *Error
+	assert.True(t, errors.As(err, &e))
+	assert.Equal(t, CodeInvalidRequest, e.Code)
+	assert.True(t, errors.Is(err, ErrInvalid))
+}
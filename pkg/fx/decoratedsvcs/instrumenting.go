@@ -0,0 +1,38 @@
+package decoratedsvcs
+
+import (
+	"context"
+	"financial-app/pkg/fx"
+	"financial-app/pkg/money"
+	"time"
+
+	"github.com/go-kit/kit/metrics"
+)
+
+type instrumentingService struct {
+	requestCount   metrics.Counter
+	requestLatency metrics.Histogram
+	next           fx.Service
+}
+
+// NewInstrumentingService returns an instance of an instrumenting Service.
+func NewInstrumentingService(
+	counter metrics.Counter, latency metrics.Histogram, s fx.Service,
+) fx.Service {
+	return &instrumentingService{
+		requestCount:   counter,
+		requestLatency: latency,
+		next:           s,
+	}
+}
+
+func (s *instrumentingService) Quote(
+	ctx context.Context, amount money.Money, target string,
+) (quote fx.Quote, err error) {
+	defer func(begin time.Time) {
+		s.requestCount.With("method", "quote").Add(1)
+		s.requestLatency.With("method", "quote").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return s.next.Quote(ctx, amount, target)
+}
@@ -0,0 +1,38 @@
+package decoratedsvcs
+
+import (
+	"context"
+	"financial-app/pkg/fx"
+	"financial-app/pkg/money"
+	"time"
+
+	log "go.uber.org/zap"
+)
+
+type loggingService struct {
+	logger *log.SugaredLogger
+	next   fx.Service
+}
+
+// NewLoggingService returns a new instance of a logging Service.
+func NewLoggingService(logger *log.SugaredLogger, s fx.Service) fx.Service {
+	return &loggingService{logger, s}
+}
+
+func (s *loggingService) Quote(
+	ctx context.Context, amount money.Money, target string,
+) (quote fx.Quote, err error) {
+	defer func(begin time.Time) {
+		s.logger.Infow(
+			"quote",
+			log.String("quote_id", string(quote.ID)),
+			log.Stringer("amount", amount),
+			log.String("source_currency", string(amount.Currency())),
+			log.String("target_currency", string(target)),
+			log.String("rate", string(quote.Rate)),
+			log.Duration("took", time.Since(begin)),
+			log.Error(err),
+		)
+	}(time.Now())
+	return s.next.Quote(ctx, amount, target)
+}
@@ -0,0 +1,25 @@
+package fx
+
+import "financial-app/pkg/errs"
+
+// ErrQuoteNotFound is used when a quote does not exist
+var ErrQuoteNotFound = errs.New(errs.ErrNotFound, "quote_not_found", "quote not found")
+
+// ErrQuoteExpired is used when a quote is used after its expiry
+var ErrQuoteExpired = errs.New(errs.ErrGone, "quote_expired", "quote has expired")
+
+// ErrQuoteUsed is used when a quote has already been used by a transfer
+var ErrQuoteUsed = errs.New(errs.ErrConflict, "quote_used", "quote has already been used")
+
+// ErrQuoteMismatch is used when a transfer does not match the quote it refers to
+var ErrQuoteMismatch = errs.New(errs.ErrUnprocessable, "quote_mismatch", "transfer does not match the quote")
+
+// ErrPostingQuote is used when a quote could not be created
+func ErrPostingQuote(id string) *errs.Error {
+	return errs.New(errs.ErrInternal, "posting_quote_failed", "could not create a new quote by ID "+id)
+}
+
+// ErrFetchingQuote is used when a quote could not be fetched
+func ErrFetchingQuote(id string) *errs.Error {
+	return errs.New(errs.ErrInternal, "fetching_quote_failed", "could not fetch quote by ID "+id)
+}
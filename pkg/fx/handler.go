@@ -0,0 +1,93 @@
+package fx
+
+import (
+	"encoding/json"
+	"financial-app/pkg/currency"
+	"financial-app/pkg/errs"
+	"financial-app/pkg/http/rest/httperr"
+	"financial-app/pkg/money"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+type QuoteHandler struct {
+	Service Service
+
+	Logger *zap.SugaredLogger
+}
+
+// Router sets up all the routes for quote service
+func (h *QuoteHandler) Router(routerGroup *gin.RouterGroup) {
+	routerGroup.POST("fx/quotes", h.quote)
+}
+
+// validAmount validates if the given amount is a positive amount of the
+// source currency
+func validAmount(fl validator.FieldLevel) bool {
+	currency := fl.Parent().FieldByName("SourceCurrency").String()
+	amount, err := money.Parse(fl.Field().String(), currency)
+	if err != nil {
+		return false
+	}
+	return amount.IsPositive()
+}
+
+// quoteRequest
+type quoteRequest struct {
+	SourceCurrency string      `json:"source_currency" validate:"currency"`
+	TargetCurrency string      `json:"target_currency" validate:"currency,nefield=SourceCurrency"`
+	Amount         json.Number `json:"amount" validate:"required,amount"`
+}
+
+// quote locks the current rate for an amount in the source currency
+func (h *QuoteHandler) quote(context *gin.Context) {
+	var quoteReq quoteRequest
+	if err := context.ShouldBindJSON(&quoteReq); err != nil {
+		h.Logger.Error(err)
+
+		httperr.Respond(context, errs.Invalid(err))
+		return
+	}
+
+	v := validator.New()
+	v.RegisterTagNameFunc(httperr.JSONFieldName)
+	v.RegisterValidation("currency", currency.Validate)
+	v.RegisterValidation("amount", validAmount)
+
+	for _, code := range []string{quoteReq.SourceCurrency, quoteReq.TargetCurrency} {
+		if err := v.Var(code, "currency"); err != nil {
+			h.Logger.Error(err)
+
+			httperr.Respond(context, currency.ErrNotSupported)
+			return
+		}
+	}
+
+	if err := v.Struct(quoteReq); err != nil {
+		h.Logger.Error(err)
+
+		httperr.Respond(context, errs.Invalid(err))
+		return
+	}
+
+	amount, err := money.Parse(quoteReq.Amount.String(), quoteReq.SourceCurrency)
+	if err != nil {
+		h.Logger.Error(err)
+
+		httperr.Respond(context, errs.Invalid(err))
+		return
+	}
+
+	quote, err := h.Service.Quote(context, amount, quoteReq.TargetCurrency)
+	if err != nil {
+		h.Logger.Error(err)
+
+		httperr.Respond(context, err)
+		return
+	}
+
+	context.JSON(http.StatusCreated, quote)
+}
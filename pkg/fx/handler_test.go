@@ -0,0 +1,85 @@
+package fx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"financial-app/pkg/money"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+type MockService struct {
+	mock.Mock
+}
+
+func (m *MockService) Quote(
+	ctx context.Context, amount money.Money, target string,
+) (Quote, error) {
+	args := m.Called(ctx, amount, target)
+	return args.Get(0).(Quote), args.Error(1)
+}
+
+func TestQuoteHandler_Quote(t *testing.T) {
+	testCases := []struct {
+		Name         string
+		Request      quoteRequest
+		ServiceError error
+		ExpectedCode int
+	}{
+		{
+			Name:         "Valid Quote",
+			Request:      quoteRequest{SourceCurrency: "EUR", TargetCurrency: "USD", Amount: "100"},
+			ExpectedCode: http.StatusCreated,
+		},
+		{
+			Name:         "Same Currency",
+			Request:      quoteRequest{SourceCurrency: "EUR", TargetCurrency: "EUR", Amount: "100"},
+			ExpectedCode: http.StatusBadRequest,
+		},
+		{
+			Name:         "Invalid Currency",
+			Request:      quoteRequest{SourceCurrency: "EUR", TargetCurrency: "XYZ", Amount: "100"},
+			ExpectedCode: http.StatusBadRequest,
+		},
+		{
+			Name:         "Negative Amount",
+			Request:      quoteRequest{SourceCurrency: "EUR", TargetCurrency: "USD", Amount: "-1"},
+			ExpectedCode: http.StatusBadRequest,
+		},
+		{
+			Name:         "Missing Rate",
+			Request:      quoteRequest{SourceCurrency: "EUR", TargetCurrency: "USD", Amount: "100"},
+			ServiceError: ErrRateNotFound,
+			ExpectedCode: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			mockService := new(MockService)
+			logger, _ := zap.NewDevelopment()
+			handler := &QuoteHandler{Service: mockService, Logger: logger.Sugar()}
+
+			r := gin.Default()
+			r.POST("/fx/quotes", handler.quote)
+
+			mockService.On("Quote", mock.Anything, mock.Anything, mock.Anything).
+				Return(Quote{ID: "9999"}, tc.ServiceError)
+
+			requestBody, _ := json.Marshal(tc.Request)
+			req, _ := http.NewRequest("POST", "/fx/quotes", bytes.NewReader(requestBody))
+			rr := httptest.NewRecorder()
+
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.ExpectedCode, rr.Code)
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package fx
+
+import (
+	"context"
+	"financial-app/pkg/errs"
+	"financial-app/pkg/money"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// RatePrecision is the number of decimals kept for derived rates
+const RatePrecision = 10
+
+// ErrRateNotFound is used when no rate is quoted for a currency pair
+var ErrRateNotFound = errs.New(errs.ErrUnprocessable, "rate_not_found",
+	"no exchange rate for the currency pair")
+
+// ErrInvalidRate is used when a rate is not a positive decimal
+var ErrInvalidRate = errs.New(errs.ErrInvalid, "invalid_rate", "exchange rate is not a positive decimal")
+
+// ErrAmountTooSmall is used when an amount converts to zero in the target currency
+var ErrAmountTooSmall = errs.New(errs.ErrUnprocessable, "amount_too_small",
+	"amount is too small to be converted")
+
+// Rate is the price of one unit of the source currency in the target currency
+type Rate struct {
+	Source    string    `json:"source_currency"`
+	Target    string    `json:"target_currency"`
+	Value     string    `json:"rate"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// RateProvider provides the current exchange rates
+type RateProvider interface {
+	// Rate returns the rate to convert from the source to the target currency
+	Rate(ctx context.Context, source, target string) (Rate, error)
+}
+
+// Convert converts an amount of the source currency to the target currency
+func (r Rate) Convert(m money.Money) (money.Money, error) {
+	if m.Currency() != r.Source {
+		return money.Money{}, money.ErrCurrencyMismatch
+	}
+
+	rate, err := parseRate(r.Value)
+	if err != nil {
+		return money.Money{}, err
+	}
+
+	converted, err := m.Convert(rate, r.Target)
+	if err != nil {
+		return money.Money{}, err
+	}
+	if m.IsPositive() && !converted.IsPositive() {
+		return money.Money{}, ErrAmountTooSmall
+	}
+
+	return converted, nil
+}
+
+// Inverse returns the rate to convert from the target back to the source currency
+func (r Rate) Inverse() (Rate, error) {
+	rate, err := parseRate(r.Value)
+	if err != nil {
+		return Rate{}, err
+	}
+
+	return Rate{
+		Source:    r.Target,
+		Target:    r.Source,
+		Value:     formatRate(rate.Inv(rate)),
+		Timestamp: r.Timestamp,
+	}, nil
+}
+
+// parseRate converts a positive decimal string such as "1.0842" to an exact rate
+func parseRate(s string) (*big.Rat, error) {
+	intPart, fracPart := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		intPart, fracPart = s[:i], s[i+1:]
+	}
+	if intPart == "" || strings.Trim(intPart, "0123456789") != "" ||
+		strings.Trim(fracPart, "0123456789") != "" {
+		return nil, ErrInvalidRate
+	}
+
+	rate, ok := new(big.Rat).SetString(s)
+	if !ok || rate.Sign() <= 0 {
+		return nil, ErrInvalidRate
+	}
+	return rate, nil
+}
+
+// formatRate returns a rate as a decimal string without trailing zeros
+func formatRate(rate *big.Rat) string {
+	s := strings.TrimRight(rate.FloatString(RatePrecision), "0")
+	return strings.TrimSuffix(s, ".")
+}
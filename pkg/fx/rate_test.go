@@ -0,0 +1,72 @@
+package fx
+
+import (
+	"financial-app/pkg/money"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRate_Convert(t *testing.T) {
+	rate := Rate{Source: "EUR", Target: "USD", Value: "1.0842", Timestamp: time.Now()}
+
+	testCases := []struct {
+		Name          string
+		Amount        money.Money
+		Expected      money.Money
+		ExpectedError error
+	}{
+		{
+			Name:     "Converted",
+			Amount:   money.MustParse("100.00", "EUR"),
+			Expected: money.MustParse("108.42", "USD"),
+		},
+		{
+			Name:     "Rounded",
+			Amount:   money.MustParse("10.05", "EUR"),
+			Expected: money.MustParse("10.90", "USD"),
+		},
+		{
+			Name:          "Wrong Currency",
+			Amount:        money.MustParse("100.00", "USD"),
+			ExpectedError: money.ErrCurrencyMismatch,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			converted, err := rate.Convert(tc.Amount)
+
+			assert.Equal(t, tc.ExpectedError, err)
+			assert.Equal(t, tc.Expected, converted)
+		})
+	}
+}
+
+func TestRate_ConvertTooSmall(t *testing.T) {
+	rate := Rate{Source: "EUR", Target: "USD", Value: "0.1"}
+
+	_, err := rate.Convert(money.MustParse("0.01", "EUR"))
+
+	assert.Equal(t, ErrAmountTooSmall, err)
+}
+
+func TestRate_Inverse(t *testing.T) {
+	rate := Rate{Source: "EUR", Target: "USD", Value: "1.25"}
+
+	inverse, err := rate.Inverse()
+
+	assert.NoError(t, err)
+	assert.Equal(t, Rate{Source: "USD", Target: "EUR", Value: "0.8"}, inverse)
+}
+
+func TestRate_InvalidValue(t *testing.T) {
+	for _, value := range []string{"", "0", "-1.2", "1/3", "1e3", "abc"} {
+		rate := Rate{Source: "EUR", Target: "USD", Value: value}
+
+		_, err := rate.Convert(money.MustParse("1.00", "EUR"))
+
+		assert.Equal(t, ErrInvalidRate, err, value)
+	}
+}
@@ -0,0 +1,9 @@
+package fx
+
+import "context"
+
+// QuoteRepository provides access a quote store
+type QuoteRepository interface {
+	Store(ctx context.Context, q *Quote) (*Quote, error)
+	Find(ctx context.Context, id string) (*Quote, error)
+}
@@ -0,0 +1,97 @@
+package fx
+
+import (
+	"context"
+	"financial-app/pkg/money"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// Quote is a read model for quote views. It locks a rate for a given amount
+// until it expires or is used by a transfer.
+type Quote struct {
+	ID             string      `json:"id"`
+	SourceCurrency string      `json:"source_currency"`
+	TargetCurrency string      `json:"target_currency"`
+	SourceAmount   money.Money `json:"source_amount"`
+	TargetAmount   money.Money `json:"target_amount"`
+	Rate           string      `json:"rate"`
+	RateTimestamp  time.Time   `json:"rate_timestamp"`
+	ExpiresAt      time.Time   `json:"expires_at"`
+	UsedAt         *time.Time  `json:"used_at,omitempty"`
+}
+
+// Service is the interface that provides quote methods
+type Service interface {
+	// Quote locks the current rate to convert an amount to the target currency
+	Quote(ctx context.Context, amount money.Money, target string) (Quote, error)
+}
+
+// Check returns an error if the quote cannot be used at the given time
+func (q Quote) Check(now time.Time) error {
+	if q.UsedAt != nil {
+		return ErrQuoteUsed
+	}
+	if !now.Before(q.ExpiresAt) {
+		return ErrQuoteExpired
+	}
+	return nil
+}
+
+func (s *service) Quote(
+	ctx context.Context, amount money.Money, target string,
+) (Quote, error) {
+	rate, err := s.rates.Rate(ctx, amount.Currency(), target)
+	if err != nil {
+		return Quote{}, err
+	}
+
+	targetAmount, err := rate.Convert(amount)
+	if err != nil {
+		return Quote{}, err
+	}
+
+	quote := Quote{
+		ID:             nextQuoteID(), // Generate a new uuid
+		SourceCurrency: amount.Currency(),
+		TargetCurrency: target,
+		SourceAmount:   amount,
+		TargetAmount:   targetAmount,
+		Rate:           rate.Value,
+		RateTimestamp:  rate.Timestamp,
+		ExpiresAt:      time.Now().UTC().Add(s.ttl),
+	}
+
+	// Store the new quote to the repository
+	q, err := s.quotes.Store(ctx, &quote)
+	if err != nil {
+		return Quote{}, err
+	}
+	return *q, nil
+}
+
+type service struct {
+	rates  RateProvider
+	quotes QuoteRepository
+	ttl    time.Duration
+}
+
+// NewService creates a quote service with necessary dependencies. Quotes
+// expire after the given time to live.
+func NewService(
+	rates RateProvider,
+	quotes QuoteRepository,
+	ttl time.Duration,
+) Service {
+	return &service{
+		rates:  rates,
+		quotes: quotes,
+		ttl:    ttl,
+	}
+}
+
+// nextQuoteID generates a new quote ID.
+func nextQuoteID() string {
+	return uuid.NewV4().String()
+}
@@ -0,0 +1,93 @@
+package fx
+
+import (
+	"context"
+	"financial-app/pkg/money"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockQuoteRepository struct {
+	Quotes map[string]*Quote
+}
+
+func (m *mockQuoteRepository) Store(
+	ctx context.Context, q *Quote,
+) (*Quote, error) {
+	m.Quotes[q.ID] = q
+	return q, nil
+}
+
+func (m *mockQuoteRepository) Find(
+	ctx context.Context, id string,
+) (*Quote, error) {
+	if q, ok := m.Quotes[id]; ok {
+		return q, nil
+	}
+	return nil, ErrQuoteNotFound
+}
+
+func TestService_Quote(t *testing.T) {
+	timestamp := time.Date(2023, 9, 7, 0, 0, 0, 0, time.UTC)
+	rates, err := NewStaticProvider(map[string]string{"EUR/USD": "1.0842"}, timestamp)
+	assert.NoError(t, err)
+
+	mockQuoteRepository := &mockQuoteRepository{Quotes: make(map[string]*Quote)}
+	service := NewService(rates, mockQuoteRepository, 30*time.Second)
+
+	before := time.Now()
+	quote, err := service.Quote(
+		context.Background(), money.MustParse("100.00", "EUR"), "USD",
+	)
+
+	assert.NoError(t, err, "Error should be nil")
+	assert.NotEmpty(t, quote.ID)
+	assert.Equal(t, "EUR", quote.SourceCurrency)
+	assert.Equal(t, "USD", quote.TargetCurrency)
+	assert.Equal(t, money.MustParse("108.42", "USD"), quote.TargetAmount)
+	assert.Equal(t, "1.0842", quote.Rate)
+	assert.Equal(t, timestamp, quote.RateTimestamp)
+	assert.False(t, quote.ExpiresAt.Before(before.Add(30*time.Second)),
+		"Quote should expire after its time to live")
+	assert.Contains(t, mockQuoteRepository.Quotes, quote.ID, "Quote should be stored")
+}
+
+func TestService_QuoteNoRate(t *testing.T) {
+	rates, err := NewStaticProvider(nil, time.Now())
+	assert.NoError(t, err)
+
+	mockQuoteRepository := &mockQuoteRepository{Quotes: make(map[string]*Quote)}
+	service := NewService(rates, mockQuoteRepository, 30*time.Second)
+
+	_, err = service.Quote(context.Background(), money.MustParse("100.00", "EUR"), "USD")
+
+	assert.Equal(t, ErrRateNotFound, err)
+	assert.Empty(t, mockQuoteRepository.Quotes, "No quote should be stored")
+}
+
+func TestQuote_Check(t *testing.T) {
+	now := time.Date(2023, 9, 8, 12, 0, 0, 0, time.UTC)
+	usedAt := now.Add(-time.Second)
+
+	testCases := []struct {
+		Name          string
+		Quote         Quote
+		ExpectedError error
+	}{
+		{Name: "Valid", Quote: Quote{ExpiresAt: now.Add(time.Second)}},
+		{Name: "Expired", Quote: Quote{ExpiresAt: now}, ExpectedError: ErrQuoteExpired},
+		{
+			Name:          "Used",
+			Quote:         Quote{ExpiresAt: now.Add(time.Second), UsedAt: &usedAt},
+			ExpectedError: ErrQuoteUsed,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert.Equal(t, tc.ExpectedError, tc.Quote.Check(now))
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package fx
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"time"
+)
+
+// StaticProvider is a rate provider backed by a fixed table of rates
+type StaticProvider struct {
+	rates map[string]Rate
+}
+
+// rateTable is the file format of a static rate table, e.g.
+//
+//	{"timestamp": "2023-09-07T00:00:00Z", "rates": {"EUR/USD": "1.0842"}}
+type rateTable struct {
+	Timestamp time.Time         `json:"timestamp"`
+	Rates     map[string]string `json:"rates"`
+}
+
+// NewStaticProvider returns a rate provider for a table of rates keyed by
+// currency pair such as "EUR/USD". The inverse of every pair is derived unless
+// it is quoted as well.
+func NewStaticProvider(rates map[string]string, timestamp time.Time) (*StaticProvider, error) {
+	p := &StaticProvider{rates: make(map[string]Rate)}
+
+	for pair, value := range rates {
+		source, target, ok := strings.Cut(pair, "/")
+		if !ok || source == "" || target == "" {
+			return nil, ErrInvalidRate
+		}
+
+		rate := Rate{Source: source, Target: target, Value: value, Timestamp: timestamp}
+		inverse, err := rate.Inverse()
+		if err != nil {
+			return nil, err
+		}
+
+		p.rates[pair] = rate
+		if _, ok := rates[target+"/"+source]; !ok {
+			p.rates[target+"/"+source] = inverse
+		}
+	}
+
+	return p, nil
+}
+
+// LoadFile returns a rate provider for the rate table stored in a JSON file
+func LoadFile(path string) (*StaticProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var table rateTable
+	if err := json.Unmarshal(data, &table); err != nil {
+		return nil, err
+	}
+	if table.Timestamp.IsZero() {
+		table.Timestamp = time.Now().UTC()
+	}
+
+	return NewStaticProvider(table.Rates, table.Timestamp)
+}
+
+// Rate returns the rate to convert from the source to the target currency
+func (p *StaticProvider) Rate(ctx context.Context, source, target string) (Rate, error) {
+	rate, ok := p.rates[source+"/"+target]
+	if !ok {
+		return Rate{}, ErrRateNotFound
+	}
+	return rate, nil
+}
@@ -0,0 +1,78 @@
+package fx
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStaticProvider_Rate(t *testing.T) {
+	timestamp := time.Date(2023, 9, 7, 0, 0, 0, 0, time.UTC)
+	provider, err := NewStaticProvider(map[string]string{"EUR/USD": "1.25"}, timestamp)
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		Name          string
+		Source        string
+		Target        string
+		Expected      Rate
+		ExpectedError error
+	}{
+		{
+			Name:     "Quoted Pair",
+			Source:   "EUR",
+			Target:   "USD",
+			Expected: Rate{Source: "EUR", Target: "USD", Value: "1.25", Timestamp: timestamp},
+		},
+		{
+			Name:     "Derived Inverse",
+			Source:   "USD",
+			Target:   "EUR",
+			Expected: Rate{Source: "USD", Target: "EUR", Value: "0.8", Timestamp: timestamp},
+		},
+		{
+			Name:          "Unknown Pair",
+			Source:        "EUR",
+			Target:        "GBP",
+			ExpectedError: ErrRateNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			rate, err := provider.Rate(context.Background(), tc.Source, tc.Target)
+
+			assert.Equal(t, tc.ExpectedError, err)
+			assert.Equal(t, tc.Expected, rate)
+		})
+	}
+}
+
+func TestStaticProvider_InvalidTable(t *testing.T) {
+	_, err := NewStaticProvider(map[string]string{"EURUSD": "1.25"}, time.Now())
+	assert.Equal(t, ErrInvalidRate, err)
+
+	_, err = NewStaticProvider(map[string]string{"EUR/USD": "-1"}, time.Now())
+	assert.Equal(t, ErrInvalidRate, err)
+}
+
+func TestLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.json")
+	err := os.WriteFile(path, []byte(`{
+		"timestamp": "2023-09-07T00:00:00Z",
+		"rates": {"EUR/USD": "1.0842"}
+	}`), 0o600)
+	assert.NoError(t, err)
+
+	provider, err := LoadFile(path)
+	assert.NoError(t, err)
+
+	rate, err := provider.Rate(context.Background(), "EUR", "USD")
+	assert.NoError(t, err)
+	assert.Equal(t, "1.0842", rate.Value)
+	assert.Equal(t, time.Date(2023, 9, 7, 0, 0, 0, 0, time.UTC), rate.Timestamp)
+}
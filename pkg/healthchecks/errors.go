@@ -0,0 +1,6 @@
+package healthchecks
+
+import "financial-app/pkg/errs"
+
+// ErrDatabaseUnavailable is used when the database cannot be reached
+var ErrDatabaseUnavailable = errs.New(errs.ErrUnavailable, "database_unavailable", "database is unavailable")
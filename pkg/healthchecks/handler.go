@@ -1,6 +1,7 @@
 package healthchecks
 
 import (
+	"financial-app/pkg/http/rest/httperr"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -21,9 +22,7 @@ func (h *HealthcheckHandler) Router(routerGroup *gin.RouterGroup) {
 func (h *HealthcheckHandler) aliveCheck(context *gin.Context) {
 	if err := h.Service.Alive(context); err != nil {
 		h.Logger.Error(err)
-		context.JSON(http.StatusServiceUnavailable, gin.H{
-			"error": err.Error(),
-		})
+		httperr.Respond(context, err)
 
 		return
 	}
@@ -2,6 +2,7 @@ package healthchecks
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -61,3 +62,27 @@ func TestHealthcheckHandler_AliveCheck(t *testing.T) {
 		})
 	}
 }
+
+func TestHealthcheckHandler_AliveCheckUnavailable(t *testing.T) {
+	mockService := new(MockService)
+	logger, _ := zap.NewDevelopment()
+	handler := &HealthcheckHandler{Service: mockService, Logger: logger.Sugar()}
+
+	r := gin.Default()
+	r.GET("/alive", handler.aliveCheck)
+
+	mockService.On("Alive", mock.Anything).
+		Return(ErrDatabaseUnavailable.Wrap(errors.New("dial tcp: connection refused")))
+
+	req, _ := http.NewRequest("GET", "/alive", nil)
+	rr := httptest.NewRecorder()
+
+	r.ServeHTTP(rr, req)
+
+	// The cause of the outage is not sent to the client
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.Equal(t, "application/problem+json", rr.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"type":"/problems/database_unavailable","title":"Service Unavailable",`+
+		`"status":503,"detail":"the server could not complete the request","instance":"/alive",`+
+		`"code":"database_unavailable"}`, rr.Body.String())
+}
@@ -23,7 +23,7 @@ func NewService(
 
 func (s *service) Alive(ctx context.Context) error {
 	if err := s.healthcheck.Ping(ctx); err != nil {
-		return err
+		return ErrDatabaseUnavailable.Wrap(err)
 	}
 	return nil
 }
@@ -0,0 +1,81 @@
+package decoratedsvcs
+
+import (
+	"context"
+	"financial-app/pkg/holds"
+	"time"
+
+	"github.com/go-kit/kit/metrics"
+)
+
+type instrumentingService struct {
+	requestCount   metrics.Counter
+	requestLatency metrics.Histogram
+	next           holds.Service
+}
+
+// NewInstrumentingService returns an instance of an instrumenting Service.
+func NewInstrumentingService(
+	counter metrics.Counter, latency metrics.Histogram, s holds.Service,
+) holds.Service {
+	return &instrumentingService{
+		requestCount:   counter,
+		requestLatency: latency,
+		next:           s,
+	}
+}
+
+func (s *instrumentingService) Load(
+	ctx context.Context, id string,
+) (hold holds.Hold, err error) {
+	defer func(begin time.Time) {
+		s.requestCount.With("method", "load").Add(1)
+		s.requestLatency.With("method", "load").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return s.next.Load(ctx, id)
+}
+
+func (s *instrumentingService) Place(
+	ctx context.Context, h holds.Hold,
+) (hold holds.Hold, err error) {
+	defer func(begin time.Time) {
+		s.requestCount.With("method", "place").Add(1)
+		s.requestLatency.With("method", "place").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return s.next.Place(ctx, h)
+}
+
+func (s *instrumentingService) Capture(
+	ctx context.Context, id, targetAccountID, amount string,
+) (hold holds.Hold, err error) {
+	defer func(begin time.Time) {
+		s.requestCount.With("method", "capture").Add(1)
+		s.requestLatency.With("method", "capture").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return s.next.Capture(ctx, id, targetAccountID, amount)
+}
+
+func (s *instrumentingService) Void(
+	ctx context.Context, id string,
+) (hold holds.Hold, err error) {
+	defer func(begin time.Time) {
+		s.requestCount.With("method", "void").Add(1)
+		s.requestLatency.With("method", "void").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return s.next.Void(ctx, id)
+}
+
+func (s *instrumentingService) Expire(
+	ctx context.Context, now time.Time,
+) (expired int64, err error) {
+	defer func(begin time.Time) {
+		s.requestCount.With("method", "expire").Add(1)
+		s.requestLatency.With("method", "expire").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return s.next.Expire(ctx, now)
+}
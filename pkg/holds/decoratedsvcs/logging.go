@@ -0,0 +1,97 @@
+package decoratedsvcs
+
+import (
+	"context"
+	"financial-app/pkg/holds"
+	"time"
+
+	log "go.uber.org/zap"
+)
+
+type loggingService struct {
+	logger *log.SugaredLogger
+	next   holds.Service
+}
+
+// NewLoggingService returns a new instance of a logging Service.
+func NewLoggingService(logger *log.SugaredLogger, s holds.Service) holds.Service {
+	return &loggingService{logger, s}
+}
+
+func (s *loggingService) Load(
+	ctx context.Context, id string,
+) (hold holds.Hold, err error) {
+	defer func(begin time.Time) {
+		s.logger.Infow(
+			"load",
+			log.String("hold_id", id),
+			log.Duration("took", time.Since(begin)),
+			log.Error(err),
+		)
+	}(time.Now())
+	return s.next.Load(ctx, id)
+}
+
+func (s *loggingService) Place(
+	ctx context.Context, h holds.Hold,
+) (hold holds.Hold, err error) {
+	defer func(begin time.Time) {
+		s.logger.Infow(
+			"hold place",
+			log.String("hold_id", h.ID),
+			log.String("account_id", h.AccountID),
+			log.Stringer("amount", h.Amount),
+			log.String("currency", h.Currency),
+			log.String("reference", h.Reference),
+			log.Duration("took", time.Since(begin)),
+			log.Error(err),
+		)
+	}(time.Now())
+	return s.next.Place(ctx, h)
+}
+
+func (s *loggingService) Capture(
+	ctx context.Context, id, targetAccountID, amount string,
+) (hold holds.Hold, err error) {
+	defer func(begin time.Time) {
+		s.logger.Infow(
+			"hold capture",
+			log.String("hold_id", id),
+			log.String("target_account_id", targetAccountID),
+			log.String("amount", amount),
+			log.String("transaction_id", hold.TransactionID),
+			log.Duration("took", time.Since(begin)),
+			log.Error(err),
+		)
+	}(time.Now())
+	return s.next.Capture(ctx, id, targetAccountID, amount)
+}
+
+func (s *loggingService) Void(
+	ctx context.Context, id string,
+) (hold holds.Hold, err error) {
+	defer func(begin time.Time) {
+		s.logger.Infow(
+			"hold void",
+			log.String("hold_id", id),
+			log.Duration("took", time.Since(begin)),
+			log.Error(err),
+		)
+	}(time.Now())
+	return s.next.Void(ctx, id)
+}
+
+func (s *loggingService) Expire(
+	ctx context.Context, now time.Time,
+) (expired int64, err error) {
+	defer func(begin time.Time) {
+		s.logger.Infow(
+			"expire holds",
+			log.Time("now", now),
+			log.Int64("expired", expired),
+			log.Duration("took", time.Since(begin)),
+			log.Error(err),
+		)
+	}(time.Now())
+	return s.next.Expire(ctx, now)
+}
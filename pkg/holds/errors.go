@@ -0,0 +1,63 @@
+package holds
+
+import "financial-app/pkg/errs"
+
+// ErrHoldNotFound is used when a hold does not exist
+var ErrHoldNotFound = errs.New(errs.ErrNotFound, "hold_not_found", "hold not found")
+
+// ErrFetchingHold is used when a hold could not be found. It matches
+// ErrHoldNotFound.
+func ErrFetchingHold(id string) *errs.Error {
+	return ErrHoldNotFound.WithMessage("could not fetch hold by ID " + id)
+}
+
+// ErrDuplicateHold is used when a hold with the same ID already exists
+var ErrDuplicateHold = errs.New(errs.ErrConflict, "duplicate_hold",
+	"a hold with the same ID already exists")
+
+// ErrDuplicateReference is used when the account already has a hold with the
+// same reference
+var ErrDuplicateReference = errs.New(errs.ErrConflict, "duplicate_hold_reference",
+	"the account already has a hold with the same reference")
+
+// ErrInvalidExpiry is used when a hold would expire before it is placed
+var ErrInvalidExpiry = errs.New(errs.ErrInvalid, "invalid_hold_expiry",
+	"expires_at must be in the future")
+
+// ErrHoldNotActive is used when a hold already captured, voided or expired is
+// to be captured or voided
+var ErrHoldNotActive = errs.New(errs.ErrConflict, "hold_not_active", "hold is not active")
+
+// ErrHoldExpired is used when a hold is captured or voided past its expiry
+var ErrHoldExpired = errs.New(errs.ErrGone, "hold_expired", "hold has expired")
+
+// ErrInvalidCaptureAmount is used when the amount to capture is not a
+// positive amount in the currency of the hold
+var ErrInvalidCaptureAmount = errs.New(errs.ErrInvalid, "invalid_capture_amount",
+	"amount must be a positive amount in the currency of the hold")
+
+// ErrCaptureExceedsHold is used when more than the held amount is captured
+var ErrCaptureExceedsHold = errs.New(errs.ErrConflict, "capture_exceeds_hold",
+	"cannot capture more than the held amount")
+
+// ErrPostingHold is used when a hold could not be placed
+func ErrPostingHold(id string) *errs.Error {
+	return errs.New(errs.ErrInternal, "posting_hold_failed",
+		"could not place a new hold by ID "+id)
+}
+
+// ErrQueryingHold is used when a hold could not be queried
+func ErrQueryingHold(id string) *errs.Error {
+	return errs.New(errs.ErrInternal, "querying_hold_failed",
+		"could not query the hold by ID "+id)
+}
+
+// ErrReleasingHold is used when a hold could not be captured or voided
+func ErrReleasingHold(id string) *errs.Error {
+	return errs.New(errs.ErrInternal, "releasing_hold_failed",
+		"could not release the hold by ID "+id)
+}
+
+// ErrExpiringHolds is used when the overdue holds could not be expired
+var ErrExpiringHolds = errs.New(errs.ErrInternal, "expiring_holds_failed",
+	"could not expire the overdue holds")
@@ -0,0 +1,33 @@
+package holds
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// RunExpiry expires the holds past their expiry at every interval until the
+// context is done, which releases the amounts they held
+func RunExpiry(
+	ctx context.Context, svc Service, interval time.Duration, logger *zap.SugaredLogger,
+) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := svc.Expire(ctx, time.Now().UTC())
+			if err != nil {
+				logger.Errorf("failed to expire the holds: %v", err)
+				continue
+			}
+			if n > 0 {
+				logger.Infof("expired %d holds", n)
+			}
+		}
+	}
+}
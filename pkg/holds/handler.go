@@ -0,0 +1,180 @@
+package holds
+
+import (
+	"encoding/json"
+	"financial-app/pkg/currency"
+	"financial-app/pkg/errs"
+	"financial-app/pkg/http/rest/httperr"
+	"financial-app/pkg/money"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+var holdIDRequired = errs.New(errs.ErrInvalid, "hold_id_required", "hold id required")
+
+type HoldHandler struct {
+	Service Service
+
+	Logger *zap.SugaredLogger
+}
+
+// Router sets up all the routes for hold service
+func (h *HoldHandler) Router(routerGroup *gin.RouterGroup) {
+	routerGroup.GET("holds/:id", h.load)
+	routerGroup.POST("holds", h.place)
+	routerGroup.POST("holds/:id/capture", h.capture)
+	routerGroup.POST("holds/:id/void", h.void)
+}
+
+// holdRequest
+type holdRequest struct {
+	AccountID string      `json:"account_id" validate:"required,uuid"`
+	Amount    json.Number `json:"amount" validate:"required"`
+	Currency  string      `json:"currency" validate:"required"`
+	Reference string      `json:"reference" validate:"required,max=100"`
+	ExpiresAt *time.Time  `json:"expires_at"`
+}
+
+// captureRequest
+type captureRequest struct {
+	TargetAccountID string      `json:"target_account_id" validate:"required,uuid"`
+	Amount          json.Number `json:"amount"`
+}
+
+// load retrieves a hold by ID
+func (h *HoldHandler) load(context *gin.Context) {
+	id := context.Param("id")
+	if id == "" {
+		h.Logger.Error("no hold id found")
+
+		httperr.Respond(context, holdIDRequired)
+		return
+	}
+
+	hold, err := h.Service.Load(context, id)
+	if err != nil {
+		h.Logger.Error(err)
+
+		httperr.Respond(context, err)
+		return
+	}
+
+	context.JSON(http.StatusOK, hold)
+}
+
+// place holds an amount on an account
+func (h *HoldHandler) place(context *gin.Context) {
+	var req holdRequest
+	if err := context.ShouldBindJSON(&req); err != nil {
+		h.Logger.Error(err)
+
+		httperr.Respond(context, errs.Invalid(err))
+		return
+	}
+	req.Currency = strings.ToUpper(strings.TrimSpace(req.Currency))
+	req.Reference = strings.TrimSpace(req.Reference)
+
+	validate := validator.New()
+	validate.RegisterTagNameFunc(httperr.JSONFieldName)
+	if err := validate.Struct(req); err != nil {
+		h.Logger.Error(err)
+
+		httperr.Respond(context, errs.Invalid(err))
+		return
+	}
+	if !currency.IsSupported(req.Currency) {
+		httperr.Respond(context, currency.ErrNotSupported)
+		return
+	}
+	amount, err := money.Parse(req.Amount.String(), req.Currency)
+	if err != nil {
+		h.Logger.Error(err)
+
+		httperr.Respond(context, errs.Invalid(err))
+		return
+	}
+
+	hold := Hold{
+		ID:        nextHoldID(), // Generate a new uuid
+		AccountID: req.AccountID,
+		Amount:    amount,
+		Currency:  req.Currency,
+		Reference: req.Reference,
+	}
+	if req.ExpiresAt != nil {
+		hold.ExpiresAt = req.ExpiresAt.UTC()
+	}
+
+	hold, err = h.Service.Place(context, hold)
+	if err != nil {
+		h.Logger.Error(err)
+
+		httperr.Respond(context, err)
+		return
+	}
+
+	context.JSON(http.StatusCreated, hold)
+}
+
+// capture turns a hold into a transfer of all or part of its amount
+func (h *HoldHandler) capture(context *gin.Context) {
+	id := context.Param("id")
+	if id == "" {
+		h.Logger.Error("no hold id found")
+
+		httperr.Respond(context, holdIDRequired)
+		return
+	}
+
+	var req captureRequest
+	if err := context.ShouldBindJSON(&req); err != nil {
+		h.Logger.Error(err)
+
+		httperr.Respond(context, errs.Invalid(err))
+		return
+	}
+	validate := validator.New()
+	validate.RegisterTagNameFunc(httperr.JSONFieldName)
+	if err := validate.Struct(req); err != nil {
+		h.Logger.Error(err)
+
+		httperr.Respond(context, errs.Invalid(err))
+		return
+	}
+
+	hold, err := h.Service.Capture(context, id, req.TargetAccountID, req.Amount.String())
+	if err != nil {
+		h.Logger.Error(err)
+
+		httperr.Respond(context, err)
+		return
+	}
+
+	context.JSON(http.StatusOK, hold)
+}
+
+// void releases a hold without moving any balance
+func (h *HoldHandler) void(context *gin.Context) {
+	id := context.Param("id")
+	if id == "" {
+		h.Logger.Error("no hold id found")
+
+		httperr.Respond(context, holdIDRequired)
+		return
+	}
+
+	hold, err := h.Service.Void(context, id)
+	if err != nil {
+		h.Logger.Error(err)
+
+		httperr.Respond(context, err)
+		return
+	}
+
+	context.JSON(http.StatusOK, hold)
+}
@@ -0,0 +1,260 @@
+package holds
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"financial-app/pkg/http/rest/httperr"
+	"financial-app/pkg/money"
+	"financial-app/pkg/transactions"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+const accountID = "0c9d5e6f-1a2b-4c3d-8e4f-5a6b7c8d9e0f"
+
+// MockService is a mock implementation of the Service interface for testing.
+type MockService struct {
+	mock.Mock
+}
+
+func (m *MockService) Load(ctx context.Context, id string) (Hold, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(Hold), args.Error(1)
+}
+
+func (m *MockService) Place(ctx context.Context, hold Hold) (Hold, error) {
+	args := m.Called(ctx, hold)
+	return args.Get(0).(Hold), args.Error(1)
+}
+
+func (m *MockService) Capture(
+	ctx context.Context, id, targetAccountID, amount string,
+) (Hold, error) {
+	args := m.Called(ctx, id, targetAccountID, amount)
+	return args.Get(0).(Hold), args.Error(1)
+}
+
+func (m *MockService) Void(ctx context.Context, id string) (Hold, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(Hold), args.Error(1)
+}
+
+func (m *MockService) Expire(ctx context.Context, now time.Time) (int64, error) {
+	args := m.Called(ctx, now)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// setupRouter returns a router serving the hold routes with the mock service
+func setupRouter(mockService *MockService) *gin.Engine {
+	logger, _ := zap.NewDevelopment()
+	handler := &HoldHandler{Service: mockService, Logger: logger.Sugar()}
+
+	r := gin.Default()
+	handler.Router(&r.RouterGroup)
+	return r
+}
+
+func TestHoldHandler_Place(t *testing.T) {
+	testCases := []struct {
+		Name              string
+		Body              string
+		ServiceError      error
+		ExpectedCode      int
+		ExpectedErrorCode string
+		ExpectedFields    []string
+	}{
+		{
+			Name: "Placed",
+			Body: `{"account_id": "` + accountID + `", "amount": 12.5, "currency": "usd",
+				"reference": " auth-1 ", "expires_at": "2030-01-02T03:04:05+02:00"}`,
+			ExpectedCode: http.StatusCreated,
+		},
+		{
+			Name:              "Missing Fields",
+			Body:              `{"account_id": "1111", "currency": "USD"}`,
+			ExpectedCode:      http.StatusBadRequest,
+			ExpectedErrorCode: "invalid_request",
+			ExpectedFields:    []string{"account_id", "amount", "reference"},
+		},
+		{
+			Name: "Unsupported Currency",
+			Body: `{"account_id": "` + accountID + `", "amount": 12.5, "currency": "XYZ",
+				"reference": "auth-1"}`,
+			ExpectedCode:      http.StatusBadRequest,
+			ExpectedErrorCode: "currency_not_supported",
+		},
+		{
+			Name: "Insufficient Funds",
+			Body: `{"account_id": "` + accountID + `", "amount": 12.5, "currency": "USD",
+				"reference": "auth-1"}`,
+			ServiceError: &transactions.InsufficientFundsError{
+				AccountID: accountID,
+				Balance:   money.New(1000, "USD"),
+				Amount:    money.New(1250, "USD"),
+			},
+			ExpectedCode:      http.StatusConflict,
+			ExpectedErrorCode: "insufficient_funds",
+		},
+		{
+			Name: "Duplicate Reference",
+			Body: `{"account_id": "` + accountID + `", "amount": 12.5, "currency": "USD",
+				"reference": "auth-1"}`,
+			ServiceError:      ErrDuplicateReference,
+			ExpectedCode:      http.StatusConflict,
+			ExpectedErrorCode: "duplicate_hold_reference",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			mockService := new(MockService)
+			r := setupRouter(mockService)
+
+			var placed Hold
+			mockService.On("Place", mock.Anything, mock.Anything).
+				Run(func(args mock.Arguments) { placed = args.Get(1).(Hold) }).
+				Return(Hold{ID: holdID, Status: StatusActive}, tc.ServiceError)
+
+			req, _ := http.NewRequest("POST", "/holds", bytes.NewBufferString(tc.Body))
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.ExpectedCode, rr.Code)
+			if tc.ExpectedCode == http.StatusCreated {
+				assert.NotEmpty(t, placed.ID)
+				assert.Equal(t, money.New(1250, "USD"), placed.Amount)
+				assert.Equal(t, "USD", placed.Currency)
+				assert.Equal(t, "auth-1", placed.Reference)
+				assert.Equal(t, time.Date(2030, 1, 2, 1, 4, 5, 0, time.UTC), placed.ExpiresAt)
+				return
+			}
+
+			var response httperr.Problem
+			assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+			assert.Equal(t, tc.ExpectedErrorCode, response.Code)
+			if tc.ExpectedFields != nil {
+				fields := make([]string, 0, len(response.Errors))
+				for _, fe := range response.Errors {
+					fields = append(fields, fe.Field)
+				}
+				assert.Equal(t, tc.ExpectedFields, fields)
+			}
+		})
+	}
+}
+
+func TestHoldHandler_Capture(t *testing.T) {
+	testCases := []struct {
+		Name              string
+		Body              string
+		ExpectedAmount    string
+		ServiceError      error
+		ExpectedCode      int
+		ExpectedErrorCode string
+	}{
+		{
+			Name:         "Full Capture",
+			Body:         `{"target_account_id": "` + accountID + `"}`,
+			ExpectedCode: http.StatusOK,
+		},
+		{
+			Name:           "Partial Capture",
+			Body:           `{"target_account_id": "` + accountID + `", "amount": 5.25}`,
+			ExpectedAmount: "5.25",
+			ExpectedCode:   http.StatusOK,
+		},
+		{
+			Name:              "Missing Target",
+			Body:              `{"amount": 5.25}`,
+			ExpectedCode:      http.StatusBadRequest,
+			ExpectedErrorCode: "invalid_request",
+		},
+		{
+			Name:              "Exceeds Hold",
+			Body:              `{"target_account_id": "` + accountID + `", "amount": 500}`,
+			ExpectedAmount:    "500",
+			ServiceError:      ErrCaptureExceedsHold,
+			ExpectedCode:      http.StatusConflict,
+			ExpectedErrorCode: "capture_exceeds_hold",
+		},
+		{
+			Name:              "Expired",
+			Body:              `{"target_account_id": "` + accountID + `"}`,
+			ServiceError:      ErrHoldExpired,
+			ExpectedCode:      http.StatusGone,
+			ExpectedErrorCode: "hold_expired",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			mockService := new(MockService)
+			r := setupRouter(mockService)
+
+			captured := Hold{ID: holdID, Status: StatusCaptured}
+			mockService.On("Capture", mock.Anything, holdID, accountID, tc.ExpectedAmount).
+				Return(captured, tc.ServiceError)
+
+			req, _ := http.NewRequest("POST", "/holds/"+holdID+"/capture",
+				bytes.NewBufferString(tc.Body))
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.ExpectedCode, rr.Code)
+			if tc.ExpectedCode == http.StatusOK {
+				expected, _ := json.Marshal(captured)
+				assert.JSONEq(t, string(expected), rr.Body.String())
+				return
+			}
+
+			var response httperr.Problem
+			assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+			assert.Equal(t, tc.ExpectedErrorCode, response.Code)
+		})
+	}
+}
+
+func TestHoldHandler_LoadAndVoid(t *testing.T) {
+	mockService := new(MockService)
+	r := setupRouter(mockService)
+
+	hold := Hold{ID: holdID, Amount: money.New(1250, "USD"), Status: StatusActive}
+	mockService.On("Load", mock.Anything, holdID).Return(hold, nil)
+	mockService.On("Load", mock.Anything, "1111").Return(Hold{}, ErrFetchingHold("1111"))
+	voided := hold
+	voided.Status = StatusVoided
+	mockService.On("Void", mock.Anything, holdID).Return(voided, nil)
+
+	req, _ := http.NewRequest("GET", "/holds/"+holdID, nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	expected, _ := json.Marshal(hold)
+	assert.JSONEq(t, string(expected), rr.Body.String())
+
+	req, _ = http.NewRequest("GET", "/holds/1111", nil)
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	var response httperr.Problem
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, "hold_not_found", response.Code)
+
+	req, _ = http.NewRequest("POST", "/holds/"+holdID+"/void", nil)
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	expected, _ = json.Marshal(voided)
+	assert.JSONEq(t, string(expected), rr.Body.String())
+}
@@ -0,0 +1,25 @@
+package holds
+
+import (
+	"context"
+	"financial-app/pkg/transactions"
+	"time"
+)
+
+// Repository provides access a hold store
+type Repository interface {
+	// Place atomically checks that the available balance of the account
+	// covers the hold and holds its amount on the account
+	Place(ctx context.Context, hold *Hold) (*Hold, error)
+	Find(ctx context.Context, id string) (*Hold, error)
+	// Capture atomically releases an active hold, debits the captured amount
+	// from its account, credits the target account and books the transfer.
+	// A transfer waiting for an approval only holds the captured amount. It
+	// refuses the holds no longer active or past their expiry.
+	Capture(ctx context.Context, id string, txn *transactions.Transaction) (*Hold, error)
+	// Void atomically releases an active hold without moving any balance
+	Void(ctx context.Context, id string, now time.Time) (*Hold, error)
+	// Expire expires the active holds past their expiry as of now, releases
+	// the amounts they held and returns how many expired
+	Expire(ctx context.Context, now time.Time) (int64, error)
+}
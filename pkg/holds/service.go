@@ -0,0 +1,332 @@
+package holds
+
+import (
+	"context"
+	"financial-app/pkg/accounts"
+	"financial-app/pkg/auth"
+	"financial-app/pkg/money"
+	"financial-app/pkg/transactions"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// Statuses of a hold. A hold is active until it is captured, voided or
+// expires, each of which releases the amount it held.
+const (
+	StatusActive   = "active"
+	StatusCaptured = "captured"
+	StatusVoided   = "voided"
+	StatusExpired  = "expired"
+)
+
+// Hold is a read model for an amount reserved on an account. It lowers the
+// available balance of the account but not its balance until it is captured.
+type Hold struct {
+	ID        string      `json:"id"`
+	AccountID string      `json:"account_id"`
+	Amount    money.Money `json:"amount"`
+	Currency  string      `json:"currency"`
+	// Reference identifies the hold for the caller, such as the ID of a card
+	// authorization. It is unique by account.
+	Reference string `json:"reference"`
+	Status    string `json:"status"`
+	// CapturedAmount and TransactionID describe the transfer a captured hold
+	// turned into
+	CapturedAmount *money.Money `json:"captured_amount,omitempty"`
+	TransactionID  string       `json:"transaction_id,omitempty"`
+	// PlacedBy is the API key which placed the hold, if any
+	PlacedBy  string    `json:"placed_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	// ReleasedAt is the time the hold was captured, voided or expired at
+	ReleasedAt *time.Time `json:"released_at,omitempty"`
+}
+
+// Service is the interface that provides hold methods
+type Service interface {
+	// Load returns a read model of a hold
+	Load(ctx context.Context, id string) (Hold, error)
+
+	// Place holds an amount on an account, within its available balance
+	Place(ctx context.Context, hold Hold) (Hold, error)
+
+	// Capture turns an active hold into a transfer to the target account of
+	// the given amount, or of the whole hold without an amount, and releases
+	// the rest of the hold. Above the approval threshold the transfer keeps
+	// holding the captured amount until it is approved.
+	Capture(ctx context.Context, id, targetAccountID, amount string) (Hold, error)
+
+	// Void releases an active hold without moving any balance
+	Void(ctx context.Context, id string) (Hold, error)
+
+	// Expire expires the active holds past their expiry as of now and
+	// returns how many expired
+	Expire(ctx context.Context, now time.Time) (int64, error)
+}
+
+func (s *service) Load(
+	ctx context.Context, id string,
+) (Hold, error) {
+	hold, err := s.find(ctx, id)
+	if err != nil {
+		return Hold{}, err
+	}
+	acct, err := s.accounts.Find(ctx, hold.AccountID)
+	if err != nil {
+		return Hold{}, err
+	}
+	if err := accounts.CheckRole(ctx, s.roles, acct, accounts.RoleView); err != nil {
+		return Hold{}, err
+	}
+	return *hold, nil
+}
+
+func (s *service) Place(
+	ctx context.Context, hold Hold,
+) (Hold, error) {
+	acct, err := s.accounts.Find(ctx, hold.AccountID)
+	if err != nil {
+		return Hold{}, err
+	}
+
+	// Holding money takes the same rights as sending it
+	if err := accounts.CheckRole(ctx, s.roles, acct, accounts.RoleInitiate); err != nil {
+		return Hold{}, err
+	}
+	if err := accounts.CheckActive(acct); err != nil {
+		return Hold{}, err
+	}
+	if acct.Currency != hold.Currency {
+		return Hold{}, &transactions.CurrencyMismatchError{
+			AccountID:       acct.ID,
+			AccountCurrency: acct.Currency,
+			Currency:        hold.Currency,
+		}
+	}
+	if !hold.Amount.IsPositive() {
+		return Hold{}, transactions.ErrNonPositiveAmount
+	}
+
+	// Holds expire after the default time to live unless told otherwise
+	hold.CreatedAt = time.Now().UTC()
+	if hold.ExpiresAt.IsZero() {
+		hold.ExpiresAt = hold.CreatedAt.Add(s.ttl)
+	}
+	if !hold.ExpiresAt.After(hold.CreatedAt) {
+		return Hold{}, ErrInvalidExpiry
+	}
+	hold.Status = StatusActive
+	if key, ok := auth.FromContext(ctx); ok {
+		hold.PlacedBy = key.ID
+	}
+
+	// Reject early if the available balance is insufficient. The repository
+	// checks it again under lock when placing the hold.
+	remaining, err := acct.AvailableBalance.Sub(hold.Amount)
+	if err != nil {
+		return Hold{}, err
+	}
+	if remaining.IsNegative() {
+		return Hold{}, &transactions.InsufficientFundsError{
+			AccountID: acct.ID,
+			Balance:   acct.AvailableBalance,
+			Amount:    hold.Amount,
+		}
+	}
+
+	placed, err := s.holds.Place(ctx, &hold)
+	if err != nil {
+		return Hold{}, err
+	}
+	return *placed, nil
+}
+
+func (s *service) Capture(
+	ctx context.Context, id, targetAccountID, amount string,
+) (Hold, error) {
+	hold, err := s.find(ctx, id)
+	if err != nil {
+		return Hold{}, err
+	}
+	if hold.AccountID == targetAccountID {
+		return Hold{}, transactions.ErrSameAccount
+	}
+
+	accts, err := s.accounts.FindByIDs(ctx, []string{hold.AccountID, targetAccountID})
+	if err != nil {
+		return Hold{}, err
+	}
+	sourceAccount, targetAccount := accts[hold.AccountID], accts[targetAccountID]
+	if sourceAccount == nil {
+		return Hold{}, accounts.ErrFetchingAccount(hold.AccountID)
+	}
+	if targetAccount == nil {
+		return Hold{}, accounts.ErrFetchingAccount(targetAccountID)
+	}
+
+	if err := accounts.CheckRole(ctx, s.roles, sourceAccount, accounts.RoleInitiate); err != nil {
+		return Hold{}, err
+	}
+	now := time.Now().UTC()
+	if err := checkActive(hold, now); err != nil {
+		return Hold{}, err
+	}
+
+	// Without an amount the whole hold is captured
+	captured := hold.Amount
+	if amount != "" {
+		if captured, err = money.Parse(amount, hold.Currency); err != nil ||
+			!captured.IsPositive() {
+			return Hold{}, ErrInvalidCaptureAmount
+		}
+	}
+
+	// From now on a refused capture is kept as a failed transaction
+	txn := transactions.Transaction{
+		ID:              nextTransactionID(),
+		SourceAccountID: hold.AccountID,
+		TargetAccountID: targetAccountID,
+		Amount:          captured,
+		Currency:        hold.Currency,
+		SourceCurrency:  hold.Currency,
+		TargetCurrency:  hold.Currency,
+		TargetAmount:    captured,
+		Status:          transactions.StatusPending,
+		CreatedAt:       now,
+	}
+	if key, ok := auth.FromContext(ctx); ok {
+		txn.InitiatedBy, txn.InitiatorCustomerID = key.ID, key.CustomerID
+	}
+	hold, err = s.capture(ctx, hold, txn, sourceAccount, targetAccount)
+	if err != nil {
+		transactions.Fail(ctx, s.failures, txn, err)
+		return Hold{}, err
+	}
+	return *hold, nil
+}
+
+// capture checks a pending transfer capturing a hold and hands it over to
+// the repository to be booked
+func (s *service) capture(
+	ctx context.Context, hold *Hold, txn transactions.Transaction,
+	sourceAccount, targetAccount *accounts.Account,
+) (*Hold, error) {
+	cmp, err := txn.Amount.Cmp(hold.Amount)
+	if err != nil {
+		return nil, err
+	}
+	if cmp > 0 {
+		return nil, ErrCaptureExceedsHold
+	}
+
+	// Frozen and closed accounts neither send nor receive transfers, and a
+	// capture is booked in the currency of the hold on both legs
+	if err := accounts.CheckActive(sourceAccount); err != nil {
+		return nil, err
+	}
+	if err := accounts.CheckActive(targetAccount); err != nil {
+		return nil, err
+	}
+	if targetAccount.Currency != hold.Currency {
+		return nil, &transactions.CurrencyMismatchError{
+			AccountID:       targetAccount.ID,
+			AccountCurrency: targetAccount.Currency,
+			Currency:        hold.Currency,
+		}
+	}
+
+	// Captures above the approval threshold wait for an approval like any
+	// transfer
+	s.approvals.Book(&txn)
+	return s.holds.Capture(ctx, hold.ID, &txn)
+}
+
+func (s *service) Void(ctx context.Context, id string) (Hold, error) {
+	hold, err := s.find(ctx, id)
+	if err != nil {
+		return Hold{}, err
+	}
+	acct, err := s.accounts.Find(ctx, hold.AccountID)
+	if err != nil {
+		return Hold{}, err
+	}
+	if err := accounts.CheckRole(ctx, s.roles, acct, accounts.RoleInitiate); err != nil {
+		return Hold{}, err
+	}
+	now := time.Now().UTC()
+	if err := checkActive(hold, now); err != nil {
+		return Hold{}, err
+	}
+
+	hold, err = s.holds.Void(ctx, id, now)
+	if err != nil {
+		return Hold{}, err
+	}
+	return *hold, nil
+}
+
+func (s *service) Expire(ctx context.Context, now time.Time) (int64, error) {
+	return s.holds.Expire(ctx, now)
+}
+
+// find returns a hold by ID. IDs that are not UUIDs cannot exist.
+func (s *service) find(ctx context.Context, id string) (*Hold, error) {
+	if _, err := uuid.FromString(id); err != nil {
+		return nil, ErrFetchingHold(id)
+	}
+	return s.holds.Find(ctx, id)
+}
+
+// checkActive returns an error unless the hold is still active at the given
+// time
+func checkActive(hold *Hold, now time.Time) error {
+	if hold.Status != StatusActive {
+		return ErrHoldNotActive
+	}
+	if !hold.ExpiresAt.After(now) {
+		return ErrHoldExpired
+	}
+	return nil
+}
+
+type service struct {
+	accounts  accounts.AccountRepository
+	roles     accounts.RoleRepository
+	holds     Repository
+	failures  transactions.Failer
+	approvals transactions.ApprovalPolicy
+	ttl       time.Duration
+}
+
+// NewService creates a hold service with necessary dependencies. The refused
+// captures are stored as failed transactions, the captures above the approval
+// thresholds wait for an approval, and the holds placed without an expiry
+// expire after the given time to live.
+func NewService(
+	accounts accounts.AccountRepository,
+	roles accounts.RoleRepository,
+	holds Repository,
+	failures transactions.Failer,
+	approvals transactions.ApprovalPolicy,
+	ttl time.Duration,
+) Service {
+	return &service{
+		accounts:  accounts,
+		roles:     roles,
+		holds:     holds,
+		failures:  failures,
+		approvals: approvals,
+		ttl:       ttl,
+	}
+}
+
+// nextHoldID generates a new hold ID.
+func nextHoldID() string {
+	return uuid.NewV4().String()
+}
+
+// nextTransactionID generates the ID of the transaction a hold is captured by.
+func nextTransactionID() string {
+	return uuid.NewV4().String()
+}
@@ -0,0 +1,474 @@
+package holds
+
+import (
+	"context"
+	"errors"
+	"financial-app/pkg/accounts"
+	"financial-app/pkg/auth"
+	"financial-app/pkg/money"
+	"financial-app/pkg/transactions"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	holdID          = "7a1d3c5e-8f42-4b6a-9c0d-2e4f6a8b0c1d"
+	sourceAccountID = "2222"
+	targetAccountID = "3333"
+)
+
+// mockAccountRepository finds the accounts whose available balance is the
+// balance less the amounts held by the mock hold repository
+type mockAccountRepository struct {
+	accounts.AccountRepository
+	Accounts map[string]*accounts.Account
+	Holds    *mockRepository
+}
+
+func (m *mockAccountRepository) Find(
+	ctx context.Context, id string,
+) (*accounts.Account, error) {
+	acct, ok := m.Accounts[id]
+	if !ok {
+		return nil, accounts.ErrFetchingAccount(id)
+	}
+	found := *acct
+	found.AvailableBalance, _ = acct.Balance.Sub(m.Holds.held(id, acct.Currency))
+	return &found, nil
+}
+
+func (m *mockAccountRepository) FindByIDs(
+	ctx context.Context, ids []string,
+) (map[string]*accounts.Account, error) {
+	accts := make(map[string]*accounts.Account)
+	for _, id := range ids {
+		if acct, err := m.Find(ctx, id); err == nil {
+			accts[id] = acct
+		}
+	}
+	return accts, nil
+}
+
+// mockRoleRepository grants the roles by account and customer
+type mockRoleRepository struct {
+	accounts.RoleRepository
+	Roles map[string]accounts.Role
+}
+
+func (m *mockRoleRepository) Find(
+	ctx context.Context, accountID, customerID string,
+) (*accounts.Grant, error) {
+	role, ok := m.Roles[accountID+"/"+customerID]
+	if !ok {
+		return nil, accounts.ErrRoleNotFound
+	}
+	return &accounts.Grant{AccountID: accountID, CustomerID: customerID, Role: role}, nil
+}
+
+type mockRepository struct {
+	Holds    map[string]*Hold
+	Accounts map[string]*accounts.Account
+	// Captured are the transactions the holds were captured by
+	Captured map[string]*transactions.Transaction
+	// Failed are the refused captures stored as failed transactions
+	Failed []*transactions.Transaction
+}
+
+// held returns the amount of the active holds of an account
+func (m *mockRepository) held(accountID, currency string) money.Money {
+	held := money.New(0, currency)
+	for _, hold := range m.Holds {
+		if hold.AccountID == accountID && hold.Status == StatusActive {
+			held, _ = held.Add(hold.Amount)
+		}
+	}
+	return held
+}
+
+func (m *mockRepository) Place(ctx context.Context, hold *Hold) (*Hold, error) {
+	for _, h := range m.Holds {
+		if h.AccountID == hold.AccountID && h.Reference == hold.Reference {
+			return nil, ErrDuplicateReference
+		}
+	}
+	stored := *hold
+	m.Holds[hold.ID] = &stored
+	return hold, nil
+}
+
+func (m *mockRepository) Find(ctx context.Context, id string) (*Hold, error) {
+	hold, ok := m.Holds[id]
+	if !ok {
+		return nil, ErrFetchingHold(id)
+	}
+	found := *hold
+	return &found, nil
+}
+
+func (m *mockRepository) Capture(
+	ctx context.Context, id string, txn *transactions.Transaction,
+) (*Hold, error) {
+	hold := m.Holds[id]
+	if txn.Status != transactions.StatusPendingApproval {
+		source, target := m.Accounts[txn.SourceAccountID], m.Accounts[txn.TargetAccountID]
+		source.Balance, _ = source.Balance.Sub(txn.Amount)
+		target.Balance, _ = target.Balance.Add(txn.TargetAmount)
+	}
+
+	now := time.Now().UTC()
+	captured := txn.Amount
+	hold.Status = StatusCaptured
+	hold.CapturedAmount = &captured
+	hold.TransactionID = txn.ID
+	hold.ReleasedAt = &now
+	if m.Captured == nil {
+		m.Captured = make(map[string]*transactions.Transaction)
+	}
+	m.Captured[id] = txn
+	return m.Find(ctx, id)
+}
+
+func (m *mockRepository) Fail(
+	ctx context.Context, txn *transactions.Transaction,
+) (*transactions.Transaction, error) {
+	m.Failed = append(m.Failed, txn)
+	return txn, nil
+}
+
+func (m *mockRepository) Void(ctx context.Context, id string, now time.Time) (*Hold, error) {
+	hold := m.Holds[id]
+	hold.Status = StatusVoided
+	hold.ReleasedAt = &now
+	return m.Find(ctx, id)
+}
+
+func (m *mockRepository) Expire(ctx context.Context, now time.Time) (int64, error) {
+	var expired int64
+	for _, hold := range m.Holds {
+		if hold.Status == StatusActive && !hold.ExpiresAt.After(now) {
+			hold.Status = StatusExpired
+			hold.ReleasedAt = &now
+			expired++
+		}
+	}
+	return expired, nil
+}
+
+// setupService returns a hold service over a source account of 100 USD with
+// an active hold of 40 USD, and an empty target account
+func setupService() (Service, *mockRepository) {
+	return setupServiceWithApprovals(transactions.ApprovalPolicy{})
+}
+
+// setupServiceWithApprovals returns the hold service of setupService with
+// the given approval policy
+func setupServiceWithApprovals(
+	approvals transactions.ApprovalPolicy,
+) (Service, *mockRepository) {
+	accts := map[string]*accounts.Account{
+		sourceAccountID: {
+			ID:         sourceAccountID,
+			CustomerID: "owner",
+			Balance:    money.New(10000, "USD"),
+			Currency:   "USD",
+			Status:     accounts.StatusActive,
+		},
+		targetAccountID: {
+			ID:       targetAccountID,
+			Balance:  money.New(0, "USD"),
+			Currency: "USD",
+			Status:   accounts.StatusActive,
+		},
+	}
+	holds := &mockRepository{
+		Holds: map[string]*Hold{
+			holdID: {
+				ID:        holdID,
+				AccountID: sourceAccountID,
+				Amount:    money.New(4000, "USD"),
+				Currency:  "USD",
+				Reference: "auth-1",
+				Status:    StatusActive,
+				ExpiresAt: time.Now().UTC().Add(time.Hour),
+			},
+		},
+		Accounts: accts,
+	}
+	roles := &mockRoleRepository{Roles: map[string]accounts.Role{
+		sourceAccountID + "/viewer": accounts.RoleView,
+	}}
+	svc := NewService(
+		&mockAccountRepository{Accounts: accts, Holds: holds}, roles, holds, holds, approvals,
+		time.Hour,
+	)
+	return svc, holds
+}
+
+func TestService_Place(t *testing.T) {
+	testCases := []struct {
+		Name          string
+		Hold          Hold
+		Key           *auth.Key
+		ExpectedError error
+	}{
+		{
+			Name: "Placed",
+			Hold: Hold{Amount: money.New(6000, "USD"), Currency: "USD", Reference: "auth-2"},
+			Key:  &auth.Key{ID: "key", CustomerID: "owner"},
+		},
+		{
+			Name: "Insufficient Available Balance",
+			Hold: Hold{Amount: money.New(6001, "USD"), Currency: "USD", Reference: "auth-2"},
+			ExpectedError: &transactions.InsufficientFundsError{
+				AccountID: sourceAccountID,
+				Balance:   money.New(6000, "USD"),
+				Amount:    money.New(6001, "USD"),
+			},
+		},
+		{
+			Name:          "Currency Mismatch",
+			Hold:          Hold{Amount: money.New(100, "EUR"), Currency: "EUR", Reference: "auth-2"},
+			ExpectedError: transactions.ErrCurrencyMismatch,
+		},
+		{
+			Name:          "Non Positive Amount",
+			Hold:          Hold{Amount: money.New(0, "USD"), Currency: "USD", Reference: "auth-2"},
+			ExpectedError: transactions.ErrNonPositiveAmount,
+		},
+		{
+			Name: "Expiry In The Past",
+			Hold: Hold{
+				Amount:    money.New(100, "USD"),
+				Currency:  "USD",
+				Reference: "auth-2",
+				ExpiresAt: time.Now().UTC().Add(-time.Minute),
+			},
+			ExpectedError: ErrInvalidExpiry,
+		},
+		{
+			Name:          "Duplicate Reference",
+			Hold:          Hold{Amount: money.New(100, "USD"), Currency: "USD", Reference: "auth-1"},
+			ExpectedError: ErrDuplicateReference,
+		},
+		{
+			Name:          "Viewer",
+			Hold:          Hold{Amount: money.New(100, "USD"), Currency: "USD", Reference: "auth-2"},
+			Key:           &auth.Key{ID: "key", CustomerID: "viewer"},
+			ExpectedError: accounts.ErrInsufficientRole,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			svc, repo := setupService()
+			ctx := context.Background()
+			if tc.Key != nil {
+				ctx = auth.NewContext(ctx, *tc.Key)
+			}
+			tc.Hold.ID = nextHoldID()
+			tc.Hold.AccountID = sourceAccountID
+
+			hold, err := svc.Place(ctx, tc.Hold)
+
+			if tc.ExpectedError != nil {
+				if _, ok := tc.ExpectedError.(*transactions.InsufficientFundsError); ok {
+					assert.Equal(t, tc.ExpectedError, err)
+				} else {
+					assert.ErrorIs(t, err, tc.ExpectedError)
+				}
+				assert.NotContains(t, repo.Holds, tc.Hold.ID)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, StatusActive, hold.Status)
+			assert.Equal(t, "key", hold.PlacedBy)
+			assert.Equal(t, hold.CreatedAt.Add(time.Hour), hold.ExpiresAt,
+				"The hold should expire after the default time to live")
+			assert.Contains(t, repo.Holds, tc.Hold.ID)
+		})
+	}
+}
+
+func TestService_Capture(t *testing.T) {
+	testCases := []struct {
+		Name            string
+		TargetAccountID string
+		Amount          string
+		Status          string
+		ExpiresAt       time.Time
+		TargetStatus    string
+		ExpectedError   error
+		ExpectedFailure string
+		ExpectedAmount  money.Money
+	}{
+		{
+			Name:            "Full Capture",
+			TargetAccountID: targetAccountID,
+			ExpectedAmount:  money.New(4000, "USD"),
+		},
+		{
+			Name:            "Partial Capture",
+			TargetAccountID: targetAccountID,
+			Amount:          "25.50",
+			ExpectedAmount:  money.New(2550, "USD"),
+		},
+		{
+			Name:            "Exceeds Hold",
+			TargetAccountID: targetAccountID,
+			Amount:          "40.01",
+			ExpectedError:   ErrCaptureExceedsHold,
+			ExpectedFailure: "capture_exceeds_hold",
+		},
+		{
+			Name:            "Frozen Target",
+			TargetAccountID: targetAccountID,
+			TargetStatus:    accounts.StatusFrozen,
+			ExpectedError:   accounts.ErrAccountInactive,
+			ExpectedFailure: "account_inactive",
+		},
+		{
+			Name:            "Invalid Amount",
+			TargetAccountID: targetAccountID,
+			Amount:          "-1",
+			ExpectedError:   ErrInvalidCaptureAmount,
+		},
+		{
+			Name:            "Same Account",
+			TargetAccountID: sourceAccountID,
+			ExpectedError:   transactions.ErrSameAccount,
+		},
+		{
+			Name:            "Unknown Target",
+			TargetAccountID: "4444",
+			ExpectedError:   accounts.ErrAccountNotFound,
+		},
+		{
+			Name:            "Voided",
+			TargetAccountID: targetAccountID,
+			Status:          StatusVoided,
+			ExpectedError:   ErrHoldNotActive,
+		},
+		{
+			Name:            "Expired",
+			TargetAccountID: targetAccountID,
+			ExpiresAt:       time.Now().UTC().Add(-time.Second),
+			ExpectedError:   ErrHoldExpired,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			svc, repo := setupService()
+			if tc.Status != "" {
+				repo.Holds[holdID].Status = tc.Status
+			}
+			if !tc.ExpiresAt.IsZero() {
+				repo.Holds[holdID].ExpiresAt = tc.ExpiresAt
+			}
+			if tc.TargetStatus != "" {
+				repo.Accounts[targetAccountID].Status = tc.TargetStatus
+			}
+
+			hold, err := svc.Capture(context.Background(), holdID, tc.TargetAccountID, tc.Amount)
+
+			if tc.ExpectedError != nil {
+				assert.ErrorIs(t, err, tc.ExpectedError)
+				assert.Empty(t, repo.Captured)
+				assert.Equal(t, money.New(10000, "USD"), repo.Accounts[sourceAccountID].Balance)
+
+				// Only the captures refused for their amount or accounts are
+				// kept as failed transactions
+				if tc.ExpectedFailure == "" {
+					assert.Empty(t, repo.Failed)
+					return
+				}
+				if assert.Len(t, repo.Failed, 1) {
+					assert.Equal(t, transactions.StatusFailed, repo.Failed[0].Status)
+					assert.Equal(t, tc.ExpectedFailure, repo.Failed[0].FailureReason)
+					assert.Equal(t, sourceAccountID, repo.Failed[0].SourceAccountID)
+				}
+				return
+			}
+			assert.Empty(t, repo.Failed)
+			assert.NoError(t, err)
+			assert.Equal(t, StatusCaptured, hold.Status)
+			assert.Equal(t, &tc.ExpectedAmount, hold.CapturedAmount)
+
+			txn := repo.Captured[holdID]
+			assert.Equal(t, hold.TransactionID, txn.ID)
+			assert.Equal(t, tc.ExpectedAmount, txn.Amount)
+			assert.Equal(t, tc.ExpectedAmount, txn.TargetAmount)
+			assert.Equal(t, transactions.StatusCompleted, txn.Status)
+			remaining, _ := money.New(10000, "USD").Sub(tc.ExpectedAmount)
+			assert.Equal(t, remaining, repo.Accounts[sourceAccountID].Balance)
+			assert.Equal(t, tc.ExpectedAmount, repo.Accounts[targetAccountID].Balance)
+		})
+	}
+}
+
+func TestService_CaptureApproval(t *testing.T) {
+	svc, repo := setupServiceWithApprovals(transactions.ApprovalPolicy{
+		Thresholds: map[string]money.Money{"USD": money.New(3000, "USD")},
+		TTL:        time.Hour,
+	})
+
+	// Above the threshold the captured amount is only held by the transfer
+	hold, err := svc.Capture(context.Background(), holdID, targetAccountID, "")
+	assert.NoError(t, err)
+	assert.Equal(t, StatusCaptured, hold.Status)
+
+	txn := repo.Captured[holdID]
+	assert.Equal(t, transactions.StatusPendingApproval, txn.Status)
+	assert.Equal(t, txn.CreatedAt.Add(time.Hour), *txn.ExpiresAt)
+	assert.Equal(t, money.New(10000, "USD"), repo.Accounts[sourceAccountID].Balance)
+	assert.True(t, repo.Accounts[targetAccountID].Balance.IsZero())
+}
+
+func TestService_Void(t *testing.T) {
+	svc, repo := setupService()
+
+	hold, err := svc.Void(context.Background(), holdID)
+	assert.NoError(t, err)
+	assert.Equal(t, StatusVoided, hold.Status)
+	assert.NotNil(t, hold.ReleasedAt)
+	assert.Equal(t, money.New(10000, "USD"), repo.Accounts[sourceAccountID].Balance,
+		"Voiding a hold should not move any balance")
+
+	_, err = svc.Void(context.Background(), holdID)
+	assert.Equal(t, ErrHoldNotActive, err)
+
+	_, err = svc.Void(context.Background(), "1111")
+	assert.Equal(t, ErrFetchingHold("1111"), err)
+}
+
+func TestService_Load(t *testing.T) {
+	svc, _ := setupService()
+
+	hold, err := svc.Load(context.Background(), holdID)
+	assert.NoError(t, err)
+	assert.Equal(t, "auth-1", hold.Reference)
+
+	// A viewer of the account reads its holds but a stranger does not
+	ctx := auth.NewContext(context.Background(), auth.Key{ID: "key", CustomerID: "viewer"})
+	_, err = svc.Load(ctx, holdID)
+	assert.NoError(t, err)
+
+	ctx = auth.NewContext(context.Background(), auth.Key{ID: "key", CustomerID: "stranger"})
+	_, err = svc.Load(ctx, holdID)
+	assert.True(t, errors.Is(err, accounts.ErrNotAccountOwner))
+}
+
+func TestService_Expire(t *testing.T) {
+	svc, repo := setupService()
+
+	expired, err := svc.Expire(context.Background(), time.Now().UTC())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), expired)
+
+	expired, err = svc.Expire(context.Background(), time.Now().UTC().Add(2*time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), expired)
+	assert.Equal(t, StatusExpired, repo.Holds[holdID].Status)
+}
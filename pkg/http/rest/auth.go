@@ -0,0 +1,135 @@
+package rest
+
+import (
+	"errors"
+	"financial-app/pkg/auth"
+	"financial-app/pkg/errs"
+	"financial-app/pkg/http/rest/httperr"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-kit/kit/metrics"
+	"go.uber.org/zap"
+)
+
+// bearerScheme is the authorization scheme carrying the API keys
+const bearerScheme = "Bearer"
+
+// Reasons of the refused requests, as counted in the metrics
+const (
+	reasonUnauthorized = "unauthorized"
+	reasonForbidden    = "forbidden"
+)
+
+// routeScopes maps the routes of the API to the scope they require. A route
+// missing from it is refused to every key.
+var routeScopes = map[string]auth.Scope{
+	"GET /api/v1/accounts":                           auth.ScopeAccountsRead,
+	"GET /api/v1/accounts/:id":                       auth.ScopeAccountsRead,
+	"GET /api/v1/accounts/:id/balance":               auth.ScopeAccountsRead,
+	"GET /api/v1/accounts/:id/statement":             auth.ScopeAccountsRead,
+	"POST /api/v1/accounts":                          auth.ScopeAccountsWrite,
+	"POST /api/v1/accounts/:id/freeze":               auth.ScopeAccountsWrite,
+	"POST /api/v1/accounts/:id/unfreeze":             auth.ScopeAccountsWrite,
+	"POST /api/v1/accounts/:id/close":                auth.ScopeAccountsWrite,
+	"DELETE /api/v1/accounts/:id":                    auth.ScopeAccountsWrite,
+	"GET /api/v1/accounts/:id/roles":                 auth.ScopeAccountsRead,
+	"GET /api/v1/accounts/:id/roles/changes":         auth.ScopeAccountsRead,
+	"PUT /api/v1/accounts/:id/roles/:customer_id":    auth.ScopeAccountsWrite,
+	"DELETE /api/v1/accounts/:id/roles/:customer_id": auth.ScopeAccountsWrite,
+	"GET /api/v1/accounts/:id/transactions":          auth.ScopeTransactionsRead,
+	"GET /api/v1/transactions":                       auth.ScopeTransactionsRead,
+	"GET /api/v1/transactions/:id":                   auth.ScopeTransactionsRead,
+	"POST /api/v1/transactions":                      auth.ScopeTransactionsWrite,
+	"POST /api/v1/transactions/:id/reverse":          auth.ScopeTransactionsWrite,
+	"POST /api/v1/transactions/:id/approve":          auth.ScopeTransactionsWrite,
+	"POST /api/v1/transactions/:id/reject":           auth.ScopeTransactionsWrite,
+	"DELETE /api/v1/transactions/:id":                auth.ScopeTransactionsWrite,
+	"POST /api/v1/fx/quotes":                         auth.ScopeTransactionsWrite,
+	"GET /api/v1/ledger/transactions/:id":            auth.ScopeLedgerRead,
+	"GET /api/v1/ledger/accounts/:id/verification":   auth.ScopeLedgerRead,
+	"GET /api/v1/customers/:id":                      auth.ScopeCustomersRead,
+	"GET /api/v1/customers/:id/accounts":             auth.ScopeAccountsRead,
+	"POST /api/v1/customers":                         auth.ScopeCustomersWrite,
+	"PUT /api/v1/customers/:id":                      auth.ScopeCustomersWrite,
+	"DELETE /api/v1/customers/:id":                   auth.ScopeCustomersWrite,
+	"GET /api/v1/holds/:id":                          auth.ScopeTransactionsRead,
+	"POST /api/v1/holds":                             auth.ScopeTransactionsWrite,
+	"POST /api/v1/holds/:id/capture":                 auth.ScopeTransactionsWrite,
+	"POST /api/v1/holds/:id/void":                    auth.ScopeTransactionsWrite,
+}
+
+// authMiddleware authenticates the requests with the API key of their
+// "Authorization: Bearer" header and checks that the key has the scope of the
+// route. Refused requests are logged and counted by reason.
+func authMiddleware(
+	svc auth.Service, logger *zap.SugaredLogger, rejected metrics.Counter,
+) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.Request.Method + " " + c.FullPath()
+
+		key, err := svc.Authenticate(c, bearerToken(c.GetHeader("Authorization")))
+		if err == nil {
+			err = authorize(key, route)
+		}
+		if err != nil {
+			reason := rejectionReason(err)
+			if reason == "" {
+				logger.Error(err)
+
+				httperr.Respond(c, err)
+				return
+			}
+			if reason == reasonUnauthorized {
+				c.Header("WWW-Authenticate", bearerScheme)
+			}
+
+			rejected.With("reason", reason).Add(1)
+			logger.Warnw(
+				"request refused",
+				zap.String("reason", reason),
+				zap.String("route", route),
+				zap.String("client_ip", c.ClientIP()),
+				zap.String("key_id", key.ID),
+				zap.Error(err),
+			)
+
+			httperr.Respond(c, err)
+			return
+		}
+
+		c.Request = c.Request.WithContext(auth.NewContext(c.Request.Context(), key))
+		c.Next()
+	}
+}
+
+// rejectionReason returns the reason a request is refused for, or an empty
+// string if the error is not an authentication or authorization failure
+func rejectionReason(err error) string {
+	switch {
+	case errors.Is(err, errs.ErrUnauthenticated):
+		return reasonUnauthorized
+	case errors.Is(err, errs.ErrForbidden):
+		return reasonForbidden
+	}
+	return ""
+}
+
+// authorize returns an error unless the key has the scope of the route
+func authorize(key auth.Key, route string) error {
+	scope, ok := routeScopes[route]
+	if !ok {
+		return auth.ErrInsufficientScope
+	}
+	return key.Authorize(scope)
+}
+
+// bearerToken returns the token of a bearer authorization header, or an empty
+// string if the header does not use the bearer scheme
+func bearerToken(header string) string {
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, bearerScheme) {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
@@ -0,0 +1,201 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"financial-app/pkg/auth"
+	"financial-app/pkg/http/rest/httperr"
+	"financial-app/pkg/transactions"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-kit/kit/metrics"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// mockAuthService authenticates the tokens of its keys
+type mockAuthService struct {
+	auth.Service
+	Keys  map[string]auth.Key
+	Error error
+}
+
+func (m *mockAuthService) Authenticate(ctx context.Context, token string) (auth.Key, error) {
+	if m.Error != nil {
+		return auth.Key{}, m.Error
+	}
+	if token == "" {
+		return auth.Key{}, auth.ErrMissingKey
+	}
+	key, ok := m.Keys[token]
+	if !ok {
+		return auth.Key{}, auth.ErrInvalidKey
+	}
+	return key, nil
+}
+
+// mockCounter counts the additions by label values
+type mockCounter struct {
+	counts map[string]float64
+	labels []string
+}
+
+func (m *mockCounter) With(labelValues ...string) metrics.Counter {
+	return &mockCounter{counts: m.counts, labels: labelValues}
+}
+
+func (m *mockCounter) Add(delta float64) {
+	m.counts[strings.Join(m.labels, ",")] += delta
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	svc := &mockAuthService{Keys: map[string]auth.Key{
+		"fa_reader": {ID: "1111", Scopes: []auth.Scope{auth.ScopeAccountsRead}},
+	}}
+
+	testCases := []struct {
+		Name              string
+		Method            string
+		Path              string
+		Authorization     string
+		ServiceError      error
+		ExpectedCode      int
+		ExpectedErrorCode string
+		ExpectedReason    string
+	}{
+		{
+			Name:          "Authorized",
+			Method:        "GET",
+			Path:          "/api/v1/accounts/1111",
+			Authorization: "Bearer fa_reader",
+			ExpectedCode:  http.StatusOK,
+		},
+		{
+			Name:              "Missing Key",
+			Method:            "GET",
+			Path:              "/api/v1/accounts/1111",
+			ExpectedCode:      http.StatusUnauthorized,
+			ExpectedErrorCode: "missing_api_key",
+			ExpectedReason:    "reason,unauthorized",
+		},
+		{
+			Name:              "Other Scheme",
+			Method:            "GET",
+			Path:              "/api/v1/accounts/1111",
+			Authorization:     "Basic fa_reader",
+			ExpectedCode:      http.StatusUnauthorized,
+			ExpectedErrorCode: "missing_api_key",
+			ExpectedReason:    "reason,unauthorized",
+		},
+		{
+			Name:              "Invalid Key",
+			Method:            "GET",
+			Path:              "/api/v1/accounts/1111",
+			Authorization:     "Bearer fa_unknown",
+			ExpectedCode:      http.StatusUnauthorized,
+			ExpectedErrorCode: "invalid_api_key",
+			ExpectedReason:    "reason,unauthorized",
+		},
+		{
+			Name:              "Insufficient Scope",
+			Method:            "POST",
+			Path:              "/api/v1/transactions",
+			Authorization:     "Bearer fa_reader",
+			ExpectedCode:      http.StatusForbidden,
+			ExpectedErrorCode: "insufficient_scope",
+			ExpectedReason:    "reason,forbidden",
+		},
+		{
+			Name:              "Route Without Scope",
+			Method:            "GET",
+			Path:              "/api/v1/unscoped",
+			Authorization:     "Bearer fa_reader",
+			ExpectedCode:      http.StatusForbidden,
+			ExpectedErrorCode: "insufficient_scope",
+			ExpectedReason:    "reason,forbidden",
+		},
+		{
+			Name:              "Storage Error",
+			Method:            "GET",
+			Path:              "/api/v1/accounts/1111",
+			Authorization:     "Bearer fa_reader",
+			ServiceError:      auth.ErrQueryingKeys.Wrap(errors.New("pq: connection refused")),
+			ExpectedCode:      http.StatusInternalServerError,
+			ExpectedErrorCode: "querying_api_keys_failed",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			svc.Error = tc.ServiceError
+			counter := &mockCounter{counts: make(map[string]float64)}
+			logger, _ := zap.NewDevelopment()
+
+			r := gin.New()
+			r.ContextWithFallback = true
+			g := r.Group("/api/v1/")
+			g.Use(authMiddleware(svc, logger.Sugar(), counter))
+			ok := func(c *gin.Context) {
+				key, _ := auth.FromContext(c)
+				c.JSON(http.StatusOK, gin.H{"key_id": key.ID})
+			}
+			g.GET("accounts/:id", ok)
+			g.POST("transactions", ok)
+			g.GET("unscoped", ok)
+
+			req, _ := http.NewRequest(tc.Method, tc.Path, nil)
+			if tc.Authorization != "" {
+				req.Header.Set("Authorization", tc.Authorization)
+			}
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.ExpectedCode, rr.Code)
+			if tc.ExpectedCode == http.StatusOK {
+				assert.JSONEq(t, `{"key_id":"1111"}`, rr.Body.String(),
+					"The key should be in the request context")
+				assert.Empty(t, counter.counts)
+				return
+			}
+
+			var response httperr.Problem
+			assert.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+			assert.Equal(t, tc.ExpectedErrorCode, response.Code)
+			if tc.ExpectedCode == http.StatusUnauthorized {
+				assert.Equal(t, bearerScheme, rr.Header().Get("WWW-Authenticate"))
+			}
+			if tc.ExpectedReason == "" {
+				assert.Empty(t, counter.counts)
+			} else {
+				assert.Equal(t, map[string]float64{tc.ExpectedReason: 1}, counter.counts)
+			}
+		})
+	}
+}
+
+func TestRouteScopes(t *testing.T) {
+	s := NewServer(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+		time.Minute, time.Hour, transactions.ApprovalPolicy{}, time.Hour, zap.NewNop().Sugar())
+
+	for _, route := range s.router.Routes() {
+		if !strings.HasPrefix(route.Path, "/api/v1/") {
+			continue
+		}
+		_, ok := routeScopes[route.Method+" "+route.Path]
+		assert.True(t, ok, "%s %s should require a scope", route.Method, route.Path)
+	}
+}
+
+func TestBearerToken(t *testing.T) {
+	assert.Equal(t, "fa_key", bearerToken("Bearer fa_key"))
+	assert.Equal(t, "fa_key", bearerToken("bearer  fa_key"))
+	assert.Equal(t, "", bearerToken("Basic dXNlcjpwYXNz"))
+	assert.Equal(t, "", bearerToken("fa_key"))
+	assert.Equal(t, "", bearerToken(""))
+}
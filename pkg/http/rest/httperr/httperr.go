@@ -0,0 +1,76 @@
+package httperr
+
+import (
+	"errors"
+	"financial-app/pkg/errs"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CodeInternal is the code of the errors that are not domain errors
+const CodeInternal = "internal_error"
+
+// serverErrorDetail is the detail of every server error, whose actual cause
+// is only logged
+const serverErrorDetail = "the server could not complete the request"
+
+// statuses maps the kinds of domain errors to HTTP status codes
+var statuses = map[error]int{
+	errs.ErrInvalid:         http.StatusBadRequest,
+	errs.ErrUnauthenticated: http.StatusUnauthorized,
+	errs.ErrForbidden:       http.StatusForbidden,
+	errs.ErrNotFound:        http.StatusNotFound,
+	errs.ErrConflict:        http.StatusConflict,
+	errs.ErrGone:            http.StatusGone,
+	errs.ErrUnprocessable:   http.StatusUnprocessableEntity,
+	errs.ErrNotAllowed:      http.StatusMethodNotAllowed,
+	errs.ErrTooLarge:        http.StatusRequestEntityTooLarge,
+	errs.ErrTimeout:         http.StatusRequestTimeout,
+	errs.ErrUnavailable:     http.StatusServiceUnavailable,
+	errs.ErrInternal:        http.StatusInternalServerError,
+}
+
+// Status returns the HTTP status code of an error. Errors that are not
+// domain errors are internal server errors.
+func Status(err error) int {
+	var e *errs.Error
+	if !errors.As(err, &e) {
+		return http.StatusInternalServerError
+	}
+	if status, ok := statuses[e.Kind]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// Code returns the machine-readable code of an error
+func Code(err error) string {
+	var e *errs.Error
+	if !errors.As(err, &e) || e.Code == "" {
+		return CodeInternal
+	}
+	return e.Code
+}
+
+// Detail returns the description of an error that is safe to send to a
+// client. Server errors are not described as they may reveal details of the
+// storage, and neither are the validation and decoding errors, which are
+// described field by field.
+func Detail(err error) string {
+	switch {
+	case Status(err) >= http.StatusInternalServerError:
+		return serverErrorDetail
+	case len(Fields(err)) > 0:
+		return invalidFieldsDetail
+	case isDecodingError(err):
+		return decodingErrorDetail
+	}
+	return err.Error()
+}
+
+// Respond aborts the request with the problem details of an error
+func Respond(c *gin.Context, err error) {
+	c.Header("Content-Type", ContentType)
+	c.AbortWithStatusJSON(Status(err), NewProblem(c.Request, err))
+}
@@ -0,0 +1,153 @@
+package httperr
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"financial-app/pkg/errs"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+)
+
+var errInsufficientFunds = errs.New(errs.ErrConflict, "insufficient_funds", "insufficient funds")
+
+// insufficientFundsError is a typed error wrapping a domain error
+type insufficientFundsError struct{}
+
+func (e *insufficientFundsError) Error() string {
+	return "the source amount is insufficient"
+}
+
+func (e *insufficientFundsError) Unwrap() error {
+	return errInsufficientFunds
+}
+
+// transferRequest is a request body with validation rules
+type transferRequest struct {
+	SourceAccountID string `json:"source_account_id" validate:"required,uuid"`
+	Reason          string `json:"reason" validate:"max=5"`
+}
+
+func TestRespond(t *testing.T) {
+	cause := errors.New("pq: connection refused")
+
+	testCases := []struct {
+		Name             string
+		Error            error
+		ExpectedCode     int
+		ExpectedResponse string
+	}{
+		{
+			Name:         "Domain Error",
+			Error:        errInsufficientFunds,
+			ExpectedCode: http.StatusConflict,
+			ExpectedResponse: `{"type":"/problems/insufficient_funds","title":"Conflict","status":409,` +
+				`"detail":"insufficient funds","instance":"/transactions","code":"insufficient_funds"}`,
+		},
+		{
+			Name:         "Typed Error",
+			Error:        &insufficientFundsError{},
+			ExpectedCode: http.StatusConflict,
+			ExpectedResponse: `{"type":"/problems/insufficient_funds","title":"Conflict","status":409,` +
+				`"detail":"the source amount is insufficient","instance":"/transactions",` +
+				`"code":"insufficient_funds"}`,
+		},
+		{
+			Name: "Not Found",
+			Error: errs.New(errs.ErrNotFound, "account_not_found", "account not found").
+				WithMessage("could not fetch account by ID 1111"),
+			ExpectedCode: http.StatusNotFound,
+			ExpectedResponse: `{"type":"/problems/account_not_found","title":"Not Found","status":404,` +
+				`"detail":"could not fetch account by ID 1111","instance":"/transactions",` +
+				`"code":"account_not_found"}`,
+		},
+		{
+			Name:         "Invalid Request",
+			Error:        errs.Invalid(errors.New("unexpected EOF")),
+			ExpectedCode: http.StatusBadRequest,
+			ExpectedResponse: `{"type":"/problems/invalid_request","title":"Bad Request","status":400,` +
+				`"detail":"unexpected EOF","instance":"/transactions","code":"invalid_request"}`,
+		},
+		{
+			Name: "Storage Error",
+			Error: errs.New(errs.ErrInternal, "listing_accounts_failed", "could not list the accounts").
+				Wrap(cause),
+			ExpectedCode: http.StatusInternalServerError,
+			ExpectedResponse: `{"type":"/problems/listing_accounts_failed","title":"Internal Server Error",` +
+				`"status":500,"detail":"the server could not complete the request",` +
+				`"instance":"/transactions","code":"listing_accounts_failed"}`,
+		},
+		{
+			Name:         "Unknown Error",
+			Error:        cause,
+			ExpectedCode: http.StatusInternalServerError,
+			ExpectedResponse: `{"type":"/problems/internal_error","title":"Internal Server Error",` +
+				`"status":500,"detail":"the server could not complete the request",` +
+				`"instance":"/transactions","code":"internal_error"}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			r := gin.New()
+			r.POST("/transactions", func(c *gin.Context) {
+				Respond(c, tc.Error)
+			})
+
+			req, _ := http.NewRequest("POST", "/transactions", nil)
+			rr := httptest.NewRecorder()
+
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.ExpectedCode, rr.Code)
+			assert.Equal(t, ContentType, rr.Header().Get("Content-Type"))
+			assert.JSONEq(t, tc.ExpectedResponse, rr.Body.String())
+		})
+	}
+}
+
+func TestNewProblem_ValidationErrors(t *testing.T) {
+	v := validator.New()
+	v.RegisterTagNameFunc(JSONFieldName)
+
+	err := v.Struct(transferRequest{SourceAccountID: "", Reason: "too long"})
+	problem := NewProblem(nil, errs.Invalid(err))
+
+	assert.Equal(t, http.StatusBadRequest, problem.Status)
+	assert.Equal(t, errs.CodeInvalidRequest, problem.Code)
+	assert.Equal(t, "one or more fields of the request are not valid", problem.Detail)
+	assert.Equal(t, []FieldError{
+		{
+			Field:  "source_account_id",
+			Code:   "required",
+			Detail: "source_account_id failed the required validation",
+		},
+		{
+			Field:  "reason",
+			Code:   "max",
+			Detail: "reason failed the max=5 validation",
+		},
+	}, problem.Errors)
+}
+
+func TestNewProblem_DecodingErrors(t *testing.T) {
+	var req transferRequest
+	err := json.NewDecoder(bytes.NewBufferString(`{"reason": 42}`)).Decode(&req)
+	problem := NewProblem(nil, errs.Invalid(err))
+
+	assert.Equal(t, "one or more fields of the request are not valid", problem.Detail)
+	assert.Equal(t, []FieldError{
+		{Field: "reason", Code: "invalid_type", Detail: "reason cannot be a JSON number"},
+	}, problem.Errors)
+
+	err = json.NewDecoder(bytes.NewBufferString(`{"reason": }`)).Decode(&req)
+	problem = NewProblem(nil, errs.Invalid(err))
+
+	assert.Equal(t, "the request body is not valid JSON", problem.Detail)
+	assert.Empty(t, problem.Errors)
+}
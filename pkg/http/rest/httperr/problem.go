@@ -0,0 +1,126 @@
+package httperr
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// ContentType is the media type of the problem details
+const ContentType = "application/problem+json"
+
+// TypeBase prefixes the code of an error to build the type of its problem
+const TypeBase = "/problems/"
+
+// codeInvalidType is the code of the fields holding a JSON value of the wrong type
+const codeInvalidType = "invalid_type"
+
+// Details of the validation and decoding errors
+const (
+	invalidFieldsDetail = "one or more fields of the request are not valid"
+	decodingErrorDetail = "the request body is not valid JSON"
+)
+
+// Problem is an RFC 7807 problem details object describing an error
+type Problem struct {
+	// Type identifies the kind of problem, e.g. "/problems/account_not_found"
+	Type string `json:"type"`
+	// Title is the short summary of the status
+	Title string `json:"title"`
+	// Status is the HTTP status code of the response
+	Status int `json:"status"`
+	// Detail describes this occurrence of the problem
+	Detail string `json:"detail,omitempty"`
+	// Instance is the path of the request that failed
+	Instance string `json:"instance,omitempty"`
+	// Code is the stable machine-readable code of the error
+	Code string `json:"code"`
+	// Errors are the fields of the request that are not valid, if any
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError describes a field of a request that is not valid
+type FieldError struct {
+	// Field is the JSON name of the field
+	Field string `json:"field"`
+	// Code is the rule that the field does not satisfy, e.g. "required"
+	Code string `json:"code"`
+	// Detail describes why the field is not valid
+	Detail string `json:"detail"`
+}
+
+// NewProblem returns the problem details of an error raised by a request
+func NewProblem(r *http.Request, err error) Problem {
+	status := Status(err)
+	code := Code(err)
+
+	p := Problem{
+		Type:   TypeBase + code,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: Detail(err),
+		Code:   code,
+		Errors: Fields(err),
+	}
+	if r != nil {
+		p.Instance = r.URL.Path
+	}
+	return p
+}
+
+// Fields returns the field errors of a validation or decoding error, or nil
+func Fields(err error) []FieldError {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		fields := make([]FieldError, 0, len(validationErrs))
+		for _, fe := range validationErrs {
+			rule := fe.Tag()
+			if fe.Param() != "" {
+				rule += "=" + fe.Param()
+			}
+			fields = append(fields, FieldError{
+				Field:  fe.Field(),
+				Code:   fe.Tag(),
+				Detail: fe.Field() + " failed the " + rule + " validation",
+			})
+		}
+		return fields
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return []FieldError{{
+			Field:  typeErr.Field,
+			Code:   codeInvalidType,
+			Detail: typeErr.Field + " cannot be a JSON " + typeErr.Value,
+		}}
+	}
+
+	return nil
+}
+
+// JSONFieldName names the fields of the validation errors after their JSON
+// name. It is meant to be registered with RegisterTagNameFunc.
+func JSONFieldName(field reflect.StructField) string {
+	name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+// isDecodingError returns true if a request body is not valid JSON
+func isDecodingError(err error) bool {
+	var (
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+	)
+	return errors.As(err, &syntaxErr) || errors.As(err, &typeErr)
+}
@@ -4,14 +4,30 @@ import (
 	"context"
 	"financial-app/pkg/accounts"
 	acctsvcs "financial-app/pkg/accounts/decoratedsvcs"
+	"financial-app/pkg/auth"
+	"financial-app/pkg/customers"
+	custsvcs "financial-app/pkg/customers/decoratedsvcs"
+	"financial-app/pkg/errs"
+	"financial-app/pkg/fx"
+	fxsvcs "financial-app/pkg/fx/decoratedsvcs"
 	"financial-app/pkg/healthchecks"
 	healthsvcs "financial-app/pkg/healthchecks/decoratedsvcs"
+	"financial-app/pkg/holds"
+	holdsvcs "financial-app/pkg/holds/decoratedsvcs"
+	"financial-app/pkg/http/rest/httperr"
+	"financial-app/pkg/idempotency"
+	"financial-app/pkg/ledger"
+	ledgersvcs "financial-app/pkg/ledger/decoratedsvcs"
+	"financial-app/pkg/statements"
+	stmtsvcs "financial-app/pkg/statements/decoratedsvcs"
 	"financial-app/pkg/transactions"
 	txnsvcs "financial-app/pkg/transactions/decoratedsvcs"
+	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-contrib/cors"
@@ -23,11 +39,24 @@ import (
 	"go.uber.org/zap"
 )
 
+// Errors answered by the router itself
+var (
+	errRouteNotFound = errs.New(errs.ErrNotFound, "route_not_found", "no route matches the request")
+	errServerTimeout = errs.New(errs.ErrTimeout, "server_timeout", "server timeout")
+)
+
 // Server holds the dependencies for a HTTP server.
 type Server struct {
 	AccountService     accounts.Service
 	TransactionService transactions.Service
 	HealthcheckService healthchecks.Service
+	QuoteService       fx.Service
+	IdempotencyService idempotency.Service
+	LedgerService      ledger.Service
+	StatementService   statements.Service
+	AuthService        auth.Service
+	CustomerService    customers.Service
+	HoldService        holds.Service
 
 	Logger *zap.SugaredLogger
 
@@ -39,13 +68,29 @@ func setupServices(
 	accountRepo accounts.AccountRepository,
 	transactionRepo transactions.TransactionRepository,
 	healthcheckRepo healthchecks.HealthcheckRepository,
+	quoteRepo fx.QuoteRepository,
+	idempotencyRepo idempotency.Repository,
+	ledgerRepo ledger.Repository,
+	apiKeyRepo auth.Repository,
+	customerRepo customers.Repository,
+	roleRepo accounts.RoleRepository,
+	holdRepo holds.Repository,
+	rates fx.RateProvider,
+	quoteTTL time.Duration,
+	idempotencyRetention time.Duration,
+	approvals transactions.ApprovalPolicy,
+	holdTTL time.Duration,
 	log *zap.SugaredLogger,
-) (accounts.Service, transactions.Service, healthchecks.Service) {
+) (
+	accounts.Service, transactions.Service, healthchecks.Service,
+	fx.Service, idempotency.Service, ledger.Service, statements.Service,
+	auth.Service, customers.Service, holds.Service,
+) {
 	fieldKeys := []string{"method"}
 
 	// Setup services
 	var as accounts.Service
-	as = accounts.NewService(accountRepo)
+	as = accounts.NewService(accountRepo, roleRepo)
 	as = acctsvcs.NewLoggingService(log, as)
 	as = acctsvcs.NewInstrumentingService(
 		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
@@ -63,7 +108,9 @@ func setupServices(
 		as)
 
 	var ts transactions.Service
-	ts = transactions.NewService(accountRepo, transactionRepo)
+	ts = transactions.NewService(
+		accountRepo, roleRepo, transactionRepo, rates, quoteRepo, approvals,
+	)
 	ts = txnsvcs.NewLoggingService(log, ts)
 	ts = txnsvcs.NewInstrumentingService(
 		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
@@ -84,7 +131,103 @@ func setupServices(
 	hs = healthchecks.NewService(healthcheckRepo)
 	hs = healthsvcs.NewLoggingService(log, hs)
 
-	return as, ts, hs
+	var qs fx.Service
+	qs = fx.NewService(rates, quoteRepo, quoteTTL)
+	qs = fxsvcs.NewLoggingService(log, qs)
+	qs = fxsvcs.NewInstrumentingService(
+		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: "api",
+			Subsystem: "quote_service",
+			Name:      "request_count",
+			Help:      "Number of requests received.",
+		}, fieldKeys),
+		kitprometheus.NewSummaryFrom(stdprometheus.SummaryOpts{
+			Namespace: "api",
+			Subsystem: "quote_service",
+			Name:      "request_latency_microseconds",
+			Help:      "Total duration of requests in microseconds.",
+		}, fieldKeys),
+		qs)
+
+	is := idempotency.NewService(idempotencyRepo, idempotencyRetention)
+
+	var ls ledger.Service
+	ls = ledger.NewService(accountRepo, roleRepo, ledgerRepo)
+	ls = ledgersvcs.NewLoggingService(log, ls)
+	ls = ledgersvcs.NewInstrumentingService(
+		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: "api",
+			Subsystem: "ledger_service",
+			Name:      "request_count",
+			Help:      "Number of requests received.",
+		}, fieldKeys),
+		kitprometheus.NewSummaryFrom(stdprometheus.SummaryOpts{
+			Namespace: "api",
+			Subsystem: "ledger_service",
+			Name:      "request_latency_microseconds",
+			Help:      "Total duration of requests in microseconds.",
+		}, fieldKeys),
+		ls)
+
+	var ss statements.Service
+	ss = statements.NewService(accountRepo, roleRepo, transactionRepo)
+	ss = stmtsvcs.NewLoggingService(log, ss)
+	ss = stmtsvcs.NewInstrumentingService(
+		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: "api",
+			Subsystem: "statement_service",
+			Name:      "request_count",
+			Help:      "Number of requests received.",
+		}, fieldKeys),
+		kitprometheus.NewSummaryFrom(stdprometheus.SummaryOpts{
+			Namespace: "api",
+			Subsystem: "statement_service",
+			Name:      "request_latency_microseconds",
+			Help:      "Total duration of requests in microseconds.",
+		}, fieldKeys),
+		ss)
+
+	aus := auth.NewService(apiKeyRepo)
+
+	var cs customers.Service
+	cs = customers.NewService(customerRepo, as)
+	cs = custsvcs.NewLoggingService(log, cs)
+	cs = custsvcs.NewInstrumentingService(
+		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: "api",
+			Subsystem: "customer_service",
+			Name:      "request_count",
+			Help:      "Number of requests received.",
+		}, fieldKeys),
+		kitprometheus.NewSummaryFrom(stdprometheus.SummaryOpts{
+			Namespace: "api",
+			Subsystem: "customer_service",
+			Name:      "request_latency_microseconds",
+			Help:      "Total duration of requests in microseconds.",
+		}, fieldKeys),
+		cs)
+
+	var hds holds.Service
+	hds = holds.NewService(
+		accountRepo, roleRepo, holdRepo, transactionRepo, approvals, holdTTL,
+	)
+	hds = holdsvcs.NewLoggingService(log, hds)
+	hds = holdsvcs.NewInstrumentingService(
+		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: "api",
+			Subsystem: "hold_service",
+			Name:      "request_count",
+			Help:      "Number of requests received.",
+		}, fieldKeys),
+		kitprometheus.NewSummaryFrom(stdprometheus.SummaryOpts{
+			Namespace: "api",
+			Subsystem: "hold_service",
+			Name:      "request_latency_microseconds",
+			Help:      "Total duration of requests in microseconds.",
+		}, fieldKeys),
+		hds)
+
+	return as, ts, hs, qs, is, ls, ss, aus, cs, hds
 }
 
 // NewServer returns a new HTTP server.
@@ -92,18 +235,43 @@ func NewServer(
 	accountRepo accounts.AccountRepository,
 	transactionRepo transactions.TransactionRepository,
 	healthcheckRepo healthchecks.HealthcheckRepository,
+	quoteRepo fx.QuoteRepository,
+	idempotencyRepo idempotency.Repository,
+	ledgerRepo ledger.Repository,
+	apiKeyRepo auth.Repository,
+	customerRepo customers.Repository,
+	roleRepo accounts.RoleRepository,
+	holdRepo holds.Repository,
+	rates fx.RateProvider,
+	quoteTTL time.Duration,
+	idempotencyRetention time.Duration,
+	approvals transactions.ApprovalPolicy,
+	holdTTL time.Duration,
 	logger *zap.SugaredLogger,
 ) *Server {
-	as, ts, hs := setupServices(accountRepo, transactionRepo, healthcheckRepo, logger)
+	as, ts, hs, qs, is, ls, ss, aus, cs, hds := setupServices(
+		accountRepo, transactionRepo, healthcheckRepo, quoteRepo, idempotencyRepo,
+		ledgerRepo, apiKeyRepo, customerRepo, roleRepo, holdRepo, rates, quoteTTL,
+		idempotencyRetention, approvals, holdTTL, logger,
+	)
 	s := &Server{
 		AccountService:     as,
 		TransactionService: ts,
 		HealthcheckService: hs,
+		QuoteService:       qs,
+		IdempotencyService: is,
+		LedgerService:      ls,
+		StatementService:   ss,
+		AuthService:        aus,
+		CustomerService:    cs,
+		HoldService:        hds,
 		Logger:             logger,
 	}
 
 	// Creates a router without any middleware by default
 	r := gin.New()
+	// Let the services read the authenticated API key from the request context
+	r.ContextWithFallback = true
 
 	// Global middleware
 	// Logger middleware will write the logs to gin.DefaultWriter
@@ -111,20 +279,24 @@ func NewServer(
 	// By default gin.DefaultWriter = os.Stdout
 	r.Use(gin.Logger())
 	// Recovery middleware recovers from any panics and writes a 500 if there was one.
-	r.Use(gin.Recovery())
+	r.Use(gin.CustomRecovery(recoveryResponse))
 	// Custom middlewares
 	r.Use(timeoutMiddleware())
-	r.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"*"},
-		AllowMethods:     []string{"POST, OPTIONS, GET, PUT, DELETE"},
-		AllowHeaders:     []string{"Origin"},
-		ExposeHeaders:    []string{"Content-Length", "Content-Type"},
-		AllowCredentials: true,
-		MaxAge:           12 * time.Hour,
-	}))
+	r.Use(corsMiddleware())
 
 	// Setup routes
 	servicesRoutes := r.Group("/api/v1/")
+	// Every request must carry an API key with the scope of its route
+	servicesRoutes.Use(authMiddleware(s.AuthService, s.Logger,
+		kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: "api",
+			Subsystem: "auth",
+			Name:      "rejected_request_count",
+			Help:      "Number of requests refused as unauthorized or forbidden.",
+		}, []string{"reason"}),
+	))
+	// Retried POST requests with the same Idempotency-Key are replayed
+	servicesRoutes.Use(idempotency.Middleware(s.IdempotencyService, s.Logger))
 
 	// healthchecks
 	hh := healthchecks.HealthcheckHandler{Service: s.HealthcheckService, Logger: s.Logger}
@@ -135,8 +307,27 @@ func NewServer(
 	// transactions
 	th := transactions.TransactionHandler{Service: s.TransactionService, Logger: s.Logger}
 	th.Router(servicesRoutes)
+	// fx quotes
+	qh := fx.QuoteHandler{Service: s.QuoteService, Logger: s.Logger}
+	qh.Router(servicesRoutes)
+	// ledger
+	lh := ledger.LedgerHandler{Service: s.LedgerService, Logger: s.Logger}
+	lh.Router(servicesRoutes)
+	// statements
+	sh := statements.StatementHandler{Service: s.StatementService, Logger: s.Logger}
+	sh.Router(servicesRoutes)
+	// customers
+	ch := customers.CustomerHandler{Service: s.CustomerService, Logger: s.Logger}
+	ch.Router(servicesRoutes)
+	// holds
+	hdh := holds.HoldHandler{Service: s.HoldService, Logger: s.Logger}
+	hdh.Router(servicesRoutes)
 	// metrics
 	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	// Unknown routes are answered with problem details too
+	r.NoRoute(func(c *gin.Context) {
+		httperr.Respond(c, errRouteNotFound)
+	})
 
 	s.router = r
 
@@ -148,18 +339,57 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 }
 
 func timeoutResponse(c *gin.Context) {
-	c.String(http.StatusRequestTimeout, "server timeout")
+	httperr.Respond(c, errServerTimeout)
+}
+
+// recoveryResponse answers a request whose handler panicked
+func recoveryResponse(c *gin.Context, recovered interface{}) {
+	httperr.Respond(c, fmt.Errorf("panic: %v", recovered))
+}
+
+// corsMiddleware allows the origins of the comma separated CORS_ALLOWED_ORIGINS
+// list, or any origin without it. The API keys are sent in a header rather
+// than in cookies, so credentials are never allowed.
+func corsMiddleware() gin.HandlerFunc {
+	config := cors.Config{
+		AllowMethods:  []string{"POST, OPTIONS, GET, PUT, DELETE"},
+		AllowHeaders:  []string{"Origin", "Authorization", "Content-Type", idempotency.HeaderKey},
+		ExposeHeaders: []string{"Content-Length", "Content-Type", idempotency.HeaderReplayed},
+		MaxAge:        12 * time.Hour,
+	}
+	for _, origin := range strings.Split(os.Getenv("CORS_ALLOWED_ORIGINS"), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			config.AllowOrigins = append(config.AllowOrigins, origin)
+		}
+	}
+	if len(config.AllowOrigins) == 0 {
+		config.AllowAllOrigins = true
+	}
+	return cors.New(config)
+}
+
+// streamingRoutes are the routes whose responses are streamed, which the
+// timeout middleware would buffer in full and cut short
+var streamingRoutes = map[string]bool{
+	"/api/v1/accounts/:id/statement": true,
 }
 
 func timeoutMiddleware() gin.HandlerFunc {
 	serverTimeout, _ := strconv.ParseInt(os.Getenv("SERVER_TIMEOUT"), 10, 0)
-	return timeout.New(
+	t := timeout.New(
 		timeout.WithTimeout(time.Duration(serverTimeout)*time.Second),
 		timeout.WithHandler(func(c *gin.Context) {
 			c.Next()
 		}),
 		timeout.WithResponse(timeoutResponse),
 	)
+	return func(c *gin.Context) {
+		if streamingRoutes[c.FullPath()] {
+			c.Next()
+			return
+		}
+		t(c)
+	}
 }
 
 // Serve gracefully serves our newly set up handler function
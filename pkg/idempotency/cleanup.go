@@ -0,0 +1,31 @@
+package idempotency
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// RunCleanup removes the records older than the retention window at every
+// interval until the context is done
+func RunCleanup(
+	ctx context.Context, svc Service, interval time.Duration, logger *zap.SugaredLogger,
+) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := svc.Cleanup(ctx)
+			if err != nil {
+				logger.Errorf("failed to clean up the idempotency keys: %v", err)
+				continue
+			}
+			logger.Infof("removed %d expired idempotency keys", n)
+		}
+	}
+}
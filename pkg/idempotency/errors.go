@@ -0,0 +1,48 @@
+package idempotency
+
+import "financial-app/pkg/errs"
+
+// ErrInvalidKey is used when an idempotency key is empty or too long
+var ErrInvalidKey = errs.New(errs.ErrInvalid, "invalid_idempotency_key",
+	"idempotency key must be between 1 and 255 characters")
+
+// ErrBodyTooLarge is used when the body of a request with an idempotency key
+// exceeds MaxBodySize
+var ErrBodyTooLarge = errs.New(errs.ErrTooLarge, "request_body_too_large",
+	"the body of a request with an idempotency key must not exceed 1 MiB")
+
+// ErrKeyExists is used when a record is stored for a key that is already taken
+var ErrKeyExists = errs.New(errs.ErrConflict, "idempotency_key_exists", "idempotency key already exists")
+
+// ErrKeyReused is used when a key is replayed with a different request
+var ErrKeyReused = errs.New(errs.ErrUnprocessable, "idempotency_key_reused",
+	"idempotency key was used with a different request")
+
+// ErrRequestInProgress is used when a key is replayed while the original
+// request is still being processed
+var ErrRequestInProgress = errs.New(errs.ErrConflict, "request_in_progress",
+	"a request with this idempotency key is in progress")
+
+// ErrPostingRecord is used when an idempotency record could not be stored
+func ErrPostingRecord(key string) *errs.Error {
+	return errs.New(errs.ErrInternal, "posting_record_failed",
+		"could not store idempotency record by key "+key)
+}
+
+// ErrFetchingRecord is used when an idempotency record could not be fetched
+func ErrFetchingRecord(key string) *errs.Error {
+	return errs.New(errs.ErrInternal, "fetching_record_failed",
+		"could not fetch idempotency record by key "+key)
+}
+
+// ErrUpdatingRecord is used when an idempotency record could not be completed
+func ErrUpdatingRecord(key string) *errs.Error {
+	return errs.New(errs.ErrInternal, "updating_record_failed",
+		"could not update idempotency record by key "+key)
+}
+
+// ErrDeletingRecord is used when an idempotency record could not be removed
+func ErrDeletingRecord(key string) *errs.Error {
+	return errs.New(errs.ErrInternal, "deleting_record_failed",
+		"could not delete idempotency record by key "+key)
+}
@@ -0,0 +1,122 @@
+package idempotency
+
+import (
+	"bytes"
+	"errors"
+	"financial-app/pkg/auth"
+	"financial-app/pkg/errs"
+	"financial-app/pkg/http/rest/httperr"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// HeaderKey is the request header carrying the idempotency key
+const HeaderKey = "Idempotency-Key"
+
+// HeaderReplayed marks the responses replayed from a stored record
+const HeaderReplayed = "Idempotent-Replayed"
+
+// MaxBodySize is the size in bytes of the largest request body read to hash
+// a request with an idempotency key
+const MaxBodySize = 1 << 20
+
+// responseRecorder keeps a copy of the response body written by the handlers
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Middleware makes POST requests carrying an Idempotency-Key header safe to
+// retry. The first response for a key is stored and returned again for every
+// replay of the same request by the same API key, whereas a replay with a
+// different request is rejected. Server errors and panics are not stored so
+// that the request can be retried.
+func Middleware(svc Service, logger *zap.SugaredLogger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(HeaderKey)
+		if c.Request.Method != http.MethodPost || key == "" {
+			c.Next()
+			return
+		}
+
+		// The body is read in full to be hashed, so its size is capped
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, MaxBodySize))
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			logger.Error(err)
+
+			httperr.Respond(c, ErrBodyTooLarge)
+			return
+		}
+		if err != nil {
+			logger.Error(err)
+
+			httperr.Respond(c, errs.Invalid(err))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		// The API key set by the auth middleware is in the request context
+		ctx := c.Request.Context()
+		apiKey, _ := auth.FromContext(ctx)
+		requestHash := HashRequest(apiKey.ID, c.Request.Method, c.Request.URL.Path, body)
+		rec, err := svc.Begin(ctx, key, requestHash)
+		if err != nil {
+			logger.Error(err)
+
+			httperr.Respond(c, err)
+			return
+		}
+
+		// Replay the stored response of the original request
+		if rec.Completed() {
+			c.Header(HeaderReplayed, "true")
+			c.Data(rec.StatusCode, rec.ContentType, rec.Body)
+			c.Abort()
+			return
+		}
+
+		w := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = w
+
+		// Free the key of a request whose handler panicked before passing the
+		// panic on to the recovery middleware
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				if err := svc.Release(ctx, key); err != nil {
+					logger.Error(err)
+				}
+				panic(recovered)
+			}
+		}()
+
+		c.Next()
+
+		if w.Status() >= http.StatusInternalServerError {
+			if err := svc.Release(ctx, key); err != nil {
+				logger.Error(err)
+			}
+			return
+		}
+
+		rec.StatusCode = w.Status()
+		rec.ContentType = w.Header().Get("Content-Type")
+		rec.Body = w.body.Bytes()
+		if err := svc.Complete(ctx, rec); err != nil {
+			logger.Error(err)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package idempotency
+
+import (
+	"bytes"
+	"financial-app/pkg/auth"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// setupRouter returns a router whose handler counts its calls and echoes the
+// request body with the given status code
+func setupRouter(svc Service, status int, calls *int) *gin.Engine {
+	logger, _ := zap.NewDevelopment()
+
+	r := gin.Default()
+	r.Use(Middleware(svc, logger.Sugar()))
+	r.POST("/transactions", func(c *gin.Context) {
+		*calls++
+		body, _ := io.ReadAll(c.Request.Body)
+		c.JSON(status, gin.H{"call": *calls, "request": string(body)})
+	})
+
+	return r
+}
+
+func post(r *gin.Engine, key, body string) *httptest.ResponseRecorder {
+	return postAs(r, "", key, body)
+}
+
+// postAs sends the request as if the auth middleware had authenticated the
+// API key with the given id
+func postAs(r *gin.Engine, apiKeyID, key, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", "/transactions", bytes.NewBufferString(body))
+	if apiKeyID != "" {
+		req = req.WithContext(auth.NewContext(req.Context(), auth.Key{ID: apiKeyID}))
+	}
+	if key != "" {
+		req.Header.Set(HeaderKey, key)
+	}
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestMiddleware_Replay(t *testing.T) {
+	mockRepository := &mockRepository{Records: make(map[string]*Record)}
+	var calls int
+	r := setupRouter(NewService(mockRepository, time.Hour), http.StatusOK, &calls)
+
+	first := post(r, "key-1", `{"amount":"10"}`)
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Empty(t, first.Header().Get(HeaderReplayed))
+
+	second := post(r, "key-1", `{"amount":"10"}`)
+	assert.Equal(t, http.StatusOK, second.Code)
+	assert.Equal(t, "true", second.Header().Get(HeaderReplayed))
+	assert.Equal(t, first.Body.String(), second.Body.String(),
+		"Replay should return the original response")
+	assert.Equal(t, 1, calls, "Handler should run only once")
+
+	mismatch := post(r, "key-1", `{"amount":"20"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, mismatch.Code)
+	assert.Equal(t, 1, calls, "Handler should not run for a different request")
+}
+
+func TestMiddleware_WithoutKey(t *testing.T) {
+	mockRepository := &mockRepository{Records: make(map[string]*Record)}
+	var calls int
+	r := setupRouter(NewService(mockRepository, time.Hour), http.StatusOK, &calls)
+
+	post(r, "", `{"amount":"10"}`)
+	post(r, "", `{"amount":"10"}`)
+
+	assert.Equal(t, 2, calls, "Requests without a key should not be deduplicated")
+	assert.Empty(t, mockRepository.Records)
+}
+
+func TestMiddleware_ServerErrorReleasesKey(t *testing.T) {
+	mockRepository := &mockRepository{Records: make(map[string]*Record)}
+	var calls int
+	r := setupRouter(
+		NewService(mockRepository, time.Hour), http.StatusInternalServerError, &calls,
+	)
+
+	post(r, "key-1", `{"amount":"10"}`)
+	post(r, "key-1", `{"amount":"10"}`)
+
+	assert.Equal(t, 2, calls, "Request should be retried after a server error")
+	assert.Empty(t, mockRepository.Records)
+}
+
+func TestMiddleware_BodyTooLarge(t *testing.T) {
+	mockRepository := &mockRepository{Records: make(map[string]*Record)}
+	var calls int
+	r := setupRouter(NewService(mockRepository, time.Hour), http.StatusOK, &calls)
+
+	rr := post(r, "key-1", strings.Repeat("a", MaxBodySize+1))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+	assert.Equal(t, 0, calls, "Handler should not run for a body over the cap")
+	assert.Empty(t, mockRepository.Records)
+
+	rr = post(r, "key-2", strings.Repeat("a", MaxBodySize))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, 1, calls)
+}
+
+func TestMiddleware_ScopedToAPIKey(t *testing.T) {
+	mockRepository := &mockRepository{Records: make(map[string]*Record)}
+	var calls int
+	r := setupRouter(NewService(mockRepository, time.Hour), http.StatusOK, &calls)
+
+	first := postAs(r, "api-key-1", "key-1", `{"amount":"10"}`)
+	assert.Equal(t, http.StatusOK, first.Code)
+
+	other := postAs(r, "api-key-2", "key-1", `{"amount":"10"}`)
+	assert.Equal(t, http.StatusOK, other.Code)
+	assert.Empty(t, other.Header().Get(HeaderReplayed),
+		"Another API key should not get the response of the first")
+	assert.Equal(t, 2, calls, "Each API key should have its own records")
+
+	replay := postAs(r, "api-key-1", "key-1", `{"amount":"10"}`)
+	assert.Equal(t, "true", replay.Header().Get(HeaderReplayed))
+	assert.Equal(t, first.Body.String(), replay.Body.String())
+	assert.Equal(t, 2, calls)
+	assert.Len(t, mockRepository.Records, 2)
+}
+
+func TestMiddleware_PanicReleasesKey(t *testing.T) {
+	mockRepository := &mockRepository{Records: make(map[string]*Record)}
+	logger, _ := zap.NewDevelopment()
+	var calls int
+
+	r := gin.New()
+	r.Use(gin.Recovery(), Middleware(NewService(mockRepository, time.Hour), logger.Sugar()))
+	r.POST("/transactions", func(c *gin.Context) {
+		calls++
+		panic("handler failed")
+	})
+
+	first := post(r, "key-1", `{"amount":"10"}`)
+	assert.Equal(t, http.StatusInternalServerError, first.Code)
+	assert.Empty(t, mockRepository.Records, "Key should be released after a panic")
+
+	post(r, "key-1", `{"amount":"10"}`)
+	assert.Equal(t, 2, calls, "Request should be retried after a panic")
+}
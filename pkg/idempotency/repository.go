@@ -0,0 +1,19 @@
+package idempotency
+
+import (
+	"context"
+	"time"
+)
+
+// Repository provides access an idempotency record store
+type Repository interface {
+	// Store reserves the key of the record or returns ErrKeyExists
+	Store(ctx context.Context, rec *Record) (*Record, error)
+	Find(ctx context.Context, key string) (*Record, error)
+	// Complete saves the response of the record
+	Complete(ctx context.Context, rec *Record) error
+	Delete(ctx context.Context, key string) error
+	// DeleteBefore removes the records created before the given time and
+	// returns how many were removed
+	DeleteBefore(ctx context.Context, t time.Time) (int64, error)
+}
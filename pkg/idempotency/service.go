@@ -0,0 +1,135 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"financial-app/pkg/auth"
+	"time"
+)
+
+// maxKeyLength is the maximum length of an idempotency key
+const maxKeyLength = 255
+
+// Record is the stored outcome of a request sent with an idempotency key.
+// A record without a status code belongs to a request still in progress.
+type Record struct {
+	// Key is the idempotency key scoped to the API key which sent it
+	Key         string
+	RequestHash string
+	StatusCode  int
+	ContentType string
+	Body        []byte
+	CreatedAt   time.Time
+}
+
+// Completed returns true if the response of the request has been saved
+func (r Record) Completed() bool {
+	return r.StatusCode != 0
+}
+
+// Service is the interface that provides idempotency methods
+type Service interface {
+	// Begin reserves the key for a new request, or returns the completed
+	// record of the original request with the same key. Keys are scoped to
+	// the API key of the request, so that two principals never share them.
+	Begin(ctx context.Context, key, requestHash string) (Record, error)
+
+	// Complete saves the response of the request sent with the key
+	Complete(ctx context.Context, rec Record) error
+
+	// Release frees the key of the API key of the request so that the
+	// request can be retried
+	Release(ctx context.Context, key string) error
+
+	// Cleanup removes the records older than the retention window
+	Cleanup(ctx context.Context) (int64, error)
+}
+
+// HashRequest returns a fingerprint of the API key, method, path and body of
+// a request
+func HashRequest(keyID, method, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(keyID))
+	h.Write([]byte{0})
+	h.Write([]byte(method))
+	h.Write([]byte{0})
+	h.Write([]byte(path))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (s *service) Begin(
+	ctx context.Context, key, requestHash string,
+) (Record, error) {
+	if key == "" || len(key) > maxKeyLength {
+		return Record{}, ErrInvalidKey
+	}
+
+	key = scopedKey(ctx, key)
+	rec, err := s.records.Store(ctx, &Record{
+		Key:         key,
+		RequestHash: requestHash,
+		CreatedAt:   time.Now().UTC(),
+	})
+	if err == nil {
+		return *rec, nil
+	}
+	if !errors.Is(err, ErrKeyExists) {
+		return Record{}, err
+	}
+
+	// The key is taken, so this is a replay of the original request
+	rec, err = s.records.Find(ctx, key)
+	if err != nil {
+		return Record{}, err
+	}
+	if rec.RequestHash != requestHash {
+		return Record{}, ErrKeyReused
+	}
+	if !rec.Completed() {
+		return Record{}, ErrRequestInProgress
+	}
+
+	return *rec, nil
+}
+
+func (s *service) Complete(ctx context.Context, rec Record) error {
+	return s.records.Complete(ctx, &rec)
+}
+
+func (s *service) Release(ctx context.Context, key string) error {
+	return s.records.Delete(ctx, scopedKey(ctx, key))
+}
+
+// scopedKey returns the idempotency key as stored for the API key of the
+// request, if any
+func scopedKey(ctx context.Context, key string) string {
+	if apiKey, ok := auth.FromContext(ctx); ok {
+		return apiKey.ID + "/" + key
+	}
+	return key
+}
+
+func (s *service) Cleanup(ctx context.Context) (int64, error) {
+	return s.records.DeleteBefore(ctx, time.Now().Add(-s.retention))
+}
+
+type service struct {
+	records   Repository
+	retention time.Duration
+}
+
+// NewService creates an idempotency service with necessary dependencies.
+// Records are kept for the given retention window.
+func NewService(
+	records Repository,
+	retention time.Duration,
+) Service {
+	return &service{
+		records:   records,
+		retention: retention,
+	}
+}
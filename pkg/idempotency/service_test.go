@@ -0,0 +1,136 @@
+package idempotency
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockRepository struct {
+	Records map[string]*Record
+}
+
+func (m *mockRepository) Store(
+	ctx context.Context, rec *Record,
+) (*Record, error) {
+	if _, ok := m.Records[rec.Key]; ok {
+		return nil, ErrKeyExists
+	}
+	stored := *rec
+	m.Records[rec.Key] = &stored
+	return rec, nil
+}
+
+func (m *mockRepository) Find(
+	ctx context.Context, key string,
+) (*Record, error) {
+	if rec, ok := m.Records[key]; ok {
+		found := *rec
+		return &found, nil
+	}
+	return nil, ErrFetchingRecord(key)
+}
+
+func (m *mockRepository) Complete(
+	ctx context.Context, rec *Record,
+) error {
+	if _, ok := m.Records[rec.Key]; !ok {
+		return ErrUpdatingRecord(rec.Key)
+	}
+	stored := *rec
+	m.Records[rec.Key] = &stored
+	return nil
+}
+
+func (m *mockRepository) Delete(
+	ctx context.Context, key string,
+) error {
+	delete(m.Records, key)
+	return nil
+}
+
+func (m *mockRepository) DeleteBefore(
+	ctx context.Context, t time.Time,
+) (int64, error) {
+	var n int64
+	for key, rec := range m.Records {
+		if rec.CreatedAt.Before(t) {
+			delete(m.Records, key)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func TestService_Begin(t *testing.T) {
+	mockRepository := &mockRepository{Records: map[string]*Record{
+		"in-progress": {Key: "in-progress", RequestHash: "hash"},
+		"completed": {
+			Key:         "completed",
+			RequestHash: "hash",
+			StatusCode:  200,
+			ContentType: "application/json",
+			Body:        []byte(`{"id":"1111"}`),
+		},
+	}}
+	service := NewService(mockRepository, time.Hour)
+
+	testCases := []struct {
+		Name              string
+		Key               string
+		RequestHash       string
+		ExpectedCompleted bool
+		ExpectedError     error
+	}{
+		{Name: "New Key", Key: "new", RequestHash: "hash"},
+		{Name: "Replay", Key: "completed", RequestHash: "hash", ExpectedCompleted: true},
+		{Name: "Different Request", Key: "completed", RequestHash: "other",
+			ExpectedError: ErrKeyReused},
+		{Name: "In Progress", Key: "in-progress", RequestHash: "hash",
+			ExpectedError: ErrRequestInProgress},
+		{Name: "Empty Key", Key: "", RequestHash: "hash", ExpectedError: ErrInvalidKey},
+		{Name: "Too Long Key", Key: strings.Repeat("k", maxKeyLength+1), RequestHash: "hash",
+			ExpectedError: ErrInvalidKey},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			rec, err := service.Begin(context.Background(), tc.Key, tc.RequestHash)
+
+			assert.Equal(t, tc.ExpectedError, err)
+			if tc.ExpectedError == nil {
+				assert.Equal(t, tc.Key, rec.Key)
+				assert.Equal(t, tc.ExpectedCompleted, rec.Completed())
+			}
+		})
+	}
+}
+
+func TestService_Cleanup(t *testing.T) {
+	mockRepository := &mockRepository{Records: map[string]*Record{
+		"old":    {Key: "old", CreatedAt: time.Now().Add(-2 * time.Hour)},
+		"recent": {Key: "recent", CreatedAt: time.Now()},
+	}}
+	service := NewService(mockRepository, time.Hour)
+
+	n, err := service.Cleanup(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	assert.Contains(t, mockRepository.Records, "recent")
+	assert.NotContains(t, mockRepository.Records, "old")
+}
+
+func TestHashRequest(t *testing.T) {
+	body := []byte(`{"amount":"10"}`)
+	hash := HashRequest("api-key-1", "POST", "/api/v1/transactions", body)
+
+	assert.Equal(t, hash, HashRequest("api-key-1", "POST", "/api/v1/transactions", body))
+	assert.NotEqual(t, hash,
+		HashRequest("api-key-1", "POST", "/api/v1/transactions", []byte(`{"amount":"20"}`)))
+	assert.NotEqual(t, hash, HashRequest("api-key-1", "POST", "/api/v1/accounts", body))
+	assert.NotEqual(t, hash, HashRequest("api-key-2", "POST", "/api/v1/transactions", body))
+}
@@ -0,0 +1,48 @@
+package decoratedsvcs
+
+import (
+	"context"
+	"financial-app/pkg/ledger"
+	"time"
+
+	"github.com/go-kit/kit/metrics"
+)
+
+type instrumentingService struct {
+	requestCount   metrics.Counter
+	requestLatency metrics.Histogram
+	next           ledger.Service
+}
+
+// NewInstrumentingService returns an instance of an instrumenting Service.
+func NewInstrumentingService(
+	counter metrics.Counter, latency metrics.Histogram, s ledger.Service,
+) ledger.Service {
+	return &instrumentingService{
+		requestCount:   counter,
+		requestLatency: latency,
+		next:           s,
+	}
+}
+
+func (s *instrumentingService) Entry(
+	ctx context.Context, transactionID string,
+) (entry ledger.JournalEntry, err error) {
+	defer func(begin time.Time) {
+		s.requestCount.With("method", "entry").Add(1)
+		s.requestLatency.With("method", "entry").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return s.next.Entry(ctx, transactionID)
+}
+
+func (s *instrumentingService) Verify(
+	ctx context.Context, accountID string,
+) (verification ledger.Verification, err error) {
+	defer func(begin time.Time) {
+		s.requestCount.With("method", "verify").Add(1)
+		s.requestLatency.With("method", "verify").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return s.next.Verify(ctx, accountID)
+}
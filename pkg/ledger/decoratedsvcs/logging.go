@@ -0,0 +1,48 @@
+package decoratedsvcs
+
+import (
+	"context"
+	"financial-app/pkg/ledger"
+	"time"
+
+	log "go.uber.org/zap"
+)
+
+type loggingService struct {
+	logger *log.SugaredLogger
+	next   ledger.Service
+}
+
+// NewLoggingService returns a new instance of a logging Service.
+func NewLoggingService(logger *log.SugaredLogger, s ledger.Service) ledger.Service {
+	return &loggingService{logger, s}
+}
+
+func (s *loggingService) Entry(
+	ctx context.Context, transactionID string,
+) (entry ledger.JournalEntry, err error) {
+	defer func(begin time.Time) {
+		s.logger.Infow(
+			"entry",
+			log.String("transaction_id", transactionID),
+			log.Duration("took", time.Since(begin)),
+			log.Error(err),
+		)
+	}(time.Now())
+	return s.next.Entry(ctx, transactionID)
+}
+
+func (s *loggingService) Verify(
+	ctx context.Context, accountID string,
+) (verification ledger.Verification, err error) {
+	defer func(begin time.Time) {
+		s.logger.Infow(
+			"verify",
+			log.String("account_id", accountID),
+			log.Bool("balanced", verification.Balanced),
+			log.Duration("took", time.Since(begin)),
+			log.Error(err),
+		)
+	}(time.Now())
+	return s.next.Verify(ctx, accountID)
+}
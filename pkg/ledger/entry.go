@@ -0,0 +1,142 @@
+package ledger
+
+import (
+	"financial-app/pkg/money"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// Kinds of journal entries
+const (
+	KindOpeningBalance = "opening_balance"
+	KindTransfer       = "transfer"
+	KindReversal       = "reversal"
+)
+
+// System accounts hold the counterpart of the postings that do not move
+// money between two customer accounts. They keep a balance per currency.
+const (
+	// OpeningBalanceAccount funds the initial balance of new accounts
+	OpeningBalanceAccount = "opening_balance"
+	// FXClearingAccount converts between the legs of cross-currency transfers
+	FXClearingAccount = "fx_clearing"
+)
+
+// Posting moves an amount in or out of a single account. Credits are positive
+// and increase the balance of the account, debits are negative.
+type Posting struct {
+	AccountID     string      `json:"account_id,omitempty"`
+	SystemAccount string      `json:"system_account,omitempty"`
+	Amount        money.Money `json:"amount"`
+	Currency      string      `json:"currency"`
+}
+
+// JournalEntry is an immutable record of balanced postings
+type JournalEntry struct {
+	ID            string    `json:"id"`
+	TransactionID string    `json:"transaction_id,omitempty"`
+	Kind          string    `json:"kind"`
+	Postings      []Posting `json:"postings"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// Validate returns an error unless every posting is valid and the postings
+// sum up to zero in every currency
+func (e JournalEntry) Validate() error {
+	if len(e.Postings) < 2 {
+		return ErrUnbalancedEntry
+	}
+
+	sums := make(map[string]money.Money)
+	for _, p := range e.Postings {
+		if (p.AccountID == "") == (p.SystemAccount == "") || p.Amount.IsZero() ||
+			p.Amount.Currency() != p.Currency {
+			return ErrInvalidPosting
+		}
+
+		sum, ok := sums[p.Currency]
+		if !ok {
+			sum = money.Zero(p.Currency)
+		}
+		sum, err := sum.Add(p.Amount)
+		if err != nil {
+			return err
+		}
+		sums[p.Currency] = sum
+	}
+
+	for _, sum := range sums {
+		if !sum.IsZero() {
+			return ErrUnbalancedEntry
+		}
+	}
+
+	return nil
+}
+
+// NewOpeningBalanceEntry returns the entry funding the initial balance of an
+// account from the opening balance system account
+func NewOpeningBalanceEntry(accountID string, balance money.Money) JournalEntry {
+	return JournalEntry{
+		ID:   nextEntryID(),
+		Kind: KindOpeningBalance,
+		Postings: []Posting{
+			systemPosting(OpeningBalanceAccount, balance.Neg()),
+			accountPosting(accountID, balance),
+		},
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+// NewTransferEntry returns the entry of a transfer debiting the source account
+// and crediting the target account. The legs of a cross-currency transfer are
+// balanced through the fx clearing system account.
+func NewTransferEntry(
+	transactionID, sourceAccountID, targetAccountID string,
+	amount, targetAmount money.Money,
+) JournalEntry {
+	postings := []Posting{accountPosting(sourceAccountID, amount.Neg())}
+	if amount.Currency() != targetAmount.Currency() {
+		postings = append(postings,
+			systemPosting(FXClearingAccount, amount),
+			systemPosting(FXClearingAccount, targetAmount.Neg()),
+		)
+	}
+	postings = append(postings, accountPosting(targetAccountID, targetAmount))
+
+	return JournalEntry{
+		ID:            nextEntryID(),
+		TransactionID: transactionID,
+		Kind:          KindTransfer,
+		Postings:      postings,
+		CreatedAt:     time.Now().UTC(),
+	}
+}
+
+// NewReversalEntry returns the entry of a transfer reversing another one, in
+// full or in part. Its source account is the target account of the transfer
+// it reverses.
+func NewReversalEntry(
+	transactionID, sourceAccountID, targetAccountID string,
+	amount, targetAmount money.Money,
+) JournalEntry {
+	entry := NewTransferEntry(
+		transactionID, sourceAccountID, targetAccountID, amount, targetAmount,
+	)
+	entry.Kind = KindReversal
+	return entry
+}
+
+func accountPosting(accountID string, amount money.Money) Posting {
+	return Posting{AccountID: accountID, Amount: amount, Currency: amount.Currency()}
+}
+
+func systemPosting(account string, amount money.Money) Posting {
+	return Posting{SystemAccount: account, Amount: amount, Currency: amount.Currency()}
+}
+
+// nextEntryID generates a new journal entry ID.
+func nextEntryID() string {
+	return uuid.NewV4().String()
+}
@@ -0,0 +1,133 @@
+package ledger
+
+import (
+	"financial-app/pkg/money"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTransferEntry(t *testing.T) {
+	testCases := []struct {
+		Name             string
+		Amount           money.Money
+		TargetAmount     money.Money
+		ExpectedPostings []Posting
+	}{
+		{
+			Name:         "Same Currency",
+			Amount:       money.MustParse("10.00", "EUR"),
+			TargetAmount: money.MustParse("10.00", "EUR"),
+			ExpectedPostings: []Posting{
+				{AccountID: "2222", Amount: money.MustParse("-10.00", "EUR"), Currency: "EUR"},
+				{AccountID: "3333", Amount: money.MustParse("10.00", "EUR"), Currency: "EUR"},
+			},
+		},
+		{
+			Name:         "Cross Currency",
+			Amount:       money.MustParse("10.00", "EUR"),
+			TargetAmount: money.MustParse("10.84", "USD"),
+			ExpectedPostings: []Posting{
+				{AccountID: "2222", Amount: money.MustParse("-10.00", "EUR"), Currency: "EUR"},
+				{SystemAccount: FXClearingAccount, Amount: money.MustParse("10.00", "EUR"), Currency: "EUR"},
+				{SystemAccount: FXClearingAccount, Amount: money.MustParse("-10.84", "USD"), Currency: "USD"},
+				{AccountID: "3333", Amount: money.MustParse("10.84", "USD"), Currency: "USD"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			entry := NewTransferEntry("1111", "2222", "3333", tc.Amount, tc.TargetAmount)
+
+			assert.NotEmpty(t, entry.ID)
+			assert.Equal(t, "1111", entry.TransactionID)
+			assert.Equal(t, KindTransfer, entry.Kind)
+			assert.Equal(t, tc.ExpectedPostings, entry.Postings)
+			assert.NoError(t, entry.Validate(), "Entry should be balanced")
+		})
+	}
+}
+
+func TestNewOpeningBalanceEntry(t *testing.T) {
+	entry := NewOpeningBalanceEntry("2222", money.MustParse("100.00", "EUR"))
+
+	assert.Equal(t, KindOpeningBalance, entry.Kind)
+	assert.Equal(t, []Posting{
+		{SystemAccount: OpeningBalanceAccount, Amount: money.MustParse("-100.00", "EUR"), Currency: "EUR"},
+		{AccountID: "2222", Amount: money.MustParse("100.00", "EUR"), Currency: "EUR"},
+	}, entry.Postings)
+	assert.NoError(t, entry.Validate(), "Entry should be balanced")
+}
+
+func TestNewReversalEntry(t *testing.T) {
+	entry := NewReversalEntry(
+		"4444", "3333", "2222", money.MustParse("5.42", "USD"), money.MustParse("5.00", "EUR"),
+	)
+
+	assert.Equal(t, "4444", entry.TransactionID)
+	assert.Equal(t, KindReversal, entry.Kind)
+	assert.Equal(t, []Posting{
+		{AccountID: "3333", Amount: money.MustParse("-5.42", "USD"), Currency: "USD"},
+		{SystemAccount: FXClearingAccount, Amount: money.MustParse("5.42", "USD"), Currency: "USD"},
+		{SystemAccount: FXClearingAccount, Amount: money.MustParse("-5.00", "EUR"), Currency: "EUR"},
+		{AccountID: "2222", Amount: money.MustParse("5.00", "EUR"), Currency: "EUR"},
+	}, entry.Postings)
+	assert.NoError(t, entry.Validate(), "Entry should be balanced")
+}
+
+func TestJournalEntry_Validate(t *testing.T) {
+	testCases := []struct {
+		Name          string
+		Postings      []Posting
+		ExpectedError error
+	}{
+		{
+			Name: "Unbalanced",
+			Postings: []Posting{
+				{AccountID: "2222", Amount: money.MustParse("-10.00", "EUR"), Currency: "EUR"},
+				{AccountID: "3333", Amount: money.MustParse("9.99", "EUR"), Currency: "EUR"},
+			},
+			ExpectedError: ErrUnbalancedEntry,
+		},
+		{
+			Name: "Unbalanced Currency",
+			Postings: []Posting{
+				{AccountID: "2222", Amount: money.MustParse("-10.00", "EUR"), Currency: "EUR"},
+				{AccountID: "3333", Amount: money.MustParse("10.00", "USD"), Currency: "USD"},
+			},
+			ExpectedError: ErrUnbalancedEntry,
+		},
+		{
+			Name: "Single Posting",
+			Postings: []Posting{
+				{AccountID: "2222", Amount: money.MustParse("10.00", "EUR"), Currency: "EUR"},
+			},
+			ExpectedError: ErrUnbalancedEntry,
+		},
+		{
+			Name: "Zero Amount",
+			Postings: []Posting{
+				{AccountID: "2222", Amount: money.Zero("EUR"), Currency: "EUR"},
+				{AccountID: "3333", Amount: money.Zero("EUR"), Currency: "EUR"},
+			},
+			ExpectedError: ErrInvalidPosting,
+		},
+		{
+			Name: "Missing Account",
+			Postings: []Posting{
+				{Amount: money.MustParse("-10.00", "EUR"), Currency: "EUR"},
+				{AccountID: "3333", Amount: money.MustParse("10.00", "EUR"), Currency: "EUR"},
+			},
+			ExpectedError: ErrInvalidPosting,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			entry := JournalEntry{ID: "1111", Kind: KindTransfer, Postings: tc.Postings}
+
+			assert.Equal(t, tc.ExpectedError, entry.Validate())
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package ledger
+
+import "financial-app/pkg/errs"
+
+// ErrUnbalancedEntry is used when the postings of a journal entry do not sum
+// up to zero in every currency
+var ErrUnbalancedEntry = errs.New(errs.ErrInternal, "unbalanced_entry", "journal entry is not balanced")
+
+// ErrInvalidPosting is used when a posting has no account or a zero amount
+var ErrInvalidPosting = errs.New(errs.ErrInternal, "invalid_posting",
+	"posting needs an account and a non-zero amount")
+
+// ErrPostingEntry is used when a journal entry could not be created
+func ErrPostingEntry(id string) *errs.Error {
+	return errs.New(errs.ErrInternal, "posting_entry_failed", "could not create a new journal entry by ID "+id)
+}
+
+// ErrEntryNotFound is used when a transaction has no journal entry
+var ErrEntryNotFound = errs.New(errs.ErrNotFound, "entry_not_found", "journal entry not found")
+
+// ErrFetchingEntry is used when the journal entry of a transaction could not
+// be found. It matches ErrEntryNotFound.
+func ErrFetchingEntry(transactionID string) *errs.Error {
+	return ErrEntryNotFound.WithMessage("could not fetch journal entry by transaction ID " + transactionID)
+}
+
+// ErrQueryingEntry is used when the journal entry of a transaction could not
+// be queried
+func ErrQueryingEntry(transactionID string) *errs.Error {
+	return errs.New(errs.ErrInternal, "querying_entry_failed",
+		"could not query journal entry by transaction ID "+transactionID)
+}
+
+// ErrFetchingBalance is used when the posted balance of an account could not be fetched
+func ErrFetchingBalance(accountID string) *errs.Error {
+	return errs.New(errs.ErrInternal, "fetching_balance_failed",
+		"could not fetch the posted balance by account ID "+accountID)
+}
@@ -0,0 +1,69 @@
+package ledger
+
+import (
+	"financial-app/pkg/errs"
+	"financial-app/pkg/http/rest/httperr"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+var (
+	accountIDRequired     = errs.New(errs.ErrInvalid, "account_id_required", "account id required")
+	transactionIDRequired = errs.New(errs.ErrInvalid, "transaction_id_required", "transaction id required")
+)
+
+type LedgerHandler struct {
+	Service Service
+
+	Logger *zap.SugaredLogger
+}
+
+// Router sets up all the routes for ledger service
+func (h *LedgerHandler) Router(routerGroup *gin.RouterGroup) {
+	routerGroup.GET("ledger/transactions/:id", h.entry)
+	routerGroup.GET("ledger/accounts/:id/verification", h.verify)
+}
+
+// entry retrieves the journal entry of a transaction
+func (h *LedgerHandler) entry(context *gin.Context) {
+	id := context.Param("id")
+	if id == "" {
+		h.Logger.Error("no transaction id found")
+
+		httperr.Respond(context, transactionIDRequired)
+		return
+	}
+
+	entry, err := h.Service.Entry(context, id)
+	if err != nil {
+		h.Logger.Error(err)
+
+		httperr.Respond(context, err)
+		return
+	}
+
+	context.JSON(http.StatusOK, entry)
+}
+
+// verify checks the balance of an account against its postings
+func (h *LedgerHandler) verify(context *gin.Context) {
+	id := context.Param("id")
+	if id == "" {
+		h.Logger.Error("no account id found")
+
+		httperr.Respond(context, accountIDRequired)
+		return
+	}
+
+	verification, err := h.Service.Verify(context, id)
+	if err != nil {
+		h.Logger.Error(err)
+
+		httperr.Respond(context, err)
+		return
+	}
+
+	context.JSON(http.StatusOK, verification)
+}
@@ -0,0 +1,94 @@
+package ledger
+
+import (
+	"context"
+	"encoding/json"
+	"financial-app/pkg/accounts"
+	"financial-app/pkg/money"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+type MockService struct {
+	mock.Mock
+}
+
+func (m *MockService) Entry(ctx context.Context, transactionID string) (JournalEntry, error) {
+	args := m.Called(ctx, transactionID)
+	return args.Get(0).(JournalEntry), args.Error(1)
+}
+
+func (m *MockService) Verify(ctx context.Context, accountID string) (Verification, error) {
+	args := m.Called(ctx, accountID)
+	return args.Get(0).(Verification), args.Error(1)
+}
+
+func TestLedgerHandler_Entry(t *testing.T) {
+	mockService := new(MockService)
+	logger, _ := zap.NewDevelopment()
+	handler := &LedgerHandler{Service: mockService, Logger: logger.Sugar()}
+
+	r := gin.Default()
+	r.GET("/ledger/transactions/:id", handler.entry)
+
+	entry := NewTransferEntry(
+		"1111", "2222", "3333", money.MustParse("10.00", "EUR"), money.MustParse("10.00", "EUR"),
+	)
+	mockService.On("Entry", mock.Anything, "1111").Return(entry, nil)
+	mockService.On("Entry", mock.Anything, "9999").
+		Return(JournalEntry{}, ErrFetchingEntry("9999"))
+
+	req, _ := http.NewRequest("GET", "/ledger/transactions/1111", nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	expected, _ := json.Marshal(entry)
+	assert.JSONEq(t, string(expected), rr.Body.String())
+
+	req, _ = http.NewRequest("GET", "/ledger/transactions/9999", nil)
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestLedgerHandler_Verify(t *testing.T) {
+	mockService := new(MockService)
+	logger, _ := zap.NewDevelopment()
+	handler := &LedgerHandler{Service: mockService, Logger: logger.Sugar()}
+
+	r := gin.Default()
+	r.GET("/ledger/accounts/:id/verification", handler.verify)
+
+	verification := Verification{
+		AccountID:     "2222",
+		Balance:       money.MustParse("90.00", "EUR"),
+		PostedBalance: money.MustParse("90.00", "EUR"),
+		Balanced:      true,
+	}
+	mockService.On("Verify", mock.Anything, "2222").Return(verification, nil)
+	mockService.On("Verify", mock.Anything, "9999").
+		Return(Verification{}, accounts.ErrFetchingAccount("9999"))
+
+	req, _ := http.NewRequest("GET", "/ledger/accounts/2222/verification", nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t,
+		`{"account_id":"2222","balance":"90.00","posted_balance":"90.00","balanced":true}`,
+		rr.Body.String())
+
+	req, _ = http.NewRequest("GET", "/ledger/accounts/9999/verification", nil)
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
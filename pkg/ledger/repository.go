@@ -0,0 +1,14 @@
+package ledger
+
+import (
+	"context"
+	"financial-app/pkg/money"
+)
+
+// Repository provides access a journal entry store. Entries are written by
+// the account and transaction repositories along with the balances.
+type Repository interface {
+	FindByTransaction(ctx context.Context, transactionID string) (*JournalEntry, error)
+	// PostedBalance sums up the postings of an account in its currency
+	PostedBalance(ctx context.Context, accountID, currency string) (money.Money, error)
+}
@@ -0,0 +1,112 @@
+package ledger
+
+import (
+	"context"
+	"financial-app/pkg/accounts"
+	"financial-app/pkg/auth"
+	"financial-app/pkg/money"
+)
+
+// Verification compares the balance of an account with the sum of its postings
+type Verification struct {
+	AccountID     string      `json:"account_id"`
+	Balance       money.Money `json:"balance"`
+	PostedBalance money.Money `json:"posted_balance"`
+	Balanced      bool        `json:"balanced"`
+}
+
+// Service is the interface that provides ledger methods
+type Service interface {
+	// Entry returns the journal entry of a transaction
+	Entry(ctx context.Context, transactionID string) (JournalEntry, error)
+
+	// Verify checks the balance of an account against its postings
+	Verify(ctx context.Context, accountID string) (Verification, error)
+}
+
+func (s *service) Entry(
+	ctx context.Context, transactionID string,
+) (JournalEntry, error) {
+	entry, err := s.entries.FindByTransaction(ctx, transactionID)
+	if err != nil {
+		return JournalEntry{}, err
+	}
+	if err := s.checkView(ctx, entry); err != nil {
+		return JournalEntry{}, err
+	}
+	return *entry, nil
+}
+
+// checkView returns an error unless the caller of the request may view one
+// of the customer accounts an entry posts to
+func (s *service) checkView(ctx context.Context, entry *JournalEntry) error {
+	if key, ok := auth.FromContext(ctx); !ok || key.CustomerID == "" {
+		return nil
+	}
+
+	var denied error
+	for _, p := range entry.Postings {
+		if p.AccountID == "" {
+			continue
+		}
+		acct, err := s.accounts.Find(ctx, p.AccountID)
+		if err != nil {
+			return err
+		}
+		err = accounts.CheckRole(ctx, s.roles, acct, accounts.RoleView)
+		if err == nil {
+			return nil
+		}
+		if denied == nil {
+			denied = err
+		}
+	}
+	// The entries posting to system accounts only belong to the operator
+	if denied == nil {
+		denied = ErrFetchingEntry(entry.TransactionID)
+	}
+	return denied
+}
+
+func (s *service) Verify(
+	ctx context.Context, accountID string,
+) (Verification, error) {
+	acct, err := s.accounts.Find(ctx, accountID)
+	if err != nil {
+		return Verification{}, err
+	}
+	if err := accounts.CheckRole(ctx, s.roles, acct, accounts.RoleView); err != nil {
+		return Verification{}, err
+	}
+
+	posted, err := s.entries.PostedBalance(ctx, acct.ID, acct.Currency)
+	if err != nil {
+		return Verification{}, err
+	}
+
+	return Verification{
+		AccountID:     acct.ID,
+		Balance:       acct.Balance,
+		PostedBalance: posted,
+		Balanced:      acct.Balance == posted,
+	}, nil
+}
+
+type service struct {
+	accounts accounts.AccountRepository
+	roles    accounts.RoleRepository
+	entries  Repository
+}
+
+// NewService creates a ledger service with necessary dependencies
+func NewService(
+	accounts accounts.AccountRepository,
+	roles accounts.RoleRepository,
+	entries Repository,
+) Service {
+	return &service{
+		accounts: accounts,
+		roles:    roles,
+		entries:  entries,
+	}
+}
@@ -0,0 +1,203 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"financial-app/pkg/accounts"
+	"financial-app/pkg/auth"
+	"financial-app/pkg/money"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockAccountRepository struct {
+	Accounts map[string]*accounts.Account
+}
+
+func (m *mockAccountRepository) Find(
+	ctx context.Context, id string,
+) (*accounts.Account, error) {
+	if acct, ok := m.Accounts[id]; ok {
+		return acct, nil
+	}
+	return nil, accounts.ErrFetchingAccount(id)
+}
+
+func (m *mockAccountRepository) FindByIDs(
+	ctx context.Context, ids []string,
+) (map[string]*accounts.Account, error) {
+	return nil, nil
+}
+
+func (m *mockAccountRepository) Store(
+	ctx context.Context, acct *accounts.Account,
+) (*accounts.Account, error) {
+	return acct, nil
+}
+
+func (m *mockAccountRepository) Query(
+	ctx context.Context, q accounts.Query,
+) ([]*accounts.Account, error) {
+	return nil, nil
+}
+
+func (m *mockAccountRepository) UpdateStatus(
+	ctx context.Context, id string, change accounts.StatusChange,
+) (*accounts.Account, error) {
+	return nil, nil
+}
+
+func (m *mockAccountRepository) BalanceAsOf(
+	ctx context.Context, id string, asOf time.Time,
+) (money.Money, error) {
+	if acct, ok := m.Accounts[id]; ok {
+		return acct.Balance, nil
+	}
+	return money.Money{}, accounts.ErrFetchingAccount(id)
+}
+
+func (m *mockAccountRepository) SnapshotBalances(
+	ctx context.Context, asOf time.Time,
+) (int64, error) {
+	return int64(len(m.Accounts)), nil
+}
+
+type mockRepository struct {
+	Entries []JournalEntry
+}
+
+func (m *mockRepository) FindByTransaction(
+	ctx context.Context, transactionID string,
+) (*JournalEntry, error) {
+	for _, e := range m.Entries {
+		if e.TransactionID == transactionID {
+			return &e, nil
+		}
+	}
+	return nil, ErrFetchingEntry(transactionID)
+}
+
+func (m *mockRepository) PostedBalance(
+	ctx context.Context, accountID, currency string,
+) (money.Money, error) {
+	sum := money.Zero(currency)
+	for _, e := range m.Entries {
+		for _, p := range e.Postings {
+			if p.AccountID == accountID && p.Currency == currency {
+				sum, _ = sum.Add(p.Amount)
+			}
+		}
+	}
+	return sum, nil
+}
+
+func TestService_Entry(t *testing.T) {
+	entry := NewTransferEntry(
+		"1111", "2222", "3333", money.MustParse("10.00", "EUR"), money.MustParse("10.00", "EUR"),
+	)
+	service := NewService(nil, nil, &mockRepository{Entries: []JournalEntry{entry}})
+
+	found, err := service.Entry(context.Background(), "1111")
+	assert.NoError(t, err)
+	assert.Equal(t, entry, found)
+
+	_, err = service.Entry(context.Background(), "9999")
+	assert.Equal(t, ErrFetchingEntry("9999"), err)
+}
+
+func TestService_Verify(t *testing.T) {
+	mockRepository := &mockRepository{Entries: []JournalEntry{
+		NewOpeningBalanceEntry("2222", money.MustParse("100.00", "EUR")),
+		NewTransferEntry(
+			"1111", "2222", "3333", money.MustParse("10.00", "EUR"), money.MustParse("10.00", "EUR"),
+		),
+	}}
+
+	testCases := []struct {
+		Name             string
+		Balance          string
+		ExpectedBalanced bool
+	}{
+		{Name: "Balanced", Balance: "90.00", ExpectedBalanced: true},
+		{Name: "Drifted", Balance: "95.00", ExpectedBalanced: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			mockAccountRepository := &mockAccountRepository{
+				Accounts: map[string]*accounts.Account{
+					"2222": {ID: "2222", Balance: money.MustParse(tc.Balance, "EUR"), Currency: "EUR"},
+				},
+			}
+			service := NewService(mockAccountRepository, nil, mockRepository)
+
+			verification, err := service.Verify(context.Background(), "2222")
+
+			assert.NoError(t, err)
+			assert.Equal(t, money.MustParse("90.00", "EUR"), verification.PostedBalance)
+			assert.Equal(t, tc.ExpectedBalanced, verification.Balanced)
+		})
+	}
+}
+
+// mockRoleRepository only finds the roles of the customers on the accounts
+type mockRoleRepository struct {
+	accounts.RoleRepository
+	// Roles are keyed by account and customer IDs
+	Roles map[string]accounts.Role
+}
+
+func (m *mockRoleRepository) Find(
+	ctx context.Context, accountID, customerID string,
+) (*accounts.Grant, error) {
+	role, ok := m.Roles[accountID+"/"+customerID]
+	if !ok {
+		return nil, accounts.ErrFetchingRole(accountID, customerID)
+	}
+	return &accounts.Grant{AccountID: accountID, CustomerID: customerID, Role: role}, nil
+}
+
+func TestService_Ownership(t *testing.T) {
+	ownerID := "5d7e6e6c-52b4-4a3e-9d1c-3f7b1d2c9a10"
+	viewerID := "a7c6a8f5-3b8e-4a55-8b5b-7a2d0f0b9e21"
+	otherID := "0c1b5a7e-9d3f-4e2a-8b6c-5f4d3e2a1b09"
+
+	mockAccountRepository := &mockAccountRepository{
+		Accounts: map[string]*accounts.Account{
+			"2222": {ID: "2222", Balance: money.MustParse("90.00", "EUR"), Currency: "EUR",
+				CustomerID: ownerID},
+			"3333": {ID: "3333", Balance: money.MustParse("10.00", "EUR"), Currency: "EUR"},
+		},
+	}
+	mockRepository := &mockRepository{Entries: []JournalEntry{
+		NewOpeningBalanceEntry("2222", money.MustParse("100.00", "EUR")),
+		NewTransferEntry(
+			"1111", "2222", "3333", money.MustParse("10.00", "EUR"), money.MustParse("10.00", "EUR"),
+		),
+	}}
+	mockRoleRepository := &mockRoleRepository{Roles: map[string]accounts.Role{
+		"3333/" + viewerID: accounts.RoleView,
+	}}
+	service := NewService(mockAccountRepository, mockRoleRepository, mockRepository)
+
+	// A customer reads the entries posting to an account it may view
+	for _, customerID := range []string{ownerID, viewerID} {
+		ctx := auth.NewContext(context.Background(), auth.Key{ID: "key", CustomerID: customerID})
+		_, err := service.Entry(ctx, "1111")
+		assert.NoError(t, err)
+	}
+
+	other := auth.NewContext(context.Background(), auth.Key{ID: "key", CustomerID: otherID})
+	_, err := service.Entry(other, "1111")
+	assert.True(t, errors.Is(err, accounts.ErrNotAccountOwner))
+	_, err = service.Verify(other, "2222")
+	assert.True(t, errors.Is(err, accounts.ErrNotAccountOwner))
+
+	viewer := auth.NewContext(context.Background(), auth.Key{ID: "key", CustomerID: viewerID})
+	_, err = service.Verify(viewer, "2222")
+	assert.True(t, errors.Is(err, accounts.ErrNotAccountOwner))
+	_, err = service.Verify(viewer, "3333")
+	assert.NoError(t, err)
+}
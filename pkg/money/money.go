@@ -0,0 +1,284 @@
+package money
+
+import (
+	"database/sql/driver"
+	"financial-app/pkg/currency"
+	"financial-app/pkg/errs"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// ErrCurrencyMismatch is used when two amounts of different currencies are combined
+var ErrCurrencyMismatch = errs.New(errs.ErrUnprocessable, "amount_currency_mismatch",
+	"money amounts have different currencies")
+
+// ErrInvalidAmount is used when an amount could not be parsed as a decimal
+var ErrInvalidAmount = errs.New(errs.ErrInvalid, "invalid_amount", "amount is not a valid decimal")
+
+// ErrTooManyDecimals is used when an amount has more decimals than its currency allows
+var ErrTooManyDecimals = errs.New(errs.ErrInvalid, "too_many_decimals",
+	"amount has more decimals than the currency allows")
+
+// ErrOverflow is used when an amount does not fit in the supported range
+var ErrOverflow = errs.New(errs.ErrUnprocessable, "amount_out_of_range", "amount is out of range")
+
+// Money is an exact monetary amount expressed in the minor units of its currency
+type Money struct {
+	amount   int64
+	currency string
+}
+
+// New returns an amount of the given minor units (e.g. cents) of a currency
+func New(minor int64, currency string) Money {
+	return Money{amount: minor, currency: currency}
+}
+
+// Zero returns a zero amount of the given currency
+func Zero(currency string) Money {
+	return Money{currency: currency}
+}
+
+// Parse converts a decimal string such as "10.50" to an amount of the given currency
+func Parse(s, currency string) (Money, error) {
+	exp := exponent(currency)
+
+	s = strings.TrimSpace(s)
+	neg := false
+	switch {
+	case strings.HasPrefix(s, "-"):
+		neg = true
+		s = s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+
+	intPart, fracPart := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		intPart, fracPart = s[:i], s[i+1:]
+	}
+	if intPart == "" && fracPart == "" || !isDigits(intPart) || !isDigits(fracPart) {
+		return Money{}, ErrInvalidAmount
+	}
+
+	// Trailing zeros beyond the currency precision do not change the value
+	if len(fracPart) > exp {
+		if strings.TrimRight(fracPart[exp:], "0") != "" {
+			return Money{}, ErrTooManyDecimals
+		}
+		fracPart = fracPart[:exp]
+	}
+	fracPart += strings.Repeat("0", exp-len(fracPart))
+
+	digits := strings.TrimLeft(intPart+fracPart, "0")
+	if digits == "" {
+		return Zero(currency), nil
+	}
+	amount, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return Money{}, ErrOverflow
+	}
+	if neg {
+		amount = -amount
+	}
+
+	return New(amount, currency), nil
+}
+
+// MustParse is like Parse but panics if the amount is invalid
+func MustParse(s, currency string) Money {
+	m, err := Parse(s, currency)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// Amount returns the amount in minor units
+func (m Money) Amount() int64 {
+	return m.amount
+}
+
+// Currency returns the ISO currency code of the amount
+func (m Money) Currency() string {
+	return m.currency
+}
+
+// IsZero returns true if the amount is zero
+func (m Money) IsZero() bool {
+	return m.amount == 0
+}
+
+// IsPositive returns true if the amount is greater than zero
+func (m Money) IsPositive() bool {
+	return m.amount > 0
+}
+
+// IsNegative returns true if the amount is less than zero
+func (m Money) IsNegative() bool {
+	return m.amount < 0
+}
+
+// Neg returns the amount with its sign inverted
+func (m Money) Neg() Money {
+	return New(-m.amount, m.currency)
+}
+
+// Add returns the sum of two amounts of the same currency
+func (m Money) Add(o Money) (Money, error) {
+	if m.currency != o.currency {
+		return Money{}, ErrCurrencyMismatch
+	}
+	if (o.amount > 0 && m.amount > math.MaxInt64-o.amount) ||
+		(o.amount < 0 && m.amount < math.MinInt64-o.amount) {
+		return Money{}, ErrOverflow
+	}
+	return New(m.amount+o.amount, m.currency), nil
+}
+
+// Sub returns the difference of two amounts of the same currency
+func (m Money) Sub(o Money) (Money, error) {
+	if o.amount == math.MinInt64 {
+		return Money{}, ErrOverflow
+	}
+	return m.Add(o.Neg())
+}
+
+// Cmp compares two amounts of the same currency and returns -1, 0 or +1
+func (m Money) Cmp(o Money) (int, error) {
+	if m.currency != o.currency {
+		return 0, ErrCurrencyMismatch
+	}
+	switch {
+	case m.amount < o.amount:
+		return -1, nil
+	case m.amount > o.amount:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// Convert returns the amount in another currency at the given exchange rate,
+// rounded half away from zero to the minor units of that currency
+func (m Money) Convert(rate *big.Rat, currency string) (Money, error) {
+	r := new(big.Rat).Mul(new(big.Rat).SetInt64(m.amount), rate)
+
+	// Scale between the minor units of both currencies
+	shift := exponent(currency) - exponent(m.currency)
+	scale := new(big.Rat).SetInt(pow10(shift))
+	if shift < 0 {
+		scale = new(big.Rat).SetInt(pow10(-shift))
+		scale.Inv(scale)
+	}
+	r.Mul(r, scale)
+
+	amount, err := round(r)
+	if err != nil {
+		return Money{}, err
+	}
+	return New(amount, currency), nil
+}
+
+// Prorate returns the share of the amount in proportion of part to whole,
+// rounded half away from zero. Part and whole must have the same currency.
+func (m Money) Prorate(part, whole Money) (Money, error) {
+	if part.currency != whole.currency {
+		return Money{}, ErrCurrencyMismatch
+	}
+	if whole.amount == 0 {
+		return Money{}, ErrInvalidAmount
+	}
+
+	r := new(big.Rat).SetFrac(
+		new(big.Int).Mul(big.NewInt(m.amount), big.NewInt(part.amount)),
+		big.NewInt(whole.amount),
+	)
+	amount, err := round(r)
+	if err != nil {
+		return Money{}, err
+	}
+	return New(amount, m.currency), nil
+}
+
+// String returns the amount as a decimal string such as "-10.50"
+func (m Money) String() string {
+	exp := exponent(m.currency)
+
+	// Format the absolute value through uint64 so that MinInt64 is handled
+	abs := uint64(m.amount)
+	if m.amount < 0 {
+		abs = uint64(-(m.amount + 1)) + 1
+	}
+	digits := strconv.FormatUint(abs, 10)
+	if len(digits) <= exp {
+		digits = strings.Repeat("0", exp-len(digits)+1) + digits
+	}
+
+	s := digits
+	if exp > 0 {
+		s = digits[:len(digits)-exp] + "." + digits[len(digits)-exp:]
+	}
+	if m.amount < 0 {
+		s = "-" + s
+	}
+	return s
+}
+
+// MarshalJSON encodes the amount as a decimal string
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(m.String())), nil
+}
+
+// UnmarshalJSON decodes a decimal string or number. The currency of the
+// receiver, if any, is kept and determines the allowed precision.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+
+	parsed, err := Parse(s, m.currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Value writes the amount to NUMERIC columns as an exact decimal string
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+// exponent returns the number of minor unit digits of a currency
+func exponent(code string) int {
+	return currency.MinorUnits(code)
+}
+
+// round rounds a number of minor units half away from zero
+func round(r *big.Rat) (int64, error) {
+	q, rem := new(big.Int).QuoRem(r.Num(), r.Denom(), new(big.Int))
+	if rem.Sign() != 0 && new(big.Int).Lsh(new(big.Int).Abs(rem), 1).Cmp(r.Denom()) >= 0 {
+		q.Add(q, big.NewInt(int64(r.Sign())))
+	}
+	if !q.IsInt64() {
+		return 0, ErrOverflow
+	}
+	return q.Int64(), nil
+}
+
+// pow10 returns 10 to the power of a non-negative exponent
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// isDigits returns true if the string only contains ASCII digits
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
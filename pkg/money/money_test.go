@@ -0,0 +1,193 @@
+package money
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		Name          string
+		Input         string
+		Expected      Money
+		ExpectedError error
+	}{
+		{Name: "Integer", Input: "100", Expected: New(10000, "USD")},
+		{Name: "Two Decimals", Input: "10.50", Expected: New(1050, "USD")},
+		{Name: "One Decimal", Input: "11.5", Expected: New(1150, "USD")},
+		{Name: "Leading Dot", Input: ".05", Expected: New(5, "USD")},
+		{Name: "Negative", Input: "-0.01", Expected: New(-1, "USD")},
+		{Name: "Trailing Zeros", Input: "1.2300", Expected: New(123, "USD")},
+		{Name: "Zero", Input: "0.00", Expected: Zero("USD")},
+		{Name: "Too Many Decimals", Input: "1.001", ExpectedError: ErrTooManyDecimals},
+		{Name: "Empty", Input: "", ExpectedError: ErrInvalidAmount},
+		{Name: "Not A Number", Input: "1e3", ExpectedError: ErrInvalidAmount},
+		{Name: "Overflow", Input: "100000000000000000000", ExpectedError: ErrOverflow},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			m, err := Parse(tc.Input, "USD")
+
+			assert.Equal(t, tc.ExpectedError, err)
+			if tc.ExpectedError == nil {
+				assert.Equal(t, tc.Expected, m)
+			}
+		})
+	}
+}
+
+func TestParse_MinorUnits(t *testing.T) {
+	testCases := []struct {
+		Name          string
+		Input         string
+		Currency      string
+		Expected      Money
+		ExpectedError error
+	}{
+		{Name: "JPY Integer", Input: "150", Currency: "JPY", Expected: New(150, "JPY")},
+		{Name: "JPY Trailing Zeros", Input: "150.00", Currency: "JPY", Expected: New(150, "JPY")},
+		{Name: "JPY Decimals", Input: "150.5", Currency: "JPY", ExpectedError: ErrTooManyDecimals},
+		{Name: "KWD Three Decimals", Input: "1.234", Currency: "KWD", Expected: New(1234, "KWD")},
+		{Name: "KWD Four Decimals", Input: "1.2345", Currency: "KWD", ExpectedError: ErrTooManyDecimals},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			m, err := Parse(tc.Input, tc.Currency)
+
+			assert.Equal(t, tc.ExpectedError, err)
+			if tc.ExpectedError == nil {
+				assert.Equal(t, tc.Expected, m)
+			}
+		})
+	}
+}
+
+func TestMoney_String(t *testing.T) {
+	assert.Equal(t, "10.50", New(1050, "EUR").String())
+	assert.Equal(t, "0.05", New(5, "EUR").String())
+	assert.Equal(t, "-0.05", New(-5, "EUR").String())
+	assert.Equal(t, "0.00", Zero("EUR").String())
+	assert.Equal(t, "-92233720368547758.08", New(math.MinInt64, "EUR").String())
+	assert.Equal(t, "150", New(150, "JPY").String())
+	assert.Equal(t, "1.234", New(1234, "KWD").String())
+}
+
+func TestMoney_Arithmetic(t *testing.T) {
+	a := New(1050, "EUR")
+	b := New(25, "EUR")
+
+	sum, err := a.Add(b)
+	assert.NoError(t, err)
+	assert.Equal(t, New(1075, "EUR"), sum)
+
+	diff, err := b.Sub(a)
+	assert.NoError(t, err)
+	assert.Equal(t, New(-1025, "EUR"), diff)
+	assert.True(t, diff.IsNegative())
+
+	cmp, err := a.Cmp(b)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, cmp)
+
+	_, err = New(math.MaxInt64, "EUR").Add(New(1, "EUR"))
+	assert.Equal(t, ErrOverflow, err)
+}
+
+func TestMoney_CurrencyMismatch(t *testing.T) {
+	eur := New(100, "EUR")
+	usd := New(100, "USD")
+
+	_, err := eur.Add(usd)
+	assert.Equal(t, ErrCurrencyMismatch, err)
+
+	_, err = eur.Sub(usd)
+	assert.Equal(t, ErrCurrencyMismatch, err)
+
+	_, err = eur.Cmp(usd)
+	assert.Equal(t, ErrCurrencyMismatch, err)
+}
+
+func TestMoney_JSON(t *testing.T) {
+	data, err := json.Marshal(New(1050, "EUR"))
+	assert.NoError(t, err)
+	assert.Equal(t, `"10.50"`, string(data))
+
+	m := Zero("EUR")
+	assert.NoError(t, json.Unmarshal([]byte(`"10.50"`), &m))
+	assert.Equal(t, New(1050, "EUR"), m)
+
+	m = Zero("EUR")
+	assert.NoError(t, json.Unmarshal([]byte(`11.5`), &m))
+	assert.Equal(t, New(1150, "EUR"), m)
+
+	assert.Error(t, json.Unmarshal([]byte(`"ten"`), &m))
+}
+
+func TestMoney_Value(t *testing.T) {
+	v, err := New(-1050, "USD").Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "-10.50", v)
+}
+
+func TestMoney_Convert(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Amount   Money
+		Rate     *big.Rat
+		Expected Money
+	}{
+		{Name: "Exact", Amount: New(10000, "EUR"), Rate: big.NewRat(10842, 10000),
+			Expected: New(10842, "USD")},
+		{Name: "Round Half Up", Amount: New(5, "EUR"), Rate: big.NewRat(11, 10),
+			Expected: New(6, "USD")},
+		{Name: "Round Down", Amount: New(4, "EUR"), Rate: big.NewRat(11, 10),
+			Expected: New(4, "USD")},
+		{Name: "Negative", Amount: New(-5, "EUR"), Rate: big.NewRat(11, 10),
+			Expected: New(-6, "USD")},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			converted, err := tc.Amount.Convert(tc.Rate, "USD")
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.Expected, converted)
+		})
+	}
+}
+
+func TestMoney_ConvertMinorUnits(t *testing.T) {
+	// 100.00 EUR at 157.25 JPY per EUR
+	converted, err := New(10000, "EUR").Convert(big.NewRat(15725, 100), "JPY")
+	assert.NoError(t, err)
+	assert.Equal(t, New(15725, "JPY"), converted)
+
+	// 1000 JPY at 0.0021 KWD per JPY
+	converted, err = New(1000, "JPY").Convert(big.NewRat(21, 10000), "KWD")
+	assert.NoError(t, err)
+	assert.Equal(t, New(2100, "KWD"), converted)
+}
+
+func TestMoney_Prorate(t *testing.T) {
+	// A third of 108.42 USD received for 100.00 EUR
+	share, err := New(10842, "USD").Prorate(New(3333, "EUR"), New(10000, "EUR"))
+	assert.NoError(t, err)
+	assert.Equal(t, New(3614, "USD"), share)
+
+	// Half a cent is rounded away from zero
+	share, err = New(5, "JPY").Prorate(New(1, "EUR"), New(2, "EUR"))
+	assert.NoError(t, err)
+	assert.Equal(t, New(3, "JPY"), share)
+
+	_, err = New(5, "JPY").Prorate(New(1, "EUR"), New(2, "USD"))
+	assert.Equal(t, ErrCurrencyMismatch, err)
+
+	_, err = New(5, "JPY").Prorate(New(1, "EUR"), Zero("EUR"))
+	assert.Equal(t, ErrInvalidAmount, err)
+}
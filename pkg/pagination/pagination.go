@@ -0,0 +1,63 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"financial-app/pkg/errs"
+	"strconv"
+)
+
+const (
+	// DefaultLimit is the page size used when none is requested
+	DefaultLimit = 50
+	// MaxLimit is the largest page size that can be requested
+	MaxLimit = 500
+)
+
+// ErrInvalidCursor is used when a cursor was not issued by the API
+var ErrInvalidCursor = errs.New(errs.ErrInvalid, "invalid_cursor", "cursor is not valid")
+
+// ErrInvalidLimit is used when a page size is not between 1 and MaxLimit
+var ErrInvalidLimit = errs.New(errs.ErrInvalid, "invalid_limit",
+	"limit must be between 1 and "+strconv.Itoa(MaxLimit))
+
+// Cursor points right after the last item of a page in a listing ordered by
+// a sort key and then by ID, so that the next page continues from there even
+// when items are added in the meantime
+type Cursor struct {
+	Key string `json:"k"`
+	ID  string `json:"id"`
+}
+
+// Encode returns the opaque form of the cursor handed out to clients
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Decode parses a cursor returned by Encode
+func Decode(s string) (Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == "" {
+		return Cursor{}, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// ParseLimit parses a requested page size, which defaults to DefaultLimit
+func ParseLimit(s string) (int, error) {
+	if s == "" {
+		return DefaultLimit, nil
+	}
+
+	limit, err := strconv.Atoi(s)
+	if err != nil || limit < 1 || limit > MaxLimit {
+		return 0, ErrInvalidLimit
+	}
+	return limit, nil
+}
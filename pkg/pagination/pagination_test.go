@@ -0,0 +1,47 @@
+package pagination
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursor_EncodeDecode(t *testing.T) {
+	c := Cursor{Key: "2023-09-12T09:00:00Z", ID: "967c2536-57ed-410a-bb2e-08a002e73138"}
+
+	decoded, err := Decode(c.Encode())
+
+	assert.NoError(t, err)
+	assert.Equal(t, c, decoded)
+}
+
+func TestDecode_Invalid(t *testing.T) {
+	for _, s := range []string{"not base64!", "bm90IGpzb24", "e30"} {
+		_, err := Decode(s)
+		assert.Equal(t, ErrInvalidCursor, err, s)
+	}
+}
+
+func TestParseLimit(t *testing.T) {
+	testCases := []struct {
+		Input         string
+		Expected      int
+		ExpectedError error
+	}{
+		{Input: "", Expected: DefaultLimit},
+		{Input: "10", Expected: 10},
+		{Input: "500", Expected: MaxLimit},
+		{Input: "0", ExpectedError: ErrInvalidLimit},
+		{Input: "501", ExpectedError: ErrInvalidLimit},
+		{Input: "ten", ExpectedError: ErrInvalidLimit},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Input, func(t *testing.T) {
+			limit, err := ParseLimit(tc.Input)
+
+			assert.Equal(t, tc.ExpectedError, err)
+			assert.Equal(t, tc.Expected, limit)
+		})
+	}
+}
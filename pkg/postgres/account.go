@@ -4,8 +4,13 @@ import "database/sql"
 
 // Account models how our account look in the database
 type Account struct {
-	ID        string
-	Balance   float64
-	Currency  string
-	CreatedAt sql.NullTime
+	ID              string
+	Balance         string
+	Held            string
+	Currency        string
+	Status          string
+	StatusReason    sql.NullString `db:"status_reason"`
+	StatusChangedAt sql.NullTime   `db:"status_changed_at"`
+	CreatedAt       sql.NullTime
+	CustomerID      sql.NullString `db:"customer_id"`
 }
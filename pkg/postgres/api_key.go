@@ -0,0 +1,20 @@
+package postgres
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// APIKey models how our API key look in the database
+type APIKey struct {
+	ID         string
+	Name       string
+	Prefix     string
+	KeyHash    string         `db:"key_hash"`
+	Scopes     pq.StringArray `db:"scopes"`
+	CustomerID sql.NullString `db:"customer_id"`
+	CreatedAt  time.Time      `db:"created_at"`
+	RevokedAt  sql.NullTime   `db:"revoked_at"`
+}
@@ -0,0 +1,12 @@
+package postgres
+
+import "time"
+
+// Customer models how our customer look in the database
+type Customer struct {
+	ID        string
+	Name      string
+	Email     string
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
@@ -0,0 +1,117 @@
+package postgres
+
+import (
+	"errors"
+	"financial-app/pkg/accounts"
+	"financial-app/pkg/customers"
+	"financial-app/pkg/fx"
+	"financial-app/pkg/holds"
+	"financial-app/pkg/transactions"
+
+	"github.com/lib/pq"
+)
+
+// integrityViolation is the class of the errors raised by integrity constraints
+const integrityViolation = "23"
+
+// violatedConstraint returns the name of the integrity constraint violated by
+// a statement, or an empty string if the error is not an integrity violation
+func violatedConstraint(err error) string {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code.Class() == integrityViolation {
+		return pqErr.Constraint
+	}
+	return ""
+}
+
+// accountConstraintError returns the domain error matching the constraint
+// violated by an account statement, or nil if no known constraint is violated
+func accountConstraintError(err error) error {
+	switch violatedConstraint(err) {
+	case "accounts_pkey":
+		return accounts.ErrDuplicateAccount
+	case "accounts_balance_non_negative":
+		return accounts.ErrNegativeBalance
+	case "accounts_currency_format":
+		return accounts.ErrInvalidCurrencyCode
+	case "accounts_status_check":
+		return accounts.ErrInvalidTransition
+	case "accounts_customer_fk":
+		return accounts.ErrUnknownCustomer
+	}
+	return nil
+}
+
+// transactionConstraintError returns the domain error matching the constraint
+// violated by the statements storing a transaction, or nil if no known
+// constraint is violated
+func transactionConstraintError(err error, txn *transactions.Transaction) error {
+	switch violatedConstraint(err) {
+	case "transactions_pkey":
+		return transactions.ErrDuplicateTransaction
+	case "transactions_source_account_fk":
+		return accounts.ErrFetchingAccount(txn.SourceAccountID)
+	case "transactions_target_account_fk":
+		return accounts.ErrFetchingAccount(txn.TargetAccountID)
+	case "transactions_quote_id_fkey":
+		return fx.ErrQuoteNotFound
+	case "transactions_reversal_of_fkey":
+		return transactions.ErrFetchingTransaction(txn.ReversalOf)
+	case "transactions_distinct_accounts":
+		return transactions.ErrSameAccount
+	case "transactions_status_transition":
+		return transactions.ErrInvalidTransition
+	case "transactions_amount_positive", "transactions_target_amount_positive":
+		return transactions.ErrNonPositiveAmount
+	case "transactions_currency_format",
+		"transactions_source_currency_format",
+		"transactions_target_currency_format":
+		return transactions.ErrInvalidCurrencyCode
+	}
+	return nil
+}
+
+// customerConstraintError returns the domain error matching the constraint
+// violated by a customer statement, or nil if no known constraint is violated
+func customerConstraintError(err error) error {
+	switch violatedConstraint(err) {
+	case "customers_email_key":
+		return customers.ErrDuplicateEmail
+	case "accounts_customer_fk":
+		return customers.ErrCustomerHasAccounts
+	}
+	return nil
+}
+
+// roleConstraintError returns the domain error matching the constraint
+// violated by a statement on the roles of an account, or nil if no known
+// constraint is violated
+func roleConstraintError(err error, accountID string) error {
+	switch violatedConstraint(err) {
+	case "account_roles_account_fk", "account_role_changes_account_fk":
+		return accounts.ErrFetchingAccount(accountID)
+	case "account_roles_customer_fk":
+		return accounts.ErrUnknownCustomer
+	case "account_roles_role_check":
+		return accounts.ErrInvalidRole
+	}
+	return nil
+}
+
+// holdConstraintError returns the domain error matching the constraint
+// violated by a hold statement, or nil if no known constraint is violated
+func holdConstraintError(err error, hold *holds.Hold) error {
+	switch violatedConstraint(err) {
+	case "holds_pkey":
+		return holds.ErrDuplicateHold
+	case "holds_account_reference_key":
+		return holds.ErrDuplicateReference
+	case "holds_account_fk":
+		return accounts.ErrFetchingAccount(hold.AccountID)
+	case "holds_amount_positive":
+		return transactions.ErrNonPositiveAmount
+	case "holds_currency_format":
+		return transactions.ErrInvalidCurrencyCode
+	}
+	return nil
+}
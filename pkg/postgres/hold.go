@@ -0,0 +1,22 @@
+package postgres
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Hold models how our hold look in the database
+type Hold struct {
+	ID             string
+	AccountID      string `db:"account_id"`
+	Amount         string
+	Currency       string
+	Reference      string
+	Status         string
+	CapturedAmount sql.NullString `db:"captured_amount"`
+	TransactionID  sql.NullString `db:"transaction_id"`
+	PlacedBy       sql.NullString `db:"placed_by"`
+	CreatedAt      time.Time      `db:"created_at"`
+	ExpiresAt      time.Time      `db:"expires_at"`
+	ReleasedAt     sql.NullTime   `db:"released_at"`
+}
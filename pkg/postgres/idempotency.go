@@ -0,0 +1,16 @@
+package postgres
+
+import (
+	"database/sql"
+	"time"
+)
+
+// IdempotencyRecord models how our idempotency record look in the database
+type IdempotencyRecord struct {
+	Key          string
+	RequestHash  string         `db:"request_hash"`
+	StatusCode   sql.NullInt64  `db:"status_code"`
+	ContentType  sql.NullString `db:"content_type"`
+	ResponseBody []byte         `db:"response_body"`
+	CreatedAt    time.Time      `db:"created_at"`
+}
@@ -0,0 +1,23 @@
+package postgres
+
+import (
+	"database/sql"
+	"time"
+)
+
+// JournalEntry models how our journal entry look in the database
+type JournalEntry struct {
+	ID            string
+	TransactionID sql.NullString `db:"transaction_id"`
+	Kind          string
+	CreatedAt     time.Time `db:"created_at"`
+}
+
+// Posting models how our posting look in the database
+type Posting struct {
+	JournalEntryID string         `db:"journal_entry_id"`
+	AccountID      sql.NullString `db:"account_id"`
+	SystemAccount  sql.NullString `db:"system_account"`
+	Amount         string
+	Currency       string
+}
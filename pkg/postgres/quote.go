@@ -0,0 +1,19 @@
+package postgres
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Quote models how our fx quote look in the database
+type Quote struct {
+	ID             string
+	SourceCurrency string `db:"source_currency"`
+	TargetCurrency string `db:"target_currency"`
+	SourceAmount   string `db:"source_amount"`
+	TargetAmount   string `db:"target_amount"`
+	Rate           string
+	RateTimestamp  time.Time    `db:"rate_timestamp"`
+	ExpiresAt      time.Time    `db:"expires_at"`
+	UsedAt         sql.NullTime `db:"used_at"`
+}
@@ -3,16 +3,25 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"financial-app/pkg/accounts"
+	"financial-app/pkg/auth"
+	"financial-app/pkg/customers"
+	"financial-app/pkg/fx"
 	"financial-app/pkg/healthchecks"
+	"financial-app/pkg/holds"
+	"financial-app/pkg/idempotency"
+	"financial-app/pkg/ledger"
+	"financial-app/pkg/money"
 	"financial-app/pkg/transactions"
 	"fmt"
+	"strings"
+	"time"
 
 	_ "github.com/golang-migrate/migrate/v4/source/file"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
+	uuid "github.com/satori/go.uuid"
 	"go.uber.org/zap"
-
-	"github.com/allisson/go-pglock/v3"
 )
 
 type accountRepository struct {
@@ -35,43 +44,110 @@ func NewAccountRepository(
 func (r *accountRepository) Store(
 	ctx context.Context, acct *accounts.Account,
 ) (*accounts.Account, error) {
+	if acct.CreatedAt.IsZero() {
+		acct.CreatedAt = time.Now().UTC()
+	}
+	if acct.Status == "" {
+		acct.Status = accounts.StatusActive
+	}
+	// Nothing is held on a new account yet
+	acct.AvailableBalance = acct.Balance
+
 	acctRow := Account{
-		ID:       string(acct.ID),
-		Balance:  acct.Balance,
-		Currency: string(acct.Currency),
+		ID:        string(acct.ID),
+		Balance:   acct.Balance.String(),
+		Currency:  string(acct.Currency),
+		Status:    acct.Status,
+		CreatedAt: sql.NullTime{Time: acct.CreatedAt, Valid: true},
+		CustomerID: sql.NullString{
+			String: acct.CustomerID, Valid: acct.CustomerID != "",
+		},
 	}
 
-	// Define the insert query
-	query := "INSERT INTO accounts (id, balance, currency) VALUES ($1, $2, $3)"
+	// Store the account along with the journal entry of its initial balance
+	err := executeDBTransaction(ctx, r.client, func(tx *sql.Tx) error {
+		// Define the insert query
+		query := `INSERT INTO accounts (id, balance, currency, status, created_at, customer_id)
+		VALUES ($1, $2, $3, $4, $5, $6)`
 
-	_, err := r.client.ExecContext(
-		ctx, query, acctRow.ID, acctRow.Balance, acctRow.Currency,
-	)
+		_, err := tx.ExecContext(
+			ctx, query, acctRow.ID, acctRow.Balance, acctRow.Currency, acctRow.Status,
+			acctRow.CreatedAt, acctRow.CustomerID,
+		)
+		if err != nil {
+			r.logger.Errorf("failed to insert account: %w", err)
+			if cerr := accountConstraintError(err); cerr != nil {
+				return cerr
+			}
+			return accounts.ErrPostingAccount(acct.ID).Wrap(err)
+		}
+
+		if acct.Balance.IsZero() {
+			return nil
+		}
+
+		entry := ledger.NewOpeningBalanceEntry(acct.ID, acct.Balance)
+		entry.CreatedAt = acct.CreatedAt
+		if err := insertJournalEntry(ctx, tx, &entry); err != nil {
+			r.logger.Errorf("failed to insert opening balance entry: %w", err)
+			return accounts.ErrPostingAccount(acct.ID).Wrap(err)
+		}
+
+		return nil
+	})
 	if err != nil {
-		r.logger.Errorf("failed to insert account: %w", err)
-		return nil, accounts.ErrPostingAccount(acct.ID)
+		return nil, err
 	}
 
 	return acct, nil
 }
 
-func convertAccountRowToAccount(a Account) *accounts.Account {
-	return &accounts.Account{
-		ID:        a.ID,
-		Balance:   a.Balance,
-		Currency:  a.Currency,
-		CreatedAt: a.CreatedAt.Time,
+func convertAccountRowToAccount(a Account) (*accounts.Account, error) {
+	balance, err := money.Parse(a.Balance, a.Currency)
+	if err != nil {
+		return nil, err
+	}
+	held, err := money.Parse(a.Held, a.Currency)
+	if err != nil {
+		return nil, err
+	}
+	available, err := balance.Sub(held)
+	if err != nil {
+		return nil, err
+	}
+
+	acct := &accounts.Account{
+		ID:               a.ID,
+		Balance:          balance,
+		AvailableBalance: available,
+		Currency:         a.Currency,
+		Status:           a.Status,
+		StatusReason:     a.StatusReason.String,
+		CreatedAt:        a.CreatedAt.Time,
+		CustomerID:       a.CustomerID.String,
+	}
+	if a.StatusChangedAt.Valid {
+		acct.StatusChangedAt = &a.StatusChangedAt.Time
 	}
+
+	return acct, nil
 }
 
 func (r *accountRepository) Find(
 	ctx context.Context, id string,
 ) (*accounts.Account, error) {
+	// The id column is a uuid: an ID that is not one names no account, and
+	// Postgres would fail the query rather than find no row
+	if _, err := uuid.FromString(id); err != nil {
+		return nil, accounts.ErrFetchingAccount(id)
+	}
+
 	// Fetch accountRow from the database and then convert to Account
 	var acctRow Account
 	row := r.client.QueryRowContext(
 		ctx,
-		`SELECT id, balance, currency, created_at 
+		`SELECT id, balance, held, currency, status, status_reason, status_changed_at,
+		created_at, customer_id
 		FROM accounts 
 		WHERE id = $1`,
 		id,
@@ -79,14 +155,28 @@ func (r *accountRepository) Find(
 	err := row.Scan(
 		&acctRow.ID,
 		&acctRow.Balance,
+		&acctRow.Held,
 		&acctRow.Currency,
-		&acctRow.CreatedAt)
-	if err != nil {
+		&acctRow.Status,
+		&acctRow.StatusReason,
+		&acctRow.StatusChangedAt,
+		&acctRow.CreatedAt,
+		&acctRow.CustomerID)
+	if errors.Is(err, sql.ErrNoRows) {
 		return nil, accounts.ErrFetchingAccount(id)
+	}
+	if err != nil {
+		r.logger.Errorf("an error occurred fetching account row: %w", err)
+		return nil, accounts.ErrQueryingAccounts([]string{id}).Wrap(err)
+	}
 
+	acct, err := convertAccountRowToAccount(acctRow)
+	if err != nil {
+		r.logger.Errorf("an error occurred converting account row: %w", err)
+		return nil, accounts.ErrScanAccounts([]string{id}).Wrap(err)
 	}
 
-	return convertAccountRowToAccount(acctRow), nil
+	return acct, nil
 }
 
 func (r *accountRepository) FindByIDs(
@@ -97,13 +187,25 @@ func (r *accountRepository) FindByIDs(
 		return nil, accounts.ErrEmptyAccountList
 	}
 
+	// The IDs that are not uuids name no account, so they are left out of
+	// the query and missing from the result
+	valid := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if _, err := uuid.FromString(id); err == nil {
+			valid = append(valid, id)
+		}
+	}
+	if len(valid) == 0 {
+		return map[string]*accounts.Account{}, nil
+	}
+
 	// Create a map to store the fetched account rows by UUID
 	acctRows := make(map[string]Account)
 
 	// Build the query placeholders for the IN operator
-	placeholders := make([]interface{}, len(ids))
+	placeholders := make([]interface{}, len(valid))
 	inquery := "$1"
-	for i, id := range ids {
+	for i, id := range valid {
 		placeholders[i] = id
 		if i > 0 {
 			inquery += ",$" + fmt.Sprint(i+1)
@@ -113,14 +215,15 @@ func (r *accountRepository) FindByIDs(
 	// Execute the query and retrieve the account rows
 	rows, err := r.client.QueryContext(
 		ctx,
-		`SELECT id, balance, currency, created_at
+		`SELECT id, balance, held, currency, status, status_reason, status_changed_at,
+		created_at, customer_id
 		FROM accounts
 		WHERE id IN (`+inquery+`)`,
 		placeholders...,
 	)
 	if err != nil {
 		r.logger.Errorf("an error occurred fetching accounts by UUIDs: %w", err)
-		return nil, accounts.ErrQueryingAccounts(ids)
+		return nil, accounts.ErrQueryingAccounts(ids).Wrap(err)
 	}
 	defer rows.Close()
 
@@ -130,12 +233,17 @@ func (r *accountRepository) FindByIDs(
 		err := rows.Scan(
 			&acctRow.ID,
 			&acctRow.Balance,
+			&acctRow.Held,
 			&acctRow.Currency,
+			&acctRow.Status,
+			&acctRow.StatusReason,
+			&acctRow.StatusChangedAt,
 			&acctRow.CreatedAt,
+			&acctRow.CustomerID,
 		)
 		if err != nil {
 			r.logger.Errorf("an error occurred scanning account row: %w", err)
-			return nil, accounts.ErrScanAccounts(ids)
+			return nil, accounts.ErrScanAccounts(ids).Wrap(err)
 		}
 		acctRows[acctRow.ID] = acctRow
 	}
@@ -143,32 +251,87 @@ func (r *accountRepository) FindByIDs(
 	// Check for any errors during iteration
 	if err = rows.Err(); err != nil {
 		r.logger.Errorf("an error occurred iterating account rows: %w", err)
-		return nil, accounts.ErrFetchingAccounts(ids)
+		return nil, accounts.ErrFetchingAccounts(ids).Wrap(err)
 	}
 
 	// Convert the account rows to account
-	accounts := make(map[string]*accounts.Account)
+	accts := make(map[string]*accounts.Account)
 	for _, acctRow := range acctRows {
-		accounts[acctRow.ID] = convertAccountRowToAccount(acctRow)
+		acct, err := convertAccountRowToAccount(acctRow)
+		if err != nil {
+			r.logger.Errorf("an error occurred converting account row: %w", err)
+			return nil, accounts.ErrScanAccounts(ids).Wrap(err)
+		}
+		accts[acctRow.ID] = acct
 	}
 
-	r.logger.Info(accounts)
+	r.logger.Info(accts)
 
-	return accounts, nil
+	return accts, nil
 }
 
-func (r *accountRepository) FindAll(
-	ctx context.Context,
-) []*accounts.Account {
-	// Fetch all account rows from the database
-	rows, err := r.client.QueryContext(
-		ctx,
-		`SELECT id, balance, currency, created_at 
-		FROM accounts`,
+func (r *accountRepository) Query(
+	ctx context.Context, q accounts.Query,
+) ([]*accounts.Account, error) {
+	// Build the filters of the query
+	var (
+		where []string
+		args  []interface{}
 	)
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return "$" + fmt.Sprint(len(args))
+	}
+
+	if q.Currency != "" {
+		where = append(where, "currency = "+arg(q.Currency))
+	}
+	if q.Status != "" {
+		where = append(where, "status = "+arg(q.Status))
+	}
+	if q.CustomerID != "" {
+		where = append(where, "customer_id = "+arg(q.CustomerID))
+	}
+	if q.AccessibleTo != "" {
+		customer := arg(q.AccessibleTo)
+		where = append(where, "(customer_id = "+customer+
+			" OR id IN (SELECT account_id FROM account_roles WHERE customer_id = "+customer+"))")
+	}
+	if q.MinBalance != nil {
+		where = append(where, "balance >= "+arg(q.MinBalance.String()))
+	}
+	if q.MaxBalance != nil {
+		where = append(where, "balance <= "+arg(q.MaxBalance.String()))
+	}
+
+	// Only the whitelisted sort keys end up in the query
+	column, cast := "created_at", "::timestamptz"
+	if q.Sort == accounts.SortBalance {
+		column, cast = "balance", "::numeric"
+	}
+	order, cmp := "ASC", ">"
+	if q.Descending {
+		order, cmp = "DESC", "<"
+	}
+
+	// Continue right after the last account of the previous page
+	if q.After != nil {
+		where = append(where, "("+column+", id) "+cmp+" ("+arg(q.After.Key)+cast+", "+
+			arg(q.After.ID)+"::uuid)")
+	}
+
+	query := `SELECT id, balance, held, currency, status, status_reason, status_changed_at,
+		created_at, customer_id
+		FROM accounts`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY " + column + " " + order + ", id " + order + " LIMIT " + arg(q.Limit)
+
+	rows, err := r.client.QueryContext(ctx, query, args...)
 	if err != nil {
 		r.logger.Errorf("an error occurred quering account rows:  %w", err)
-		return []*accounts.Account{}
+		return nil, accounts.ErrListingAccounts.Wrap(err)
 	}
 	defer rows.Close()
 
@@ -179,36 +342,179 @@ func (r *accountRepository) FindAll(
 		err := rows.Scan(
 			&acctRow.ID,
 			&acctRow.Balance,
+			&acctRow.Held,
 			&acctRow.Currency,
+			&acctRow.Status,
+			&acctRow.StatusReason,
+			&acctRow.StatusChangedAt,
 			&acctRow.CreatedAt,
+			&acctRow.CustomerID,
 		)
 		if err != nil {
 			r.logger.Errorf("an error occurred scanning account row:  %w", err)
-			return []*accounts.Account{}
+			return nil, accounts.ErrListingAccounts.Wrap(err)
+		}
+		acct, err := convertAccountRowToAccount(acctRow)
+		if err != nil {
+			r.logger.Errorf("an error occurred converting account row:  %w", err)
+			return nil, accounts.ErrListingAccounts.Wrap(err)
 		}
-		acct := convertAccountRowToAccount(acctRow)
 		accts = append(accts, acct)
 	}
 
 	if err = rows.Err(); err != nil {
-		r.logger.Errorf("an error occurred iterating transaction rows: %w", err)
-		return []*accounts.Account{}
+		r.logger.Errorf("an error occurred iterating account rows: %w", err)
+		return nil, accounts.ErrListingAccounts.Wrap(err)
 	}
 
-	return accts
+	return accts, nil
 }
 
-func (r *accountRepository) Delete(ctx context.Context, id string) error {
-	_, err := r.client.ExecContext(
+func (r *accountRepository) UpdateStatus(
+	ctx context.Context, id string, change accounts.StatusChange,
+) (*accounts.Account, error) {
+	var acct *accounts.Account
+	err := executeDBTransaction(ctx, r.client, func(tx *sql.Tx) error {
+		// Lock the account so that its balance and status cannot change until
+		// the new status is stored
+		accts, err := lockAccounts(ctx, tx, id)
+		if err != nil {
+			r.logger.Errorf("failed to lock the account: %w", err)
+			return accounts.ErrUpdatingStatus(id).Wrap(err)
+		}
+		var ok bool
+		if acct, ok = accts[id]; !ok {
+			return accounts.ErrFetchingAccount(id)
+		}
+		if err := accounts.CheckTransition(acct, change.Status); err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(
+			ctx,
+			`UPDATE accounts SET status = $1, status_reason = $2, status_changed_at = $3
+			WHERE id = $4`,
+			change.Status,
+			sql.NullString{String: change.Reason, Valid: change.Reason != ""},
+			change.ChangedAt,
+			id,
+		)
+		if err != nil {
+			r.logger.Errorf("failed to update the account status: %w", err)
+			if cerr := accountConstraintError(err); cerr != nil {
+				return cerr
+			}
+			return accounts.ErrUpdatingStatus(id).Wrap(err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	acct.Status = change.Status
+	acct.StatusReason = change.Reason
+	acct.StatusChangedAt = &change.ChangedAt
+
+	return acct, nil
+}
+
+func (r *accountRepository) BalanceAsOf(
+	ctx context.Context, id string, asOf time.Time,
+) (money.Money, error) {
+	amount, err := balanceAsOf(ctx, r.client, id, asOf)
+	if err != nil && !errors.Is(err, accounts.ErrAccountNotFound) {
+		r.logger.Errorf("an error occurred computing the balance: %w", err)
+	}
+	return amount, err
+}
+
+// queryRower runs queries returning at most one row, either on its own or
+// within a DB transaction
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// balanceAsOf computes the balance of an account at the given time
+func balanceAsOf(
+	ctx context.Context, client queryRower, id string, asOf time.Time,
+) (money.Money, error) {
+	var currency, balance string
+
+	// Start from the latest snapshot before the given time, if any, and add
+	// the postings booked after the snapshot
+	row := client.QueryRowContext(
 		ctx,
-		`DELETE FROM accounts where id = $1`,
-		id,
+		`WITH snapshot AS (
+			SELECT as_of, balance
+			FROM balance_snapshots
+			WHERE account_id = $1 AND as_of <= $2
+			ORDER BY as_of DESC
+			LIMIT 1
+		)
+		SELECT a.currency,
+			COALESCE((SELECT balance FROM snapshot), 0) + COALESCE((
+				SELECT SUM(p.amount)
+				FROM postings p
+				JOIN journal_entries e ON e.id = p.journal_entry_id
+				WHERE p.account_id = a.id
+				AND e.created_at <= $2
+				AND e.created_at > COALESCE((SELECT as_of FROM snapshot), '-infinity')
+			), 0)
+		FROM accounts a
+		WHERE a.id = $1`,
+		id, asOf,
 	)
+	err := row.Scan(&currency, &balance)
+	if errors.Is(err, sql.ErrNoRows) {
+		return money.Money{}, accounts.ErrFetchingAccount(id)
+	}
 	if err != nil {
-		r.logger.Errorf("failed to delete accounts from the database: %w", err)
-		return accounts.ErrDeletingAccount(id)
+		return money.Money{}, accounts.ErrFetchingBalance(id).Wrap(err)
 	}
-	return nil
+
+	amount, err := money.Parse(balance, currency)
+	if err != nil {
+		return money.Money{}, accounts.ErrFetchingBalance(id).Wrap(err)
+	}
+
+	return amount, nil
+}
+
+func (r *accountRepository) SnapshotBalances(
+	ctx context.Context, asOf time.Time,
+) (int64, error) {
+	// Every snapshot builds on the previous one of the same account
+	res, err := r.client.ExecContext(
+		ctx,
+		`INSERT INTO balance_snapshots (account_id, as_of, balance, currency)
+		SELECT a.id, $1,
+			COALESCE(s.balance, 0) + COALESCE((
+				SELECT SUM(p.amount)
+				FROM postings p
+				JOIN journal_entries e ON e.id = p.journal_entry_id
+				WHERE p.account_id = a.id
+				AND e.created_at <= $1
+				AND e.created_at > COALESCE(s.as_of, '-infinity')
+			), 0),
+			a.currency
+		FROM accounts a
+		LEFT JOIN LATERAL (
+			SELECT as_of, balance
+			FROM balance_snapshots
+			WHERE account_id = a.id AND as_of <= $1
+			ORDER BY as_of DESC
+			LIMIT 1
+		) s ON true
+		ON CONFLICT (account_id, as_of) DO NOTHING`,
+		asOf,
+	)
+	if err != nil {
+		r.logger.Errorf("failed to insert balance snapshots: %w", err)
+		return 0, err
+	}
+	return res.RowsAffected()
 }
 
 type transactionRepository struct {
@@ -228,25 +534,69 @@ func NewTransactionRepository(
 	return r
 }
 
-func convertTransactionRowToTransaction(t Transaction) *transactions.Transaction {
-	return &transactions.Transaction{
+func convertTransactionRowToTransaction(t Transaction) (*transactions.Transaction, error) {
+	amount, err := money.Parse(t.Amount, t.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	targetAmount, err := money.Parse(t.TargetAmount, t.TargetCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	txn := &transactions.Transaction{
 		ID:              t.ID,
 		SourceAccountID: t.SourceAccountID,
 		TargetAccountID: t.TargetAccountID,
-		Amount:          t.Amount,
+		Amount:          amount,
 		Currency:        t.Currency,
+		SourceCurrency:  t.SourceCurrency,
+		TargetCurrency:  t.TargetCurrency,
+		TargetAmount:    targetAmount,
+		Rate:            t.Rate.String,
+		QuoteID:         t.QuoteID.String,
+		ReversalOf:      t.ReversalOf.String,
+		Status:          t.Status,
+		FailureReason:   t.FailureReason.String,
+		InitiatedBy:     t.InitiatedBy.String,
+		ReviewedBy:      t.ReviewedBy.String,
+		CreatedAt:       t.CreatedAt,
+		BookedAt:        t.BookedAt,
+
+		InitiatorCustomerID: t.InitiatorCustomerID.String,
+	}
+	if t.RateTimestamp.Valid {
+		txn.RateTimestamp = &t.RateTimestamp.Time
+	}
+	if t.ReviewedAt.Valid {
+		txn.ReviewedAt = &t.ReviewedAt.Time
+	}
+	if t.ExpiresAt.Valid {
+		txn.ExpiresAt = &t.ExpiresAt.Time
 	}
+
+	return txn, nil
 }
 
 func (r *transactionRepository) Find(
 	ctx context.Context, id string,
 ) (*transactions.Transaction, error) {
+	// The id column is a uuid: an ID that is not one names no transaction
+	if _, err := uuid.FromString(id); err != nil {
+		return nil, transactions.ErrFetchingTransaction(id)
+	}
+
 	// Fetch transactionRow from the database and then convert to Transaction
 	var txnRow Transaction
 
 	row := r.client.QueryRowContext(
 		ctx,
-		`SELECT id, source_account_id, target_account_id, amount, currency 
+		`SELECT id, source_account_id, target_account_id, amount, currency,
+		source_currency, target_currency, target_amount, rate, rate_timestamp,
+		quote_id, reversal_of, status, failure_reason, initiated_by,
+		initiator_customer_id, reviewed_by, reviewed_at, expires_at, created_at,
+		booked_at
 		FROM transactions 
 		WHERE id = $1`,
 		id,
@@ -256,27 +606,104 @@ func (r *transactionRepository) Find(
 		&txnRow.SourceAccountID,
 		&txnRow.TargetAccountID,
 		&txnRow.Amount,
-		&txnRow.Currency)
-	if err != nil {
+		&txnRow.Currency,
+		&txnRow.SourceCurrency,
+		&txnRow.TargetCurrency,
+		&txnRow.TargetAmount,
+		&txnRow.Rate,
+		&txnRow.RateTimestamp,
+		&txnRow.QuoteID,
+		&txnRow.ReversalOf,
+		&txnRow.Status,
+		&txnRow.FailureReason,
+		&txnRow.InitiatedBy,
+		&txnRow.InitiatorCustomerID,
+		&txnRow.ReviewedBy,
+		&txnRow.ReviewedAt,
+		&txnRow.ExpiresAt,
+		&txnRow.CreatedAt,
+		&txnRow.BookedAt)
+	if errors.Is(err, sql.ErrNoRows) {
 		return nil, transactions.ErrFetchingTransaction(id)
+	}
+	if err != nil {
+		r.logger.Errorf("an error occurred fetching transaction row: %w", err)
+		return nil, transactions.ErrQueryingTransactions.Wrap(err)
+	}
 
+	txn, err := convertTransactionRowToTransaction(txnRow)
+	if err != nil {
+		r.logger.Errorf("an error occurred converting transaction row: %w", err)
+		return nil, transactions.ErrQueryingTransactions.Wrap(err)
 	}
 
-	return convertTransactionRowToTransaction(txnRow), nil
+	return txn, nil
 }
 
-func (r *transactionRepository) FindAll(
-	ctx context.Context,
-) []*transactions.Transaction {
-	// Fetch all transaction rows from the database
-	rows, err := r.client.QueryContext(
-		ctx,
-		`SELECT id, source_account_id, target_account_id, amount, currency 
-		FROM transactions`,
+func (r *transactionRepository) Query(
+	ctx context.Context, q transactions.Query,
+) ([]*transactions.Transaction, error) {
+	// Build the filters of the query
+	var (
+		where []string
+		args  []interface{}
 	)
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return "$" + fmt.Sprint(len(args))
+	}
+
+	if q.AccountID != "" {
+		p := arg(q.AccountID)
+		where = append(where, "(source_account_id = "+p+" OR target_account_id = "+p+")")
+	}
+	if q.Currency != "" {
+		p := arg(q.Currency)
+		where = append(where, "(source_currency = "+p+" OR target_currency = "+p+")")
+	}
+	if q.Status != "" {
+		where = append(where, "status = "+arg(q.Status))
+	}
+	if q.AccessibleTo != "" {
+		customer := arg(q.AccessibleTo)
+		accessible := "(SELECT id FROM accounts WHERE customer_id = " + customer +
+			" UNION SELECT account_id FROM account_roles WHERE customer_id = " + customer + ")"
+		where = append(where, "(source_account_id IN "+accessible+
+			" OR target_account_id IN "+accessible+")")
+	}
+	if q.MinAmount != nil {
+		where = append(where, "amount >= "+arg(q.MinAmount.String()))
+	}
+	if q.MaxAmount != nil {
+		where = append(where, "amount <= "+arg(q.MaxAmount.String()))
+	}
+	if q.From != nil {
+		where = append(where, "booked_at >= "+arg(*q.From))
+	}
+	if q.To != nil {
+		where = append(where, "booked_at < "+arg(*q.To))
+	}
+	// Continue right after the last transaction of the previous page
+	if q.After != nil {
+		where = append(where, "(booked_at, id) < ("+arg(q.After.Key)+"::timestamptz, "+
+			arg(q.After.ID)+"::uuid)")
+	}
+
+	query := `SELECT id, source_account_id, target_account_id, amount, currency,
+		source_currency, target_currency, target_amount, rate, rate_timestamp,
+		quote_id, reversal_of, status, failure_reason, initiated_by,
+		initiator_customer_id, reviewed_by, reviewed_at, expires_at, created_at,
+		booked_at
+		FROM transactions`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY booked_at DESC, id DESC LIMIT " + arg(q.Limit)
+
+	rows, err := r.client.QueryContext(ctx, query, args...)
 	if err != nil {
 		r.logger.Errorf("an error occurred quering transaction rows:  %w", err)
-		return []*transactions.Transaction{}
+		return nil, transactions.ErrQueryingTransactions.Wrap(err)
 	}
 	defer rows.Close()
 
@@ -290,158 +717,2401 @@ func (r *transactionRepository) FindAll(
 			&txnRow.TargetAccountID,
 			&txnRow.Amount,
 			&txnRow.Currency,
+			&txnRow.SourceCurrency,
+			&txnRow.TargetCurrency,
+			&txnRow.TargetAmount,
+			&txnRow.Rate,
+			&txnRow.RateTimestamp,
+			&txnRow.QuoteID,
+			&txnRow.ReversalOf,
+			&txnRow.Status,
+			&txnRow.FailureReason,
+			&txnRow.InitiatedBy,
+			&txnRow.InitiatorCustomerID,
+			&txnRow.ReviewedBy,
+			&txnRow.ReviewedAt,
+			&txnRow.ExpiresAt,
+			&txnRow.CreatedAt,
+			&txnRow.BookedAt,
 		)
 		if err != nil {
 			r.logger.Errorf("an error occurred scanning transaction row:  %w", err)
-			return []*transactions.Transaction{}
+			return nil, transactions.ErrQueryingTransactions.Wrap(err)
+		}
+		txn, err := convertTransactionRowToTransaction(txnRow)
+		if err != nil {
+			r.logger.Errorf("an error occurred converting transaction row:  %w", err)
+			return nil, transactions.ErrQueryingTransactions.Wrap(err)
 		}
-		txn := convertTransactionRowToTransaction(txnRow)
 		transacts = append(transacts, txn)
 	}
 
 	if err = rows.Err(); err != nil {
 		r.logger.Errorf("an error occurred iterating transaction rows: %w", err)
-		return []*transactions.Transaction{}
+		return nil, transactions.ErrQueryingTransactions.Wrap(err)
 	}
 
-	return transacts
+	return transacts, nil
 }
 
-func (r *transactionRepository) Delete(
-	ctx context.Context, id string,
-) error {
-	_, err := r.client.ExecContext(
-		ctx,
-		`DELETE FROM transactions where id = $1`,
-		id,
-	)
+func convertHistoryRowToHistoryEntry(
+	accountID string, h HistoryEntry,
+) (*transactions.HistoryEntry, error) {
+	amount, err := money.Parse(h.Amount, h.Currency)
 	if err != nil {
-		r.logger.Errorf("failed to delete transaction from the database: %w", err)
-		return transactions.ErrDeletingTransaction(id)
+		return nil, err
 	}
-	return nil
+	balanceAfter, err := money.Parse(h.BalanceAfter, h.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := transactions.NewHistoryEntry(accountID, &transactions.Transaction{
+		ID:              h.TransactionID,
+		SourceAccountID: h.SourceAccountID,
+		TargetAccountID: h.TargetAccountID,
+		BookedAt:        h.BookedAt,
+	}, amount, balanceAfter)
+	return &entry, nil
 }
 
-func (r *transactionRepository) Transfer(
-	ctx context.Context,
-	txn *transactions.Transaction,
-	sacc *accounts.Account,
-	tacc *accounts.Account,
-) (*transactions.Transaction, error) {
-	postRow := Transaction{
-		ID:              txn.ID,
-		SourceAccountID: txn.SourceAccountID,
-		TargetAccountID: txn.TargetAccountID,
-		Amount:          txn.Amount,
-		Currency:        txn.Currency,
-	}
+// accountPostings selects the postings of the account $1 along with its
+// running balance, which sums up every posting of the account, including its
+// opening balance, in the order the journal entries were booked in
+const accountPostings = `(
+	SELECT e.transaction_id, e.created_at AS booked_at, p.amount, p.currency,
+		SUM(p.amount) OVER (
+			ORDER BY e.created_at, e.transaction_id NULLS FIRST, p.id
+		) AS balance_after
+	FROM postings p
+	JOIN journal_entries e ON e.id = p.journal_entry_id
+	WHERE p.account_id = $1
+)`
 
-	id := int64(1)
-	lock, err := pglock.NewLock(ctx, id, r.client)
-	if err != nil {
-		r.logger.Errorf("failed to initialise the advisory lock in the db: %w", err)
-		return nil, err
+func (r *transactionRepository) History(
+	ctx context.Context, q transactions.HistoryQuery,
+) ([]*transactions.HistoryEntry, error) {
+	args := []interface{}{q.AccountID, q.Limit}
+	after := ""
+	// Continue right after the last transaction of the previous page
+	if q.After != nil {
+		args = append(args, q.After.Key, q.After.ID)
+		after = "WHERE (p.booked_at, p.transaction_id) < ($3::timestamptz, $4::uuid)"
 	}
 
-	// Obtains exclusive session level advisory lock
-	ok, err := lock.Lock(ctx)
+	rows, err := r.client.QueryContext(
+		ctx,
+		`SELECT p.transaction_id, t.source_account_id, t.target_account_id,
+			p.amount, p.currency, p.balance_after, p.booked_at
+		FROM `+accountPostings+` p
+		JOIN transactions t ON t.id = p.transaction_id
+		`+after+`
+		ORDER BY p.booked_at DESC, p.transaction_id DESC
+		LIMIT $2`,
+		args...,
+	)
 	if err != nil {
-		r.logger.Errorf("failed to lock the db: %w", err)
-		return nil, err
+		r.logger.Errorf("an error occurred quering history rows:  %w", err)
+		return nil, transactions.ErrQueryingHistory.Wrap(err)
 	}
-	r.logger.Info("lock.Lock()==", ok)
-
-	// Release the lock
-	defer func() {
-		r.logger.Info("release lock")
-		if err = lock.Unlock(ctx); err != nil {
-			r.logger.Errorf("failed to unlock the db: %w", err)
-		}
-	}()
+	defer rows.Close()
 
-	// Transfer money securely from one account to another one through DB transactions
-	err = r.executeDBTransaction(func(tx *sql.Tx) error {
-		r.logger.Info("transfer ongoing...")
-		// Define the update query for the accounts
-		query := "UPDATE accounts SET balance = $1 WHERE id = $2"
-		// Update the source account
-		_, err := tx.ExecContext(ctx, query, sacc.Balance, sacc.ID)
+	entries := make([]*transactions.HistoryEntry, 0)
+	for rows.Next() {
+		var historyRow HistoryEntry
+		err := rows.Scan(
+			&historyRow.TransactionID,
+			&historyRow.SourceAccountID,
+			&historyRow.TargetAccountID,
+			&historyRow.Amount,
+			&historyRow.Currency,
+			&historyRow.BalanceAfter,
+			&historyRow.BookedAt,
+		)
 		if err != nil {
-			r.logger.Errorf("failed to update the source account: %w", err)
-			return transactions.ErrUpdateAccount(sacc.ID)
+			r.logger.Errorf("an error occurred scanning history row:  %w", err)
+			return nil, transactions.ErrQueryingHistory.Wrap(err)
 		}
-
-		// Update the target account
-		_, err = tx.ExecContext(ctx, query, tacc.Balance, tacc.ID)
+		entry, err := convertHistoryRowToHistoryEntry(q.AccountID, historyRow)
 		if err != nil {
-			r.logger.Errorf("failed to update the target account: %w", err)
-			return transactions.ErrUpdateAccount(tacc.ID)
+			r.logger.Errorf("an error occurred converting history row:  %w", err)
+			return nil, transactions.ErrQueryingHistory.Wrap(err)
 		}
+		entries = append(entries, entry)
+	}
 
-		_, err = tx.ExecContext(
-			ctx,
-			`INSERT INTO transactions 
-		(id, source_account_id, target_account_id, amount, currency) VALUES
-		($1, $2, $3, $4, $5)`,
-			postRow.ID, postRow.SourceAccountID, postRow.TargetAccountID, postRow.Amount,
-			postRow.Currency,
-		)
-		if err != nil {
-			r.logger.Errorf("failed to insert transaction: %w", err)
-			return transactions.ErrPostingTransaction(txn.ID)
-		}
+	if err = rows.Err(); err != nil {
+		r.logger.Errorf("an error occurred iterating history rows: %w", err)
+		return nil, transactions.ErrQueryingHistory.Wrap(err)
+	}
 
-		r.logger.Info("transfer completed")
+	return entries, nil
+}
 
-		return nil
+func (r *transactionRepository) StreamHistory(
+	ctx context.Context, accountID string, from, to time.Time,
+	begin func(opening, closing money.Money) error,
+	fn func(*transactions.HistoryEntry) error,
+) error {
+	// Read the balances and the entries from one snapshot, so that the
+	// transfers committed in the meantime are left out of all of them
+	tx, err := r.client.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelRepeatableRead,
+		ReadOnly:  true,
 	})
-
 	if err != nil {
-		return nil, err
+		r.logger.Errorf("an error occurred starting the history snapshot: %w", err)
+		return transactions.ErrStreamingHistory.Wrap(err)
 	}
+	defer func() { _ = tx.Rollback() }()
 
-	return convertTransactionRowToTransaction(postRow), nil
-}
-
-// executeDBTransaction executes a safe transaction via the provided function
-func (r *transactionRepository) executeDBTransaction(fn func(tx *sql.Tx) error) error {
-	tx, err := r.client.Begin()
+	opening, err := balanceAsOf(ctx, tx, accountID, from)
 	if err != nil {
+		r.logger.Errorf("an error occurred computing the opening balance: %w", err)
 		return err
 	}
-
-	err = fn(tx)
+	closing, err := balanceAsOf(ctx, tx, accountID, to)
 	if err != nil {
-		_ = tx.Rollback()
+		r.logger.Errorf("an error occurred computing the closing balance: %w", err)
 		return err
 	}
-
-	err = tx.Commit()
-	if err != nil {
-		_ = tx.Rollback()
+	if err := begin(opening, closing); err != nil {
 		return err
 	}
 
-	return nil
-}
+	// The opening balance has no transaction
+	rows, err := tx.QueryContext(
+		ctx,
+		`SELECT COALESCE(p.transaction_id::text, ''),
+			COALESCE(t.source_account_id::text, ''), COALESCE(t.target_account_id::text, ''),
+			p.amount, p.currency, p.balance_after, p.booked_at
+		FROM `+accountPostings+` p
+		LEFT JOIN transactions t ON t.id = p.transaction_id
+		WHERE p.booked_at > $2 AND p.booked_at <= $3
+		ORDER BY p.booked_at, p.transaction_id NULLS FIRST`,
+		accountID, from, to,
+	)
+	if err != nil {
+		r.logger.Errorf("an error occurred quering history rows:  %w", err)
+		return transactions.ErrStreamingHistory.Wrap(err)
+	}
+	defer rows.Close()
 
-type healthcheckRepository struct {
-	client *sql.DB
-	logger *zap.SugaredLogger
-}
+	for rows.Next() {
+		var historyRow HistoryEntry
+		err := rows.Scan(
+			&historyRow.TransactionID,
+			&historyRow.SourceAccountID,
+			&historyRow.TargetAccountID,
+			&historyRow.Amount,
+			&historyRow.Currency,
+			&historyRow.BalanceAfter,
+			&historyRow.BookedAt,
+		)
+		if err != nil {
+			r.logger.Errorf("an error occurred scanning history row:  %w", err)
+			return transactions.ErrStreamingHistory.Wrap(err)
+		}
+		entry, err := convertHistoryRowToHistoryEntry(accountID, historyRow)
+		if err != nil {
+			r.logger.Errorf("an error occurred converting history row:  %w", err)
+			return transactions.ErrStreamingHistory.Wrap(err)
+		}
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
 
-// NewHealthcheckRepository returns a new instance of a postgres healthcheck repository.
-func NewHealthcheckRepository(
-	client *sql.DB, logger *zap.SugaredLogger,
-) healthchecks.HealthcheckRepository {
-	r := &healthcheckRepository{
-		client: client,
-		logger: logger,
+	if err = rows.Err(); err != nil {
+		r.logger.Errorf("an error occurred iterating history rows: %w", err)
+		return transactions.ErrStreamingHistory.Wrap(err)
 	}
 
-	return r
+	return nil
 }
 
-func (r *healthcheckRepository) Ping(ctx context.Context) error {
-	return r.client.PingContext(ctx)
+func (r *transactionRepository) FindReversals(
+	ctx context.Context, id string,
+) ([]*transactions.Transaction, error) {
+	rows, err := r.client.QueryContext(
+		ctx,
+		`SELECT id, source_account_id, target_account_id, amount, currency,
+		source_currency, target_currency, target_amount, rate, rate_timestamp,
+		quote_id, reversal_of, status, failure_reason, initiated_by,
+		initiator_customer_id, reviewed_by, reviewed_at, expires_at, created_at,
+		booked_at
+		FROM transactions
+		WHERE reversal_of = $1
+		ORDER BY booked_at, id`,
+		id,
+	)
+	if err != nil {
+		r.logger.Errorf("an error occurred quering reversal rows: %w", err)
+		return nil, transactions.ErrFetchingReversals(id).Wrap(err)
+	}
+	defer rows.Close()
+
+	reversals := make([]*transactions.Transaction, 0)
+	for rows.Next() {
+		var txnRow Transaction
+		err := rows.Scan(
+			&txnRow.ID,
+			&txnRow.SourceAccountID,
+			&txnRow.TargetAccountID,
+			&txnRow.Amount,
+			&txnRow.Currency,
+			&txnRow.SourceCurrency,
+			&txnRow.TargetCurrency,
+			&txnRow.TargetAmount,
+			&txnRow.Rate,
+			&txnRow.RateTimestamp,
+			&txnRow.QuoteID,
+			&txnRow.ReversalOf,
+			&txnRow.Status,
+			&txnRow.FailureReason,
+			&txnRow.InitiatedBy,
+			&txnRow.InitiatorCustomerID,
+			&txnRow.ReviewedBy,
+			&txnRow.ReviewedAt,
+			&txnRow.ExpiresAt,
+			&txnRow.CreatedAt,
+			&txnRow.BookedAt,
+		)
+		if err != nil {
+			r.logger.Errorf("an error occurred scanning reversal row: %w", err)
+			return nil, transactions.ErrFetchingReversals(id).Wrap(err)
+		}
+		txn, err := convertTransactionRowToTransaction(txnRow)
+		if err != nil {
+			r.logger.Errorf("an error occurred converting reversal row: %w", err)
+			return nil, transactions.ErrFetchingReversals(id).Wrap(err)
+		}
+		reversals = append(reversals, txn)
+	}
+
+	if err = rows.Err(); err != nil {
+		r.logger.Errorf("an error occurred iterating reversal rows: %w", err)
+		return nil, transactions.ErrFetchingReversals(id).Wrap(err)
+	}
+
+	return reversals, nil
+}
+
+// convertTransactionToRow returns the row storing a transaction
+func convertTransactionToRow(txn *transactions.Transaction) Transaction {
+	row := Transaction{
+		ID:              txn.ID,
+		SourceAccountID: txn.SourceAccountID,
+		TargetAccountID: txn.TargetAccountID,
+		Amount:          txn.Amount.String(),
+		Currency:        txn.Currency,
+		SourceCurrency:  txn.SourceCurrency,
+		TargetCurrency:  txn.TargetCurrency,
+		TargetAmount:    txn.TargetAmount.String(),
+		Rate:            sql.NullString{String: txn.Rate, Valid: txn.Rate != ""},
+		QuoteID:         sql.NullString{String: txn.QuoteID, Valid: txn.QuoteID != ""},
+		ReversalOf:      sql.NullString{String: txn.ReversalOf, Valid: txn.ReversalOf != ""},
+		Status:          txn.Status,
+		FailureReason:   sql.NullString{String: txn.FailureReason, Valid: txn.FailureReason != ""},
+		InitiatedBy:     sql.NullString{String: txn.InitiatedBy, Valid: txn.InitiatedBy != ""},
+		CreatedAt:       txn.CreatedAt,
+
+		InitiatorCustomerID: sql.NullString{
+			String: txn.InitiatorCustomerID, Valid: txn.InitiatorCustomerID != "",
+		},
+	}
+	if row.Status == "" {
+		row.Status = transactions.StatusCompleted
+	}
+	if txn.RateTimestamp != nil {
+		row.RateTimestamp = sql.NullTime{Time: *txn.RateTimestamp, Valid: true}
+	}
+	if txn.ExpiresAt != nil {
+		row.ExpiresAt = sql.NullTime{Time: *txn.ExpiresAt, Valid: true}
+	}
+	return row
+}
+
+// insertTransaction inserts the row of a transaction
+func insertTransaction(ctx context.Context, tx *sql.Tx, row Transaction) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO transactions 
+		(id, source_account_id, target_account_id, amount, currency,
+		source_currency, target_currency, target_amount, rate, rate_timestamp,
+		quote_id, reversal_of, status, failure_reason, initiated_by,
+		initiator_customer_id, expires_at, created_at, booked_at) VALUES
+		($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16,
+		$17, $18, $19)`,
+		row.ID, row.SourceAccountID, row.TargetAccountID, row.Amount,
+		row.Currency, row.SourceCurrency, row.TargetCurrency,
+		row.TargetAmount, row.Rate, row.RateTimestamp, row.QuoteID,
+		row.ReversalOf, row.Status, row.FailureReason, row.InitiatedBy,
+		row.InitiatorCustomerID, row.ExpiresAt, row.CreatedAt, row.BookedAt,
+	)
+	return err
+}
+
+func (r *transactionRepository) Transfer(
+	ctx context.Context, txn *transactions.Transaction,
+) (*transactions.Transaction, error) {
+	postRow := convertTransactionToRow(txn)
+
+	// Transfer money securely from one account to another one through DB transactions
+	err := executeDBTransaction(ctx, r.client, func(tx *sql.Tx) error {
+		r.logger.Info("transfer ongoing...")
+		// Lock only the rows of the accounts involved so that unrelated transfers
+		// proceed in parallel, and re-read their balances under the lock
+		accts, err := lockAccounts(ctx, tx, txn.SourceAccountID, txn.TargetAccountID)
+		if err != nil {
+			r.logger.Errorf("failed to lock the accounts: %w", err)
+			return transactions.ErrPostingTransaction(txn.ID).Wrap(err)
+		}
+
+		sacc, ok := accts[txn.SourceAccountID]
+		if !ok {
+			return accounts.ErrFetchingAccount(txn.SourceAccountID)
+		}
+		tacc, ok := accts[txn.TargetAccountID]
+		if !ok {
+			return accounts.ErrFetchingAccount(txn.TargetAccountID)
+		}
+
+		// Frozen and closed accounts neither send nor receive transfers
+		if err := accounts.CheckActive(sacc); err != nil {
+			return err
+		}
+		if err := accounts.CheckActive(tacc); err != nil {
+			return err
+		}
+
+		// Use up the quote in the same DB transaction so that it is claimed by
+		// exactly one transfer
+		if txn.QuoteID != "" {
+			if err := claimQuote(ctx, tx, txn.QuoteID); err != nil {
+				r.logger.Errorf("failed to claim the quote: %w", err)
+				return err
+			}
+		}
+
+		// Serialise the reversals of a transaction so that together they never
+		// take back more than it moved
+		fullReversal := false
+		if txn.ReversalOf != "" {
+			if fullReversal, err = checkReversal(ctx, tx, txn); err != nil {
+				r.logger.Errorf("failed to check the reversal: %w", err)
+				return err
+			}
+		}
+
+		// Check if the source account has sufficient balance, less the amounts
+		// already held on it
+		remaining, err := sacc.AvailableBalance.Sub(txn.Amount)
+		if err != nil {
+			return err
+		}
+		if remaining.IsNegative() {
+			return &transactions.InsufficientFundsError{
+				AccountID: sacc.ID,
+				Balance:   sacc.AvailableBalance,
+				Amount:    txn.Amount,
+			}
+		}
+
+		// A transfer waiting for an approval only holds its amount, which the
+		// balance must cover along with the amounts already held
+		pending := postRow.Status == transactions.StatusPendingApproval
+		if pending {
+			_, err = tx.ExecContext(
+				ctx,
+				"UPDATE accounts SET held = held + $1 WHERE id = $2",
+				txn.Amount, sacc.ID,
+			)
+		} else {
+			// Debit the source account relative to its current balance
+			_, err = tx.ExecContext(
+				ctx,
+				"UPDATE accounts SET balance = balance - $1 WHERE id = $2",
+				txn.Amount, sacc.ID,
+			)
+		}
+		if err != nil {
+			r.logger.Errorf("failed to update the source account: %w", err)
+			switch violatedConstraint(err) {
+			case "accounts_balance_non_negative", "accounts_held_within_balance":
+				return &transactions.InsufficientFundsError{
+					AccountID: sacc.ID,
+					Balance:   sacc.AvailableBalance,
+					Amount:    txn.Amount,
+				}
+			}
+			return transactions.ErrUpdateAccount(sacc.ID).Wrap(err)
+		}
+
+		if !pending {
+			// Credit the target account relative to its current balance
+			_, err = tx.ExecContext(
+				ctx,
+				"UPDATE accounts SET balance = balance + $1 WHERE id = $2",
+				txn.TargetAmount, txn.TargetAccountID,
+			)
+			if err != nil {
+				r.logger.Errorf("failed to update the target account: %w", err)
+				return transactions.ErrUpdateAccount(txn.TargetAccountID).Wrap(err)
+			}
+		}
+
+		// The balances have moved, so the transfer is booked now. A transfer
+		// waiting for an approval is booked again once approved.
+		postRow.BookedAt = time.Now().UTC()
+		if postRow.CreatedAt.IsZero() {
+			postRow.CreatedAt = postRow.BookedAt
+		}
+
+		err = insertTransaction(ctx, tx, postRow)
+		if err != nil {
+			r.logger.Errorf("failed to insert transaction: %w", err)
+			if cerr := transactionConstraintError(err, txn); cerr != nil {
+				return cerr
+			}
+			return transactions.ErrPostingTransaction(txn.ID).Wrap(err)
+		}
+
+		// The last reversal of a transaction takes back all of it once booked
+		if fullReversal && !pending {
+			_, err = tx.ExecContext(
+				ctx,
+				"UPDATE transactions SET status = $1 WHERE id = $2",
+				transactions.StatusReversed, txn.ReversalOf,
+			)
+			if err != nil {
+				r.logger.Errorf("failed to mark the transaction reversed: %w", err)
+				if cerr := transactionConstraintError(err, txn); cerr != nil {
+					return cerr
+				}
+				return transactions.ErrPostingTransaction(txn.ID).Wrap(err)
+			}
+		}
+
+		if pending {
+			r.logger.Info("transfer pending approval")
+
+			return nil
+		}
+
+		// Record the balanced postings of the transfer in the ledger
+		if err := insertTransferEntry(ctx, tx, txn, postRow.BookedAt); err != nil {
+			r.logger.Errorf("failed to insert transfer entry: %w", err)
+			return transactions.ErrPostingTransaction(txn.ID).Wrap(err)
+		}
+
+		r.logger.Info("transfer completed")
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	txn.Status = postRow.Status
+	txn.CreatedAt = postRow.CreatedAt
+	txn.BookedAt = postRow.BookedAt
+
+	return txn, nil
+}
+
+func (r *transactionRepository) Fail(
+	ctx context.Context, txn *transactions.Transaction,
+) (*transactions.Transaction, error) {
+	postRow := convertTransactionToRow(txn)
+	postRow.BookedAt = time.Now().UTC()
+	if postRow.CreatedAt.IsZero() {
+		postRow.CreatedAt = postRow.BookedAt
+	}
+
+	// A failed transaction moves no balance, so it is stored on its own
+	err := executeDBTransaction(ctx, r.client, func(tx *sql.Tx) error {
+		return insertTransaction(ctx, tx, postRow)
+	})
+	if err != nil {
+		r.logger.Errorf("failed to insert the failed transaction: %w", err)
+		if cerr := transactionConstraintError(err, txn); cerr != nil {
+			return nil, cerr
+		}
+		return nil, transactions.ErrPostingTransaction(txn.ID).Wrap(err)
+	}
+
+	txn.CreatedAt = postRow.CreatedAt
+	txn.BookedAt = postRow.BookedAt
+
+	return txn, nil
+}
+
+func (r *transactionRepository) Review(
+	ctx context.Context, id string, review transactions.Review,
+) (*transactions.Transaction, error) {
+	err := executeDBTransaction(ctx, r.client, func(tx *sql.Tx) error {
+		// Lock the accounts before the transaction, in the same order as the
+		// transfers do, so that a review and a transfer cannot deadlock
+		var sourceAccountID, targetAccountID string
+		err := tx.QueryRowContext(
+			ctx,
+			`SELECT source_account_id, target_account_id FROM transactions WHERE id = $1`,
+			id,
+		).Scan(&sourceAccountID, &targetAccountID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return transactions.ErrFetchingTransaction(id)
+		}
+		if err != nil {
+			r.logger.Errorf("failed to fetch the transaction: %w", err)
+			return transactions.ErrReviewingTransaction(id).Wrap(err)
+		}
+
+		accts, err := lockAccounts(ctx, tx, sourceAccountID, targetAccountID)
+		if err != nil {
+			r.logger.Errorf("failed to lock the accounts: %w", err)
+			return transactions.ErrReviewingTransaction(id).Wrap(err)
+		}
+
+		// Only a transaction still waiting for an approval is reviewed
+		var txnRow Transaction
+		err = tx.QueryRowContext(
+			ctx,
+			`SELECT id, source_account_id, target_account_id, amount, currency,
+			target_currency, target_amount, reversal_of, status, expires_at
+			FROM transactions
+			WHERE id = $1
+			FOR UPDATE`,
+			id,
+		).Scan(
+			&txnRow.ID,
+			&txnRow.SourceAccountID,
+			&txnRow.TargetAccountID,
+			&txnRow.Amount,
+			&txnRow.Currency,
+			&txnRow.TargetCurrency,
+			&txnRow.TargetAmount,
+			&txnRow.ReversalOf,
+			&txnRow.Status,
+			&txnRow.ExpiresAt,
+		)
+		if err != nil {
+			r.logger.Errorf("failed to lock the transaction: %w", err)
+			return transactions.ErrReviewingTransaction(id).Wrap(err)
+		}
+		if txnRow.Status != transactions.StatusPendingApproval {
+			return transactions.ErrNotPendingApproval
+		}
+		if txnRow.ExpiresAt.Valid && !txnRow.ExpiresAt.Time.After(review.ReviewedAt) {
+			return transactions.ErrApprovalExpired
+		}
+
+		txn, err := convertTransactionRowToTransaction(txnRow)
+		if err != nil {
+			r.logger.Errorf("failed to convert the transaction row: %w", err)
+			return transactions.ErrReviewingTransaction(id).Wrap(err)
+		}
+
+		if review.Status != transactions.StatusCompleted {
+			// Release the held amount without moving the balances
+			_, err = tx.ExecContext(
+				ctx,
+				"UPDATE accounts SET held = held - $1 WHERE id = $2",
+				txn.Amount, txn.SourceAccountID,
+			)
+			if err != nil {
+				r.logger.Errorf("failed to release the held amount: %w", err)
+				return transactions.ErrUpdateAccount(txn.SourceAccountID).Wrap(err)
+			}
+		} else {
+			// Frozen and closed accounts neither send nor receive transfers
+			for _, accountID := range []string{txn.SourceAccountID, txn.TargetAccountID} {
+				acct, ok := accts[accountID]
+				if !ok {
+					return accounts.ErrFetchingAccount(accountID)
+				}
+				if err := accounts.CheckActive(acct); err != nil {
+					return err
+				}
+			}
+
+			// Other reversals may have been booked while a reversal waited
+			// for its approval, so it is checked again before it is booked
+			if txn.ReversalOf != "" {
+				fullReversal, err := checkReversal(ctx, tx, txn)
+				if err != nil {
+					r.logger.Errorf("failed to check the reversal: %w", err)
+					return err
+				}
+				if fullReversal {
+					_, err = tx.ExecContext(
+						ctx,
+						"UPDATE transactions SET status = $1 WHERE id = $2",
+						transactions.StatusReversed, txn.ReversalOf,
+					)
+					if err != nil {
+						r.logger.Errorf("failed to mark the transaction reversed: %w", err)
+						if violatedConstraint(err) == "transactions_status_transition" {
+							return transactions.ErrInvalidTransition
+						}
+						return transactions.ErrReviewingTransaction(id).Wrap(err)
+					}
+				}
+			}
+
+			// Debit the held amount from the source account, which leaves the
+			// amount available to other transfers unchanged
+			_, err = tx.ExecContext(
+				ctx,
+				`UPDATE accounts SET balance = balance - $1, held = held - $1
+				WHERE id = $2`,
+				txn.Amount, txn.SourceAccountID,
+			)
+			if err != nil {
+				r.logger.Errorf("failed to update the source account: %w", err)
+				return transactions.ErrUpdateAccount(txn.SourceAccountID).Wrap(err)
+			}
+
+			_, err = tx.ExecContext(
+				ctx,
+				"UPDATE accounts SET balance = balance + $1 WHERE id = $2",
+				txn.TargetAmount, txn.TargetAccountID,
+			)
+			if err != nil {
+				r.logger.Errorf("failed to update the target account: %w", err)
+				return transactions.ErrUpdateAccount(txn.TargetAccountID).Wrap(err)
+			}
+
+			if err := insertTransferEntry(ctx, tx, txn, review.ReviewedAt); err != nil {
+				r.logger.Errorf("failed to insert transfer entry: %w", err)
+				return transactions.ErrReviewingTransaction(id).Wrap(err)
+			}
+		}
+
+		// An approved transaction is booked at the time it was approved
+		reviewedBy := sql.NullString{String: review.ReviewedBy, Valid: review.ReviewedBy != ""}
+		_, err = tx.ExecContext(
+			ctx,
+			`UPDATE transactions
+			SET status = $1, reviewed_by = $2, reviewed_at = $3,
+			booked_at = CASE WHEN $1 = 'completed' THEN $3 ELSE booked_at END
+			WHERE id = $4`,
+			review.Status, reviewedBy, review.ReviewedAt, id,
+		)
+		if err != nil {
+			r.logger.Errorf("failed to update the transaction: %w", err)
+			if violatedConstraint(err) == "transactions_status_transition" {
+				return transactions.ErrInvalidTransition
+			}
+			return transactions.ErrReviewingTransaction(id).Wrap(err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.Find(ctx, id)
+}
+
+func (r *transactionRepository) Expire(
+	ctx context.Context, now time.Time,
+) (int64, error) {
+	var expired int64
+	err := executeDBTransaction(ctx, r.client, func(tx *sql.Tx) error {
+		// Lock the source accounts of the overdue transactions before the
+		// transactions, in the same order as the reviews and the transfers do,
+		// so that an approval racing the expiry cannot deadlock
+		ids, err := queryIDs(
+			ctx, tx,
+			`SELECT DISTINCT source_account_id FROM transactions
+			WHERE status = 'pending_approval' AND expires_at <= $1`,
+			now,
+		)
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+		if _, err := lockAccounts(ctx, tx, ids...); err != nil {
+			return err
+		}
+
+		// Expire the overdue transactions and release what they held on
+		// their source accounts in one statement. The transactions from
+		// other accounts that became overdue in the meantime are left to
+		// the next run.
+		return tx.QueryRowContext(
+			ctx,
+			`WITH expired AS (
+				UPDATE transactions SET status = 'expired'
+				WHERE status = 'pending_approval' AND expires_at <= $1
+				AND source_account_id = ANY($2::uuid[])
+				RETURNING source_account_id, amount
+			), released AS (
+				UPDATE accounts a SET held = a.held - e.amount
+				FROM (
+					SELECT source_account_id, SUM(amount) AS amount
+					FROM expired
+					GROUP BY source_account_id
+				) e
+				WHERE a.id = e.source_account_id
+			)
+			SELECT COUNT(*) FROM expired`,
+			now, pq.Array(ids),
+		).Scan(&expired)
+	})
+	if err != nil {
+		r.logger.Errorf("an error occurred expiring the pending approvals: %w", err)
+		return 0, transactions.ErrExpiringApprovals.Wrap(err)
+	}
+
+	return expired, nil
+}
+
+// insertTransferEntry records the balanced postings of a transfer booked at
+// the given time in the ledger
+func insertTransferEntry(
+	ctx context.Context, tx *sql.Tx, txn *transactions.Transaction, bookedAt time.Time,
+) error {
+	newEntry := ledger.NewTransferEntry
+	if txn.ReversalOf != "" {
+		newEntry = ledger.NewReversalEntry
+	}
+	entry := newEntry(
+		txn.ID, txn.SourceAccountID, txn.TargetAccountID, txn.Amount, txn.TargetAmount,
+	)
+	entry.CreatedAt = bookedAt
+	return insertJournalEntry(ctx, tx, &entry)
+}
+
+// lockAccounts takes row level locks on the given accounts until the end of the
+// DB transaction and returns them by ID. The rows are always locked in ID order
+// so that two transfers in opposite directions between the same accounts cannot
+// deadlock.
+func lockAccounts(
+	ctx context.Context, tx *sql.Tx, ids ...string,
+) (map[string]*accounts.Account, error) {
+	placeholders := make([]interface{}, len(ids))
+	inquery := "$1"
+	for i, id := range ids {
+		placeholders[i] = id
+		if i > 0 {
+			inquery += ",$" + fmt.Sprint(i+1)
+		}
+	}
+
+	rows, err := tx.QueryContext(
+		ctx,
+		`SELECT id, balance, held, currency, status, status_reason, status_changed_at,
+		created_at, customer_id
+		FROM accounts
+		WHERE id IN (`+inquery+`)
+		ORDER BY id
+		FOR UPDATE`,
+		placeholders...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	accts := make(map[string]*accounts.Account)
+	for rows.Next() {
+		var acctRow Account
+		err := rows.Scan(
+			&acctRow.ID,
+			&acctRow.Balance,
+			&acctRow.Held,
+			&acctRow.Currency,
+			&acctRow.Status,
+			&acctRow.StatusReason,
+			&acctRow.StatusChangedAt,
+			&acctRow.CreatedAt,
+			&acctRow.CustomerID,
+		)
+		if err != nil {
+			return nil, err
+		}
+		acct, err := convertAccountRowToAccount(acctRow)
+		if err != nil {
+			return nil, err
+		}
+		accts[acct.ID] = acct
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return accts, nil
+}
+
+// queryIDs returns the IDs selected by a query in the DB transaction
+func queryIDs(
+	ctx context.Context, tx *sql.Tx, query string, args ...interface{},
+) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// insertJournalEntry stores a journal entry and its postings, and refuses the
+// entries whose postings do not balance
+func insertJournalEntry(
+	ctx context.Context, tx *sql.Tx, entry *ledger.JournalEntry,
+) error {
+	if err := entry.Validate(); err != nil {
+		return err
+	}
+
+	entryRow := JournalEntry{
+		ID:            entry.ID,
+		TransactionID: sql.NullString{String: entry.TransactionID, Valid: entry.TransactionID != ""},
+		Kind:          entry.Kind,
+		CreatedAt:     entry.CreatedAt,
+	}
+	_, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO journal_entries (id, transaction_id, kind, created_at)
+		VALUES ($1, $2, $3, $4)`,
+		entryRow.ID, entryRow.TransactionID, entryRow.Kind, entryRow.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range entry.Postings {
+		postingRow := Posting{
+			JournalEntryID: entry.ID,
+			AccountID:      sql.NullString{String: p.AccountID, Valid: p.AccountID != ""},
+			SystemAccount:  sql.NullString{String: p.SystemAccount, Valid: p.SystemAccount != ""},
+			Amount:         p.Amount.String(),
+			Currency:       p.Currency,
+		}
+		_, err := tx.ExecContext(
+			ctx,
+			`INSERT INTO postings
+			(journal_entry_id, account_id, system_account, amount, currency)
+			VALUES ($1, $2, $3, $4, $5)`,
+			postingRow.JournalEntryID, postingRow.AccountID, postingRow.SystemAccount,
+			postingRow.Amount, postingRow.Currency,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkReversal locks the transaction reversed by txn and refuses the reversal
+// if, together with the previous ones, it takes back more than the transaction
+// moved. It returns true if the reversal takes back all that remains of it.
+func checkReversal(
+	ctx context.Context, tx *sql.Tx, txn *transactions.Transaction,
+) (bool, error) {
+	var (
+		amount, status string
+		reversalOf     sql.NullString
+	)
+	err := tx.QueryRowContext(
+		ctx,
+		`SELECT amount, reversal_of, status FROM transactions WHERE id = $1 FOR UPDATE`,
+		txn.ReversalOf,
+	).Scan(&amount, &reversalOf, &status)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return false, transactions.ErrFetchingTransaction(txn.ReversalOf)
+	case err != nil:
+		return false, err
+	case reversalOf.Valid:
+		return false, transactions.ErrReversingReversal
+	case status == transactions.StatusReversed:
+		return false, transactions.ErrReversalExceedsOriginal
+	case status != transactions.StatusCompleted:
+		return false, transactions.ErrReversingIncomplete
+	}
+
+	// The booked reversals take back the original amount through their target
+	// leg, whereas the failed ones took nothing back
+	var reversed string
+	err = tx.QueryRowContext(
+		ctx,
+		`SELECT COALESCE(SUM(target_amount), 0) FROM transactions
+		WHERE reversal_of = $1 AND status IN ('completed', 'reversed')`,
+		txn.ReversalOf,
+	).Scan(&reversed)
+	if err != nil {
+		return false, err
+	}
+
+	original, err := money.Parse(amount, txn.TargetCurrency)
+	if err != nil {
+		return false, err
+	}
+	total, err := money.Parse(reversed, txn.TargetCurrency)
+	if err != nil {
+		return false, err
+	}
+	if total, err = total.Add(txn.TargetAmount); err != nil {
+		return false, err
+	}
+	cmp, err := total.Cmp(original)
+	if err != nil {
+		return false, err
+	}
+	if cmp > 0 {
+		return false, transactions.ErrReversalExceedsOriginal
+	}
+
+	return cmp == 0, nil
+}
+
+// claimQuote marks a quote as used unless it has already been used or has
+// expired, in which case the matching error is returned
+func claimQuote(ctx context.Context, tx *sql.Tx, id string) error {
+	res, err := tx.ExecContext(
+		ctx,
+		`UPDATE fx_quotes SET used_at = now()
+		WHERE id = $1 AND used_at IS NULL AND expires_at > now()`,
+		id,
+	)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 1 {
+		return err
+	}
+
+	// Find out why the quote could not be claimed
+	var used bool
+	err = tx.QueryRowContext(
+		ctx,
+		`SELECT used_at IS NOT NULL FROM fx_quotes WHERE id = $1`,
+		id,
+	).Scan(&used)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return fx.ErrQuoteNotFound
+	case err != nil:
+		return err
+	case used:
+		return fx.ErrQuoteUsed
+	}
+	return fx.ErrQuoteExpired
+}
+
+// executeDBTransaction executes a safe transaction via the provided function
+func executeDBTransaction(
+	ctx context.Context, client *sql.DB, fn func(tx *sql.Tx) error,
+) error {
+	tx, err := client.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	err = fn(tx)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return nil
+}
+
+type quoteRepository struct {
+	client *sql.DB
+	logger *zap.SugaredLogger
+}
+
+// NewQuoteRepository returns a new instance of a postgres fx quote repository.
+func NewQuoteRepository(
+	client *sql.DB, logger *zap.SugaredLogger,
+) fx.QuoteRepository {
+	r := &quoteRepository{
+		client: client,
+		logger: logger,
+	}
+
+	return r
+}
+
+func (r *quoteRepository) Store(
+	ctx context.Context, q *fx.Quote,
+) (*fx.Quote, error) {
+	quoteRow := Quote{
+		ID:             q.ID,
+		SourceCurrency: q.SourceCurrency,
+		TargetCurrency: q.TargetCurrency,
+		SourceAmount:   q.SourceAmount.String(),
+		TargetAmount:   q.TargetAmount.String(),
+		Rate:           q.Rate,
+		RateTimestamp:  q.RateTimestamp,
+		ExpiresAt:      q.ExpiresAt,
+	}
+
+	_, err := r.client.ExecContext(
+		ctx,
+		`INSERT INTO fx_quotes
+		(id, source_currency, target_currency, source_amount, target_amount,
+		rate, rate_timestamp, expires_at) VALUES
+		($1, $2, $3, $4, $5, $6, $7, $8)`,
+		quoteRow.ID, quoteRow.SourceCurrency, quoteRow.TargetCurrency,
+		quoteRow.SourceAmount, quoteRow.TargetAmount, quoteRow.Rate,
+		quoteRow.RateTimestamp, quoteRow.ExpiresAt,
+	)
+	if err != nil {
+		r.logger.Errorf("failed to insert quote: %w", err)
+		return nil, fx.ErrPostingQuote(q.ID).Wrap(err)
+	}
+
+	return q, nil
+}
+
+func convertQuoteRowToQuote(q Quote) (*fx.Quote, error) {
+	sourceAmount, err := money.Parse(q.SourceAmount, q.SourceCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	targetAmount, err := money.Parse(q.TargetAmount, q.TargetCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	quote := &fx.Quote{
+		ID:             q.ID,
+		SourceCurrency: q.SourceCurrency,
+		TargetCurrency: q.TargetCurrency,
+		SourceAmount:   sourceAmount,
+		TargetAmount:   targetAmount,
+		Rate:           q.Rate,
+		RateTimestamp:  q.RateTimestamp,
+		ExpiresAt:      q.ExpiresAt,
+	}
+	if q.UsedAt.Valid {
+		quote.UsedAt = &q.UsedAt.Time
+	}
+
+	return quote, nil
+}
+
+func (r *quoteRepository) Find(
+	ctx context.Context, id string,
+) (*fx.Quote, error) {
+	var quoteRow Quote
+
+	row := r.client.QueryRowContext(
+		ctx,
+		`SELECT id, source_currency, target_currency, source_amount, target_amount,
+		rate, rate_timestamp, expires_at, used_at
+		FROM fx_quotes
+		WHERE id = $1`,
+		id,
+	)
+	err := row.Scan(
+		&quoteRow.ID,
+		&quoteRow.SourceCurrency,
+		&quoteRow.TargetCurrency,
+		&quoteRow.SourceAmount,
+		&quoteRow.TargetAmount,
+		&quoteRow.Rate,
+		&quoteRow.RateTimestamp,
+		&quoteRow.ExpiresAt,
+		&quoteRow.UsedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fx.ErrQuoteNotFound
+	}
+	if err != nil {
+		r.logger.Errorf("an error occurred fetching quote row: %w", err)
+		return nil, fx.ErrFetchingQuote(id).Wrap(err)
+	}
+
+	quote, err := convertQuoteRowToQuote(quoteRow)
+	if err != nil {
+		r.logger.Errorf("an error occurred converting quote row: %w", err)
+		return nil, fx.ErrFetchingQuote(id).Wrap(err)
+	}
+
+	return quote, nil
+}
+
+type idempotencyRepository struct {
+	client *sql.DB
+	logger *zap.SugaredLogger
+}
+
+// NewIdempotencyRepository returns a new instance of a postgres idempotency repository.
+func NewIdempotencyRepository(
+	client *sql.DB, logger *zap.SugaredLogger,
+) idempotency.Repository {
+	r := &idempotencyRepository{
+		client: client,
+		logger: logger,
+	}
+
+	return r
+}
+
+func (r *idempotencyRepository) Store(
+	ctx context.Context, rec *idempotency.Record,
+) (*idempotency.Record, error) {
+	// The key is reserved by the first request only
+	res, err := r.client.ExecContext(
+		ctx,
+		`INSERT INTO idempotency_keys (key, request_hash, created_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (key) DO NOTHING`,
+		rec.Key, rec.RequestHash, rec.CreatedAt,
+	)
+	if err != nil {
+		r.logger.Errorf("failed to insert idempotency record: %w", err)
+		return nil, idempotency.ErrPostingRecord(rec.Key).Wrap(err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		r.logger.Errorf("failed to insert idempotency record: %w", err)
+		return nil, idempotency.ErrPostingRecord(rec.Key).Wrap(err)
+	}
+	if n == 0 {
+		return nil, idempotency.ErrKeyExists
+	}
+
+	return rec, nil
+}
+
+func (r *idempotencyRepository) Find(
+	ctx context.Context, key string,
+) (*idempotency.Record, error) {
+	var recRow IdempotencyRecord
+
+	row := r.client.QueryRowContext(
+		ctx,
+		`SELECT key, request_hash, status_code, content_type, response_body, created_at
+		FROM idempotency_keys
+		WHERE key = $1`,
+		key,
+	)
+	err := row.Scan(
+		&recRow.Key,
+		&recRow.RequestHash,
+		&recRow.StatusCode,
+		&recRow.ContentType,
+		&recRow.ResponseBody,
+		&recRow.CreatedAt,
+	)
+	if err != nil {
+		r.logger.Errorf("an error occurred fetching idempotency row: %w", err)
+		return nil, idempotency.ErrFetchingRecord(key).Wrap(err)
+	}
+
+	return &idempotency.Record{
+		Key:         recRow.Key,
+		RequestHash: recRow.RequestHash,
+		StatusCode:  int(recRow.StatusCode.Int64),
+		ContentType: recRow.ContentType.String,
+		Body:        recRow.ResponseBody,
+		CreatedAt:   recRow.CreatedAt,
+	}, nil
+}
+
+func (r *idempotencyRepository) Complete(
+	ctx context.Context, rec *idempotency.Record,
+) error {
+	_, err := r.client.ExecContext(
+		ctx,
+		`UPDATE idempotency_keys
+		SET status_code = $2, content_type = $3, response_body = $4
+		WHERE key = $1`,
+		rec.Key, rec.StatusCode, rec.ContentType, rec.Body,
+	)
+	if err != nil {
+		r.logger.Errorf("failed to update idempotency record: %w", err)
+		return idempotency.ErrUpdatingRecord(rec.Key).Wrap(err)
+	}
+	return nil
+}
+
+func (r *idempotencyRepository) Delete(ctx context.Context, key string) error {
+	_, err := r.client.ExecContext(
+		ctx,
+		`DELETE FROM idempotency_keys WHERE key = $1`,
+		key,
+	)
+	if err != nil {
+		r.logger.Errorf("failed to delete idempotency record: %w", err)
+		return idempotency.ErrDeletingRecord(key).Wrap(err)
+	}
+	return nil
+}
+
+func (r *idempotencyRepository) DeleteBefore(
+	ctx context.Context, t time.Time,
+) (int64, error) {
+	res, err := r.client.ExecContext(
+		ctx,
+		`DELETE FROM idempotency_keys WHERE created_at < $1`,
+		t,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+type ledgerRepository struct {
+	client *sql.DB
+	logger *zap.SugaredLogger
+}
+
+// NewLedgerRepository returns a new instance of a postgres ledger repository.
+func NewLedgerRepository(
+	client *sql.DB, logger *zap.SugaredLogger,
+) ledger.Repository {
+	r := &ledgerRepository{
+		client: client,
+		logger: logger,
+	}
+
+	return r
+}
+
+func (r *ledgerRepository) FindByTransaction(
+	ctx context.Context, transactionID string,
+) (*ledger.JournalEntry, error) {
+	// The transaction_id column is a uuid: an ID that is not one names no
+	// journal entry
+	if _, err := uuid.FromString(transactionID); err != nil {
+		return nil, ledger.ErrFetchingEntry(transactionID)
+	}
+
+	var entryRow JournalEntry
+
+	row := r.client.QueryRowContext(
+		ctx,
+		`SELECT id, transaction_id, kind, created_at
+		FROM journal_entries
+		WHERE transaction_id = $1`,
+		transactionID,
+	)
+	err := row.Scan(
+		&entryRow.ID,
+		&entryRow.TransactionID,
+		&entryRow.Kind,
+		&entryRow.CreatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ledger.ErrFetchingEntry(transactionID)
+	}
+	if err != nil {
+		r.logger.Errorf("an error occurred fetching journal entry row: %w", err)
+		return nil, ledger.ErrQueryingEntry(transactionID).Wrap(err)
+	}
+
+	rows, err := r.client.QueryContext(
+		ctx,
+		`SELECT journal_entry_id, account_id, system_account, amount, currency
+		FROM postings
+		WHERE journal_entry_id = $1
+		ORDER BY id`,
+		entryRow.ID,
+	)
+	if err != nil {
+		r.logger.Errorf("an error occurred querying posting rows: %w", err)
+		return nil, ledger.ErrQueryingEntry(transactionID).Wrap(err)
+	}
+	defer rows.Close()
+
+	entry := &ledger.JournalEntry{
+		ID:            entryRow.ID,
+		TransactionID: entryRow.TransactionID.String,
+		Kind:          entryRow.Kind,
+		Postings:      make([]ledger.Posting, 0),
+		CreatedAt:     entryRow.CreatedAt,
+	}
+	for rows.Next() {
+		var postingRow Posting
+		err := rows.Scan(
+			&postingRow.JournalEntryID,
+			&postingRow.AccountID,
+			&postingRow.SystemAccount,
+			&postingRow.Amount,
+			&postingRow.Currency,
+		)
+		if err != nil {
+			r.logger.Errorf("an error occurred scanning posting row: %w", err)
+			return nil, ledger.ErrQueryingEntry(transactionID).Wrap(err)
+		}
+		amount, err := money.Parse(postingRow.Amount, postingRow.Currency)
+		if err != nil {
+			r.logger.Errorf("an error occurred converting posting row: %w", err)
+			return nil, ledger.ErrQueryingEntry(transactionID).Wrap(err)
+		}
+		entry.Postings = append(entry.Postings, ledger.Posting{
+			AccountID:     postingRow.AccountID.String,
+			SystemAccount: postingRow.SystemAccount.String,
+			Amount:        amount,
+			Currency:      postingRow.Currency,
+		})
+	}
+
+	if err = rows.Err(); err != nil {
+		r.logger.Errorf("an error occurred iterating posting rows: %w", err)
+		return nil, ledger.ErrQueryingEntry(transactionID).Wrap(err)
+	}
+
+	return entry, nil
+}
+
+func (r *ledgerRepository) PostedBalance(
+	ctx context.Context, accountID, currency string,
+) (money.Money, error) {
+	var sum string
+
+	err := r.client.QueryRowContext(
+		ctx,
+		`SELECT COALESCE(SUM(amount), 0)
+		FROM postings
+		WHERE account_id = $1 AND currency = $2`,
+		accountID, currency,
+	).Scan(&sum)
+	if err != nil {
+		r.logger.Errorf("an error occurred summing posting rows: %w", err)
+		return money.Money{}, ledger.ErrFetchingBalance(accountID).Wrap(err)
+	}
+
+	balance, err := money.Parse(sum, currency)
+	if err != nil {
+		r.logger.Errorf("an error occurred converting the posted balance: %w", err)
+		return money.Money{}, ledger.ErrFetchingBalance(accountID).Wrap(err)
+	}
+
+	return balance, nil
+}
+
+type healthcheckRepository struct {
+	client *sql.DB
+	logger *zap.SugaredLogger
+}
+
+// NewHealthcheckRepository returns a new instance of a postgres healthcheck repository.
+func NewHealthcheckRepository(
+	client *sql.DB, logger *zap.SugaredLogger,
+) healthchecks.HealthcheckRepository {
+	r := &healthcheckRepository{
+		client: client,
+		logger: logger,
+	}
+
+	return r
+}
+
+func (r *healthcheckRepository) Ping(ctx context.Context) error {
+	return r.client.PingContext(ctx)
+}
+
+type customerRepository struct {
+	client *sql.DB
+	logger *zap.SugaredLogger
+}
+
+// NewCustomerRepository returns a new instance of a postgres customer repository.
+func NewCustomerRepository(
+	client *sql.DB, logger *zap.SugaredLogger,
+) customers.Repository {
+	r := &customerRepository{
+		client: client,
+		logger: logger,
+	}
+
+	return r
+}
+
+func (r *customerRepository) Store(
+	ctx context.Context, customer *customers.Customer,
+) (*customers.Customer, error) {
+	_, err := r.client.ExecContext(
+		ctx,
+		`INSERT INTO customers (id, name, email, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5)`,
+		customer.ID, customer.Name, customer.Email, customer.CreatedAt, customer.UpdatedAt,
+	)
+	if err != nil {
+		r.logger.Errorf("failed to insert customer: %w", err)
+		if cerr := customerConstraintError(err); cerr != nil {
+			return nil, cerr
+		}
+		return nil, customers.ErrPostingCustomer(customer.ID).Wrap(err)
+	}
+
+	return customer, nil
+}
+
+func (r *customerRepository) Find(
+	ctx context.Context, id string,
+) (*customers.Customer, error) {
+	var customerRow Customer
+
+	row := r.client.QueryRowContext(
+		ctx,
+		`SELECT id, name, email, created_at, updated_at
+		FROM customers
+		WHERE id = $1`,
+		id,
+	)
+	err := row.Scan(
+		&customerRow.ID,
+		&customerRow.Name,
+		&customerRow.Email,
+		&customerRow.CreatedAt,
+		&customerRow.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, customers.ErrFetchingCustomer(id)
+	}
+	if err != nil {
+		r.logger.Errorf("an error occurred fetching customer row: %w", err)
+		return nil, customers.ErrQueryingCustomer(id).Wrap(err)
+	}
+
+	return &customers.Customer{
+		ID:        customerRow.ID,
+		Name:      customerRow.Name,
+		Email:     customerRow.Email,
+		CreatedAt: customerRow.CreatedAt,
+		UpdatedAt: customerRow.UpdatedAt,
+	}, nil
+}
+
+func (r *customerRepository) Update(
+	ctx context.Context, customer *customers.Customer,
+) (*customers.Customer, error) {
+	res, err := r.client.ExecContext(
+		ctx,
+		`UPDATE customers SET name = $2, email = $3, updated_at = $4
+		WHERE id = $1`,
+		customer.ID, customer.Name, customer.Email, customer.UpdatedAt,
+	)
+	if err != nil {
+		r.logger.Errorf("failed to update customer: %w", err)
+		if cerr := customerConstraintError(err); cerr != nil {
+			return nil, cerr
+		}
+		return nil, customers.ErrUpdatingCustomer(customer.ID).Wrap(err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		r.logger.Errorf("failed to update customer: %w", err)
+		return nil, customers.ErrUpdatingCustomer(customer.ID).Wrap(err)
+	}
+	if n == 0 {
+		return nil, customers.ErrFetchingCustomer(customer.ID)
+	}
+
+	return customer, nil
+}
+
+func (r *customerRepository) Delete(ctx context.Context, id string) error {
+	// The accounts keep their customer, so removing a customer owning
+	// accounts violates their foreign key
+	res, err := r.client.ExecContext(
+		ctx,
+		`DELETE FROM customers WHERE id = $1`,
+		id,
+	)
+	if err != nil {
+		r.logger.Errorf("failed to delete customer: %w", err)
+		if cerr := customerConstraintError(err); cerr != nil {
+			return cerr
+		}
+		return customers.ErrDeletingCustomer(id).Wrap(err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		r.logger.Errorf("failed to delete customer: %w", err)
+		return customers.ErrDeletingCustomer(id).Wrap(err)
+	}
+	if n == 0 {
+		return customers.ErrFetchingCustomer(id)
+	}
+
+	return nil
+}
+
+type apiKeyRepository struct {
+	client *sql.DB
+	logger *zap.SugaredLogger
+}
+
+// NewAPIKeyRepository returns a new instance of a postgres API key repository.
+func NewAPIKeyRepository(
+	client *sql.DB, logger *zap.SugaredLogger,
+) auth.Repository {
+	r := &apiKeyRepository{
+		client: client,
+		logger: logger,
+	}
+
+	return r
+}
+
+func (r *apiKeyRepository) Store(
+	ctx context.Context, key *auth.Key,
+) (*auth.Key, error) {
+	scopes := make(pq.StringArray, 0, len(key.Scopes))
+	for _, scope := range key.Scopes {
+		scopes = append(scopes, string(scope))
+	}
+
+	_, err := r.client.ExecContext(
+		ctx,
+		`INSERT INTO api_keys (id, name, prefix, key_hash, scopes, customer_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		key.ID, key.Name, key.Prefix, key.Hash, scopes,
+		sql.NullString{String: key.CustomerID, Valid: key.CustomerID != ""},
+		key.CreatedAt,
+	)
+	if err != nil {
+		r.logger.Errorf("failed to insert API key: %w", err)
+		if violatedConstraint(err) == "api_keys_customer_fk" {
+			return nil, auth.ErrUnknownCustomer
+		}
+		return nil, auth.ErrPostingKey(key.ID).Wrap(err)
+	}
+
+	return key, nil
+}
+
+func (r *apiKeyRepository) FindByHash(
+	ctx context.Context, hash string,
+) (*auth.Key, error) {
+	var keyRow APIKey
+
+	row := r.client.QueryRowContext(
+		ctx,
+		`SELECT id, name, prefix, key_hash, scopes, customer_id, created_at, revoked_at
+		FROM api_keys
+		WHERE key_hash = $1`,
+		hash,
+	)
+	err := row.Scan(
+		&keyRow.ID,
+		&keyRow.Name,
+		&keyRow.Prefix,
+		&keyRow.KeyHash,
+		&keyRow.Scopes,
+		&keyRow.CustomerID,
+		&keyRow.CreatedAt,
+		&keyRow.RevokedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, auth.ErrInvalidKey
+	}
+	if err != nil {
+		r.logger.Errorf("an error occurred fetching API key row: %w", err)
+		return nil, auth.ErrQueryingKeys.Wrap(err)
+	}
+
+	key := convertAPIKeyRowToKey(keyRow)
+	return &key, nil
+}
+
+func (r *apiKeyRepository) FindAll(ctx context.Context) ([]auth.Key, error) {
+	rows, err := r.client.QueryContext(
+		ctx,
+		`SELECT id, name, prefix, key_hash, scopes, customer_id, created_at, revoked_at
+		FROM api_keys
+		ORDER BY created_at DESC, id DESC`,
+	)
+	if err != nil {
+		r.logger.Errorf("an error occurred querying API keys: %w", err)
+		return nil, auth.ErrQueryingKeys.Wrap(err)
+	}
+	defer rows.Close()
+
+	keys := []auth.Key{}
+	for rows.Next() {
+		var keyRow APIKey
+		err := rows.Scan(
+			&keyRow.ID,
+			&keyRow.Name,
+			&keyRow.Prefix,
+			&keyRow.KeyHash,
+			&keyRow.Scopes,
+			&keyRow.CustomerID,
+			&keyRow.CreatedAt,
+			&keyRow.RevokedAt,
+		)
+		if err != nil {
+			r.logger.Errorf("an error occurred scanning API key row: %w", err)
+			return nil, auth.ErrQueryingKeys.Wrap(err)
+		}
+		keys = append(keys, convertAPIKeyRowToKey(keyRow))
+	}
+
+	if err = rows.Err(); err != nil {
+		r.logger.Errorf("an error occurred iterating API key rows: %w", err)
+		return nil, auth.ErrQueryingKeys.Wrap(err)
+	}
+
+	return keys, nil
+}
+
+func (r *apiKeyRepository) Revoke(
+	ctx context.Context, id string, at time.Time,
+) error {
+	// Revoking a revoked key keeps the time it was first revoked at
+	res, err := r.client.ExecContext(
+		ctx,
+		`UPDATE api_keys
+		SET revoked_at = COALESCE(revoked_at, $2)
+		WHERE id = $1`,
+		id, at,
+	)
+	if err != nil {
+		r.logger.Errorf("failed to revoke API key: %w", err)
+		return auth.ErrRevokingKey(id).Wrap(err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		r.logger.Errorf("failed to revoke API key: %w", err)
+		return auth.ErrRevokingKey(id).Wrap(err)
+	}
+	if n == 0 {
+		return auth.ErrFetchingKey(id)
+	}
+
+	return nil
+}
+
+// convertAPIKeyRowToKey converts an API key row to an API key
+func convertAPIKeyRowToKey(keyRow APIKey) auth.Key {
+	key := auth.Key{
+		ID:         keyRow.ID,
+		Name:       keyRow.Name,
+		Prefix:     keyRow.Prefix,
+		Hash:       keyRow.KeyHash,
+		Scopes:     make([]auth.Scope, 0, len(keyRow.Scopes)),
+		CustomerID: keyRow.CustomerID.String,
+		CreatedAt:  keyRow.CreatedAt,
+	}
+	for _, scope := range keyRow.Scopes {
+		key.Scopes = append(key.Scopes, auth.Scope(scope))
+	}
+	if keyRow.RevokedAt.Valid {
+		revokedAt := keyRow.RevokedAt.Time
+		key.RevokedAt = &revokedAt
+	}
+	return key
+}
+
+type roleRepository struct {
+	client *sql.DB
+	logger *zap.SugaredLogger
+}
+
+// NewRoleRepository returns a new instance of a postgres account role repository.
+func NewRoleRepository(
+	client *sql.DB, logger *zap.SugaredLogger,
+) accounts.RoleRepository {
+	r := &roleRepository{
+		client: client,
+		logger: logger,
+	}
+
+	return r
+}
+
+func (r *roleRepository) Find(
+	ctx context.Context, accountID, customerID string,
+) (*accounts.Grant, error) {
+	var roleRow AccountRole
+
+	row := r.client.QueryRowContext(
+		ctx,
+		`SELECT account_id, customer_id, role, granted_by, granted_at
+		FROM account_roles
+		WHERE account_id = $1 AND customer_id = $2`,
+		accountID, customerID,
+	)
+	err := row.Scan(
+		&roleRow.AccountID,
+		&roleRow.CustomerID,
+		&roleRow.Role,
+		&roleRow.GrantedBy,
+		&roleRow.GrantedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, accounts.ErrFetchingRole(accountID, customerID)
+	}
+	if err != nil {
+		r.logger.Errorf("an error occurred fetching account role row: %w", err)
+		return nil, accounts.ErrQueryingRoles(accountID).Wrap(err)
+	}
+
+	return convertRoleRowToGrant(roleRow), nil
+}
+
+func (r *roleRepository) FindAll(
+	ctx context.Context, accountID string,
+) ([]*accounts.Grant, error) {
+	rows, err := r.client.QueryContext(
+		ctx,
+		`SELECT account_id, customer_id, role, granted_by, granted_at
+		FROM account_roles
+		WHERE account_id = $1
+		ORDER BY customer_id`,
+		accountID,
+	)
+	if err != nil {
+		r.logger.Errorf("an error occurred querying account role rows: %w", err)
+		return nil, accounts.ErrQueryingRoles(accountID).Wrap(err)
+	}
+	defer rows.Close()
+
+	grants := make([]*accounts.Grant, 0)
+	for rows.Next() {
+		var roleRow AccountRole
+		err := rows.Scan(
+			&roleRow.AccountID,
+			&roleRow.CustomerID,
+			&roleRow.Role,
+			&roleRow.GrantedBy,
+			&roleRow.GrantedAt,
+		)
+		if err != nil {
+			r.logger.Errorf("an error occurred scanning account role row: %w", err)
+			return nil, accounts.ErrQueryingRoles(accountID).Wrap(err)
+		}
+		grants = append(grants, convertRoleRowToGrant(roleRow))
+	}
+
+	if err = rows.Err(); err != nil {
+		r.logger.Errorf("an error occurred iterating account role rows: %w", err)
+		return nil, accounts.ErrQueryingRoles(accountID).Wrap(err)
+	}
+
+	return grants, nil
+}
+
+func (r *roleRepository) Grant(
+	ctx context.Context, grant *accounts.Grant, change *accounts.RoleChange,
+) (*accounts.Grant, error) {
+	err := executeDBTransaction(ctx, r.client, func(tx *sql.Tx) error {
+		// Granting a role to a customer holding one replaces it
+		_, err := tx.ExecContext(
+			ctx,
+			`INSERT INTO account_roles (account_id, customer_id, role, granted_by, granted_at)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (account_id, customer_id) DO UPDATE
+			SET role = EXCLUDED.role,
+				granted_by = EXCLUDED.granted_by,
+				granted_at = EXCLUDED.granted_at`,
+			grant.AccountID,
+			grant.CustomerID,
+			string(grant.Role),
+			sql.NullString{String: grant.GrantedBy, Valid: grant.GrantedBy != ""},
+			grant.GrantedAt,
+		)
+		if err != nil {
+			r.logger.Errorf("failed to insert account role: %w", err)
+			if cerr := roleConstraintError(err, grant.AccountID); cerr != nil {
+				return cerr
+			}
+			return accounts.ErrChangingRole(grant.AccountID, grant.CustomerID).Wrap(err)
+		}
+
+		return r.storeChange(ctx, tx, change)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return grant, nil
+}
+
+func (r *roleRepository) Revoke(
+	ctx context.Context, change *accounts.RoleChange,
+) error {
+	return executeDBTransaction(ctx, r.client, func(tx *sql.Tx) error {
+		var role string
+		err := tx.QueryRowContext(
+			ctx,
+			`DELETE FROM account_roles
+			WHERE account_id = $1 AND customer_id = $2
+			RETURNING role`,
+			change.AccountID, change.CustomerID,
+		).Scan(&role)
+		if errors.Is(err, sql.ErrNoRows) {
+			return accounts.ErrFetchingRole(change.AccountID, change.CustomerID)
+		}
+		if err != nil {
+			r.logger.Errorf("failed to delete account role: %w", err)
+			return accounts.ErrChangingRole(change.AccountID, change.CustomerID).Wrap(err)
+		}
+
+		// The audit keeps the role that was revoked
+		change.Role = accounts.Role(role)
+		return r.storeChange(ctx, tx, change)
+	})
+}
+
+// storeChange records a role change within the transaction changing the role
+func (r *roleRepository) storeChange(
+	ctx context.Context, tx *sql.Tx, change *accounts.RoleChange,
+) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO account_role_changes
+		(id, account_id, customer_id, action, role, changed_by, changed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		change.ID,
+		change.AccountID,
+		change.CustomerID,
+		change.Action,
+		string(change.Role),
+		sql.NullString{String: change.ChangedBy, Valid: change.ChangedBy != ""},
+		change.ChangedAt,
+	)
+	if err != nil {
+		r.logger.Errorf("failed to insert account role change: %w", err)
+		if cerr := roleConstraintError(err, change.AccountID); cerr != nil {
+			return cerr
+		}
+		return accounts.ErrChangingRole(change.AccountID, change.CustomerID).Wrap(err)
+	}
+	return nil
+}
+
+func (r *roleRepository) Changes(
+	ctx context.Context, accountID string,
+) ([]*accounts.RoleChange, error) {
+	rows, err := r.client.QueryContext(
+		ctx,
+		`SELECT id, account_id, customer_id, action, role, changed_by, changed_at
+		FROM account_role_changes
+		WHERE account_id = $1
+		ORDER BY changed_at, id`,
+		accountID,
+	)
+	if err != nil {
+		r.logger.Errorf("an error occurred querying account role change rows: %w", err)
+		return nil, accounts.ErrQueryingRoles(accountID).Wrap(err)
+	}
+	defer rows.Close()
+
+	changes := make([]*accounts.RoleChange, 0)
+	for rows.Next() {
+		var changeRow AccountRoleChange
+		err := rows.Scan(
+			&changeRow.ID,
+			&changeRow.AccountID,
+			&changeRow.CustomerID,
+			&changeRow.Action,
+			&changeRow.Role,
+			&changeRow.ChangedBy,
+			&changeRow.ChangedAt,
+		)
+		if err != nil {
+			r.logger.Errorf("an error occurred scanning account role change row: %w", err)
+			return nil, accounts.ErrQueryingRoles(accountID).Wrap(err)
+		}
+		changes = append(changes, &accounts.RoleChange{
+			ID:         changeRow.ID,
+			AccountID:  changeRow.AccountID,
+			CustomerID: changeRow.CustomerID,
+			Action:     changeRow.Action,
+			Role:       accounts.Role(changeRow.Role),
+			ChangedBy:  changeRow.ChangedBy.String,
+			ChangedAt:  changeRow.ChangedAt,
+		})
+	}
+
+	if err = rows.Err(); err != nil {
+		r.logger.Errorf("an error occurred iterating account role change rows: %w", err)
+		return nil, accounts.ErrQueryingRoles(accountID).Wrap(err)
+	}
+
+	return changes, nil
+}
+
+// convertRoleRowToGrant converts an account role row to a grant
+func convertRoleRowToGrant(roleRow AccountRole) *accounts.Grant {
+	return &accounts.Grant{
+		AccountID:  roleRow.AccountID,
+		CustomerID: roleRow.CustomerID,
+		Role:       accounts.Role(roleRow.Role),
+		GrantedBy:  roleRow.GrantedBy.String,
+		GrantedAt:  roleRow.GrantedAt,
+	}
+}
+
+type holdRepository struct {
+	client *sql.DB
+	logger *zap.SugaredLogger
+}
+
+// NewHoldRepository returns a new instance of a postgres hold repository.
+func NewHoldRepository(
+	client *sql.DB, logger *zap.SugaredLogger,
+) holds.Repository {
+	r := &holdRepository{
+		client: client,
+		logger: logger,
+	}
+
+	return r
+}
+
+func convertHoldRowToHold(h Hold) (*holds.Hold, error) {
+	amount, err := money.Parse(h.Amount, h.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	hold := &holds.Hold{
+		ID:            h.ID,
+		AccountID:     h.AccountID,
+		Amount:        amount,
+		Currency:      h.Currency,
+		Reference:     h.Reference,
+		Status:        h.Status,
+		TransactionID: h.TransactionID.String,
+		PlacedBy:      h.PlacedBy.String,
+		CreatedAt:     h.CreatedAt,
+		ExpiresAt:     h.ExpiresAt,
+	}
+	if h.CapturedAmount.Valid {
+		captured, err := money.Parse(h.CapturedAmount.String, h.Currency)
+		if err != nil {
+			return nil, err
+		}
+		hold.CapturedAmount = &captured
+	}
+	if h.ReleasedAt.Valid {
+		hold.ReleasedAt = &h.ReleasedAt.Time
+	}
+
+	return hold, nil
+}
+
+func (r *holdRepository) Place(
+	ctx context.Context, hold *holds.Hold,
+) (*holds.Hold, error) {
+	holdRow := Hold{
+		ID:        hold.ID,
+		AccountID: hold.AccountID,
+		Amount:    hold.Amount.String(),
+		Currency:  hold.Currency,
+		Reference: hold.Reference,
+		Status:    hold.Status,
+		PlacedBy:  sql.NullString{String: hold.PlacedBy, Valid: hold.PlacedBy != ""},
+		CreatedAt: hold.CreatedAt,
+		ExpiresAt: hold.ExpiresAt,
+	}
+	if holdRow.Status == "" {
+		holdRow.Status = holds.StatusActive
+	}
+
+	err := executeDBTransaction(ctx, r.client, func(tx *sql.Tx) error {
+		// Re-read the available balance under the lock of the account
+		accts, err := lockAccounts(ctx, tx, hold.AccountID)
+		if err != nil {
+			r.logger.Errorf("failed to lock the account: %w", err)
+			return holds.ErrPostingHold(hold.ID).Wrap(err)
+		}
+		acct, ok := accts[hold.AccountID]
+		if !ok {
+			return accounts.ErrFetchingAccount(hold.AccountID)
+		}
+		if err := accounts.CheckActive(acct); err != nil {
+			return err
+		}
+
+		remaining, err := acct.AvailableBalance.Sub(hold.Amount)
+		if err != nil {
+			return err
+		}
+		insufficient := &transactions.InsufficientFundsError{
+			AccountID: acct.ID,
+			Balance:   acct.AvailableBalance,
+			Amount:    hold.Amount,
+		}
+		if remaining.IsNegative() {
+			return insufficient
+		}
+
+		_, err = tx.ExecContext(
+			ctx,
+			"UPDATE accounts SET held = held + $1 WHERE id = $2",
+			hold.Amount, acct.ID,
+		)
+		if err != nil {
+			r.logger.Errorf("failed to hold the amount: %w", err)
+			if violatedConstraint(err) == "accounts_held_within_balance" {
+				return insufficient
+			}
+			return holds.ErrPostingHold(hold.ID).Wrap(err)
+		}
+
+		_, err = tx.ExecContext(
+			ctx,
+			`INSERT INTO holds
+			(id, account_id, amount, currency, reference, status, placed_by,
+			created_at, expires_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+			holdRow.ID, holdRow.AccountID, holdRow.Amount, holdRow.Currency,
+			holdRow.Reference, holdRow.Status, holdRow.PlacedBy, holdRow.CreatedAt,
+			holdRow.ExpiresAt,
+		)
+		if err != nil {
+			r.logger.Errorf("failed to insert hold: %w", err)
+			if cerr := holdConstraintError(err, hold); cerr != nil {
+				return cerr
+			}
+			return holds.ErrPostingHold(hold.ID).Wrap(err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	hold.Status = holdRow.Status
+	return hold, nil
+}
+
+func (r *holdRepository) Find(
+	ctx context.Context, id string,
+) (*holds.Hold, error) {
+	var holdRow Hold
+	err := r.client.QueryRowContext(
+		ctx,
+		`SELECT id, account_id, amount, currency, reference, status,
+		captured_amount, transaction_id, placed_by, created_at, expires_at,
+		released_at
+		FROM holds
+		WHERE id = $1`,
+		id,
+	).Scan(
+		&holdRow.ID,
+		&holdRow.AccountID,
+		&holdRow.Amount,
+		&holdRow.Currency,
+		&holdRow.Reference,
+		&holdRow.Status,
+		&holdRow.CapturedAmount,
+		&holdRow.TransactionID,
+		&holdRow.PlacedBy,
+		&holdRow.CreatedAt,
+		&holdRow.ExpiresAt,
+		&holdRow.ReleasedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, holds.ErrFetchingHold(id)
+	}
+	if err != nil {
+		r.logger.Errorf("an error occurred fetching hold row: %w", err)
+		return nil, holds.ErrQueryingHold(id).Wrap(err)
+	}
+
+	hold, err := convertHoldRowToHold(holdRow)
+	if err != nil {
+		r.logger.Errorf("an error occurred converting hold row: %w", err)
+		return nil, holds.ErrQueryingHold(id).Wrap(err)
+	}
+
+	return hold, nil
+}
+
+func (r *holdRepository) Capture(
+	ctx context.Context, id string, txn *transactions.Transaction,
+) (*holds.Hold, error) {
+	err := executeDBTransaction(ctx, r.client, func(tx *sql.Tx) error {
+		// Lock the accounts before the hold, in the same order as the
+		// transfers do, so that a capture and a transfer cannot deadlock
+		accts, err := lockAccounts(ctx, tx, txn.SourceAccountID, txn.TargetAccountID)
+		if err != nil {
+			r.logger.Errorf("failed to lock the accounts: %w", err)
+			return holds.ErrReleasingHold(id).Wrap(err)
+		}
+		hold, err := lockHold(ctx, tx, id, txn.CreatedAt)
+		if err != nil {
+			r.logger.Errorf("failed to lock the hold: %w", err)
+			return err
+		}
+		if hold.AccountID != txn.SourceAccountID {
+			return holds.ErrFetchingHold(id)
+		}
+		cmp, err := txn.Amount.Cmp(hold.Amount)
+		if err != nil {
+			return err
+		}
+		if cmp > 0 {
+			return holds.ErrCaptureExceedsHold
+		}
+
+		// Frozen and closed accounts neither send nor receive transfers
+		for _, accountID := range []string{txn.SourceAccountID, txn.TargetAccountID} {
+			acct, ok := accts[accountID]
+			if !ok {
+				return accounts.ErrFetchingAccount(accountID)
+			}
+			if err := accounts.CheckActive(acct); err != nil {
+				return err
+			}
+		}
+
+		// A capture waiting for an approval keeps holding the captured amount
+		// until it is reviewed, and releases the rest of the hold
+		pending := txn.Status == transactions.StatusPendingApproval
+		if pending {
+			_, err = tx.ExecContext(
+				ctx,
+				"UPDATE accounts SET held = held - $1 + $2 WHERE id = $3",
+				hold.Amount, txn.Amount, txn.SourceAccountID,
+			)
+			if err != nil {
+				r.logger.Errorf("failed to update the source account: %w", err)
+				return transactions.ErrUpdateAccount(txn.SourceAccountID).Wrap(err)
+			}
+		} else {
+			// Debit the captured amount and release the whole hold, which
+			// leaves the amount available to other transfers unchanged but
+			// for the rest of the hold
+			_, err = tx.ExecContext(
+				ctx,
+				`UPDATE accounts SET balance = balance - $1, held = held - $2
+				WHERE id = $3`,
+				txn.Amount, hold.Amount, txn.SourceAccountID,
+			)
+			if err != nil {
+				r.logger.Errorf("failed to update the source account: %w", err)
+				return transactions.ErrUpdateAccount(txn.SourceAccountID).Wrap(err)
+			}
+
+			_, err = tx.ExecContext(
+				ctx,
+				"UPDATE accounts SET balance = balance + $1 WHERE id = $2",
+				txn.TargetAmount, txn.TargetAccountID,
+			)
+			if err != nil {
+				r.logger.Errorf("failed to update the target account: %w", err)
+				return transactions.ErrUpdateAccount(txn.TargetAccountID).Wrap(err)
+			}
+		}
+
+		// Book the transfer the hold turned into, which a transfer waiting
+		// for an approval does once approved
+		postRow := convertTransactionToRow(txn)
+		postRow.BookedAt = time.Now().UTC()
+		if err := insertTransaction(ctx, tx, postRow); err != nil {
+			r.logger.Errorf("failed to insert transaction: %w", err)
+			if cerr := transactionConstraintError(err, txn); cerr != nil {
+				return cerr
+			}
+			return transactions.ErrPostingTransaction(txn.ID).Wrap(err)
+		}
+		if !pending {
+			if err := insertTransferEntry(ctx, tx, txn, postRow.BookedAt); err != nil {
+				r.logger.Errorf("failed to insert transfer entry: %w", err)
+				return transactions.ErrPostingTransaction(txn.ID).Wrap(err)
+			}
+		}
+
+		_, err = tx.ExecContext(
+			ctx,
+			`UPDATE holds
+			SET status = $1, captured_amount = $2, transaction_id = $3, released_at = $4
+			WHERE id = $5`,
+			holds.StatusCaptured, txn.Amount, txn.ID, postRow.BookedAt, id,
+		)
+		if err != nil {
+			r.logger.Errorf("failed to update the hold: %w", err)
+			return holds.ErrReleasingHold(id).Wrap(err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.Find(ctx, id)
+}
+
+func (r *holdRepository) Void(
+	ctx context.Context, id string, now time.Time,
+) (*holds.Hold, error) {
+	err := executeDBTransaction(ctx, r.client, func(tx *sql.Tx) error {
+		// Lock the account before the hold, like the captures do
+		var accountID string
+		err := tx.QueryRowContext(
+			ctx, `SELECT account_id FROM holds WHERE id = $1`, id,
+		).Scan(&accountID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return holds.ErrFetchingHold(id)
+		}
+		if err != nil {
+			r.logger.Errorf("failed to fetch the hold: %w", err)
+			return holds.ErrReleasingHold(id).Wrap(err)
+		}
+		if _, err := lockAccounts(ctx, tx, accountID); err != nil {
+			r.logger.Errorf("failed to lock the account: %w", err)
+			return holds.ErrReleasingHold(id).Wrap(err)
+		}
+		hold, err := lockHold(ctx, tx, id, now)
+		if err != nil {
+			r.logger.Errorf("failed to lock the hold: %w", err)
+			return err
+		}
+
+		// Release the held amount without moving the balance
+		_, err = tx.ExecContext(
+			ctx,
+			"UPDATE accounts SET held = held - $1 WHERE id = $2",
+			hold.Amount, hold.AccountID,
+		)
+		if err != nil {
+			r.logger.Errorf("failed to release the held amount: %w", err)
+			return transactions.ErrUpdateAccount(hold.AccountID).Wrap(err)
+		}
+
+		_, err = tx.ExecContext(
+			ctx,
+			"UPDATE holds SET status = $1, released_at = $2 WHERE id = $3",
+			holds.StatusVoided, now, id,
+		)
+		if err != nil {
+			r.logger.Errorf("failed to update the hold: %w", err)
+			return holds.ErrReleasingHold(id).Wrap(err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.Find(ctx, id)
+}
+
+func (r *holdRepository) Expire(
+	ctx context.Context, now time.Time,
+) (int64, error) {
+	var expired int64
+	err := executeDBTransaction(ctx, r.client, func(tx *sql.Tx) error {
+		// Lock the accounts of the overdue holds before the holds, in the same
+		// order as the captures and the voids do, so that they cannot deadlock
+		ids, err := queryIDs(
+			ctx, tx,
+			`SELECT DISTINCT account_id FROM holds
+			WHERE status = 'active' AND expires_at <= $1`,
+			now,
+		)
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+		if _, err := lockAccounts(ctx, tx, ids...); err != nil {
+			return err
+		}
+
+		// Expire the overdue holds and release what they held on their
+		// accounts in one statement. The holds on other accounts that became
+		// overdue in the meantime are left to the next run.
+		return tx.QueryRowContext(
+			ctx,
+			`WITH expired AS (
+				UPDATE holds SET status = 'expired', released_at = $1
+				WHERE status = 'active' AND expires_at <= $1
+				AND account_id = ANY($2::uuid[])
+				RETURNING account_id, amount
+			), released AS (
+				UPDATE accounts a SET held = a.held - e.amount
+				FROM (
+					SELECT account_id, SUM(amount) AS amount
+					FROM expired
+					GROUP BY account_id
+				) e
+				WHERE a.id = e.account_id
+			)
+			SELECT COUNT(*) FROM expired`,
+			now, pq.Array(ids),
+		).Scan(&expired)
+	})
+	if err != nil {
+		r.logger.Errorf("an error occurred expiring the holds: %w", err)
+		return 0, holds.ErrExpiringHolds.Wrap(err)
+	}
+
+	return expired, nil
+}
+
+// lockHold locks a hold in the DB transaction and returns it, unless it is no
+// longer active or past its expiry at the given time
+func lockHold(
+	ctx context.Context, tx *sql.Tx, id string, now time.Time,
+) (*holds.Hold, error) {
+	var holdRow Hold
+	err := tx.QueryRowContext(
+		ctx,
+		`SELECT id, account_id, amount, currency, status, expires_at
+		FROM holds
+		WHERE id = $1
+		FOR UPDATE`,
+		id,
+	).Scan(
+		&holdRow.ID,
+		&holdRow.AccountID,
+		&holdRow.Amount,
+		&holdRow.Currency,
+		&holdRow.Status,
+		&holdRow.ExpiresAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, holds.ErrFetchingHold(id)
+	}
+	if err != nil {
+		return nil, holds.ErrReleasingHold(id).Wrap(err)
+	}
+	if holdRow.Status != holds.StatusActive {
+		return nil, holds.ErrHoldNotActive
+	}
+	if !holdRow.ExpiresAt.After(now) {
+		return nil, holds.ErrHoldExpired
+	}
+
+	hold, err := convertHoldRowToHold(holdRow)
+	if err != nil {
+		return nil, holds.ErrReleasingHold(id).Wrap(err)
+	}
+	return hold, nil
 }
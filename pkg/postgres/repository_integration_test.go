@@ -0,0 +1,991 @@
+//go:build integration
+// +build integration
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"financial-app/pkg/accounts"
+	"financial-app/pkg/auth"
+	"financial-app/pkg/customers"
+	"financial-app/pkg/errs"
+	"financial-app/pkg/holds"
+	"financial-app/pkg/ledger"
+	"financial-app/pkg/money"
+	"financial-app/pkg/pagination"
+	"financial-app/pkg/transactions"
+	"fmt"
+	"os"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// setupDB connects to the database configured by the DB_* environment variables
+func setupDB(tb testing.TB) *sql.DB {
+	connectionString := fmt.Sprintf(
+		"host=%s port=%s user=%s dbname=%s password=%s sslmode=%s",
+		os.Getenv("DB_HOST"),
+		os.Getenv("DB_PORT"),
+		os.Getenv("DB_USERNAME"),
+		os.Getenv("DB_TABLE"),
+		os.Getenv("DB_PASSWORD"),
+		os.Getenv("SSL_MODE"),
+	)
+
+	db, err := sql.Open("postgres", connectionString)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if err := db.Ping(); err != nil {
+		tb.Fatal(err)
+	}
+
+	tb.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+// createAccount stores a new EUR account with the given balance and removes it,
+// along with its holds and transactions, at the end of the test
+func createAccount(tb testing.TB, db *sql.DB, balance string) *accounts.Account {
+	repo := NewAccountRepository(db, zap.NewNop().Sugar())
+	acct, err := repo.Store(context.Background(), &accounts.Account{
+		ID:       uuid.NewV4().String(),
+		Balance:  money.MustParse(balance, "EUR"),
+		Currency: "EUR",
+	})
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	tb.Cleanup(func() { purgeAccount(tb, db, acct.ID) })
+
+	return acct
+}
+
+// purgeAccount removes an account along with its ledger entries, holds,
+// transactions, snapshots and roles. The ledger is append-only and the
+// foreign keys keep the rows referenced by it, so the triggers enforcing
+// both are skipped for the DB transaction, which takes a superuser.
+func purgeAccount(tb testing.TB, db *sql.DB, id string) {
+	err := executeDBTransaction(context.Background(), db, func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`SET LOCAL session_replication_role = replica`); err != nil {
+			return err
+		}
+		for _, query := range []string{
+			`WITH entries AS (
+				DELETE FROM postings WHERE journal_entry_id IN (
+					SELECT journal_entry_id FROM postings WHERE account_id = $1
+				)
+				RETURNING journal_entry_id
+			)
+			DELETE FROM journal_entries WHERE id IN (SELECT journal_entry_id FROM entries)`,
+			`DELETE FROM holds WHERE account_id = $1`,
+			`DELETE FROM transactions WHERE source_account_id = $1 OR target_account_id = $1`,
+			`DELETE FROM balance_snapshots WHERE account_id = $1`,
+			`DELETE FROM account_role_changes WHERE account_id = $1`,
+			`DELETE FROM account_roles WHERE account_id = $1`,
+			`DELETE FROM accounts WHERE id = $1`,
+		} {
+			if _, err := tx.Exec(query, id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		tb.Errorf("failed to remove the account %s: %v", id, err)
+	}
+}
+
+// transfer moves the given amount between two accounts through the repository
+func transfer(
+	ctx context.Context,
+	repo transactions.TransactionRepository,
+	sacc, tacc *accounts.Account,
+	amount string,
+) error {
+	_, err := repo.Transfer(ctx, &transactions.Transaction{
+		ID:              uuid.NewV4().String(),
+		SourceAccountID: sacc.ID,
+		TargetAccountID: tacc.ID,
+		Amount:          money.MustParse(amount, "EUR"),
+		Currency:        "EUR",
+		SourceCurrency:  "EUR",
+		TargetCurrency:  "EUR",
+		TargetAmount:    money.MustParse(amount, "EUR"),
+	})
+	return err
+}
+
+func TestTransactionRepository_TransferDisjointAccountsInParallel(t *testing.T) {
+	db := setupDB(t)
+	transactionRepo := NewTransactionRepository(db, zap.NewNop().Sugar())
+
+	a1 := createAccount(t, db, "100.00")
+	a2 := createAccount(t, db, "0.00")
+	b1 := createAccount(t, db, "100.00")
+	b2 := createAccount(t, db, "0.00")
+
+	// Hold the locks of the first pair as an in-flight transfer would do
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	if _, err := lockAccounts(context.Background(), tx, a1.ID, a2.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// A transfer between a disjoint pair must not queue behind it
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	assert.NoError(t, transfer(ctx, transactionRepo, b1, b2, "10.00"),
+		"Transfer between disjoint accounts should not be blocked")
+
+	// A transfer on the locked pair must wait until the locks are released
+	done := make(chan error, 1)
+	go func() {
+		done <- transfer(context.Background(), transactionRepo, a1, a2, "10.00")
+	}()
+
+	select {
+	case err := <-done:
+		t.Fatalf("transfer on locked accounts should be blocked, got %v", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	assert.NoError(t, tx.Rollback())
+	assert.NoError(t, <-done, "Transfer should proceed once the locks are released")
+}
+
+func TestTransactionRepository_TransferConservesBalances(t *testing.T) {
+	db := setupDB(t)
+	accountRepo := NewAccountRepository(db, zap.NewNop().Sugar())
+	transactionRepo := NewTransactionRepository(db, zap.NewNop().Sugar())
+
+	accts := []*accounts.Account{
+		createAccount(t, db, "100.00"),
+		createAccount(t, db, "50.00"),
+		createAccount(t, db, "0.00"),
+	}
+	ids := []string{accts[0].ID, accts[1].ID, accts[2].ID}
+	total := money.MustParse("150.00", "EUR")
+
+	// Concurrent transfers in every direction, many of which overdraw their source
+	var wg sync.WaitGroup
+	errs := make(chan error, 300)
+	for i := 0; i < 300; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sacc, tacc := accts[i%3], accts[(i+1+(i/3)%2)%3]
+			errs <- transfer(context.Background(), transactionRepo, sacc, tacc, "7.50")
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			assert.ErrorIs(t, err, transactions.ErrInsufficientFunds,
+				"Only insufficient funds errors are expected")
+		}
+	}
+
+	found, err := accountRepo.FindByIDs(context.Background(), ids)
+	assert.NoError(t, err)
+
+	ledgerRepo := NewLedgerRepository(db, zap.NewNop().Sugar())
+	sum := money.Zero("EUR")
+	for _, acct := range found {
+		assert.False(t, acct.Balance.IsNegative(), "Balance should never be negative")
+		posted, err := ledgerRepo.PostedBalance(context.Background(), acct.ID, acct.Currency)
+		assert.NoError(t, err)
+		assert.Equal(t, acct.Balance, posted, "Balance should match the ledger postings")
+		sum, err = sum.Add(acct.Balance)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, total, sum, "The sum of balances should be conserved")
+}
+
+func BenchmarkTransactionRepository_TransferDisjointAccounts(b *testing.B) {
+	db := setupDB(b)
+	transactionRepo := NewTransactionRepository(db, zap.NewNop().Sugar())
+
+	// Every goroutine transfers between its own pair of accounts
+	pairs := make(chan [2]*accounts.Account, runtime.GOMAXPROCS(0))
+	for i := 0; i < cap(pairs); i++ {
+		pairs <- [2]*accounts.Account{
+			createAccount(b, db, "1000000.00"),
+			createAccount(b, db, "0.00"),
+		}
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		pair := <-pairs
+		for pb.Next() {
+			if err := transfer(context.Background(), transactionRepo, pair[0], pair[1], "0.01"); err != nil {
+				b.Error(err)
+			}
+		}
+	})
+}
+
+func TestAccountRepository_BalanceAsOf(t *testing.T) {
+	db := setupDB(t)
+	accountRepo := NewAccountRepository(db, zap.NewNop().Sugar())
+	transactionRepo := NewTransactionRepository(db, zap.NewNop().Sugar())
+	ctx := context.Background()
+
+	sacc := createAccount(t, db, "100.00")
+	tacc := createAccount(t, db, "0.00")
+
+	assert.NoError(t, transfer(ctx, transactionRepo, sacc, tacc, "10.00"))
+	afterFirst := time.Now().UTC()
+	assert.NoError(t, transfer(ctx, transactionRepo, sacc, tacc, "5.00"))
+	afterSecond := time.Now().UTC()
+
+	testCases := []struct {
+		Name     string
+		AsOf     time.Time
+		Expected string
+	}{
+		{Name: "Before Creation", AsOf: sacc.CreatedAt.Add(-time.Second), Expected: "0.00"},
+		{Name: "After First Transfer", AsOf: afterFirst, Expected: "90.00"},
+		{Name: "After Second Transfer", AsOf: afterSecond, Expected: "85.00"},
+	}
+
+	check := func(t *testing.T) {
+		for _, tc := range testCases {
+			balance, err := accountRepo.BalanceAsOf(ctx, sacc.ID, tc.AsOf)
+			assert.NoError(t, err)
+			assert.Equal(t, money.MustParse(tc.Expected, "EUR"), balance, tc.Name)
+		}
+	}
+
+	t.Run("From Postings", check)
+
+	// The same balances must be computed on top of a snapshot
+	_, err := accountRepo.SnapshotBalances(ctx, afterFirst)
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		_, err := db.Exec(`DELETE FROM balance_snapshots WHERE account_id IN ($1, $2)`,
+			sacc.ID, tacc.ID)
+		assert.NoError(t, err)
+	})
+
+	t.Run("From Snapshot", check)
+}
+
+func TestTransactionRepository_QueryPages(t *testing.T) {
+	db := setupDB(t)
+	transactionRepo := NewTransactionRepository(db, zap.NewNop().Sugar())
+	ctx := context.Background()
+
+	sacc := createAccount(t, db, "100.00")
+	tacc := createAccount(t, db, "0.00")
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, transfer(ctx, transactionRepo, sacc, tacc, "1.00"))
+	}
+
+	// Walk through the pages and collect every transaction of the account
+	seen := map[string]bool{}
+	q := transactions.Query{AccountID: sacc.ID, Limit: 2}
+	for {
+		found, err := transactionRepo.Query(ctx, q)
+		assert.NoError(t, err)
+		if len(found) == 0 {
+			break
+		}
+		for _, txn := range found {
+			assert.False(t, seen[txn.ID], "Transaction should appear on a single page")
+			seen[txn.ID] = true
+		}
+		last := found[len(found)-1]
+		q.After = &pagination.Cursor{Key: last.BookedAt.Format(time.RFC3339Nano), ID: last.ID}
+	}
+
+	assert.Len(t, seen, 5)
+}
+
+func TestAccountRepository_QueryByBalance(t *testing.T) {
+	db := setupDB(t)
+	accountRepo := NewAccountRepository(db, zap.NewNop().Sugar())
+	ctx := context.Background()
+
+	// Balances no other test account holds so that only these ones are listed
+	min, max := money.MustParse("987654.01", "EUR"), money.MustParse("987654.03", "EUR")
+	for _, balance := range []string{"987654.03", "987654.01", "987654.02"} {
+		createAccount(t, db, balance)
+	}
+
+	var balances []string
+	q := accounts.Query{
+		Currency:   "EUR",
+		MinBalance: &min,
+		MaxBalance: &max,
+		Sort:       accounts.SortBalance,
+		Limit:      2,
+	}
+	for {
+		found, err := accountRepo.Query(ctx, q)
+		assert.NoError(t, err)
+		if len(found) == 0 {
+			break
+		}
+		for _, acct := range found {
+			balances = append(balances, acct.Balance.String())
+		}
+		last := found[len(found)-1]
+		q.After = &pagination.Cursor{Key: last.Balance.String(), ID: last.ID}
+	}
+
+	assert.Equal(t, []string{"987654.01", "987654.02", "987654.03"}, balances)
+}
+
+func TestTransactionRepository_History(t *testing.T) {
+	db := setupDB(t)
+	transactionRepo := NewTransactionRepository(db, zap.NewNop().Sugar())
+	ctx := context.Background()
+
+	sacc := createAccount(t, db, "100.00")
+	tacc := createAccount(t, db, "0.00")
+	assert.NoError(t, transfer(ctx, transactionRepo, sacc, tacc, "10.00"))
+	assert.NoError(t, transfer(ctx, transactionRepo, tacc, sacc, "4.00"))
+
+	entries, err := transactionRepo.History(ctx, transactions.HistoryQuery{
+		AccountID: sacc.ID,
+		Limit:     10,
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, entries, 2) {
+		assert.Equal(t, transactions.DirectionIncoming, entries[0].Direction)
+		assert.Equal(t, tacc.ID, entries[0].CounterpartyID)
+		assert.Equal(t, money.MustParse("4.00", "EUR"), entries[0].Amount)
+		assert.Equal(t, money.MustParse("94.00", "EUR"), entries[0].BalanceAfter)
+
+		assert.Equal(t, transactions.DirectionOutgoing, entries[1].Direction)
+		assert.Equal(t, money.MustParse("-10.00", "EUR"), entries[1].Amount)
+		assert.Equal(t, money.MustParse("90.00", "EUR"), entries[1].BalanceAfter)
+	}
+}
+
+func TestTransactionRepository_StreamHistory(t *testing.T) {
+	db := setupDB(t)
+	transactionRepo := NewTransactionRepository(db, zap.NewNop().Sugar())
+	ctx := context.Background()
+
+	sacc := createAccount(t, db, "100.00")
+	tacc := createAccount(t, db, "0.00")
+	assert.NoError(t, transfer(ctx, transactionRepo, sacc, tacc, "10.00"))
+
+	// The balances come first, then the opening balance of the account
+	var opening, closing money.Money
+	var entries []*transactions.HistoryEntry
+	err := transactionRepo.StreamHistory(ctx, sacc.ID,
+		sacc.CreatedAt.Add(-time.Second), time.Now().UTC(),
+		func(o, c money.Money) error {
+			opening, closing = o, c
+			return nil
+		},
+		func(e *transactions.HistoryEntry) error {
+			entries = append(entries, e)
+			return nil
+		},
+	)
+	assert.NoError(t, err)
+	assert.True(t, opening.IsZero())
+	assert.Equal(t, money.MustParse("90.00", "EUR"), closing)
+	if assert.Len(t, entries, 2) {
+		assert.Empty(t, entries[0].TransactionID)
+		assert.Equal(t, money.MustParse("100.00", "EUR"), entries[0].BalanceAfter)
+		assert.Equal(t, tacc.ID, entries[1].CounterpartyID)
+		assert.Equal(t, money.MustParse("90.00", "EUR"), entries[1].BalanceAfter)
+	}
+}
+
+func TestTransactionRepository_TransferReversal(t *testing.T) {
+	db := setupDB(t)
+	transactionRepo := NewTransactionRepository(db, zap.NewNop().Sugar())
+	ctx := context.Background()
+
+	sacc := createAccount(t, db, "100.00")
+	tacc := createAccount(t, db, "0.00")
+	original := &transactions.Transaction{
+		ID:              uuid.NewV4().String(),
+		SourceAccountID: sacc.ID,
+		TargetAccountID: tacc.ID,
+		Amount:          money.MustParse("10.00", "EUR"),
+		Currency:        "EUR",
+		SourceCurrency:  "EUR",
+		TargetCurrency:  "EUR",
+		TargetAmount:    money.MustParse("10.00", "EUR"),
+	}
+	_, err := transactionRepo.Transfer(ctx, original)
+	assert.NoError(t, err)
+
+	reverse := func(amount string) error {
+		_, err := transactionRepo.Transfer(ctx, &transactions.Transaction{
+			ID:              uuid.NewV4().String(),
+			SourceAccountID: tacc.ID,
+			TargetAccountID: sacc.ID,
+			Amount:          money.MustParse(amount, "EUR"),
+			Currency:        "EUR",
+			SourceCurrency:  "EUR",
+			TargetCurrency:  "EUR",
+			TargetAmount:    money.MustParse(amount, "EUR"),
+			ReversalOf:      original.ID,
+		})
+		return err
+	}
+
+	assert.NoError(t, reverse("6.00"))
+	assert.Equal(t, transactions.ErrReversalExceedsOriginal, reverse("4.01"))
+	assert.NoError(t, reverse("4.00"))
+
+	reversals, err := transactionRepo.FindReversals(ctx, original.ID)
+	assert.NoError(t, err)
+	if assert.Len(t, reversals, 2) {
+		assert.Equal(t, original.ID, reversals[0].ReversalOf)
+		assert.Equal(t, money.MustParse("6.00", "EUR"), reversals[0].TargetAmount)
+	}
+
+	// The last reversal reverses the original
+	original, err = transactionRepo.Find(ctx, original.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, transactions.StatusReversed, original.Status)
+	assert.Equal(t, transactions.ErrReversalExceedsOriginal, reverse("0.01"))
+}
+
+func TestTransactionRepository_Fail(t *testing.T) {
+	db := setupDB(t)
+	accountRepo := NewAccountRepository(db, zap.NewNop().Sugar())
+	transactionRepo := NewTransactionRepository(db, zap.NewNop().Sugar())
+	ctx := context.Background()
+
+	sacc := createAccount(t, db, "10.00")
+	tacc := createAccount(t, db, "0.00")
+	failed, err := transactionRepo.Fail(ctx, &transactions.Transaction{
+		ID:              uuid.NewV4().String(),
+		SourceAccountID: sacc.ID,
+		TargetAccountID: tacc.ID,
+		Amount:          money.MustParse("20.00", "EUR"),
+		Currency:        "EUR",
+		SourceCurrency:  "EUR",
+		TargetCurrency:  "EUR",
+		TargetAmount:    money.Zero("EUR"),
+		Status:          transactions.StatusFailed,
+		FailureReason:   transactions.ErrInsufficientFunds.Code,
+	})
+	assert.NoError(t, err)
+	assert.False(t, failed.BookedAt.IsZero())
+
+	// The failed transaction is listed but moved no balance
+	found, err := transactionRepo.Query(ctx, transactions.Query{
+		AccountID: sacc.ID,
+		Status:    transactions.StatusFailed,
+		Limit:     10,
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, found, 1) {
+		assert.Equal(t, "insufficient_funds", found[0].FailureReason)
+	}
+	acct, err := accountRepo.Find(ctx, sacc.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, money.MustParse("10.00", "EUR"), acct.Balance)
+
+	// A failed transaction stays failed
+	_, err = db.ExecContext(ctx,
+		`UPDATE transactions SET status = 'completed', failure_reason = NULL WHERE id = $1`,
+		failed.ID)
+	assert.Equal(t, "transactions_status_transition", violatedConstraint(err))
+}
+
+func TestTransactionRepository_Approval(t *testing.T) {
+	db := setupDB(t)
+	accountRepo := NewAccountRepository(db, zap.NewNop().Sugar())
+	transactionRepo := NewTransactionRepository(db, zap.NewNop().Sugar())
+	ledgerRepo := NewLedgerRepository(db, zap.NewNop().Sugar())
+	ctx := context.Background()
+
+	sacc := createAccount(t, db, "100.00")
+	tacc := createAccount(t, db, "0.00")
+	hold := func(amount string, expiresAt time.Time) (*transactions.Transaction, error) {
+		return transactionRepo.Transfer(ctx, &transactions.Transaction{
+			ID:              uuid.NewV4().String(),
+			SourceAccountID: sacc.ID,
+			TargetAccountID: tacc.ID,
+			Amount:          money.MustParse(amount, "EUR"),
+			Currency:        "EUR",
+			SourceCurrency:  "EUR",
+			TargetCurrency:  "EUR",
+			TargetAmount:    money.MustParse(amount, "EUR"),
+			Status:          transactions.StatusPendingApproval,
+			ExpiresAt:       &expiresAt,
+		})
+	}
+	review := func(id, status string) (*transactions.Transaction, error) {
+		return transactionRepo.Review(ctx, id, transactions.Review{
+			Status:     status,
+			ReviewedAt: time.Now().UTC(),
+		})
+	}
+	balance := func(acct *accounts.Account) money.Money {
+		found, err := accountRepo.Find(ctx, acct.ID)
+		assert.NoError(t, err)
+		return found.Balance
+	}
+	later := time.Now().UTC().Add(time.Hour)
+
+	// The held amount is not available to other transfers
+	rejected, err := hold("80.00", later)
+	assert.NoError(t, err)
+	assert.ErrorIs(t, transfer(ctx, transactionRepo, sacc, tacc, "30.00"),
+		transactions.ErrInsufficientFunds)
+	_, err = hold("30.00", later)
+	assert.ErrorIs(t, err, transactions.ErrInsufficientFunds)
+
+	// A rejection releases the hold without moving the balances
+	rejected, err = review(rejected.ID, transactions.StatusRejected)
+	assert.NoError(t, err)
+	assert.Equal(t, transactions.StatusRejected, rejected.Status)
+	assert.Equal(t, money.MustParse("100.00", "EUR"), balance(sacc))
+
+	// So does the expiry
+	expired, err := hold("80.00", time.Now().UTC().Add(-time.Minute))
+	assert.NoError(t, err)
+	_, err = review(expired.ID, transactions.StatusCompleted)
+	assert.Equal(t, transactions.ErrApprovalExpired, err)
+	_, err = transactionRepo.Expire(ctx, time.Now().UTC())
+	assert.NoError(t, err)
+	expired, err = transactionRepo.Find(ctx, expired.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, transactions.StatusExpired, expired.Status)
+	assert.NoError(t, transfer(ctx, transactionRepo, sacc, tacc, "30.00"))
+
+	// An approval books the transfer
+	approved, err := hold("60.00", later)
+	assert.NoError(t, err)
+	approved, err = review(approved.ID, transactions.StatusCompleted)
+	assert.NoError(t, err)
+	assert.Equal(t, transactions.StatusCompleted, approved.Status)
+	assert.Equal(t, *approved.ReviewedAt, approved.BookedAt)
+	assert.Equal(t, money.MustParse("10.00", "EUR"), balance(sacc))
+	assert.Equal(t, money.MustParse("90.00", "EUR"), balance(tacc))
+	for _, acct := range []*accounts.Account{sacc, tacc} {
+		posted, err := ledgerRepo.PostedBalance(ctx, acct.ID, acct.Currency)
+		assert.NoError(t, err)
+		assert.Equal(t, balance(acct), posted, "Balance should match the ledger postings")
+	}
+
+	_, err = review(approved.ID, transactions.StatusRejected)
+	assert.Equal(t, transactions.ErrNotPendingApproval, err)
+}
+
+func TestHoldRepository_CaptureAndVoid(t *testing.T) {
+	db := setupDB(t)
+	accountRepo := NewAccountRepository(db, zap.NewNop().Sugar())
+	transactionRepo := NewTransactionRepository(db, zap.NewNop().Sugar())
+	holdRepo := NewHoldRepository(db, zap.NewNop().Sugar())
+	ledgerRepo := NewLedgerRepository(db, zap.NewNop().Sugar())
+	ctx := context.Background()
+
+	sacc := createAccount(t, db, "100.00")
+	tacc := createAccount(t, db, "0.00")
+	place := func(amount string, expiresAt time.Time) (*holds.Hold, error) {
+		now := time.Now().UTC()
+		return holdRepo.Place(ctx, &holds.Hold{
+			ID:        uuid.NewV4().String(),
+			AccountID: sacc.ID,
+			Amount:    money.MustParse(amount, "EUR"),
+			Currency:  "EUR",
+			Reference: uuid.NewV4().String(),
+			Status:    holds.StatusActive,
+			CreatedAt: now,
+			ExpiresAt: expiresAt,
+		})
+	}
+	capture := func(hold *holds.Hold, amount string) (*holds.Hold, error) {
+		return holdRepo.Capture(ctx, hold.ID, &transactions.Transaction{
+			ID:              uuid.NewV4().String(),
+			SourceAccountID: sacc.ID,
+			TargetAccountID: tacc.ID,
+			Amount:          money.MustParse(amount, "EUR"),
+			Currency:        "EUR",
+			SourceCurrency:  "EUR",
+			TargetCurrency:  "EUR",
+			TargetAmount:    money.MustParse(amount, "EUR"),
+			Status:          transactions.StatusCompleted,
+			CreatedAt:       time.Now().UTC(),
+		})
+	}
+	balances := func(acct *accounts.Account) (money.Money, money.Money) {
+		found, err := accountRepo.Find(ctx, acct.ID)
+		assert.NoError(t, err)
+		return found.Balance, found.AvailableBalance
+	}
+	later := time.Now().UTC().Add(time.Hour)
+
+	// A hold lowers the available balance but not the balance
+	captured, err := place("60.00", later)
+	assert.NoError(t, err)
+	balance, available := balances(sacc)
+	assert.Equal(t, money.MustParse("100.00", "EUR"), balance)
+	assert.Equal(t, money.MustParse("40.00", "EUR"), available)
+	_, err = place("50.00", later)
+	assert.ErrorIs(t, err, transactions.ErrInsufficientFunds)
+	assert.ErrorIs(t, transfer(ctx, transactionRepo, sacc, tacc, "50.00"),
+		transactions.ErrInsufficientFunds)
+
+	// A partial capture books a transfer and releases the whole hold
+	captured, err = capture(captured, "25.00")
+	assert.NoError(t, err)
+	assert.Equal(t, holds.StatusCaptured, captured.Status)
+	assert.Equal(t, money.MustParse("25.00", "EUR"), *captured.CapturedAmount)
+	txn, err := transactionRepo.Find(ctx, captured.TransactionID)
+	assert.NoError(t, err)
+	assert.Equal(t, transactions.StatusCompleted, txn.Status)
+	balance, available = balances(sacc)
+	assert.Equal(t, money.MustParse("75.00", "EUR"), balance)
+	assert.Equal(t, balance, available)
+	balance, _ = balances(tacc)
+	assert.Equal(t, money.MustParse("25.00", "EUR"), balance)
+	for _, acct := range []*accounts.Account{sacc, tacc} {
+		posted, err := ledgerRepo.PostedBalance(ctx, acct.ID, acct.Currency)
+		assert.NoError(t, err)
+		posting, _ := balances(acct)
+		assert.Equal(t, posting, posted, "Balance should match the ledger postings")
+	}
+	_, err = capture(captured, "10.00")
+	assert.Equal(t, holds.ErrHoldNotActive, err)
+
+	// A void releases the hold without moving the balance
+	voided, err := place("70.00", later)
+	assert.NoError(t, err)
+	voided, err = holdRepo.Void(ctx, voided.ID, time.Now().UTC())
+	assert.NoError(t, err)
+	assert.Equal(t, holds.StatusVoided, voided.Status)
+	assert.NotNil(t, voided.ReleasedAt)
+	balance, available = balances(sacc)
+	assert.Equal(t, money.MustParse("75.00", "EUR"), balance)
+	assert.Equal(t, balance, available)
+
+	// So does the expiry
+	expired, err := place("70.00", time.Now().UTC().Add(time.Second))
+	assert.NoError(t, err)
+	_, err = holdRepo.Expire(ctx, time.Now().UTC().Add(time.Minute))
+	assert.NoError(t, err)
+	expired, err = holdRepo.Find(ctx, expired.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, holds.StatusExpired, expired.Status)
+	_, available = balances(sacc)
+	assert.Equal(t, money.MustParse("75.00", "EUR"), available)
+}
+
+func TestAccountRepository_UpdateStatus(t *testing.T) {
+	db := setupDB(t)
+	accountRepo := NewAccountRepository(db, zap.NewNop().Sugar())
+	transactionRepo := NewTransactionRepository(db, zap.NewNop().Sugar())
+	ctx := context.Background()
+
+	sacc := createAccount(t, db, "100.00")
+	tacc := createAccount(t, db, "0.00")
+
+	frozen, err := accountRepo.UpdateStatus(ctx, tacc.ID, accounts.StatusChange{
+		Status:    accounts.StatusFrozen,
+		Reason:    "suspicious activity",
+		ChangedAt: time.Now().UTC(),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, accounts.StatusFrozen, frozen.Status)
+
+	// Frozen accounts cannot receive transfers, nor be closed
+	err = transfer(ctx, transactionRepo, sacc, tacc, "10.00")
+	assert.ErrorIs(t, err, accounts.ErrAccountInactive)
+	_, err = accountRepo.UpdateStatus(ctx, tacc.ID, accounts.StatusChange{
+		Status: accounts.StatusClosed, ChangedAt: time.Now().UTC(),
+	})
+	assert.ErrorIs(t, err, accounts.ErrInvalidTransition)
+
+	// Accounts with a balance cannot be closed
+	_, err = accountRepo.UpdateStatus(ctx, sacc.ID, accounts.StatusChange{
+		Status: accounts.StatusClosed, ChangedAt: time.Now().UTC(),
+	})
+	assert.Equal(t, accounts.ErrNonZeroBalance, err)
+
+	found, err := accountRepo.Find(ctx, tacc.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, accounts.StatusFrozen, found.Status)
+	assert.Equal(t, "suspicious activity", found.StatusReason)
+	assert.NotNil(t, found.StatusChangedAt)
+}
+
+func TestRepositories_ConstraintErrors(t *testing.T) {
+	db := setupDB(t)
+	accountRepo := NewAccountRepository(db, zap.NewNop().Sugar())
+	transactionRepo := NewTransactionRepository(db, zap.NewNop().Sugar())
+	ctx := context.Background()
+
+	acct := createAccount(t, db, "100.00")
+
+	// The account ID is already taken
+	_, err := accountRepo.Store(ctx, &accounts.Account{
+		ID:       acct.ID,
+		Balance:  money.Zero("EUR"),
+		Currency: "EUR",
+	})
+	assert.Equal(t, accounts.ErrDuplicateAccount, err)
+
+	// Currencies must be ISO 4217 codes
+	_, err = accountRepo.Store(ctx, &accounts.Account{
+		ID:       uuid.NewV4().String(),
+		Balance:  money.Zero("eur"),
+		Currency: "eur",
+	})
+	assert.Equal(t, accounts.ErrInvalidCurrencyCode, err)
+
+	// Transfers need two distinct accounts
+	err = transfer(ctx, transactionRepo, acct, acct, "10.00")
+	assert.Equal(t, transactions.ErrSameAccount, err)
+}
+
+func TestRepositories_NotFoundErrors(t *testing.T) {
+	db := setupDB(t)
+	accountRepo := NewAccountRepository(db, zap.NewNop().Sugar())
+	transactionRepo := NewTransactionRepository(db, zap.NewNop().Sugar())
+	ctx := context.Background()
+
+	id := uuid.NewV4().String()
+
+	_, err := accountRepo.Find(ctx, id)
+	assert.Equal(t, accounts.ErrFetchingAccount(id), err)
+	assert.True(t, errors.Is(err, errs.ErrNotFound))
+
+	_, err = transactionRepo.Find(ctx, id)
+	assert.Equal(t, transactions.ErrFetchingTransaction(id), err)
+	assert.True(t, errors.Is(err, transactions.ErrTransactionNotFound))
+
+	// An ID that is not a uuid names nothing rather than failing the query
+	_, err = accountRepo.Find(ctx, "foo")
+	assert.Equal(t, accounts.ErrFetchingAccount("foo"), err)
+	_, err = transactionRepo.Find(ctx, "foo")
+	assert.Equal(t, transactions.ErrFetchingTransaction("foo"), err)
+	found, err := accountRepo.FindByIDs(ctx, []string{"foo", id})
+	assert.NoError(t, err)
+	assert.Empty(t, found)
+	_, err = NewLedgerRepository(db, zap.NewNop().Sugar()).FindByTransaction(ctx, "foo")
+	assert.Equal(t, ledger.ErrFetchingEntry("foo"), err)
+
+	// A storage failure is not reported as a missing account
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = accountRepo.Find(cancelled, id)
+	assert.False(t, errors.Is(err, errs.ErrNotFound))
+	assert.True(t, errors.Is(err, errs.ErrInternal))
+	assert.True(t, errors.Is(err, context.Canceled))
+}
+
+func TestAPIKeyRepository_StoreAndRevoke(t *testing.T) {
+	db := setupDB(t)
+	repo := NewAPIKeyRepository(db, zap.NewNop().Sugar())
+	ctx := context.Background()
+
+	key := &auth.Key{
+		ID:        uuid.NewV4().String(),
+		Name:      "integration",
+		Prefix:    "fa_1234567",
+		Hash:      auth.HashToken("fa_" + uuid.NewV4().String()),
+		Scopes:    []auth.Scope{auth.ScopeAccountsRead, auth.ScopeTransactionsWrite},
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+	_, err := repo.Store(ctx, key)
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		_, err := db.Exec(`DELETE FROM api_keys WHERE id = $1`, key.ID)
+		assert.NoError(t, err)
+	})
+
+	found, err := repo.FindByHash(ctx, key.Hash)
+	assert.NoError(t, err)
+	assert.Equal(t, key.Scopes, found.Scopes)
+	assert.True(t, key.CreatedAt.Equal(found.CreatedAt))
+	assert.False(t, found.Revoked())
+
+	_, err = repo.FindByHash(ctx, auth.HashToken("fa_unknown"))
+	assert.Equal(t, auth.ErrInvalidKey, err)
+
+	assert.NoError(t, repo.Revoke(ctx, key.ID, time.Now()))
+	found, err = repo.FindByHash(ctx, key.Hash)
+	assert.NoError(t, err)
+	assert.True(t, found.Revoked())
+
+	id := uuid.NewV4().String()
+	err = repo.Revoke(ctx, id, time.Now())
+	assert.Equal(t, auth.ErrFetchingKey(id), err)
+}
+
+func TestCustomerRepository_Accounts(t *testing.T) {
+	db := setupDB(t)
+	customerRepo := NewCustomerRepository(db, zap.NewNop().Sugar())
+	accountRepo := NewAccountRepository(db, zap.NewNop().Sugar())
+	ctx := context.Background()
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	customer := &customers.Customer{
+		ID:        uuid.NewV4().String(),
+		Name:      "Ada Lovelace",
+		Email:     uuid.NewV4().String() + "@example.com",
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	_, err := customerRepo.Store(ctx, customer)
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		_, err := db.Exec(`DELETE FROM customers WHERE id = $1`, customer.ID)
+		assert.NoError(t, err)
+	})
+
+	// Emails are unique
+	_, err = customerRepo.Store(ctx, &customers.Customer{
+		ID:        uuid.NewV4().String(),
+		Name:      "Ada",
+		Email:     customer.Email,
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	assert.Equal(t, customers.ErrDuplicateEmail, err)
+
+	// Accounts belong to existing customers only
+	_, err = accountRepo.Store(ctx, &accounts.Account{
+		ID:         uuid.NewV4().String(),
+		CustomerID: uuid.NewV4().String(),
+		Balance:    money.Zero("EUR"),
+		Currency:   "EUR",
+	})
+	assert.Equal(t, accounts.ErrUnknownCustomer, err)
+
+	acct, err := accountRepo.Store(ctx, &accounts.Account{
+		ID:         uuid.NewV4().String(),
+		CustomerID: customer.ID,
+		Balance:    money.Zero("EUR"),
+		Currency:   "EUR",
+	})
+	assert.NoError(t, err)
+	t.Cleanup(func() { purgeAccount(t, db, acct.ID) })
+
+	found, err := accountRepo.Find(ctx, acct.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, customer.ID, found.CustomerID)
+
+	owned, err := accountRepo.Query(ctx, accounts.Query{
+		CustomerID: customer.ID,
+		Sort:       accounts.SortCreatedAt,
+		Limit:      10,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, owned, 1)
+	assert.Equal(t, acct.ID, owned[0].ID)
+
+	// Customers owning accounts cannot be removed
+	err = customerRepo.Delete(ctx, customer.ID)
+	assert.Equal(t, customers.ErrCustomerHasAccounts, err)
+
+	id := uuid.NewV4().String()
+	err = customerRepo.Delete(ctx, id)
+	assert.Equal(t, customers.ErrFetchingCustomer(id), err)
+}
+
+func TestRoleRepository_GrantAndRevoke(t *testing.T) {
+	db := setupDB(t)
+	customerRepo := NewCustomerRepository(db, zap.NewNop().Sugar())
+	accountRepo := NewAccountRepository(db, zap.NewNop().Sugar())
+	roleRepo := NewRoleRepository(db, zap.NewNop().Sugar())
+	ctx := context.Background()
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	delegate := &customers.Customer{
+		ID:        uuid.NewV4().String(),
+		Name:      "Charles Babbage",
+		Email:     uuid.NewV4().String() + "@example.com",
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	_, err := customerRepo.Store(ctx, delegate)
+	assert.NoError(t, err)
+
+	acct := createAccount(t, db, "0.00")
+	t.Cleanup(func() {
+		_, err := db.Exec(`DELETE FROM customers WHERE id = $1`, delegate.ID)
+		assert.NoError(t, err)
+	})
+
+	change := func(action string, role accounts.Role) *accounts.RoleChange {
+		return &accounts.RoleChange{
+			ID:         uuid.NewV4().String(),
+			AccountID:  acct.ID,
+			CustomerID: delegate.ID,
+			Action:     action,
+			Role:       role,
+			ChangedAt:  time.Now().UTC(),
+		}
+	}
+	grant := func(role accounts.Role) error {
+		_, err := roleRepo.Grant(ctx, &accounts.Grant{
+			AccountID:  acct.ID,
+			CustomerID: delegate.ID,
+			Role:       role,
+			GrantedAt:  time.Now().UTC(),
+		}, change(accounts.ActionGrant, role))
+		return err
+	}
+
+	// Granting a role again replaces it
+	assert.NoError(t, grant(accounts.RoleView))
+	assert.NoError(t, grant(accounts.RoleInitiate))
+	found, err := roleRepo.Find(ctx, acct.ID, delegate.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, accounts.RoleInitiate, found.Role)
+
+	// The customer must exist, and so must the account
+	_, err = roleRepo.Grant(ctx, &accounts.Grant{
+		AccountID:  acct.ID,
+		CustomerID: uuid.NewV4().String(),
+		Role:       accounts.RoleView,
+		GrantedAt:  time.Now().UTC(),
+	}, change(accounts.ActionGrant, accounts.RoleView))
+	assert.Equal(t, accounts.ErrUnknownCustomer, err)
+
+	// The delegate sees the account among its accessible accounts
+	accessible, err := accountRepo.Query(ctx, accounts.Query{
+		AccessibleTo: delegate.ID,
+		Sort:         accounts.SortCreatedAt,
+		Limit:        10,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, accessible, 1)
+
+	revoke := change(accounts.ActionRevoke, "")
+	assert.NoError(t, roleRepo.Revoke(ctx, revoke))
+	assert.Equal(t, accounts.RoleInitiate, revoke.Role)
+	_, err = roleRepo.Find(ctx, acct.ID, delegate.ID)
+	assert.Equal(t, accounts.ErrFetchingRole(acct.ID, delegate.ID), err)
+	assert.Equal(t, accounts.ErrFetchingRole(acct.ID, delegate.ID),
+		roleRepo.Revoke(ctx, change(accounts.ActionRevoke, "")))
+
+	// The failed grant and revocation left no trace in the audit
+	changes, err := roleRepo.Changes(ctx, acct.ID)
+	assert.NoError(t, err)
+	assert.Len(t, changes, 3)
+	assert.Equal(t, accounts.ActionRevoke, changes[2].Action)
+	assert.Equal(t, accounts.RoleInitiate, changes[2].Role)
+}
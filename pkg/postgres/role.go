@@ -0,0 +1,26 @@
+package postgres
+
+import (
+	"database/sql"
+	"time"
+)
+
+// AccountRole models how our account role look in the database
+type AccountRole struct {
+	AccountID  string         `db:"account_id"`
+	CustomerID string         `db:"customer_id"`
+	Role       string         `db:"role"`
+	GrantedBy  sql.NullString `db:"granted_by"`
+	GrantedAt  time.Time      `db:"granted_at"`
+}
+
+// AccountRoleChange models how our account role change look in the database
+type AccountRoleChange struct {
+	ID         string
+	AccountID  string         `db:"account_id"`
+	CustomerID string         `db:"customer_id"`
+	Action     string         `db:"action"`
+	Role       string         `db:"role"`
+	ChangedBy  sql.NullString `db:"changed_by"`
+	ChangedAt  time.Time      `db:"changed_at"`
+}
@@ -1,10 +1,43 @@
 package postgres
 
+import (
+	"database/sql"
+	"time"
+)
+
 // Transaction models how our transaction look in the database
 type Transaction struct {
-	ID              string
-	SourceAccountID string `db:"source_account_id"`
-	TargetAccountID string `db:"target_account_id"`
-	Amount          float64
-	Currency        string
+	ID                  string
+	SourceAccountID     string `db:"source_account_id"`
+	TargetAccountID     string `db:"target_account_id"`
+	Amount              string
+	Currency            string
+	SourceCurrency      string         `db:"source_currency"`
+	TargetCurrency      string         `db:"target_currency"`
+	TargetAmount        string         `db:"target_amount"`
+	Rate                sql.NullString `db:"rate"`
+	RateTimestamp       sql.NullTime   `db:"rate_timestamp"`
+	QuoteID             sql.NullString `db:"quote_id"`
+	ReversalOf          sql.NullString `db:"reversal_of"`
+	Status              string
+	FailureReason       sql.NullString `db:"failure_reason"`
+	InitiatedBy         sql.NullString `db:"initiated_by"`
+	InitiatorCustomerID sql.NullString `db:"initiator_customer_id"`
+	ReviewedBy          sql.NullString `db:"reviewed_by"`
+	ReviewedAt          sql.NullTime   `db:"reviewed_at"`
+	ExpiresAt           sql.NullTime   `db:"expires_at"`
+	CreatedAt           time.Time      `db:"created_at"`
+	BookedAt            time.Time      `db:"booked_at"`
+}
+
+// HistoryEntry models a transaction as seen from one of its accounts, along
+// with the posting of that account and its running balance
+type HistoryEntry struct {
+	TransactionID   string    `db:"transaction_id"`
+	SourceAccountID string    `db:"source_account_id"`
+	TargetAccountID string    `db:"target_account_id"`
+	Amount          string    `db:"amount"`
+	Currency        string    `db:"currency"`
+	BalanceAfter    string    `db:"balance_after"`
+	BookedAt        time.Time `db:"booked_at"`
 }
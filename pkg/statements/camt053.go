@@ -0,0 +1,211 @@
+package statements
+
+import (
+	"encoding/xml"
+	"financial-app/pkg/money"
+	"financial-app/pkg/transactions"
+	"io"
+	"strings"
+	"time"
+)
+
+// camt053Namespace is the namespace of the ISO 20022 bank to customer
+// statement messages
+const camt053Namespace = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.02"
+
+// Codes of the camt.053 messages
+const (
+	camtCredit         = "CRDT"
+	camtDebit          = "DBIT"
+	camtBooked         = "BOOK"
+	camtOpeningBooked  = "OPBD"
+	camtClosingBooked  = "CLBD"
+	camtTransfer       = "TRANSFER"
+	camtOpeningBalance = "OPENING_BALANCE"
+)
+
+type camtAmount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type camtDateTime struct {
+	DateTime string `xml:"DtTm"`
+}
+
+type camtGroupHeader struct {
+	MessageID string `xml:"MsgId"`
+	CreatedAt string `xml:"CreDtTm"`
+}
+
+type camtPeriod struct {
+	From string `xml:"FrDtTm"`
+	To   string `xml:"ToDtTm"`
+}
+
+type camtAccount struct {
+	ID       string `xml:"Id>Othr>Id"`
+	Currency string `xml:"Ccy"`
+}
+
+type camtBalance struct {
+	Code                 string       `xml:"Tp>CdOrPrtry>Cd"`
+	Amount               camtAmount   `xml:"Amt"`
+	CreditDebitIndicator string       `xml:"CdtDbtInd"`
+	Date                 camtDateTime `xml:"Dt"`
+}
+
+type camtAccountID struct {
+	ID string `xml:"Id>Othr>Id"`
+}
+
+type camtTransactionDetails struct {
+	EndToEndID      string         `xml:"Refs>EndToEndId"`
+	DebtorAccount   *camtAccountID `xml:"RltdPties>DbtrAcct,omitempty"`
+	CreditorAccount *camtAccountID `xml:"RltdPties>CdtrAcct,omitempty"`
+}
+
+type camtEntry struct {
+	Reference            string                  `xml:"NtryRef,omitempty"`
+	Amount               camtAmount              `xml:"Amt"`
+	CreditDebitIndicator string                  `xml:"CdtDbtInd"`
+	Status               string                  `xml:"Sts"`
+	BookingDate          camtDateTime            `xml:"BookgDt"`
+	ValueDate            camtDateTime            `xml:"ValDt"`
+	TransactionCode      string                  `xml:"BkTxCd>Prtry>Cd"`
+	Details              *camtTransactionDetails `xml:"NtryDtls>TxDtls,omitempty"`
+}
+
+// camt053Writer writes a statement as an ISO 20022 camt.053 message, whose
+// balances come before the entries
+type camt053Writer struct {
+	enc *xml.Encoder
+	now func() time.Time
+}
+
+func newCamt053Writer(out io.Writer) Writer {
+	return &camt053Writer{enc: xml.NewEncoder(out), now: time.Now}
+}
+
+// start opens an element, with the given attributes if any
+func (c *camt053Writer) start(name string, attrs ...xml.Attr) error {
+	return c.enc.EncodeToken(xml.StartElement{Name: xml.Name{Local: name}, Attr: attrs})
+}
+
+// end closes an element
+func (c *camt053Writer) end(name string) error {
+	return c.enc.EncodeToken(xml.EndElement{Name: xml.Name{Local: name}})
+}
+
+// element encodes a whole element
+func (c *camt053Writer) element(name string, v interface{}) error {
+	return c.enc.EncodeElement(v, xml.StartElement{Name: xml.Name{Local: name}})
+}
+
+func (c *camt053Writer) Begin(st Statement) error {
+	now := formatTime(c.now())
+
+	if err := c.enc.EncodeToken(xml.ProcInst{
+		Target: "xml", Inst: []byte(`version="1.0" encoding="UTF-8"`),
+	}); err != nil {
+		return err
+	}
+	if err := c.start("Document", xml.Attr{
+		Name: xml.Name{Local: "xmlns"}, Value: camt053Namespace,
+	}); err != nil {
+		return err
+	}
+	if err := c.start("BkToCstmrStmt"); err != nil {
+		return err
+	}
+	id := camtIdentifier(strings.ReplaceAll(st.AccountID, "-", ""))
+	if err := c.element("GrpHdr", camtGroupHeader{
+		MessageID: camtIdentifier(st.To.UTC().Format("20060102150405") + id),
+		CreatedAt: now,
+	}); err != nil {
+		return err
+	}
+	if err := c.start("Stmt"); err != nil {
+		return err
+	}
+
+	for _, e := range []struct {
+		name string
+		v    interface{}
+	}{
+		{"Id", id},
+		{"CreDtTm", now},
+		{"FrToDt", camtPeriod{From: formatTime(st.From), To: formatTime(st.To)}},
+		{"Acct", camtAccount{ID: st.AccountID, Currency: st.Currency}},
+		{"Bal", newCamtBalance(camtOpeningBooked, st.OpeningBalance, st.From)},
+		{"Bal", newCamtBalance(camtClosingBooked, st.ClosingBalance, st.To)},
+	} {
+		if err := c.element(e.name, e.v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *camt053Writer) Entry(e transactions.HistoryEntry) error {
+	amount, indicator := camtSigned(e.Amount)
+	entry := camtEntry{
+		Reference:            e.TransactionID,
+		Amount:               amount,
+		CreditDebitIndicator: indicator,
+		Status:               camtBooked,
+		BookingDate:          camtDateTime{DateTime: formatTime(e.BookedAt)},
+		ValueDate:            camtDateTime{DateTime: formatTime(e.BookedAt)},
+		TransactionCode:      camtOpeningBalance,
+	}
+
+	if e.TransactionID != "" {
+		entry.TransactionCode = camtTransfer
+		entry.Details = &camtTransactionDetails{EndToEndID: e.TransactionID}
+		counterparty := &camtAccountID{ID: e.CounterpartyID}
+		if e.Direction == transactions.DirectionOutgoing {
+			entry.Details.CreditorAccount = counterparty
+		} else {
+			entry.Details.DebtorAccount = counterparty
+		}
+	}
+
+	return c.element("Ntry", entry)
+}
+
+func (c *camt053Writer) End(st Statement) error {
+	for _, name := range []string{"Stmt", "BkToCstmrStmt", "Document"} {
+		if err := c.end(name); err != nil {
+			return err
+		}
+	}
+	return c.enc.Flush()
+}
+
+// newCamtBalance returns a balance of the given type
+func newCamtBalance(code string, balance money.Money, asOf time.Time) camtBalance {
+	amount, indicator := camtSigned(balance)
+	return camtBalance{
+		Code:                 code,
+		Amount:               amount,
+		CreditDebitIndicator: indicator,
+		Date:                 camtDateTime{DateTime: formatTime(asOf)},
+	}
+}
+
+// camtIdentifier truncates an identifier to the 35 characters allowed
+func camtIdentifier(s string) string {
+	if len(s) > 35 {
+		return s[:35]
+	}
+	return s
+}
+
+// camtSigned splits a signed amount into its absolute value and whether it
+// is a credit or a debit
+func camtSigned(m money.Money) (camtAmount, string) {
+	if m.IsNegative() {
+		return camtAmount{Currency: m.Currency(), Value: m.Neg().String()}, camtDebit
+	}
+	return camtAmount{Currency: m.Currency(), Value: m.String()}, camtCredit
+}
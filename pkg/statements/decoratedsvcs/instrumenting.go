@@ -0,0 +1,48 @@
+package decoratedsvcs
+
+import (
+	"context"
+	"financial-app/pkg/statements"
+	"time"
+
+	"github.com/go-kit/kit/metrics"
+)
+
+type instrumentingService struct {
+	requestCount   metrics.Counter
+	requestLatency metrics.Histogram
+	next           statements.Service
+}
+
+// NewInstrumentingService returns an instance of an instrumenting Service.
+func NewInstrumentingService(
+	counter metrics.Counter, latency metrics.Histogram, s statements.Service,
+) statements.Service {
+	return &instrumentingService{
+		requestCount:   counter,
+		requestLatency: latency,
+		next:           s,
+	}
+}
+
+func (s *instrumentingService) Open(
+	ctx context.Context, q statements.Query,
+) (st statements.Statement, err error) {
+	defer func(begin time.Time) {
+		s.requestCount.With("method", "open").Add(1)
+		s.requestLatency.With("method", "open").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return s.next.Open(ctx, q)
+}
+
+func (s *instrumentingService) Export(
+	ctx context.Context, st statements.Statement, w statements.Writer,
+) (err error) {
+	defer func(begin time.Time) {
+		s.requestCount.With("method", "export").Add(1)
+		s.requestLatency.With("method", "export").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return s.next.Export(ctx, st, w)
+}
@@ -0,0 +1,51 @@
+package decoratedsvcs
+
+import (
+	"context"
+	"financial-app/pkg/statements"
+	"time"
+
+	log "go.uber.org/zap"
+)
+
+type loggingService struct {
+	logger *log.SugaredLogger
+	next   statements.Service
+}
+
+// NewLoggingService returns a new instance of a logging Service.
+func NewLoggingService(logger *log.SugaredLogger, s statements.Service) statements.Service {
+	return &loggingService{logger, s}
+}
+
+func (s *loggingService) Open(
+	ctx context.Context, q statements.Query,
+) (st statements.Statement, err error) {
+	defer func(begin time.Time) {
+		s.logger.Infow(
+			"open",
+			log.String("account_id", q.AccountID),
+			log.Time("from", q.From),
+			log.Time("to", q.To),
+			log.Duration("took", time.Since(begin)),
+			log.Error(err),
+		)
+	}(time.Now())
+	return s.next.Open(ctx, q)
+}
+
+func (s *loggingService) Export(
+	ctx context.Context, st statements.Statement, w statements.Writer,
+) (err error) {
+	defer func(begin time.Time) {
+		s.logger.Infow(
+			"export",
+			log.String("account_id", st.AccountID),
+			log.Stringer("opening_balance", st.OpeningBalance),
+			log.Stringer("closing_balance", st.ClosingBalance),
+			log.Duration("took", time.Since(begin)),
+			log.Error(err),
+		)
+	}(time.Now())
+	return s.next.Export(ctx, st, w)
+}
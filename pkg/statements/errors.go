@@ -0,0 +1,15 @@
+package statements
+
+import "financial-app/pkg/errs"
+
+// ErrInvalidPeriod is used when the period of a statement is not valid
+var ErrInvalidPeriod = errs.New(errs.ErrInvalid, "invalid_period",
+	"from and to must be RFC 3339 timestamps and from must be before to")
+
+// ErrUnknownFormat is used when a statement format is not supported
+var ErrUnknownFormat = errs.New(errs.ErrInvalid, "unknown_format", "format must be one of csv, ndjson, camt053")
+
+// ErrUnbalancedStatement is used when the opening balance and the entries of
+// a statement do not add up to its closing balance
+var ErrUnbalancedStatement = errs.New(errs.ErrInternal, "unbalanced_statement",
+	"statement entries do not add up to the closing balance")
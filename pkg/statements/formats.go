@@ -0,0 +1,169 @@
+package statements
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"financial-app/pkg/money"
+	"financial-app/pkg/transactions"
+	"io"
+	"time"
+)
+
+// Formats of the statements
+const (
+	FormatCSV     = "csv"
+	FormatNDJSON  = "ndjson"
+	FormatCamt053 = "camt053"
+)
+
+// Format describes how a statement is encoded
+type Format struct {
+	Name        string
+	ContentType string
+	Extension   string
+
+	newWriter func(out io.Writer) Writer
+}
+
+var formats = map[string]Format{
+	FormatCSV: {
+		Name:        FormatCSV,
+		ContentType: "text/csv; charset=utf-8",
+		Extension:   "csv",
+		newWriter:   newCSVWriter,
+	},
+	FormatNDJSON: {
+		Name:        FormatNDJSON,
+		ContentType: "application/x-ndjson",
+		Extension:   "ndjson",
+		newWriter:   newNDJSONWriter,
+	},
+	FormatCamt053: {
+		Name:        FormatCamt053,
+		ContentType: "application/xml; charset=utf-8",
+		Extension:   "xml",
+		newWriter:   newCamt053Writer,
+	},
+}
+
+// LookupFormat returns the statement format with the given name
+func LookupFormat(name string) (Format, error) {
+	f, ok := formats[name]
+	if !ok {
+		return Format{}, ErrUnknownFormat
+	}
+	return f, nil
+}
+
+// NewWriter returns a writer encoding a statement to the output in the format
+func (f Format) NewWriter(out io.Writer) Writer {
+	return f.newWriter(out)
+}
+
+// Record types of the CSV and NDJSON statements
+const (
+	recordOpeningBalance = "opening_balance"
+	recordEntry          = "entry"
+	recordClosingBalance = "closing_balance"
+)
+
+// formatTime formats the times of the statements
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// csvWriter writes a statement as CSV records, the opening balance first and
+// the closing balance last
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCSVWriter(out io.Writer) Writer {
+	return &csvWriter{w: csv.NewWriter(out)}
+}
+
+func (c *csvWriter) Begin(st Statement) error {
+	if err := c.w.Write([]string{
+		"type", "booked_at", "transaction_id", "direction", "counterparty_account_id",
+		"amount", "balance", "currency",
+	}); err != nil {
+		return err
+	}
+	return c.balance(recordOpeningBalance, st.From, st.OpeningBalance)
+}
+
+func (c *csvWriter) Entry(e transactions.HistoryEntry) error {
+	return c.w.Write([]string{
+		recordEntry, formatTime(e.BookedAt), e.TransactionID, e.Direction, e.CounterpartyID,
+		e.Amount.String(), e.BalanceAfter.String(), e.Currency,
+	})
+}
+
+func (c *csvWriter) End(st Statement) error {
+	if err := c.balance(recordClosingBalance, st.To, st.ClosingBalance); err != nil {
+		return err
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvWriter) balance(kind string, asOf time.Time, balance money.Money) error {
+	return c.w.Write([]string{
+		kind, formatTime(asOf), "", "", "", "", balance.String(), balance.Currency(),
+	})
+}
+
+// ndjsonBalance is an opening or closing balance record of a NDJSON statement
+type ndjsonBalance struct {
+	Type      string      `json:"type"`
+	AccountID string      `json:"account_id"`
+	Balance   money.Money `json:"balance"`
+	Currency  string      `json:"currency"`
+	AsOf      time.Time   `json:"as_of"`
+}
+
+// ndjsonEntry is an entry record of a NDJSON statement
+type ndjsonEntry struct {
+	Type string `json:"type"`
+	transactions.HistoryEntry
+}
+
+// ndjsonWriter writes a statement as one JSON record per line, the opening
+// balance first and the closing balance last
+type ndjsonWriter struct {
+	buf *bufio.Writer
+	enc *json.Encoder
+}
+
+func newNDJSONWriter(out io.Writer) Writer {
+	buf := bufio.NewWriter(out)
+	return &ndjsonWriter{buf: buf, enc: json.NewEncoder(buf)}
+}
+
+func (n *ndjsonWriter) Begin(st Statement) error {
+	return n.enc.Encode(ndjsonBalance{
+		Type:      recordOpeningBalance,
+		AccountID: st.AccountID,
+		Balance:   st.OpeningBalance,
+		Currency:  st.Currency,
+		AsOf:      st.From.UTC(),
+	})
+}
+
+func (n *ndjsonWriter) Entry(e transactions.HistoryEntry) error {
+	return n.enc.Encode(ndjsonEntry{Type: recordEntry, HistoryEntry: e})
+}
+
+func (n *ndjsonWriter) End(st Statement) error {
+	if err := n.enc.Encode(ndjsonBalance{
+		Type:      recordClosingBalance,
+		AccountID: st.AccountID,
+		Balance:   st.ClosingBalance,
+		Currency:  st.Currency,
+		AsOf:      st.To.UTC(),
+	}); err != nil {
+		return err
+	}
+	return n.buf.Flush()
+}
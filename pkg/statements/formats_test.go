@@ -0,0 +1,118 @@
+package statements
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"financial-app/pkg/money"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var statement = Statement{
+	AccountID:      "4067bfcb-d722-4e0e-a15e-b16be3b00f84",
+	Currency:       "EUR",
+	From:           from,
+	To:             to,
+	OpeningBalance: money.New(10000, "EUR"),
+	ClosingBalance: money.New(12000, "EUR"),
+}
+
+// export writes the statement in the given format
+func export(t *testing.T, format string) *bytes.Buffer {
+	f, err := LookupFormat(format)
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	transactions := &mockTransactionRepository{
+		Balances: map[time.Time]money.Money{
+			from: statement.OpeningBalance,
+			to:   statement.ClosingBalance,
+		},
+		Entries: entries,
+	}
+	err = NewService(nil, nil, transactions).
+		Export(context.Background(), statement, f.NewWriter(&buf))
+	assert.NoError(t, err)
+
+	return &buf
+}
+
+func TestLookupFormat(t *testing.T) {
+	_, err := LookupFormat("pdf")
+	assert.Equal(t, ErrUnknownFormat, err)
+}
+
+func TestFormat_CSV(t *testing.T) {
+	assert.Equal(t, `type,booked_at,transaction_id,direction,counterparty_account_id,amount,balance,currency
+opening_balance,2023-09-01T00:00:00Z,,,,,100.00,EUR
+entry,2023-09-01T01:00:00Z,1111,incoming,2222,50.00,150.00,EUR
+entry,2023-09-01T02:00:00Z,3333,outgoing,4444,-30.00,120.00,EUR
+closing_balance,2023-10-01T00:00:00Z,,,,,120.00,EUR
+`, export(t, FormatCSV).String())
+}
+
+func TestFormat_NDJSON(t *testing.T) {
+	var records []map[string]interface{}
+	scanner := bufio.NewScanner(export(t, FormatNDJSON))
+	for scanner.Scan() {
+		var record map[string]interface{}
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		records = append(records, record)
+	}
+
+	if assert.Len(t, records, 4) {
+		assert.Equal(t, "opening_balance", records[0]["type"])
+		assert.Equal(t, "100.00", records[0]["balance"])
+		assert.Equal(t, "entry", records[1]["type"])
+		assert.Equal(t, "1111", records[1]["transaction_id"])
+		assert.Equal(t, "-30.00", records[2]["amount"])
+		assert.Equal(t, "closing_balance", records[3]["type"])
+		assert.Equal(t, "120.00", records[3]["balance"])
+	}
+}
+
+func TestFormat_Camt053(t *testing.T) {
+	var doc struct {
+		XMLName xml.Name `xml:"urn:iso:std:iso:20022:tech:xsd:camt.053.001.02 Document"`
+		Stmt    struct {
+			ID       string        `xml:"Id"`
+			Account  string        `xml:"Acct>Id>Othr>Id"`
+			Balances []camtBalance `xml:"Bal"`
+			Entries  []camtEntry   `xml:"Ntry"`
+		} `xml:"BkToCstmrStmt>Stmt"`
+	}
+	assert.NoError(t, xml.Unmarshal(export(t, FormatCamt053).Bytes(), &doc))
+
+	assert.Equal(t, "4067bfcbd7224e0ea15eb16be3b00f84", doc.Stmt.ID)
+	assert.Equal(t, statement.AccountID, doc.Stmt.Account)
+	assert.Equal(t, []camtBalance{
+		{Code: camtOpeningBooked, Amount: camtAmount{Currency: "EUR", Value: "100.00"},
+			CreditDebitIndicator: camtCredit, Date: camtDateTime{DateTime: "2023-09-01T00:00:00Z"}},
+		{Code: camtClosingBooked, Amount: camtAmount{Currency: "EUR", Value: "120.00"},
+			CreditDebitIndicator: camtCredit, Date: camtDateTime{DateTime: "2023-10-01T00:00:00Z"}},
+	}, doc.Stmt.Balances)
+
+	bookedAt := camtDateTime{DateTime: entries[1].BookedAt.Format(time.RFC3339Nano)}
+	if assert.Len(t, doc.Stmt.Entries, 2) {
+		assert.Equal(t, camtEntry{
+			Reference:            "3333",
+			Amount:               camtAmount{Currency: "EUR", Value: "30.00"},
+			CreditDebitIndicator: camtDebit,
+			Status:               camtBooked,
+			BookingDate:          bookedAt,
+			ValueDate:            bookedAt,
+			TransactionCode:      camtTransfer,
+			Details: &camtTransactionDetails{
+				EndToEndID:      "3333",
+				CreditorAccount: &camtAccountID{ID: "4444"},
+			},
+		}, doc.Stmt.Entries[1])
+		assert.Equal(t, &camtAccountID{ID: "2222"}, doc.Stmt.Entries[0].Details.DebtorAccount)
+		assert.Nil(t, doc.Stmt.Entries[0].Details.CreditorAccount)
+	}
+}
@@ -0,0 +1,87 @@
+package statements
+
+import (
+	"financial-app/pkg/http/rest/httperr"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// exportTimeout bounds the time a statement may take to be streamed, which
+// the write timeout of the server would otherwise cut short
+const exportTimeout = 10 * time.Minute
+
+type StatementHandler struct {
+	Service Service
+
+	Logger *zap.SugaredLogger
+}
+
+// Router sets up all the routes for statement service
+func (h *StatementHandler) Router(routerGroup *gin.RouterGroup) {
+	routerGroup.GET("accounts/:id/statement", h.statement)
+}
+
+// queryFromRequest builds a statement query from the query parameters of a
+// statement request. The statement ends now unless to is given.
+func queryFromRequest(context *gin.Context) (Query, Format, error) {
+	format, err := LookupFormat(context.DefaultQuery("format", FormatCSV))
+	if err != nil {
+		return Query{}, Format{}, err
+	}
+
+	q := Query{AccountID: context.Param("id"), To: time.Now().UTC()}
+	if q.From, err = time.Parse(time.RFC3339Nano, context.Query("from")); err != nil {
+		return Query{}, Format{}, ErrInvalidPeriod
+	}
+	if s := context.Query("to"); s != "" {
+		if q.To, err = time.Parse(time.RFC3339Nano, s); err != nil {
+			return Query{}, Format{}, ErrInvalidPeriod
+		}
+	}
+	if !q.From.Before(q.To) {
+		return Query{}, Format{}, ErrInvalidPeriod
+	}
+
+	return q, format, nil
+}
+
+// statement streams the statement of an account over a period
+func (h *StatementHandler) statement(context *gin.Context) {
+	q, format, err := queryFromRequest(context)
+	if err != nil {
+		h.Logger.Error(err)
+
+		httperr.Respond(context, err)
+		return
+	}
+
+	st, err := h.Service.Open(context, q)
+	if err != nil {
+		h.Logger.Error(err)
+
+		httperr.Respond(context, err)
+		return
+	}
+
+	// Not every response writer supports deadlines, e.g. in tests
+	_ = http.NewResponseController(context.Writer).SetWriteDeadline(time.Now().Add(exportTimeout))
+
+	context.Header("Content-Type", format.ContentType)
+	context.Header("Content-Disposition",
+		`attachment; filename="statement-`+st.AccountID+`.`+format.Extension+`"`)
+
+	err = h.Service.Export(context, st, format.NewWriter(context.Writer))
+	if err != nil {
+		h.Logger.Error(err)
+
+		// Once streaming has started the statement can only be left incomplete
+		if !context.Writer.Written() {
+			context.Header("Content-Type", "")
+			context.Header("Content-Disposition", "")
+			httperr.Respond(context, err)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package statements
+
+import (
+	"context"
+	"errors"
+	"financial-app/pkg/accounts"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+type MockService struct {
+	mock.Mock
+}
+
+func (m *MockService) Open(ctx context.Context, q Query) (Statement, error) {
+	args := m.Called(ctx, q)
+	return args.Get(0).(Statement), args.Error(1)
+}
+
+func (m *MockService) Export(ctx context.Context, st Statement, w Writer) error {
+	args := m.Called(ctx, st, w)
+	if args.Error(0) != nil {
+		return args.Error(0)
+	}
+	// Write an empty statement
+	if err := w.Begin(st); err != nil {
+		return err
+	}
+	return w.End(st)
+}
+
+func TestStatementHandler_Statement(t *testing.T) {
+	period := "from=2023-09-01T00:00:00Z&to=2023-10-01T00:00:00Z"
+
+	testCases := []struct {
+		Name                string
+		URL                 string
+		OpenError           error
+		ExportError         error
+		ExpectedCode        int
+		ExpectedContentType string
+	}{
+		{
+			Name:                "CSV By Default",
+			URL:                 "/accounts/4067bfcb-d722-4e0e-a15e-b16be3b00f84/statement?" + period,
+			ExpectedCode:        http.StatusOK,
+			ExpectedContentType: "text/csv; charset=utf-8",
+		},
+		{
+			Name:                "camt.053",
+			URL:                 "/accounts/4067bfcb-d722-4e0e-a15e-b16be3b00f84/statement?format=camt053&" + period,
+			ExpectedCode:        http.StatusOK,
+			ExpectedContentType: "application/xml; charset=utf-8",
+		},
+		{
+			Name:         "Unknown Format",
+			URL:          "/accounts/4067bfcb-d722-4e0e-a15e-b16be3b00f84/statement?format=pdf&" + period,
+			ExpectedCode: http.StatusBadRequest,
+		},
+		{
+			Name:         "Missing From",
+			URL:          "/accounts/4067bfcb-d722-4e0e-a15e-b16be3b00f84/statement",
+			ExpectedCode: http.StatusBadRequest,
+		},
+		{
+			Name:         "From After To",
+			URL:          "/accounts/4067bfcb-d722-4e0e-a15e-b16be3b00f84/statement?from=2023-10-02T00:00:00Z&to=2023-10-01T00:00:00Z",
+			ExpectedCode: http.StatusBadRequest,
+		},
+		{
+			Name:         "Account Not Found",
+			URL:          "/accounts/4067bfcb-d722-4e0e-a15e-b16be3b00f84/statement?" + period,
+			OpenError:    accounts.ErrFetchingAccount("4067bfcb-d722-4e0e-a15e-b16be3b00f84"),
+			ExpectedCode: http.StatusNotFound,
+		},
+		{
+			Name:                "Export Error",
+			URL:                 "/accounts/4067bfcb-d722-4e0e-a15e-b16be3b00f84/statement?" + period,
+			ExportError:         errors.New("could not stream the history of the account"),
+			ExpectedCode:        http.StatusInternalServerError,
+			ExpectedContentType: "application/problem+json",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			mockService := new(MockService)
+			logger, _ := zap.NewDevelopment()
+			handler := &StatementHandler{Service: mockService, Logger: logger.Sugar()}
+
+			r := gin.Default()
+			r.GET("/accounts/:id/statement", handler.statement)
+
+			mockService.On("Open", mock.Anything, mock.Anything).
+				Return(statement, tc.OpenError)
+			mockService.On("Export", mock.Anything, statement, mock.Anything).
+				Return(tc.ExportError)
+
+			req, _ := http.NewRequest("GET", tc.URL, nil)
+			rr := httptest.NewRecorder()
+
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.ExpectedCode, rr.Code)
+			if tc.ExpectedContentType != "" {
+				assert.Equal(t, tc.ExpectedContentType, rr.Header().Get("Content-Type"))
+			}
+			if tc.ExpectedCode == http.StatusOK {
+				assert.Contains(t, rr.Header().Get("Content-Disposition"), "attachment")
+				assert.NotEmpty(t, rr.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package statements
+
+import (
+	"context"
+	"financial-app/pkg/accounts"
+	"financial-app/pkg/money"
+	"financial-app/pkg/transactions"
+	"time"
+)
+
+// Statement is a read model for the balances of an account over a period.
+// The period starts right after From and ends at To included.
+type Statement struct {
+	AccountID      string      `json:"account_id"`
+	Currency       string      `json:"currency"`
+	From           time.Time   `json:"from"`
+	To             time.Time   `json:"to"`
+	OpeningBalance money.Money `json:"opening_balance"`
+	ClosingBalance money.Money `json:"closing_balance"`
+}
+
+// Query selects the period of the statement of an account
+type Query struct {
+	AccountID string
+	From      time.Time
+	To        time.Time
+}
+
+// Writer encodes a statement as it is streamed, in a given format
+type Writer interface {
+	// Begin writes what comes before the entries
+	Begin(st Statement) error
+	// Entry writes an entry of the statement
+	Entry(e transactions.HistoryEntry) error
+	// End writes what comes after the entries and flushes the output
+	End(st Statement) error
+}
+
+// Service is the interface that provides statement methods
+type Service interface {
+	// Open checks the period of a statement and the access to its account
+	Open(ctx context.Context, q Query) (Statement, error)
+
+	// Export computes the opening and closing balances of a statement and
+	// streams it to the writer, all from one snapshot of the history. It fails
+	// with ErrUnbalancedStatement before ending the statement if its entries
+	// do not add up to the closing balance, so that the output is left
+	// incomplete.
+	Export(ctx context.Context, st Statement, w Writer) error
+}
+
+func (s *service) Open(ctx context.Context, q Query) (Statement, error) {
+	if !q.From.Before(q.To) {
+		return Statement{}, ErrInvalidPeriod
+	}
+
+	acct, err := s.accounts.Find(ctx, q.AccountID)
+	if err != nil {
+		return Statement{}, err
+	}
+	if err := accounts.CheckRole(ctx, s.roles, acct, accounts.RoleView); err != nil {
+		return Statement{}, err
+	}
+
+	return Statement{
+		AccountID: q.AccountID,
+		Currency:  acct.Currency,
+		From:      q.From,
+		To:        q.To,
+	}, nil
+}
+
+func (s *service) Export(ctx context.Context, st Statement, w Writer) error {
+	var balance money.Money
+	err := s.transactions.StreamHistory(ctx, st.AccountID, st.From, st.To,
+		func(opening, closing money.Money) error {
+			st.OpeningBalance, st.ClosingBalance = opening, closing
+			balance = opening
+			return w.Begin(st)
+		},
+		func(e *transactions.HistoryEntry) error {
+			var err error
+			if balance, err = balance.Add(e.Amount); err != nil {
+				return err
+			}
+			return w.Entry(*e)
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	if balance != st.ClosingBalance {
+		return ErrUnbalancedStatement
+	}
+	return w.End(st)
+}
+
+type service struct {
+	accounts     accounts.AccountRepository
+	roles        accounts.RoleRepository
+	transactions transactions.TransactionRepository
+}
+
+// NewService creates a statement service with necessary dependencies
+func NewService(
+	accounts accounts.AccountRepository,
+	roles accounts.RoleRepository,
+	transactions transactions.TransactionRepository,
+) Service {
+	return &service{
+		accounts:     accounts,
+		roles:        roles,
+		transactions: transactions,
+	}
+}
@@ -0,0 +1,152 @@
+package statements
+
+import (
+	"context"
+	"financial-app/pkg/accounts"
+	"financial-app/pkg/money"
+	"financial-app/pkg/transactions"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mockAccountRepository only finds the accounts of the account repository
+type mockAccountRepository struct {
+	accounts.AccountRepository
+}
+
+func (m *mockAccountRepository) Find(
+	ctx context.Context, id string,
+) (*accounts.Account, error) {
+	if id == "9999" {
+		return nil, accounts.ErrFetchingAccount(id)
+	}
+	return &accounts.Account{ID: id, Currency: "EUR", Status: accounts.StatusActive}, nil
+}
+
+// mockTransactionRepository only implements the history of the transaction
+// repository
+type mockTransactionRepository struct {
+	transactions.TransactionRepository
+	Balances map[time.Time]money.Money
+	Entries  []transactions.HistoryEntry
+}
+
+func (m *mockTransactionRepository) StreamHistory(
+	ctx context.Context, accountID string, from, to time.Time,
+	begin func(opening, closing money.Money) error,
+	fn func(*transactions.HistoryEntry) error,
+) error {
+	opening, ok := m.Balances[from]
+	if !ok {
+		return accounts.ErrFetchingAccount(accountID)
+	}
+	if err := begin(opening, m.Balances[to]); err != nil {
+		return err
+	}
+	for i := range m.Entries {
+		if err := fn(&m.Entries[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordingWriter records what is written to it
+type recordingWriter struct {
+	Begun   *Statement
+	Entries []transactions.HistoryEntry
+	Ended   *Statement
+}
+
+func (r *recordingWriter) Begin(st Statement) error {
+	r.Begun = &st
+	return nil
+}
+
+func (r *recordingWriter) Entry(e transactions.HistoryEntry) error {
+	r.Entries = append(r.Entries, e)
+	return nil
+}
+
+func (r *recordingWriter) End(st Statement) error {
+	r.Ended = &st
+	return nil
+}
+
+var (
+	from = time.Date(2023, 9, 1, 0, 0, 0, 0, time.UTC)
+	to   = time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	entries = []transactions.HistoryEntry{
+		{TransactionID: "1111", Direction: transactions.DirectionIncoming, CounterpartyID: "2222",
+			Amount: money.New(5000, "EUR"), Currency: "EUR", BalanceAfter: money.New(15000, "EUR"),
+			BookedAt: from.Add(time.Hour)},
+		{TransactionID: "3333", Direction: transactions.DirectionOutgoing, CounterpartyID: "4444",
+			Amount: money.New(-3000, "EUR"), Currency: "EUR", BalanceAfter: money.New(12000, "EUR"),
+			BookedAt: from.Add(2 * time.Hour)},
+	}
+)
+
+func TestService_Open(t *testing.T) {
+	service := NewService(&mockAccountRepository{}, nil, nil)
+
+	st, err := service.Open(context.Background(), Query{AccountID: "5555", From: from, To: to})
+
+	assert.NoError(t, err)
+	assert.Equal(t, Statement{AccountID: "5555", Currency: "EUR", From: from, To: to}, st)
+
+	// Empty period
+	_, err = service.Open(context.Background(), Query{AccountID: "5555", From: to, To: to})
+	assert.Equal(t, ErrInvalidPeriod, err)
+
+	// Unknown account
+	_, err = service.Open(context.Background(), Query{AccountID: "9999", From: from, To: to})
+	assert.ErrorIs(t, err, accounts.ErrAccountNotFound)
+}
+
+func TestService_Export(t *testing.T) {
+	testCases := []struct {
+		Name          string
+		Closing       money.Money
+		ExpectedError error
+	}{
+		{Name: "Balanced", Closing: money.New(12000, "EUR")},
+		{Name: "Unbalanced", Closing: money.New(13000, "EUR"), ExpectedError: ErrUnbalancedStatement},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			service := NewService(nil, nil, &mockTransactionRepository{
+				Balances: map[time.Time]money.Money{
+					from: money.New(10000, "EUR"),
+					to:   tc.Closing,
+				},
+				Entries: entries,
+			})
+			w := &recordingWriter{}
+
+			err := service.Export(context.Background(),
+				Statement{AccountID: "5555", Currency: "EUR", From: from, To: to}, w)
+
+			// The balances are read along with the entries
+			st := Statement{
+				AccountID:      "5555",
+				Currency:       "EUR",
+				From:           from,
+				To:             to,
+				OpeningBalance: money.New(10000, "EUR"),
+				ClosingBalance: tc.Closing,
+			}
+			assert.Equal(t, tc.ExpectedError, err)
+			assert.Equal(t, &st, w.Begun)
+			assert.Equal(t, entries, w.Entries)
+			if tc.ExpectedError == nil {
+				assert.Equal(t, &st, w.Ended)
+			} else {
+				assert.Nil(t, w.Ended, "An unbalanced statement should not be ended")
+			}
+		})
+	}
+}
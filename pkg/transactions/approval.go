@@ -0,0 +1,88 @@
+package transactions
+
+import (
+	"context"
+	"financial-app/pkg/currency"
+	"financial-app/pkg/money"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ApprovalPolicy sets which transfers a second principal must approve
+type ApprovalPolicy struct {
+	// Thresholds are the amounts by currency above which a transfer waits for
+	// an approval. Transfers in the other currencies never do.
+	Thresholds map[string]money.Money
+	// TTL is how long a transfer waits for an approval before it expires
+	TTL time.Duration
+}
+
+// RequiresApproval returns true if a transfer of the given amount waits for
+// an approval
+func (p ApprovalPolicy) RequiresApproval(amount money.Money) bool {
+	threshold, ok := p.Thresholds[amount.Currency()]
+	if !ok {
+		return false
+	}
+	cmp, err := amount.Cmp(threshold)
+	return err == nil && cmp > 0
+}
+
+// Book sets the status a transfer is booked with: completed, or waiting for an
+// approval until the TTL after its creation when its amount requires one
+func (p ApprovalPolicy) Book(txn *Transaction) {
+	txn.Status = StatusCompleted
+	if p.RequiresApproval(txn.Amount) {
+		expiresAt := txn.CreatedAt.Add(p.TTL)
+		txn.Status = StatusPendingApproval
+		txn.ExpiresAt = &expiresAt
+	}
+}
+
+// ParseThresholds parses a comma separated list of approval thresholds such
+// as "EUR:10000, USD:12000.50"
+func ParseThresholds(list string) (map[string]money.Money, error) {
+	thresholds := make(map[string]money.Money)
+	for _, s := range strings.Split(list, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		code, amount, ok := strings.Cut(s, ":")
+		if !ok {
+			return nil, ErrInvalidThreshold(s)
+		}
+		code = strings.ToUpper(strings.TrimSpace(code))
+		if _, ok := currency.Lookup(code); !ok {
+			return nil, ErrInvalidThreshold(s)
+		}
+		threshold, err := money.Parse(strings.TrimSpace(amount), code)
+		if err != nil || threshold.IsNegative() {
+			return nil, ErrInvalidThreshold(s)
+		}
+		thresholds[code] = threshold
+	}
+	return thresholds, nil
+}
+
+// RunExpiry expires the transfers whose approval is overdue at every interval
+// until the context is done, which releases the amounts they held
+func RunExpiry(
+	ctx context.Context, svc Service, interval time.Duration, logger *zap.SugaredLogger,
+) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := svc.ExpireApprovals(ctx, time.Now().UTC()); err != nil {
+				logger.Errorf("failed to expire the pending approvals: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package transactions
+
+import (
+	"context"
+	"errors"
+	"financial-app/pkg/accounts"
+	"financial-app/pkg/auth"
+	"financial-app/pkg/money"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseThresholds(t *testing.T) {
+	thresholds, err := ParseThresholds(" EUR:10000, usd:12000.50,,")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]money.Money{
+		"EUR": money.MustParse("10000", "EUR"),
+		"USD": money.MustParse("12000.50", "USD"),
+	}, thresholds)
+
+	thresholds, err = ParseThresholds("")
+	assert.NoError(t, err)
+	assert.Empty(t, thresholds)
+
+	for _, list := range []string{"EUR", "EUR:", "EUR:-1", "EUR:1.001", "XXY:100"} {
+		_, err := ParseThresholds(list)
+		assert.Error(t, err, list)
+	}
+}
+
+func TestApprovalPolicy_RequiresApproval(t *testing.T) {
+	policy := ApprovalPolicy{Thresholds: map[string]money.Money{
+		"EUR": money.MustParse("100.00", "EUR"),
+	}}
+
+	assert.False(t, policy.RequiresApproval(money.MustParse("99.99", "EUR")))
+	assert.False(t, policy.RequiresApproval(money.MustParse("100.00", "EUR")))
+	assert.True(t, policy.RequiresApproval(money.MustParse("100.01", "EUR")))
+	assert.False(t, policy.RequiresApproval(money.MustParse("1000.00", "USD")))
+}
+
+func TestService_Approval(t *testing.T) {
+	ownerID := "5d7e6e6c-52b4-4a3e-9d1c-3f7b1d2c9a10"
+	approverID := "a7c6a8f5-3b8e-4a55-8b5b-7a2d0f0b9e21"
+	viewerID := "0c1b5a7e-9d3f-4e2a-8b6c-5f4d3e2a1b09"
+
+	mockAccountRepository := &mockAccountRepository{
+		Accounts: map[string]*accounts.Account{
+			"2222": {ID: "2222", Balance: money.MustParse("200.00", "EUR"), Currency: "EUR",
+				Status: accounts.StatusActive, CustomerID: ownerID},
+			"3333": {ID: "3333", Balance: money.Zero("EUR"), Currency: "EUR",
+				Status: accounts.StatusActive},
+		},
+	}
+	mockTransactionRepository := &mockTransactionRepository{
+		Transactions: make(map[string]*Transaction),
+		Accounts:     mockAccountRepository.Accounts,
+	}
+	mockRoleRepository := &mockRoleRepository{Roles: map[string]accounts.Role{
+		"2222/" + approverID: accounts.RoleApprove,
+		"2222/" + viewerID:   accounts.RoleView,
+	}}
+	service := NewService(
+		mockAccountRepository, mockRoleRepository, mockTransactionRepository, nil, nil,
+		ApprovalPolicy{
+			Thresholds: map[string]money.Money{"EUR": money.MustParse("100.00", "EUR")},
+			TTL:        time.Hour,
+		},
+	)
+
+	maker := auth.NewContext(context.Background(), auth.Key{ID: "maker", CustomerID: ownerID})
+	transfer := func(id, amount string) (Transaction, error) {
+		return service.Transfer(maker, Transaction{
+			ID:              id,
+			SourceAccountID: "2222",
+			TargetAccountID: "3333",
+			Amount:          money.MustParse(amount, "EUR"),
+			Currency:        "EUR",
+		})
+	}
+
+	// Above the threshold the amount is only held
+	pending, err := transfer("1111", "150.00")
+	assert.NoError(t, err)
+	assert.Equal(t, StatusPendingApproval, pending.Status)
+	assert.Equal(t, "maker", pending.InitiatedBy)
+	assert.Equal(t, ownerID, pending.InitiatorCustomerID)
+	assert.Equal(t, pending.CreatedAt.Add(time.Hour), *pending.ExpiresAt)
+	assert.Equal(t, money.MustParse("200.00", "EUR"), mockAccountRepository.Accounts["2222"].Balance)
+	assert.Equal(t, money.MustParse("150.00", "EUR"), mockTransactionRepository.Held["2222"])
+
+	// The held amount is not available to other transfers
+	_, err = transfer("2222", "60.00")
+	assert.True(t, errors.Is(err, ErrInsufficientFunds))
+
+	// A pending transfer cannot be reversed, even by the operator
+	_, err = service.Reverse(context.Background(), "1111", "")
+	assert.Equal(t, ErrReversingIncomplete, err)
+
+	// The checker is another principal who may approve from the account
+	for _, tc := range []struct {
+		Key           auth.Key
+		ExpectedError error
+	}{
+		{auth.Key{ID: "maker", CustomerID: ownerID}, ErrSelfApproval},
+		{auth.Key{ID: "other", CustomerID: ownerID}, ErrSelfApproval},
+		{auth.Key{ID: "viewer", CustomerID: viewerID}, accounts.ErrInsufficientRole},
+	} {
+		_, err = service.Approve(auth.NewContext(context.Background(), tc.Key), "1111")
+		assert.True(t, errors.Is(err, tc.ExpectedError), tc.Key.ID)
+	}
+	assert.Equal(t, StatusPendingApproval, mockTransactionRepository.Transactions["1111"].Status)
+
+	checker := auth.NewContext(context.Background(), auth.Key{ID: "checker", CustomerID: approverID})
+	approved, err := service.Approve(checker, "1111")
+	assert.NoError(t, err)
+	assert.Equal(t, StatusCompleted, approved.Status)
+	assert.Equal(t, "checker", approved.ReviewedBy)
+	assert.Equal(t, *approved.ReviewedAt, approved.BookedAt)
+	assert.Equal(t, money.MustParse("50.00", "EUR"), mockAccountRepository.Accounts["2222"].Balance)
+	assert.Equal(t, money.MustParse("150.00", "EUR"), mockAccountRepository.Accounts["3333"].Balance)
+	assert.True(t, mockTransactionRepository.Held["2222"].IsZero())
+
+	_, err = service.Reject(checker, "1111")
+	assert.Equal(t, ErrNotPendingApproval, err)
+
+	// A rejected transfer releases its hold without moving the balances
+	mockAccountRepository.Accounts["2222"].Balance = money.MustParse("300.00", "EUR")
+	_, err = transfer("3333", "250.00")
+	assert.NoError(t, err)
+	rejected, err := service.Reject(checker, "3333")
+	assert.NoError(t, err)
+	assert.Equal(t, StatusRejected, rejected.Status)
+	assert.Equal(t, money.MustParse("300.00", "EUR"), mockAccountRepository.Accounts["2222"].Balance)
+	assert.True(t, mockTransactionRepository.Held["2222"].IsZero())
+
+	// An overdue approval can no longer be given, and expires
+	_, err = transfer("4444", "250.00")
+	assert.NoError(t, err)
+	overdue := time.Now().UTC().Add(-time.Minute)
+	mockTransactionRepository.Transactions["4444"].ExpiresAt = &overdue
+	_, err = service.Approve(checker, "4444")
+	assert.Equal(t, ErrApprovalExpired, err)
+
+	expired, err := service.ExpireApprovals(context.Background(), time.Now().UTC())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), expired)
+	assert.Equal(t, StatusExpired, mockTransactionRepository.Transactions["4444"].Status)
+	assert.True(t, mockTransactionRepository.Held["2222"].IsZero())
+
+	// A reversal above the threshold waits for an approval as well, and only
+	// reverses the original transaction once approved
+	reversal, err := service.Reverse(context.Background(), "1111", "")
+	assert.NoError(t, err)
+	assert.Equal(t, StatusPendingApproval, reversal.Status)
+	assert.Equal(t, money.MustParse("150.00", "EUR"), mockTransactionRepository.Held["3333"])
+	assert.Equal(t, money.MustParse("150.00", "EUR"), mockAccountRepository.Accounts["3333"].Balance)
+	assert.Equal(t, StatusCompleted, mockTransactionRepository.Transactions["1111"].Status)
+
+	operator := auth.NewContext(context.Background(), auth.Key{ID: "operator"})
+	approved, err = service.Approve(operator, reversal.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, StatusCompleted, approved.Status)
+	assert.True(t, mockAccountRepository.Accounts["3333"].Balance.IsZero())
+	assert.Equal(t, money.MustParse("450.00", "EUR"), mockAccountRepository.Accounts["2222"].Balance)
+	assert.Equal(t, StatusReversed, mockTransactionRepository.Transactions["1111"].Status)
+}
@@ -47,22 +47,68 @@ func (s *instrumentingService) Transfer(
 	return s.next.Transfer(ctx, txn)
 }
 
-func (s *instrumentingService) LoadAll(ctx context.Context) []transactions.Transaction {
+func (s *instrumentingService) LoadAll(
+	ctx context.Context, q transactions.Query,
+) (page transactions.Page, err error) {
 	defer func(begin time.Time) {
 		s.requestCount.With("method", "loadall").Add(1)
 		s.requestLatency.With("method", "loadall").Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-	return s.next.LoadAll(ctx)
+	return s.next.LoadAll(ctx, q)
 }
 
-func (s *instrumentingService) Clean(
+func (s *instrumentingService) History(
+	ctx context.Context, q transactions.HistoryQuery,
+) (page transactions.HistoryPage, err error) {
+	defer func(begin time.Time) {
+		s.requestCount.With("method", "history").Add(1)
+		s.requestLatency.With("method", "history").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return s.next.History(ctx, q)
+}
+
+func (s *instrumentingService) Reverse(
+	ctx context.Context, id string, amount string,
+) (txn transactions.Transaction, err error) {
+	defer func(begin time.Time) {
+		s.requestCount.With("method", "reverse").Add(1)
+		s.requestLatency.With("method", "reverse").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return s.next.Reverse(ctx, id, amount)
+}
+
+func (s *instrumentingService) Approve(
 	ctx context.Context, id string,
-) (err error) {
+) (txn transactions.Transaction, err error) {
+	defer func(begin time.Time) {
+		s.requestCount.With("method", "approve").Add(1)
+		s.requestLatency.With("method", "approve").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return s.next.Approve(ctx, id)
+}
+
+func (s *instrumentingService) Reject(
+	ctx context.Context, id string,
+) (txn transactions.Transaction, err error) {
+	defer func(begin time.Time) {
+		s.requestCount.With("method", "reject").Add(1)
+		s.requestLatency.With("method", "reject").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return s.next.Reject(ctx, id)
+}
+
+func (s *instrumentingService) ExpireApprovals(
+	ctx context.Context, now time.Time,
+) (expired int64, err error) {
 	defer func(begin time.Time) {
-		s.requestCount.With("method", "clean").Add(1)
-		s.requestLatency.With("method", "clean").Observe(time.Since(begin).Seconds())
+		s.requestCount.With("method", "expire_approvals").Add(1)
+		s.requestLatency.With("method", "expire_approvals").Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-	return s.next.Clean(ctx, id)
+	return s.next.ExpireApprovals(ctx, now)
 }
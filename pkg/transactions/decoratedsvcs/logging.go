@@ -41,8 +41,10 @@ func (s *loggingService) Transfer(
 			log.String("transaction_id", string(txn.ID)),
 			log.String("source_account_id", string(txn.SourceAccountID)),
 			log.String("target_account_id", string(txn.TargetAccountID)),
-			log.Float64("amount", txn.Amount),
+			log.Stringer("amount", txn.Amount),
 			log.String("currency", string(txn.Currency)),
+			log.String("target_currency", string(txn.TargetCurrency)),
+			log.String("quote_id", string(txn.QuoteID)),
 			log.Duration("took", time.Since(begin)),
 			log.Error(err),
 		)
@@ -50,26 +52,96 @@ func (s *loggingService) Transfer(
 	return s.next.Transfer(ctx, txn)
 }
 
-func (s *loggingService) LoadAll(ctx context.Context) []transactions.Transaction {
+func (s *loggingService) LoadAll(
+	ctx context.Context, q transactions.Query,
+) (page transactions.Page, err error) {
 	defer func(begin time.Time) {
 		s.logger.Infow(
 			"loadall",
+			log.String("account_id", q.AccountID),
+			log.String("currency", q.Currency),
+			log.Int("limit", q.Limit),
+			log.Int("count", len(page.Transactions)),
 			log.Duration("took", time.Since(begin)),
+			log.Error(err),
+		)
+	}(time.Now())
+	return s.next.LoadAll(ctx, q)
+}
+
+func (s *loggingService) History(
+	ctx context.Context, q transactions.HistoryQuery,
+) (page transactions.HistoryPage, err error) {
+	defer func(begin time.Time) {
+		s.logger.Infow(
+			"history",
+			log.String("account_id", q.AccountID),
+			log.Int("limit", q.Limit),
+			log.Int("count", len(page.Entries)),
+			log.Duration("took", time.Since(begin)),
+			log.Error(err),
 		)
 	}(time.Now())
-	return s.next.LoadAll(ctx)
+	return s.next.History(ctx, q)
 }
 
-func (s *loggingService) Clean(
+func (s *loggingService) Reverse(
+	ctx context.Context, id string, amount string,
+) (txn transactions.Transaction, err error) {
+	defer func(begin time.Time) {
+		s.logger.Infow(
+			"reverse",
+			log.String("transaction_id", id),
+			log.String("amount", amount),
+			log.String("reversal_id", txn.ID),
+			log.Duration("took", time.Since(begin)),
+			log.Error(err),
+		)
+	}(time.Now())
+	return s.next.Reverse(ctx, id, amount)
+}
+
+func (s *loggingService) Approve(
 	ctx context.Context, id string,
-) (err error) {
+) (txn transactions.Transaction, err error) {
 	defer func(begin time.Time) {
 		s.logger.Infow(
-			"clean",
-			log.String("transaction_id", string(id)),
+			"approve",
+			log.String("transaction_id", id),
+			log.String("status", txn.Status),
+			log.Duration("took", time.Since(begin)),
+			log.Error(err),
+		)
+	}(time.Now())
+	return s.next.Approve(ctx, id)
+}
+
+func (s *loggingService) Reject(
+	ctx context.Context, id string,
+) (txn transactions.Transaction, err error) {
+	defer func(begin time.Time) {
+		s.logger.Infow(
+			"reject",
+			log.String("transaction_id", id),
+			log.String("status", txn.Status),
+			log.Duration("took", time.Since(begin)),
+			log.Error(err),
+		)
+	}(time.Now())
+	return s.next.Reject(ctx, id)
+}
+
+func (s *loggingService) ExpireApprovals(
+	ctx context.Context, now time.Time,
+) (expired int64, err error) {
+	defer func(begin time.Time) {
+		s.logger.Infow(
+			"expire approvals",
+			log.Time("now", now),
+			log.Int64("expired", expired),
 			log.Duration("took", time.Since(begin)),
 			log.Error(err),
 		)
 	}(time.Now())
-	return s.next.Clean(ctx, id)
+	return s.next.ExpireApprovals(ctx, now)
 }
@@ -1,25 +1,184 @@
 package transactions
 
 import (
-	"errors"
+	"financial-app/pkg/errs"
+	"financial-app/pkg/money"
 )
 
+// ErrInsufficientFunds is used when the source account cannot cover a transfer
+var ErrInsufficientFunds = errs.New(errs.ErrConflict, "insufficient_funds", "insufficient funds")
+
+// InsufficientFundsError is used when a transaction could not be performed
+// because of insufficient balance. It wraps ErrInsufficientFunds.
+type InsufficientFundsError struct {
+	AccountID string
+	Balance   money.Money
+	Amount    money.Money
+}
+
+func (e *InsufficientFundsError) Error() string {
+	return "the source amount is insufficient: " + e.Balance.String() + " < " +
+		e.Amount.String() + " for the account " + e.AccountID
+}
+
+// Unwrap returns ErrInsufficientFunds
+func (e *InsufficientFundsError) Unwrap() error {
+	return ErrInsufficientFunds
+}
+
+// ErrCurrencyMismatch is used when the transfer currency differs from the
+// currency of one of the accounts
+var ErrCurrencyMismatch = errs.New(errs.ErrUnprocessable, "currency_mismatch", "currency mismatch")
+
+// CurrencyMismatchError is used when a transaction could not be performed
+// because an account is held in another currency. It wraps ErrCurrencyMismatch.
+type CurrencyMismatchError struct {
+	AccountID       string
+	AccountCurrency string
+	Currency        string
+}
+
+func (e *CurrencyMismatchError) Error() string {
+	return "the transfer currency " + e.Currency + " does not match the currency " +
+		e.AccountCurrency + " of the account " + e.AccountID
+}
+
+// Unwrap returns ErrCurrencyMismatch
+func (e *CurrencyMismatchError) Unwrap() error {
+	return ErrCurrencyMismatch
+}
+
 // ErrPostingTransaction is used when a transaction could not be created
-func ErrPostingTransaction(transactionID string) error {
-	return errors.New("could not create a new transaction by ID " + transactionID)
+func ErrPostingTransaction(transactionID string) *errs.Error {
+	return errs.New(errs.ErrInternal, "posting_transaction_failed",
+		"could not create a new transaction by ID "+transactionID)
 }
 
-// ErrFetchingTransaction is used when a transaction could not be found
-func ErrFetchingTransaction(transactionID string) error {
-	return errors.New("could not fetch transaction by ID " + transactionID)
+// ErrDuplicateTransaction is used when a transaction ID is already taken
+var ErrDuplicateTransaction = errs.New(errs.ErrConflict, "duplicate_transaction",
+	"a transaction with the same ID already exists")
+
+// ErrSameAccount is used when a transfer has the same source and target account
+var ErrSameAccount = errs.New(errs.ErrConflict, "same_account", "accounts cannot be the same")
+
+// ErrNonPositiveAmount is used when one of the legs of a transfer is not positive
+var ErrNonPositiveAmount = errs.New(errs.ErrUnprocessable, "non_positive_amount",
+	"transfer amounts must be positive")
+
+// ErrInvalidCurrencyCode is used when a currency is not an ISO 4217 alphabetic code
+var ErrInvalidCurrencyCode = errs.New(errs.ErrUnprocessable, "invalid_currency_code",
+	"currency must be a three letter ISO 4217 code")
+
+// ErrTransactionNotFound is used when a transaction does not exist
+var ErrTransactionNotFound = errs.New(errs.ErrNotFound, "transaction_not_found", "transaction not found")
+
+// ErrFetchingTransaction is used when a transaction could not be found. It
+// matches ErrTransactionNotFound.
+func ErrFetchingTransaction(transactionID string) *errs.Error {
+	return ErrTransactionNotFound.WithMessage("could not fetch transaction by ID " + transactionID)
 }
 
 // ErrUpdateAccount is used when an account could not be updated during a transfer
-func ErrUpdateAccount(accountID string) error {
-	return errors.New("could not update an account by ID " + accountID)
+func ErrUpdateAccount(accountID string) *errs.Error {
+	return errs.New(errs.ErrInternal, "updating_account_failed",
+		"could not update an account by ID "+accountID)
+}
+
+// ErrTransactionImmutable is used when a transaction is to be deleted
+var ErrTransactionImmutable = errs.New(errs.ErrNotAllowed, "transaction_immutable",
+	"transactions cannot be deleted, reverse them instead")
+
+// ErrReversingReversal is used when a reversal is to be reversed
+var ErrReversingReversal = errs.New(errs.ErrUnprocessable, "reversing_reversal",
+	"a reversal cannot be reversed")
+
+// ErrReversalExceedsOriginal is used when a reversal would take back more than
+// what remains of the transaction it reverses
+var ErrReversalExceedsOriginal = errs.New(errs.ErrConflict, "reversal_exceeds_original",
+	"reversal exceeds the amount left to reverse")
+
+// ErrInvalidReversalAmount is used when a reversal amount is not a positive
+// amount of the transaction currency
+var ErrInvalidReversalAmount = errs.New(errs.ErrInvalid, "invalid_reversal_amount",
+	"reversal amount must be a positive amount of the transaction currency")
+
+// ErrFetchingReversals is used when the reversals of a transaction could not be fetched
+func ErrFetchingReversals(transactionID string) *errs.Error {
+	return errs.New(errs.ErrInternal, "fetching_reversals_failed",
+		"could not fetch the reversals of transaction by ID "+transactionID)
+}
+
+// ErrQueryingTransactions is used when the transactions could not be queried
+var ErrQueryingTransactions = errs.New(errs.ErrInternal, "querying_transactions_failed",
+	"could not query the transactions")
+
+// ErrQueryingHistory is used when the history of an account could not be queried
+var ErrQueryingHistory = errs.New(errs.ErrInternal, "querying_history_failed",
+	"could not query the history of the account")
+
+// ErrStreamingHistory is used when the history of an account could not be streamed
+var ErrStreamingHistory = errs.New(errs.ErrInternal, "streaming_history_failed",
+	"could not stream the history of the account")
+
+// ErrInvalidFilter is used when a query parameter of a listing is not valid
+func ErrInvalidFilter(name string) *errs.Error {
+	return errs.New(errs.ErrInvalid, "invalid_filter", "invalid value of the query parameter "+name)
+}
+
+// ErrInvalidThreshold is used when an approval threshold is not a currency
+// code followed by a non negative amount, such as "EUR:10000"
+func ErrInvalidThreshold(threshold string) *errs.Error {
+	return errs.New(errs.ErrInvalid, "invalid_approval_threshold",
+		"invalid approval threshold "+threshold)
+}
+
+// ErrNotPendingApproval is used when a transaction to approve or reject is not
+// waiting for an approval
+var ErrNotPendingApproval = errs.New(errs.ErrConflict, "transaction_not_pending_approval",
+	"the transaction is not waiting for an approval")
+
+// ErrApprovalExpired is used when a transaction is approved or rejected after
+// its approval has expired
+var ErrApprovalExpired = errs.New(errs.ErrGone, "approval_expired",
+	"the approval of the transaction has expired")
+
+// ErrSelfApproval is used when the principal who initiated a transaction
+// approves or rejects it
+var ErrSelfApproval = errs.New(errs.ErrForbidden, "self_approval",
+	"a transaction must be approved or rejected by another principal than its initiator")
+
+// ErrReversingIncomplete is used when a transaction that was not booked is to
+// be reversed
+var ErrReversingIncomplete = errs.New(errs.ErrConflict, "reversing_incomplete_transaction",
+	"only completed transactions can be reversed")
+
+// ErrReviewingTransaction is used when a transaction could not be approved or rejected
+func ErrReviewingTransaction(transactionID string) *errs.Error {
+	return errs.New(errs.ErrInternal, "reviewing_transaction_failed",
+		"could not review the transaction by ID "+transactionID)
+}
+
+// ErrExpiringApprovals is used when the overdue approvals could not be expired
+var ErrExpiringApprovals = errs.New(errs.ErrInternal, "expiring_approvals_failed",
+	"could not expire the overdue approvals")
+
+// ErrInvalidTransition is used when a transaction cannot move to another status
+var ErrInvalidTransition = errs.New(errs.ErrConflict, "invalid_transaction_status_transition",
+	"invalid transaction status transition")
+
+// TransitionError is used when a transaction cannot move from its status to
+// another one. It wraps ErrInvalidTransition.
+type TransitionError struct {
+	TransactionID string
+	From          string
+	To            string
+}
+
+func (e *TransitionError) Error() string {
+	return "the transaction " + e.TransactionID + " cannot go from " + e.From + " to " + e.To
 }
 
-// ErrDeletingTransaction is used when a transaction could not be removed
-func ErrDeletingTransaction(transactionID string) error {
-	return errors.New("could not delete transaction by ID " + transactionID)
+// Unwrap returns ErrInvalidTransition
+func (e *TransitionError) Unwrap() error {
+	return ErrInvalidTransition
 }
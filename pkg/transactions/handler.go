@@ -1,20 +1,25 @@
 package transactions
 
 import (
-	"financial-app/pkg/accounts"
+	"context"
+	"encoding/json"
+	"errors"
+	"financial-app/pkg/currency"
+	"financial-app/pkg/errs"
+	"financial-app/pkg/http/rest/httperr"
+	"financial-app/pkg/money"
+	"financial-app/pkg/pagination"
+	"io"
 	"net/http"
-	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
+	uuid "github.com/satori/go.uuid"
 	"go.uber.org/zap"
 )
 
-var (
-	transactionIDRequired = "transaction id required"
-	currencyNotSupported  = "currency is not supported"
-	accountsNotSame       = "accounts cannot be the same"
-)
+var transactionIDRequired = errs.New(errs.ErrInvalid, "transaction_id_required", "transaction id required")
 
 type TransactionHandler struct {
 	Service Service
@@ -27,7 +32,11 @@ func (h *TransactionHandler) Router(routerGroup *gin.RouterGroup) {
 	routerGroup.GET("transactions/:id", h.load)
 	routerGroup.GET("transactions", h.loadAll)
 	routerGroup.POST("transactions", h.transfer)
+	routerGroup.POST("transactions/:id/reverse", h.reverse)
+	routerGroup.POST("transactions/:id/approve", h.approve)
+	routerGroup.POST("transactions/:id/reject", h.reject)
 	routerGroup.DELETE("transactions/:id", h.clean)
+	routerGroup.GET("accounts/:id/transactions", h.history)
 }
 
 // load retrieves a transaction by ID
@@ -36,9 +45,7 @@ func (h *TransactionHandler) load(context *gin.Context) {
 	if id == "" {
 		h.Logger.Error("no transaction id found")
 
-		context.JSON(http.StatusBadRequest, gin.H{
-			"error": transactionIDRequired,
-		})
+		httperr.Respond(context, transactionIDRequired)
 		return
 	}
 
@@ -46,46 +53,174 @@ func (h *TransactionHandler) load(context *gin.Context) {
 	if err != nil {
 		h.Logger.Error(err)
 
-		context.JSON(http.StatusNotFound, gin.H{
-			"error": err.Error(),
-		})
+		httperr.Respond(context, err)
 		return
 	}
 
 	context.JSON(http.StatusOK, transaction)
 }
 
-// loadAll retrieves all the completed transactions
+// bookedAtCursor decodes a cursor of a listing ordered by booking time, if any
+func bookedAtCursor(s string) (*pagination.Cursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	c, err := pagination.Decode(s)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := time.Parse(time.RFC3339Nano, c.Key); err != nil {
+		return nil, pagination.ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// queryFromRequest builds a transaction query from the query parameters of a
+// listing request
+func queryFromRequest(context *gin.Context) (Query, error) {
+	var (
+		q   Query
+		err error
+	)
+
+	if q.Limit, err = pagination.ParseLimit(context.Query("limit")); err != nil {
+		return Query{}, err
+	}
+	if q.After, err = bookedAtCursor(context.Query("cursor")); err != nil {
+		return Query{}, err
+	}
+
+	q.AccountID = context.Query("account_id")
+	if q.AccountID != "" {
+		if _, err := uuid.FromString(q.AccountID); err != nil {
+			return Query{}, ErrInvalidFilter("account_id")
+		}
+	}
+
+	q.Currency = context.Query("currency")
+	if q.Currency != "" && !currency.IsSupported(q.Currency) {
+		return Query{}, currency.ErrNotSupported
+	}
+
+	q.Status = context.Query("status")
+	if q.Status != "" && !ValidStatus(q.Status) {
+		return Query{}, ErrInvalidFilter("status")
+	}
+
+	for _, f := range []struct {
+		name   string
+		amount **money.Money
+	}{{"min_amount", &q.MinAmount}, {"max_amount", &q.MaxAmount}} {
+		if s := context.Query(f.name); s != "" {
+			amount, err := money.Parse(s, q.Currency)
+			if err != nil {
+				return Query{}, ErrInvalidFilter(f.name)
+			}
+			*f.amount = &amount
+		}
+	}
+
+	for _, f := range []struct {
+		name string
+		time **time.Time
+	}{{"from", &q.From}, {"to", &q.To}} {
+		if s := context.Query(f.name); s != "" {
+			t, err := time.Parse(time.RFC3339Nano, s)
+			if err != nil {
+				return Query{}, ErrInvalidFilter(f.name)
+			}
+			*f.time = &t
+		}
+	}
+
+	return q, nil
+}
+
+// loadAll retrieves a page of the transactions
 func (h *TransactionHandler) loadAll(context *gin.Context) {
-	transactions := h.Service.LoadAll(context)
+	q, err := queryFromRequest(context)
+	if err != nil {
+		h.Logger.Error(err)
 
-	context.JSON(http.StatusOK, transactions)
+		httperr.Respond(context, err)
+		return
+	}
+
+	page, err := h.Service.LoadAll(context, q)
+	if err != nil {
+		h.Logger.Error(err)
+
+		httperr.Respond(context, err)
+		return
+	}
+
+	context.JSON(http.StatusOK, page)
+}
+
+// history retrieves a page of the transactions of an account, the most
+// recently booked first
+func (h *TransactionHandler) history(context *gin.Context) {
+	q := HistoryQuery{AccountID: context.Param("id")}
+
+	var err error
+	if q.Limit, err = pagination.ParseLimit(context.Query("limit")); err == nil {
+		q.After, err = bookedAtCursor(context.Query("cursor"))
+	}
+	if err != nil {
+		h.Logger.Error(err)
+
+		httperr.Respond(context, err)
+		return
+	}
+
+	page, err := h.Service.History(context, q)
+	if err != nil {
+		h.Logger.Error(err)
+
+		httperr.Respond(context, err)
+		return
+	}
+
+	context.JSON(http.StatusOK, page)
 }
 
 // transferRequest
 type transactionRequest struct {
-	SourceAccountID string  `json:"source_account_id" validate:"required,uuid"`
-	TargetAccountID string  `json:"target_account_id" validate:"required,uuid"`
-	Amount          float64 `json:"amount" validate:"required,numeric,gt=0"`
-	Currency        string  `json:"currency" validate:"currency"`
+	SourceAccountID string      `json:"source_account_id" validate:"required,uuid"`
+	TargetAccountID string      `json:"target_account_id" validate:"required,uuid"`
+	Amount          json.Number `json:"amount" validate:"required,amount"`
+	Currency        string      `json:"currency" validate:"currency"`
+	TargetCurrency  string      `json:"target_currency" validate:"omitempty,currency"`
+	QuoteID         string      `json:"quote_id" validate:"omitempty,uuid"`
 }
 
-func transactionRequestFromTransactionDomain(p transactionRequest) Transaction {
+func transactionRequestFromTransactionDomain(p transactionRequest) (Transaction, error) {
+	amount, err := money.Parse(p.Amount.String(), p.Currency)
+	if err != nil {
+		return Transaction{}, err
+	}
+
 	return Transaction{
 		ID:              nextTransactionID(), // Generate a new uuid
 		SourceAccountID: p.SourceAccountID,
 		TargetAccountID: p.TargetAccountID,
-		Amount:          p.Amount,
+		Amount:          amount,
 		Currency:        p.Currency,
-	}
+		TargetCurrency:  p.TargetCurrency,
+		QuoteID:         p.QuoteID,
+	}, nil
 }
 
-// validCurrency validates if the given currency is supported
-func validCurrency(fl validator.FieldLevel) bool {
-	if currency, ok := fl.Field().Interface().(string); ok {
-		return accounts.IsSupportedCurrency(currency)
+// validAmount validates if the given amount is a positive amount of the
+// request currency
+func validAmount(fl validator.FieldLevel) bool {
+	currency := fl.Parent().FieldByName("Currency").String()
+	amount, err := money.Parse(fl.Field().String(), currency)
+	if err != nil {
+		return false
 	}
-	return false
+	return amount.IsPositive()
 }
 
 // transfer performs a new transaction from source to target account
@@ -95,22 +230,20 @@ func (h *TransactionHandler) transfer(context *gin.Context) {
 	if err := context.ShouldBindJSON(&transactionReq); err != nil {
 		h.Logger.Error(err)
 
-		context.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		httperr.Respond(context, errs.Invalid(err))
 		return
 	}
 
 	v := validator.New()
-	v.RegisterValidation("currency", validCurrency)
+	v.RegisterTagNameFunc(httperr.JSONFieldName)
+	v.RegisterValidation("currency", currency.Validate)
+	v.RegisterValidation("amount", validAmount)
 
 	err := v.Var(transactionReq.Currency, "currency")
 	if err != nil {
 		h.Logger.Error(err)
 
-		context.JSON(http.StatusBadRequest, gin.H{
-			"error": currencyNotSupported,
-		})
+		httperr.Respond(context, currency.ErrNotSupported)
 		return
 	}
 
@@ -118,9 +251,7 @@ func (h *TransactionHandler) transfer(context *gin.Context) {
 	if err != nil {
 		h.Logger.Error(err)
 
-		context.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		httperr.Respond(context, errs.Invalid(err))
 		return
 	}
 
@@ -129,65 +260,111 @@ func (h *TransactionHandler) transfer(context *gin.Context) {
 	if err != nil {
 		h.Logger.Error(err)
 
-		context.JSON(http.StatusConflict, gin.H{
-			"error": accountsNotSame,
-		})
+		httperr.Respond(context, ErrSameAccount)
+		return
+	}
+
+	txn, err := transactionRequestFromTransactionDomain(transactionReq)
+	if err != nil {
+		h.Logger.Error(err)
+
+		httperr.Respond(context, errs.Invalid(err))
 		return
 	}
 
-	txn := transactionRequestFromTransactionDomain(transactionReq)
 	transaction, err := h.Service.Transfer(context, txn)
 	if err != nil {
-		noSourceAccountFound := accounts.ErrFetchingAccount(txn.SourceAccountID).Error()
-		noTargetAccountFound := accounts.ErrFetchingAccount(txn.TargetAccountID).Error()
-		if err.Error() == noSourceAccountFound ||
-			err.Error() == noTargetAccountFound {
-			context.JSON(http.StatusNotFound, gin.H{
-				"error": err.Error(),
-			})
-			return
-		}
+		h.Logger.Error(err)
 
-		// Insufficient balance error
-		if strings.Contains(err.Error(), "insufficient") {
-			context.JSON(http.StatusConflict, gin.H{
-				"error": err.Error(),
-			})
-			return
-		}
+		httperr.Respond(context, err)
+		return
+	}
+
+	context.JSON(bookedStatus(transaction), transaction)
+}
 
-		context.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
+// bookedStatus returns the status code answering a transfer: 202 Accepted for
+// a transfer waiting for an approval, which is not booked yet, and 200 OK
+// otherwise
+func bookedStatus(txn Transaction) int {
+	if txn.Status == StatusPendingApproval {
+		return http.StatusAccepted
+	}
+	return http.StatusOK
+}
+
+// reverseRequest
+type reverseRequest struct {
+	Amount json.Number `json:"amount"`
+}
+
+// reverse books a transfer taking back a transaction in full or in part
+func (h *TransactionHandler) reverse(context *gin.Context) {
+	id := context.Param("id")
+	if id == "" {
+		h.Logger.Error("no transaction id found")
+
+		httperr.Respond(context, transactionIDRequired)
 		return
 	}
 
-	context.JSON(http.StatusOK, transaction)
+	// Without a body the transaction is reversed in full
+	var reverseReq reverseRequest
+	if err := context.ShouldBindJSON(&reverseReq); err != nil && !errors.Is(err, io.EOF) {
+		h.Logger.Error(err)
+
+		httperr.Respond(context, errs.Invalid(err))
+		return
+	}
+
+	transaction, err := h.Service.Reverse(context, id, reverseReq.Amount.String())
+	if err != nil {
+		h.Logger.Error(err)
+
+		httperr.Respond(context, err)
+		return
+	}
+
+	context.JSON(bookedStatus(transaction), transaction)
 }
 
-// clean deletes a transaction by ID
-func (h *TransactionHandler) clean(context *gin.Context) {
+// approve books a transfer waiting for an approval
+func (h *TransactionHandler) approve(context *gin.Context) {
+	h.review(context, h.Service.Approve)
+}
+
+// reject releases the amount held by a transfer waiting for an approval
+func (h *TransactionHandler) reject(context *gin.Context) {
+	h.review(context, h.Service.Reject)
+}
+
+// review answers with the transaction approved or rejected by the given method
+func (h *TransactionHandler) review(
+	context *gin.Context, decide func(context.Context, string) (Transaction, error),
+) {
 	id := context.Param("id")
 	if id == "" {
 		h.Logger.Error("no transaction id found")
 
-		context.JSON(http.StatusBadRequest, gin.H{
-			"error": transactionIDRequired,
-		})
+		httperr.Respond(context, transactionIDRequired)
 		return
 	}
 
-	err := h.Service.Clean(context, id)
+	transaction, err := decide(context, id)
 	if err != nil {
 		h.Logger.Error(err)
 
-		context.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
+		httperr.Respond(context, err)
 		return
 	}
 
-	context.JSON(http.StatusOK, gin.H{
-		id: "Deleted",
-	})
+	context.JSON(http.StatusOK, transaction)
+}
+
+// clean refuses to delete a transaction, which would leave the balances it
+// moved unexplained. Transactions are reversed instead.
+func (h *TransactionHandler) clean(context *gin.Context) {
+	h.Logger.Error(ErrTransactionImmutable)
+
+	httperr.Respond(context, ErrTransactionImmutable)
 }
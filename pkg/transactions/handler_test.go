@@ -5,9 +5,17 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"financial-app/pkg/accounts"
+	"financial-app/pkg/currency"
+	"financial-app/pkg/errs"
+	"financial-app/pkg/fx"
+	"financial-app/pkg/http/rest/httperr"
+	"financial-app/pkg/money"
+	"financial-app/pkg/pagination"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -24,9 +32,14 @@ func (m *MockService) Load(ctx context.Context, id string) (Transaction, error)
 	return args.Get(0).(Transaction), args.Error(1)
 }
 
-func (m *MockService) LoadAll(ctx context.Context) []Transaction {
-	args := m.Called(ctx)
-	return args.Get(0).([]Transaction)
+func (m *MockService) LoadAll(ctx context.Context, q Query) (Page, error) {
+	args := m.Called(ctx, q)
+	return args.Get(0).(Page), args.Error(1)
+}
+
+func (m *MockService) History(ctx context.Context, q HistoryQuery) (HistoryPage, error) {
+	args := m.Called(ctx, q)
+	return args.Get(0).(HistoryPage), args.Error(1)
 }
 
 func (m *MockService) Transfer(ctx context.Context, txn Transaction) (Transaction, error) {
@@ -34,9 +47,24 @@ func (m *MockService) Transfer(ctx context.Context, txn Transaction) (Transactio
 	return args.Get(0).(Transaction), args.Error(1)
 }
 
-func (m *MockService) Clean(ctx context.Context, id string) error {
+func (m *MockService) Reverse(ctx context.Context, id string, amount string) (Transaction, error) {
+	args := m.Called(ctx, id, amount)
+	return args.Get(0).(Transaction), args.Error(1)
+}
+
+func (m *MockService) Approve(ctx context.Context, id string) (Transaction, error) {
 	args := m.Called(ctx, id)
-	return args.Error(0)
+	return args.Get(0).(Transaction), args.Error(1)
+}
+
+func (m *MockService) Reject(ctx context.Context, id string) (Transaction, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(Transaction), args.Error(1)
+}
+
+func (m *MockService) ExpireApprovals(ctx context.Context, now time.Time) (int64, error) {
+	args := m.Called(ctx, now)
+	return args.Get(0).(int64), args.Error(1)
 }
 
 func TestTransactionHandler_Load(t *testing.T) {
@@ -81,14 +109,13 @@ func TestTransactionHandler_Load(t *testing.T) {
 
 			// If an error is expected, assert the error message
 			if tc.ExpectedError != nil {
-				// Convert the JSON response to a map
-				var response map[string]string
+				// Convert the JSON response to a problem
+				var response httperr.Problem
 				err := json.Unmarshal(rr.Body.Bytes(), &response)
-				// Grab the value & whether or not it exists
-				errorMsg, exists := response["error"]
+				// Grab the detail of the problem
+				errorMsg := response.Detail
 				// Make some assertions on the correctness of the response.
 				assert.Nil(t, err)
-				assert.True(t, exists)
 				assert.Equal(t, tc.ExpectedError.Error(), errorMsg)
 			}
 		})
@@ -96,53 +123,187 @@ func TestTransactionHandler_Load(t *testing.T) {
 }
 
 func TestTransactionHandler_LoadAll(t *testing.T) {
-	mockService := new(MockService)
-	logger, _ := zap.NewDevelopment()
-	handler := &TransactionHandler{Service: mockService, Logger: logger.Sugar()}
-
-	r := gin.Default()
-	r.GET("/transactions", handler.loadAll)
+	from := time.Date(2023, 9, 1, 0, 0, 0, 0, time.UTC)
+	minAmount := money.New(10000, "EUR")
+	cursor := pagination.Cursor{Key: "2023-09-12T09:00:00Z", ID: "transaction-id-2"}
 
 	testCases := []struct {
 		Name             string
-		ExpectedResponse []Transaction
+		URL              string
+		ExpectedQuery    Query
+		ExpectedResponse Page
+		ServiceError     error
 		ExpectedCode     int
 	}{
 		{
-			Name: "Transactions Found",
-			ExpectedResponse: []Transaction{
-				{ID: "transaction-id-1", SourceAccountID: "account-id-1",
-					TargetAccountID: "account-id-2", Amount: 100, Currency: "EUR"},
-				{ID: "transaction-id-2", SourceAccountID: "account-id-3",
-					TargetAccountID: "account-id-4", Amount: 200, Currency: "EUR"},
+			Name:          "Transactions Found",
+			URL:           "/transactions",
+			ExpectedQuery: Query{Limit: pagination.DefaultLimit},
+			ExpectedResponse: Page{
+				Transactions: []Transaction{
+					{ID: "transaction-id-1", SourceAccountID: "account-id-1",
+						TargetAccountID: "account-id-2", Amount: money.New(10000, "EUR"), Currency: "EUR"},
+					{ID: "transaction-id-2", SourceAccountID: "account-id-3",
+						TargetAccountID: "account-id-4", Amount: money.New(20000, "EUR"), Currency: "EUR"},
+				},
+				NextCursor: cursor.Encode(),
 			},
 			ExpectedCode: http.StatusOK,
 		},
 		{
-			Name:             "No Transactions Found",
-			ExpectedResponse: nil,
+			Name: "Filtered",
+			URL: "/transactions?limit=10&cursor=" + cursor.Encode() +
+				"&account_id=4067bfcb-d722-4e0e-a15e-b16be3b00f84&currency=EUR" +
+				"&min_amount=100&from=2023-09-01T00:00:00Z",
+			ExpectedQuery: Query{
+				AccountID: "4067bfcb-d722-4e0e-a15e-b16be3b00f84",
+				Currency:  "EUR",
+				MinAmount: &minAmount,
+				From:      &from,
+				After:     &cursor,
+				Limit:     10,
+			},
+			ExpectedResponse: Page{Transactions: []Transaction{}},
+			ExpectedCode:     http.StatusOK,
+		},
+		{
+			Name:         "Invalid Limit",
+			URL:          "/transactions?limit=0",
+			ExpectedCode: http.StatusBadRequest,
+		},
+		{
+			Name:         "Invalid Cursor",
+			URL:          "/transactions?cursor=abc",
+			ExpectedCode: http.StatusBadRequest,
+		},
+		{
+			Name:         "Invalid Account",
+			URL:          "/transactions?account_id=42",
+			ExpectedCode: http.StatusBadRequest,
+		},
+		{
+			Name:         "Invalid Date",
+			URL:          "/transactions?to=yesterday",
+			ExpectedCode: http.StatusBadRequest,
+		},
+		{
+			Name:             "Failed",
+			URL:              "/transactions?status=failed",
+			ExpectedQuery:    Query{Status: StatusFailed, Limit: pagination.DefaultLimit},
+			ExpectedResponse: Page{Transactions: []Transaction{}},
 			ExpectedCode:     http.StatusOK,
 		},
+		{
+			Name:         "Invalid Status",
+			URL:          "/transactions?status=booked",
+			ExpectedCode: http.StatusBadRequest,
+		},
+		{
+			Name:          "Service Error",
+			URL:           "/transactions",
+			ExpectedQuery: Query{Limit: pagination.DefaultLimit},
+			ServiceError:  ErrQueryingTransactions,
+			ExpectedCode:  http.StatusInternalServerError,
+		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.Name, func(t *testing.T) {
-			mockService.On("LoadAll", mock.Anything).
-				Return(tc.ExpectedResponse)
+			mockService := new(MockService)
+			logger, _ := zap.NewDevelopment()
+			handler := &TransactionHandler{Service: mockService, Logger: logger.Sugar()}
+
+			r := gin.Default()
+			r.GET("/transactions", handler.loadAll)
+
+			mockService.On("LoadAll", mock.Anything, tc.ExpectedQuery).
+				Return(tc.ExpectedResponse, tc.ServiceError)
 
-			req, _ := http.NewRequest("GET", "/transactions", nil)
+			req, _ := http.NewRequest("GET", tc.URL, nil)
 			rr := httptest.NewRecorder()
 
 			r.ServeHTTP(rr, req)
 
 			assert.Equal(t, tc.ExpectedCode, rr.Code)
 
-			// If transactions are expected, assert the transactions in the response body
-			if tc.ExpectedResponse != nil {
-				var transactions []Transaction
-				err := json.NewDecoder(rr.Body).Decode(&transactions)
+			// If transactions are expected, assert the page in the response body
+			if tc.ExpectedCode == http.StatusOK {
+				expected, err := json.Marshal(tc.ExpectedResponse)
 				assert.NoError(t, err)
-				assert.Equal(t, tc.ExpectedResponse, transactions)
+				assert.JSONEq(t, string(expected), rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestTransactionHandler_History(t *testing.T) {
+	cursor := pagination.Cursor{Key: "2023-09-15T09:00:00Z", ID: "transaction-id-2"}
+
+	testCases := []struct {
+		Name             string
+		URL              string
+		ExpectedQuery    HistoryQuery
+		ExpectedResponse HistoryPage
+		ServiceError     error
+		ExpectedCode     int
+	}{
+		{
+			Name:          "History Found",
+			URL:           "/accounts/account-id-1/transactions?limit=2&cursor=" + cursor.Encode(),
+			ExpectedQuery: HistoryQuery{AccountID: "account-id-1", After: &cursor, Limit: 2},
+			ExpectedResponse: HistoryPage{
+				Entries: []HistoryEntry{
+					{TransactionID: "transaction-id-1", Direction: DirectionOutgoing,
+						CounterpartyID: "account-id-2", Amount: money.New(-1000, "EUR"),
+						Currency: "EUR", BalanceAfter: money.New(9000, "EUR")},
+				},
+			},
+			ExpectedCode: http.StatusOK,
+		},
+		{
+			Name:         "Invalid Cursor",
+			URL:          "/accounts/account-id-1/transactions?cursor=abc",
+			ExpectedCode: http.StatusBadRequest,
+		},
+		{
+			Name:          "Account Not Found",
+			URL:           "/accounts/account-id-1/transactions",
+			ExpectedQuery: HistoryQuery{AccountID: "account-id-1", Limit: pagination.DefaultLimit},
+			ServiceError:  accounts.ErrFetchingAccount("account-id-1"),
+			ExpectedCode:  http.StatusNotFound,
+		},
+		{
+			Name:          "Service Error",
+			URL:           "/accounts/account-id-1/transactions",
+			ExpectedQuery: HistoryQuery{AccountID: "account-id-1", Limit: pagination.DefaultLimit},
+			ServiceError:  ErrQueryingHistory,
+			ExpectedCode:  http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			mockService := new(MockService)
+			logger, _ := zap.NewDevelopment()
+			handler := &TransactionHandler{Service: mockService, Logger: logger.Sugar()}
+
+			r := gin.Default()
+			r.GET("/accounts/:id/transactions", handler.history)
+
+			mockService.On("History", mock.Anything, tc.ExpectedQuery).
+				Return(tc.ExpectedResponse, tc.ServiceError)
+
+			req, _ := http.NewRequest("GET", tc.URL, nil)
+			rr := httptest.NewRecorder()
+
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.ExpectedCode, rr.Code)
+
+			if tc.ExpectedCode == http.StatusOK {
+				expected, err := json.Marshal(tc.ExpectedResponse)
+				assert.NoError(t, err)
+				assert.JSONEq(t, string(expected), rr.Body.String())
 			}
 		})
 	}
@@ -156,50 +317,239 @@ func TestTransactionHandler_Clean(t *testing.T) {
 	r := gin.Default()
 	r.DELETE("/transactions/:id", handler.clean)
 
+	req, _ := http.NewRequest("DELETE", "/transactions/valid-transaction-id", nil)
+	rr := httptest.NewRecorder()
+
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+	var response httperr.Problem
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, ErrTransactionImmutable.Error(), response.Detail)
+	mockService.AssertNotCalled(t, "Reverse", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestTransactionHandler_Reverse(t *testing.T) {
+	reversal := Transaction{
+		ID:              "reversal-id",
+		SourceAccountID: "fd27c968-7a4a-4bcf-b880-8aa93e5ab2d1",
+		TargetAccountID: "4067bfcb-d722-4e0e-a15e-b16be3b00f84",
+		Amount:          money.New(2500, "USD"),
+		Currency:        "USD",
+		ReversalOf:      "transaction-id",
+	}
+	pendingReversal := reversal
+	pendingReversal.Status = StatusPendingApproval
+
 	testCases := []struct {
 		Name          string
 		TransactionID string
-		ExpectedError error
+		Body          string
+		Amount        string
+		ServiceResult Transaction
+		ServiceError  error
 		ExpectedCode  int
 	}{
 		{
-			Name:          "Transaction Deleted",
-			TransactionID: "valid-transaction-id",
-			ExpectedError: nil,
+			Name:          "Full Reversal",
+			TransactionID: "transaction-id",
+			ServiceResult: reversal,
+			ExpectedCode:  http.StatusOK,
+		},
+		{
+			Name:          "Partial Reversal",
+			TransactionID: "transaction-id",
+			Body:          `{"amount": "25.00"}`,
+			Amount:        "25.00",
+			ServiceResult: reversal,
+			ExpectedCode:  http.StatusOK,
+		},
+		{
+			Name:          "Numeric Amount",
+			TransactionID: "transaction-id",
+			Body:          `{"amount": 25}`,
+			Amount:        "25",
+			ServiceResult: reversal,
 			ExpectedCode:  http.StatusOK,
 		},
 		{
-			Name:          "Transaction Not Deleted",
-			TransactionID: "transaction-id-not-deleted",
-			ExpectedError: ErrDeletingTransaction("non-existent-transaction-id"),
+			Name:          "Pending Approval",
+			TransactionID: "transaction-id",
+			ServiceResult: pendingReversal,
+			ExpectedCode:  http.StatusAccepted,
+		},
+		{
+			Name:          "Malformed Body",
+			TransactionID: "transaction-id",
+			Body:          `{"amount":`,
+			ExpectedCode:  http.StatusBadRequest,
+		},
+		{
+			Name:          "Transaction Not Found",
+			TransactionID: "unknown-id",
+			ServiceError:  ErrFetchingTransaction("unknown-id"),
+			ExpectedCode:  http.StatusNotFound,
+		},
+		{
+			Name:          "Invalid Amount",
+			TransactionID: "transaction-id",
+			Body:          `{"amount": "-1"}`,
+			Amount:        "-1",
+			ServiceError:  ErrInvalidReversalAmount,
+			ExpectedCode:  http.StatusBadRequest,
+		},
+		{
+			Name:          "Exceeds Original",
+			TransactionID: "transaction-id",
+			Body:          `{"amount": "1000"}`,
+			Amount:        "1000",
+			ServiceError:  ErrReversalExceedsOriginal,
+			ExpectedCode:  http.StatusConflict,
+		},
+		{
+			Name:          "Reversing A Reversal",
+			TransactionID: "reversal-id",
+			ServiceError:  ErrReversingReversal,
+			ExpectedCode:  http.StatusUnprocessableEntity,
+		},
+		{
+			Name:          "Insufficient Funds",
+			TransactionID: "transaction-id",
+			ServiceError: &InsufficientFundsError{
+				AccountID: reversal.SourceAccountID,
+				Balance:   money.Zero("USD"),
+				Amount:    reversal.Amount,
+			},
+			ExpectedCode: http.StatusConflict,
+		},
+		{
+			Name:          "Unexpected Error",
+			TransactionID: "transaction-id",
+			ServiceError:  ErrPostingTransaction("reversal-id"),
 			ExpectedCode:  http.StatusInternalServerError,
 		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.Name, func(t *testing.T) {
-			mockService.On("Clean", mock.Anything, tc.TransactionID).
-				Return(tc.ExpectedError)
+			mockService := new(MockService)
+			logger, _ := zap.NewDevelopment()
+			handler := &TransactionHandler{Service: mockService, Logger: logger.Sugar()}
+
+			r := gin.Default()
+			r.POST("/transactions/:id/reverse", handler.reverse)
+
+			mockService.On("Reverse", mock.Anything, tc.TransactionID, tc.Amount).
+				Return(tc.ServiceResult, tc.ServiceError)
 
-			req, _ := http.NewRequest("DELETE", "/transactions/"+tc.TransactionID, nil)
+			req, _ := http.NewRequest(
+				"POST", "/transactions/"+tc.TransactionID+"/reverse", bytes.NewBufferString(tc.Body),
+			)
 			rr := httptest.NewRecorder()
 
 			r.ServeHTTP(rr, req)
 
 			assert.Equal(t, tc.ExpectedCode, rr.Code)
+			if tc.ExpectedCode == http.StatusOK || tc.ExpectedCode == http.StatusAccepted {
+				var response Transaction
+				assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+				assert.Equal(t, tc.ServiceResult.ID, response.ID)
+				assert.Equal(t, tc.ServiceResult.ReversalOf, response.ReversalOf)
+				assert.Equal(t, tc.ServiceResult.Status, response.Status)
+			}
+			if tc.ServiceError != nil {
+				var response httperr.Problem
+				assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+				assert.Equal(t, problemDetail(tc.ServiceError), response.Detail)
+			}
+		})
+	}
+}
 
-			// If an error is expected, assert the error message
-			if tc.ExpectedError != nil {
-				// Convert the JSON response to a map
-				var response map[string]string
-				err := json.Unmarshal(rr.Body.Bytes(), &response)
-				// Grab the value & whether or not it exists
-				errorMsg, exists := response["error"]
-				// Make some assertions on the correctness of the response.
-				assert.Nil(t, err)
-				assert.True(t, exists)
-				assert.Equal(t, tc.ExpectedError.Error(), errorMsg)
+func TestTransactionHandler_Review(t *testing.T) {
+	testCases := []struct {
+		Name           string
+		Action         string
+		Method         string
+		ServiceResult  Transaction
+		ServiceError   error
+		ExpectedCode   int
+		ExpectedStatus string
+	}{
+		{
+			Name:           "Approved",
+			Action:         "approve",
+			Method:         "Approve",
+			ServiceResult:  Transaction{ID: "transaction-id", Status: StatusCompleted},
+			ExpectedCode:   http.StatusOK,
+			ExpectedStatus: StatusCompleted,
+		},
+		{
+			Name:           "Rejected",
+			Action:         "reject",
+			Method:         "Reject",
+			ServiceResult:  Transaction{ID: "transaction-id", Status: StatusRejected},
+			ExpectedCode:   http.StatusOK,
+			ExpectedStatus: StatusRejected,
+		},
+		{
+			Name:         "Self Approval",
+			Action:       "approve",
+			Method:       "Approve",
+			ServiceError: ErrSelfApproval,
+			ExpectedCode: http.StatusForbidden,
+		},
+		{
+			Name:         "Not Pending Approval",
+			Action:       "reject",
+			Method:       "Reject",
+			ServiceError: ErrNotPendingApproval,
+			ExpectedCode: http.StatusConflict,
+		},
+		{
+			Name:         "Approval Expired",
+			Action:       "approve",
+			Method:       "Approve",
+			ServiceError: ErrApprovalExpired,
+			ExpectedCode: http.StatusGone,
+		},
+		{
+			Name:         "Transaction Not Found",
+			Action:       "approve",
+			Method:       "Approve",
+			ServiceError: ErrFetchingTransaction("transaction-id"),
+			ExpectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			mockService := new(MockService)
+			logger, _ := zap.NewDevelopment()
+			handler := &TransactionHandler{Service: mockService, Logger: logger.Sugar()}
+
+			r := gin.Default()
+			handler.Router(&r.RouterGroup)
+
+			mockService.On(tc.Method, mock.Anything, "transaction-id").
+				Return(tc.ServiceResult, tc.ServiceError)
+
+			req, _ := http.NewRequest("POST", "/transactions/transaction-id/"+tc.Action, nil)
+			rr := httptest.NewRecorder()
+
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.ExpectedCode, rr.Code)
+			mockService.AssertNumberOfCalls(t, tc.Method, 1)
+			if tc.ExpectedCode == http.StatusOK {
+				var response Transaction
+				assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+				assert.Equal(t, tc.ExpectedStatus, response.Status)
+				return
 			}
+			var response httperr.Problem
+			assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+			assert.Equal(t, problemDetail(tc.ServiceError), response.Detail)
 		})
 	}
 }
@@ -224,7 +574,7 @@ func TestTransactionHandler_Transfer(t *testing.T) {
 			Request: transactionRequest{
 				SourceAccountID: "4067bfcb-d722-4e0e-a15e-b16be3b00f84",
 				TargetAccountID: "fd27c968-7a4a-4bcf-b880-8aa93e5ab2d1",
-				Amount:          100,
+				Amount:          "100",
 				Currency:        "USD",
 			},
 			ExpectedError: nil,
@@ -233,7 +583,7 @@ func TestTransactionHandler_Transfer(t *testing.T) {
 				ID:              "967c2536-57ed-410a-bb2e-08a002e73138",
 				SourceAccountID: "4067bfcb-d722-4e0e-a15e-b16be3b00f84",
 				TargetAccountID: "fd27c968-7a4a-4bcf-b880-8aa93e5ab2d1",
-				Amount:          100,
+				Amount:          money.New(10000, "USD"),
 				Currency:        "USD",
 			},
 		},
@@ -242,10 +592,10 @@ func TestTransactionHandler_Transfer(t *testing.T) {
 			Request: transactionRequest{
 				SourceAccountID: "4067bfcb-d722-4e0e-a15e-b16be3b00f84",
 				TargetAccountID: "fd27c968-7a4a-4bcf-b880-8aa93e5ab2d1",
-				Amount:          200,
+				Amount:          "200",
 				Currency:        "XYZ",
 			},
-			ExpectedError:    errors.New(currencyNotSupported),
+			ExpectedError:    currency.ErrNotSupported,
 			ExpectedCode:     http.StatusBadRequest,
 			ExpectedResponse: Transaction{},
 		},
@@ -254,13 +604,35 @@ func TestTransactionHandler_Transfer(t *testing.T) {
 			Request: transactionRequest{
 				SourceAccountID: "4067bfcb-d722-4e0e-a15e-b16be3b00f84",
 				TargetAccountID: "4067bfcb-d722-4e0e-a15e-b16be3b00f84",
-				Amount:          400,
+				Amount:          "400",
 				Currency:        "USD",
 			},
-			ExpectedError:    errors.New(accountsNotSame),
+			ExpectedError:    ErrSameAccount,
 			ExpectedCode:     http.StatusConflict,
 			ExpectedResponse: Transaction{},
 		},
+		{
+			Name: "Too Many Decimals",
+			Request: transactionRequest{
+				SourceAccountID: "4067bfcb-d722-4e0e-a15e-b16be3b00f84",
+				TargetAccountID: "fd27c968-7a4a-4bcf-b880-8aa93e5ab2d1",
+				Amount:          "10.001",
+				Currency:        "USD",
+			},
+			ExpectedCode:     http.StatusBadRequest,
+			ExpectedResponse: Transaction{},
+		},
+		{
+			Name: "Zero Amount",
+			Request: transactionRequest{
+				SourceAccountID: "4067bfcb-d722-4e0e-a15e-b16be3b00f84",
+				TargetAccountID: "fd27c968-7a4a-4bcf-b880-8aa93e5ab2d1",
+				Amount:          "0.00",
+				Currency:        "USD",
+			},
+			ExpectedCode:     http.StatusBadRequest,
+			ExpectedResponse: Transaction{},
+		},
 		// Add more test cases as needed
 	}
 
@@ -279,16 +651,189 @@ func TestTransactionHandler_Transfer(t *testing.T) {
 
 			// If a response is expected, assert the response in the body
 			if tc.ExpectedError != nil {
-				// Convert the JSON response to a map
-				var response map[string]string
+				// Convert the JSON response to a problem
+				var response httperr.Problem
 				err := json.Unmarshal(rr.Body.Bytes(), &response)
-				// Grab the value & whether or not it exists
-				errorMsg, exists := response["error"]
+				// Grab the detail of the problem
+				errorMsg := response.Detail
 				// Make some assertions on the correctness of the response.
 				assert.Nil(t, err)
-				assert.True(t, exists)
 				assert.Equal(t, tc.ExpectedError.Error(), errorMsg)
 			}
 		})
 	}
 }
+
+func TestTransactionHandler_TransferPendingApproval(t *testing.T) {
+	mockService := new(MockService)
+	logger, _ := zap.NewDevelopment()
+	handler := &TransactionHandler{Service: mockService, Logger: logger.Sugar()}
+
+	r := gin.Default()
+	r.POST("/transactions", handler.transfer)
+
+	pending := Transaction{
+		ID:              "967c2536-57ed-410a-bb2e-08a002e73138",
+		SourceAccountID: "4067bfcb-d722-4e0e-a15e-b16be3b00f84",
+		TargetAccountID: "fd27c968-7a4a-4bcf-b880-8aa93e5ab2d1",
+		Amount:          money.New(1500000, "USD"),
+		Currency:        "USD",
+		Status:          StatusPendingApproval,
+	}
+	mockService.On("Transfer", mock.Anything, mock.Anything).Return(pending, nil)
+
+	requestBody, _ := json.Marshal(transactionRequest{
+		SourceAccountID: pending.SourceAccountID,
+		TargetAccountID: pending.TargetAccountID,
+		Amount:          "15000",
+		Currency:        "USD",
+	})
+	req, _ := http.NewRequest("POST", "/transactions", bytes.NewReader(requestBody))
+	rr := httptest.NewRecorder()
+
+	r.ServeHTTP(rr, req)
+
+	// A transfer waiting for an approval is accepted but not booked yet
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+	var response Transaction
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, pending.ID, response.ID)
+	assert.Equal(t, StatusPendingApproval, response.Status)
+}
+
+func TestTransactionHandler_TransferServiceErrors(t *testing.T) {
+	request := transactionRequest{
+		SourceAccountID: "4067bfcb-d722-4e0e-a15e-b16be3b00f84",
+		TargetAccountID: "fd27c968-7a4a-4bcf-b880-8aa93e5ab2d1",
+		Amount:          "100",
+		Currency:        "USD",
+	}
+
+	testCases := []struct {
+		Name              string
+		ServiceError      error
+		ExpectedCode      int
+		ExpectedErrorCode string
+	}{
+		{
+			Name: "Insufficient Funds",
+			ServiceError: &InsufficientFundsError{
+				AccountID: request.SourceAccountID,
+				Balance:   money.New(1000, "USD"),
+				Amount:    money.New(10000, "USD"),
+			},
+			ExpectedCode:      http.StatusConflict,
+			ExpectedErrorCode: "insufficient_funds",
+		},
+		{
+			Name: "Currency Mismatch",
+			ServiceError: &CurrencyMismatchError{
+				AccountID:       request.TargetAccountID,
+				AccountCurrency: "EUR",
+				Currency:        "USD",
+			},
+			ExpectedCode:      http.StatusUnprocessableEntity,
+			ExpectedErrorCode: "currency_mismatch",
+		},
+		{
+			Name:              "Missing Exchange Rate",
+			ServiceError:      fx.ErrRateNotFound,
+			ExpectedCode:      http.StatusUnprocessableEntity,
+			ExpectedErrorCode: "rate_not_found",
+		},
+		{
+			Name:              "Quote Not Found",
+			ServiceError:      fx.ErrQuoteNotFound,
+			ExpectedCode:      http.StatusNotFound,
+			ExpectedErrorCode: "quote_not_found",
+		},
+		{
+			Name:              "Quote Expired",
+			ServiceError:      fx.ErrQuoteExpired,
+			ExpectedCode:      http.StatusGone,
+			ExpectedErrorCode: "quote_expired",
+		},
+		{
+			Name:              "Quote Used",
+			ServiceError:      fx.ErrQuoteUsed,
+			ExpectedCode:      http.StatusConflict,
+			ExpectedErrorCode: "quote_used",
+		},
+		{
+			Name:              "Quote Mismatch",
+			ServiceError:      fx.ErrQuoteMismatch,
+			ExpectedCode:      http.StatusUnprocessableEntity,
+			ExpectedErrorCode: "quote_mismatch",
+		},
+		{
+			Name: "Frozen Account",
+			ServiceError: &accounts.InactiveAccountError{
+				AccountID: request.TargetAccountID,
+				Status:    accounts.StatusFrozen,
+			},
+			ExpectedCode:      http.StatusConflict,
+			ExpectedErrorCode: "account_inactive",
+		},
+		{
+			Name:              "Duplicate Transaction",
+			ServiceError:      ErrDuplicateTransaction,
+			ExpectedCode:      http.StatusConflict,
+			ExpectedErrorCode: "duplicate_transaction",
+		},
+		{
+			Name:              "Non Positive Amount",
+			ServiceError:      ErrNonPositiveAmount,
+			ExpectedCode:      http.StatusUnprocessableEntity,
+			ExpectedErrorCode: "non_positive_amount",
+		},
+		{
+			Name:              "Source Account Not Found",
+			ServiceError:      accounts.ErrFetchingAccount(request.SourceAccountID),
+			ExpectedCode:      http.StatusNotFound,
+			ExpectedErrorCode: "account_not_found",
+		},
+		{
+			Name:              "Unexpected Error",
+			ServiceError:      ErrPostingTransaction("transaction-id"),
+			ExpectedCode:      http.StatusInternalServerError,
+			ExpectedErrorCode: "posting_transaction_failed",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			mockService := new(MockService)
+			logger, _ := zap.NewDevelopment()
+			handler := &TransactionHandler{Service: mockService, Logger: logger.Sugar()}
+
+			r := gin.Default()
+			r.POST("/transactions", handler.transfer)
+
+			mockService.On("Transfer", mock.Anything, mock.Anything).
+				Return(Transaction{}, tc.ServiceError)
+
+			requestBody, _ := json.Marshal(request)
+			req, _ := http.NewRequest("POST", "/transactions", bytes.NewReader(requestBody))
+			rr := httptest.NewRecorder()
+
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.ExpectedCode, rr.Code)
+
+			var response httperr.Problem
+			err := json.Unmarshal(rr.Body.Bytes(), &response)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.ExpectedErrorCode, response.Code)
+			assert.Equal(t, problemDetail(tc.ServiceError), response.Detail)
+		})
+	}
+}
+
+// problemDetail returns the detail of the problem reported for a service
+// error, which leaves out the message of the server errors
+func problemDetail(err error) string {
+	if errors.Is(err, errs.ErrInternal) {
+		return "the server could not complete the request"
+	}
+	return err.Error()
+}
@@ -0,0 +1,65 @@
+package transactions
+
+import (
+	"financial-app/pkg/money"
+	"financial-app/pkg/pagination"
+	"time"
+)
+
+// Directions of a transaction as seen from one of its accounts
+const (
+	DirectionIncoming = "incoming"
+	DirectionOutgoing = "outgoing"
+)
+
+// HistoryEntry is a read model for a transaction as seen from one of its
+// accounts. Amount is signed, negative when funds leave the account, and
+// expressed in the account currency. The opening balance of an account has
+// no transaction nor counterparty.
+type HistoryEntry struct {
+	TransactionID  string      `json:"transaction_id"`
+	Direction      string      `json:"direction"`
+	CounterpartyID string      `json:"counterparty_account_id"`
+	Amount         money.Money `json:"amount"`
+	Currency       string      `json:"currency"`
+	BalanceAfter   money.Money `json:"balance_after"`
+	BookedAt       time.Time   `json:"booked_at"`
+}
+
+// HistoryPage is a read model for a page of the history of an account.
+// NextCursor is empty on the last page.
+type HistoryPage struct {
+	Entries    []HistoryEntry `json:"entries"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+// HistoryQuery selects the history of an account, the most recently booked
+// transactions first
+type HistoryQuery struct {
+	AccountID string
+	// After continues the history after the given cursor
+	After *pagination.Cursor
+	Limit int
+}
+
+// NewHistoryEntry returns the entry of a transaction in the history of one of
+// its accounts, given the signed amount the account was posted and its
+// balance right after
+func NewHistoryEntry(
+	accountID string, txn *Transaction, amount, balanceAfter money.Money,
+) HistoryEntry {
+	entry := HistoryEntry{
+		TransactionID:  txn.ID,
+		Direction:      DirectionIncoming,
+		CounterpartyID: txn.SourceAccountID,
+		Amount:         amount,
+		Currency:       amount.Currency(),
+		BalanceAfter:   balanceAfter,
+		BookedAt:       txn.BookedAt,
+	}
+	if amount.IsNegative() {
+		entry.Direction = DirectionOutgoing
+		entry.CounterpartyID = txn.TargetAccountID
+	}
+	return entry
+}
@@ -2,15 +2,82 @@ package transactions
 
 import (
 	"context"
-	"financial-app/pkg/accounts"
+	"financial-app/pkg/money"
+	"financial-app/pkg/pagination"
+	"time"
 )
 
+// Query selects transactions, the most recently booked first. Empty fields
+// do not filter.
+type Query struct {
+	// AccountID matches the transactions on either side of an account
+	AccountID string
+	// Currency matches the transactions with a leg in the currency
+	Currency string
+	// Status matches the transactions in the status
+	Status string
+	// AccessibleTo keeps the transactions from or to the accounts a customer
+	// owns or holds a role on
+	AccessibleTo string
+	// MinAmount and MaxAmount bound the amount of the source leg
+	MinAmount *money.Money
+	MaxAmount *money.Money
+	// From and To bound the booking time, from inclusive and to exclusive
+	From *time.Time
+	To   *time.Time
+	// After continues the listing after the given cursor
+	After *pagination.Cursor
+	Limit int
+}
+
+// Review is the decision on a transaction waiting for an approval
+type Review struct {
+	// Status is StatusCompleted once approved and StatusRejected otherwise
+	Status     string
+	ReviewedBy string
+	ReviewedAt time.Time
+}
+
 // TransactionRepository provides access a transaction store
 type TransactionRepository interface {
-	Transfer(
-		ctx context.Context, txn *Transaction, sacc *accounts.Account, tacc *accounts.Account,
-	) (*Transaction, error)
+	// Transfer atomically checks the source balance, debits the source and
+	// credits the target account, and stores the transaction with the time
+	// it was booked at. A reversal is refused if it takes back more than
+	// what remains of the transaction it reverses. A transaction waiting for
+	// an approval only holds its amount on the source account. A booked
+	// reversal taking back what remains of the transaction it reverses marks
+	// this transaction reversed.
+	Transfer(ctx context.Context, txn *Transaction) (*Transaction, error)
+	// Fail stores a failed transaction, booked at the time it failed,
+	// without moving any balance
+	Fail(ctx context.Context, txn *Transaction) (*Transaction, error)
+	// Review atomically releases the amount held by a transaction waiting for
+	// an approval and, if it is approved, moves the balances and books it. It
+	// refuses the transactions no longer waiting or past their expiry, and
+	// checks approved reversals like Transfer does.
+	Review(ctx context.Context, id string, review Review) (*Transaction, error)
+	// Expire expires the transactions waiting for an approval past their
+	// expiry as of now, releases the amounts they held and returns how many
+	// expired
+	Expire(ctx context.Context, now time.Time) (int64, error)
 	Find(ctx context.Context, id string) (*Transaction, error)
-	FindAll(ctx context.Context) []*Transaction
-	Delete(ctx context.Context, id string) error
+	// FindReversals returns the transactions reversing a transaction, including
+	// the refused ones kept as failed
+	FindReversals(ctx context.Context, id string) ([]*Transaction, error)
+	// Query returns at most q.Limit transactions matching the query
+	Query(ctx context.Context, q Query) ([]*Transaction, error)
+	// History returns at most q.Limit entries of the history of an account
+	// along with the balance of the account after each of them
+	History(ctx context.Context, q HistoryQuery) ([]*HistoryEntry, error)
+	// StreamHistory calls begin with the balances of an account as of from
+	// and to, then fn with every entry of its history booked after from and
+	// until to, the oldest first, including the opening balance of the
+	// account. Everything is read from one snapshot, so that the entries add
+	// up to the difference of the balances even while transfers are booked.
+	// It stops at the first error begin or fn returns.
+	StreamHistory(
+		ctx context.Context, accountID string, from, to time.Time,
+		begin func(opening, closing money.Money) error,
+		fn func(*HistoryEntry) error,
+	) error
 }
@@ -4,18 +4,54 @@ import (
 	"context"
 	"errors"
 	account "financial-app/pkg/accounts"
-	"fmt"
+	"financial-app/pkg/auth"
+	"financial-app/pkg/errs"
+	"financial-app/pkg/fx"
+	"financial-app/pkg/money"
+	"financial-app/pkg/pagination"
+	"time"
 
 	uuid "github.com/satori/go.uuid"
 )
 
 // Transaction is a read model for transaction views
 type Transaction struct {
-	ID              string  `json:"id"`
-	SourceAccountID string  `json:"source_account_id"`
-	TargetAccountID string  `json:"target_account_id"`
-	Amount          float64 `json:"amount"`
-	Currency        string  `json:"currency"`
+	ID              string      `json:"id"`
+	SourceAccountID string      `json:"source_account_id"`
+	TargetAccountID string      `json:"target_account_id"`
+	Amount          money.Money `json:"amount"`
+	Currency        string      `json:"currency"`
+	SourceCurrency  string      `json:"source_currency"`
+	TargetCurrency  string      `json:"target_currency"`
+	TargetAmount    money.Money `json:"target_amount"`
+	Rate            string      `json:"rate,omitempty"`
+	RateTimestamp   *time.Time  `json:"rate_timestamp,omitempty"`
+	QuoteID         string      `json:"quote_id,omitempty"`
+	ReversalOf      string      `json:"reversal_of,omitempty"`
+	Status          string      `json:"status"`
+	// FailureReason is the code of the error a failed transaction was
+	// refused with
+	FailureReason string `json:"failure_reason,omitempty"`
+	// InitiatedBy is the API key which submitted the transaction and
+	// InitiatorCustomerID the customer this key acts for, if any
+	InitiatedBy         string `json:"initiated_by,omitempty"`
+	InitiatorCustomerID string `json:"initiator_customer_id,omitempty"`
+	// ReviewedBy is the API key which approved or rejected the transaction
+	ReviewedBy string     `json:"reviewed_by,omitempty"`
+	ReviewedAt *time.Time `json:"reviewed_at,omitempty"`
+	// ExpiresAt is when a transaction waiting for an approval expires
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	// BookedAt is when the balances moved. It is the time a transaction was
+	// submitted at as long as it waits for an approval.
+	BookedAt time.Time `json:"booked_at"`
+}
+
+// Page is a read model for a page of transactions. NextCursor is empty on
+// the last page.
+type Page struct {
+	Transactions []Transaction `json:"transactions"`
+	NextCursor   string        `json:"next_cursor,omitempty"`
 }
 
 // Service is the interface that provides transaction methods
@@ -26,11 +62,28 @@ type Service interface {
 	// Transfer makes a transaction between two accounts
 	Transfer(ctx context.Context, txn Transaction) (Transaction, error)
 
-	// LoadAll returns a list of transactions have been completed
-	LoadAll(ctx context.Context) []Transaction
+	// LoadAll returns a page of the transactions matching the query
+	LoadAll(ctx context.Context, q Query) (Page, error)
+
+	// History returns a page of the transactions of an account
+	History(ctx context.Context, q HistoryQuery) (HistoryPage, error)
+
+	// Reverse books a transfer taking back the given amount of a transaction,
+	// or whatever of it has not been reversed yet if the amount is empty
+	Reverse(ctx context.Context, id string, amount string) (Transaction, error)
+
+	// Approve books a transfer waiting for an approval and releases the amount
+	// it held. Only another principal than its initiator may approve it.
+	Approve(ctx context.Context, id string) (Transaction, error)
+
+	// Reject releases the amount held by a transfer waiting for an approval
+	// without booking it. Only another principal than its initiator may
+	// reject it.
+	Reject(ctx context.Context, id string) (Transaction, error)
 
-	// Clean deletes a transaction
-	Clean(ctx context.Context, id string) error
+	// ExpireApprovals expires the transfers whose approval is overdue as of
+	// now, which releases the amounts they held, and returns how many expired
+	ExpireApprovals(ctx context.Context, now time.Time) (int64, error)
 }
 
 func (s *service) Load(
@@ -41,12 +94,49 @@ func (s *service) Load(
 	if err != nil {
 		return Transaction{}, err
 	}
+	if err := s.checkView(ctx, txn); err != nil {
+		return Transaction{}, err
+	}
 	return *txn, nil
 }
 
+// checkView returns an error unless the caller of the request may view the
+// source or the target account of a transaction
+func (s *service) checkView(ctx context.Context, txn *Transaction) error {
+	if key, ok := auth.FromContext(ctx); !ok || key.CustomerID == "" {
+		return nil
+	}
+
+	ids := []string{txn.SourceAccountID, txn.TargetAccountID}
+	accts, err := s.accounts.FindByIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+	var denied error
+	for _, id := range ids {
+		acct, ok := accts[id]
+		if !ok {
+			continue
+		}
+		err := account.CheckRole(ctx, s.roles, acct, account.RoleView)
+		if err == nil {
+			return nil
+		}
+		if denied == nil {
+			denied = err
+		}
+	}
+	if denied == nil {
+		denied = account.ErrFetchingAccount(txn.SourceAccountID)
+	}
+	return denied
+}
+
 func (s *service) Transfer(
 	ctx context.Context, txn Transaction,
 ) (Transaction, error) {
+	txn.CreatedAt = time.Now().UTC()
+
 	// Get the source and target accounts using one database query
 	uuids := []string{txn.SourceAccountID, txn.TargetAccountID}
 	accounts, err := s.accounts.FindByIDs(ctx, uuids)
@@ -65,55 +155,489 @@ func (s *service) Transfer(
 		return Transaction{}, account.ErrFetchingAccount(txn.TargetAccountID)
 	}
 
-	// Check if the source account has sufficient balance
-	if sourceAccount.Balance < txn.Amount {
-		return Transaction{},
-			errInsufficientBalance(sourceAccount.Balance, txn.Amount, txn.SourceAccountID)
+	// Customers send money from the accounts they own or may initiate
+	// transfers from
+	if err := account.CheckRole(ctx, s.roles, sourceAccount, account.RoleInitiate); err != nil {
+		return Transaction{}, err
+	}
+
+	// From now on a refused transfer is kept as a failed transaction
+	txn.Status = StatusPending
+	if key, ok := auth.FromContext(ctx); ok {
+		txn.InitiatedBy, txn.InitiatorCustomerID = key.ID, key.CustomerID
+	}
+	transaction, err := s.transfer(ctx, &txn, sourceAccount, targetAccount)
+	if err != nil {
+		s.fail(ctx, txn, err)
+		return Transaction{}, err
 	}
 
-	// Debit the balance from the source account
-	sourceAccount.Balance -= txn.Amount
+	return *transaction, nil
+}
 
-	// Credit the balance to the target account
-	targetAccount.Balance += txn.Amount
+// transfer checks a pending transfer between two accounts, sets its legs and
+// hands it over to the repository, either to be booked or, above the approval
+// threshold of its currency, to wait for an approval
+func (s *service) transfer(
+	ctx context.Context, txn *Transaction, sourceAccount, targetAccount *account.Account,
+) (*Transaction, error) {
+	// Frozen and closed accounts neither send nor receive transfers
+	if err := account.CheckActive(sourceAccount); err != nil {
+		return nil, err
+	}
+	if err := account.CheckActive(targetAccount); err != nil {
+		return nil, err
+	}
+
+	// A quote must still be usable and sets the default target currency
+	var quote *fx.Quote
+	if txn.QuoteID != "" {
+		var err error
+		if quote, err = s.loadQuote(ctx, txn.QuoteID); err != nil {
+			return nil, err
+		}
+		if txn.TargetCurrency == "" {
+			txn.TargetCurrency = quote.TargetCurrency
+		}
+	}
+
+	// The source leg is booked in the transfer currency and the target leg in
+	// the requested target currency, which defaults to the transfer currency
+	if txn.TargetCurrency == "" {
+		txn.TargetCurrency = txn.Currency
+	}
+	if sourceAccount.Currency != txn.Currency {
+		return nil, &CurrencyMismatchError{
+			AccountID:       sourceAccount.ID,
+			AccountCurrency: sourceAccount.Currency,
+			Currency:        txn.Currency,
+		}
+	}
+	if targetAccount.Currency != txn.TargetCurrency {
+		return nil, &CurrencyMismatchError{
+			AccountID:       targetAccount.ID,
+			AccountCurrency: targetAccount.Currency,
+			Currency:        txn.TargetCurrency,
+		}
+	}
+	txn.SourceCurrency = sourceAccount.Currency
+
+	// Convert the amount at the locked rate of the quote, or at the current
+	// rate when the legs are booked in different currencies
+	txn.TargetAmount = txn.Amount
+	switch {
+	case quote != nil:
+		if err := applyQuote(txn, quote); err != nil {
+			return nil, err
+		}
+	case txn.TargetCurrency != txn.SourceCurrency:
+		if err := s.convert(ctx, txn); err != nil {
+			return nil, err
+		}
+	}
+
+	// Reject early if the available balance of the source account, which
+	// leaves out the amounts held on it, is insufficient. The repository
+	// checks it again under lock when transferring.
+	remaining, err := sourceAccount.AvailableBalance.Sub(txn.Amount)
+	if err != nil {
+		return nil, err
+	}
+	if remaining.IsNegative() {
+		return nil, &InsufficientFundsError{
+			AccountID: txn.SourceAccountID,
+			Balance:   sourceAccount.AvailableBalance,
+			Amount:    txn.Amount,
+		}
+	}
+
+	// Transfers above the approval threshold of their currency only hold
+	// their amount until another principal approves them
+	booked := *txn
+	s.approvals.Book(&booked)
+	if err := CheckTransition(txn, booked.Status); err != nil {
+		return nil, err
+	}
 
 	// Transfer money from source to target account
-	transaction, err := s.transactions.Transfer(ctx, &txn, sourceAccount, targetAccount)
+	return s.transactions.Transfer(ctx, &booked)
+}
+
+// fail stores a pending transfer refused for a business reason as a failed
+// transaction
+func (s *service) fail(ctx context.Context, txn Transaction, cause error) {
+	Fail(ctx, s.transactions, txn, cause)
+}
+
+// Failer stores failed transactions
+type Failer interface {
+	Fail(ctx context.Context, txn *Transaction) (*Transaction, error)
+}
+
+// Fail stores a pending transfer refused for a business reason as a failed
+// transaction, along with the code of the error it was refused with. The
+// transfers refused because of a server error are not stored, and neither
+// are the duplicates of an existing transaction.
+func Fail(ctx context.Context, failer Failer, txn Transaction, cause error) {
+	var e *errs.Error
+	if !errors.As(cause, &e) ||
+		errors.Is(cause, errs.ErrInternal) ||
+		errors.Is(cause, errs.ErrUnavailable) ||
+		errors.Is(cause, errs.ErrTimeout) ||
+		errors.Is(cause, ErrDuplicateTransaction) {
+		return
+	}
+	if CheckTransition(&txn, StatusFailed) != nil {
+		return
+	}
+
+	txn.Status = StatusFailed
+	txn.FailureReason = e.Code
+	if txn.SourceCurrency == "" {
+		txn.SourceCurrency = txn.Currency
+	}
+	if txn.TargetCurrency == "" {
+		txn.TargetCurrency = txn.Currency
+	}
+	// A transfer refused before its conversion has no target amount
+	if txn.TargetAmount.Currency() != txn.TargetCurrency {
+		txn.TargetAmount = money.Zero(txn.TargetCurrency)
+	}
+	if errors.Is(cause, fx.ErrQuoteNotFound) {
+		txn.QuoteID = ""
+	}
+
+	// The transfer is refused with its cause whether or not the failed
+	// transaction could be stored, which the repository logs
+	_, _ = failer.Fail(ctx, &txn)
+}
+
+// convert sets the target amount of a cross-currency transaction along with the
+// rate and rate timestamp it was converted at
+func (s *service) convert(ctx context.Context, txn *Transaction) error {
+	if s.rates == nil {
+		return fx.ErrRateNotFound
+	}
+
+	rate, err := s.rates.Rate(ctx, txn.SourceCurrency, txn.TargetCurrency)
+	if err != nil {
+		return err
+	}
+
+	targetAmount, err := rate.Convert(txn.Amount)
+	if err != nil {
+		return err
+	}
+
+	txn.TargetAmount = targetAmount
+	txn.Rate = rate.Value
+	txn.RateTimestamp = &rate.Timestamp
+
+	return nil
+}
+
+// loadQuote returns a quote if it has not expired and has not been used yet
+func (s *service) loadQuote(ctx context.Context, id string) (*fx.Quote, error) {
+	if s.quotes == nil {
+		return nil, fx.ErrQuoteNotFound
+	}
+
+	quote, err := s.quotes.Find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := quote.Check(time.Now()); err != nil {
+		return nil, err
+	}
+
+	return quote, nil
+}
+
+// applyQuote sets the target amount of a transaction to the one locked by the
+// quote, provided that the transaction matches the quote
+func applyQuote(txn *Transaction, quote *fx.Quote) error {
+	if quote.SourceCurrency != txn.SourceCurrency ||
+		quote.TargetCurrency != txn.TargetCurrency ||
+		quote.SourceAmount != txn.Amount {
+		return fx.ErrQuoteMismatch
+	}
+
+	txn.TargetAmount = quote.TargetAmount
+	txn.Rate = quote.Rate
+	txn.RateTimestamp = &quote.RateTimestamp
+
+	return nil
+}
+
+func (s *service) LoadAll(ctx context.Context, q Query) (Page, error) {
+	// Customers only see the transactions from or to the accounts they own
+	// or hold a role on
+	if key, ok := auth.FromContext(ctx); ok && key.CustomerID != "" {
+		q.AccessibleTo = key.CustomerID
+	}
+
+	// Fetch one more transaction to find out if there is a next page
+	limit := q.Limit
+	q.Limit++
+	found, err := s.transactions.Query(ctx, q)
+	if err != nil {
+		return Page{}, err
+	}
+
+	page := Page{Transactions: make([]Transaction, 0, len(found))}
+	for i, t := range found {
+		if i == limit {
+			last := page.Transactions[limit-1]
+			page.NextCursor = pagination.Cursor{
+				Key: last.BookedAt.Format(time.RFC3339Nano),
+				ID:  last.ID,
+			}.Encode()
+			break
+		}
+		page.Transactions = append(page.Transactions, *t)
+	}
+	return page, nil
+}
+
+func (s *service) History(
+	ctx context.Context, q HistoryQuery,
+) (HistoryPage, error) {
+	acct, err := s.accounts.Find(ctx, q.AccountID)
+	if err != nil {
+		return HistoryPage{}, err
+	}
+	if err := account.CheckRole(ctx, s.roles, acct, account.RoleView); err != nil {
+		return HistoryPage{}, err
+	}
+
+	// Fetch one more entry to find out if there is a next page
+	limit := q.Limit
+	q.Limit++
+	found, err := s.transactions.History(ctx, q)
+	if err != nil {
+		return HistoryPage{}, err
+	}
+
+	page := HistoryPage{Entries: make([]HistoryEntry, 0, len(found))}
+	for i, e := range found {
+		if i == limit {
+			last := page.Entries[limit-1]
+			page.NextCursor = pagination.Cursor{
+				Key: last.BookedAt.Format(time.RFC3339Nano),
+				ID:  last.TransactionID,
+			}.Encode()
+			break
+		}
+		page.Entries = append(page.Entries, *e)
+	}
+	return page, nil
+}
+
+func (s *service) Reverse(
+	ctx context.Context, id string, amount string,
+) (Transaction, error) {
+	original, err := s.transactions.Find(ctx, id)
+	if err != nil {
+		return Transaction{}, err
+	}
+
+	// A reversal sends money from the target account of the original
+	// transaction, which takes the same rights as any transfer from it
+	targetAccount, err := s.accounts.Find(ctx, original.TargetAccountID)
 	if err != nil {
 		return Transaction{}, err
 	}
+	if err := account.CheckRole(ctx, s.roles, targetAccount, account.RoleInitiate); err != nil {
+		return Transaction{}, err
+	}
+
+	if original.ReversalOf != "" {
+		return Transaction{}, ErrReversingReversal
+	}
+	// A reversed transaction has nothing left to take back, and only the
+	// completed ones moved any money
+	switch original.Status {
+	case StatusCompleted:
+	case StatusReversed:
+		return Transaction{}, ErrReversalExceedsOriginal
+	default:
+		return Transaction{}, ErrReversingIncomplete
+	}
+
+	// Sum up what the previous reversals took back on both legs. The failed
+	// ones took nothing back, and those waiting for an approval nothing yet.
+	reversals, err := s.transactions.FindReversals(ctx, id)
+	if err != nil {
+		return Transaction{}, err
+	}
+	remaining, remainingTarget := original.Amount, original.TargetAmount
+	for _, r := range reversals {
+		if r.Status != StatusCompleted {
+			continue
+		}
+		if remaining, err = remaining.Sub(r.TargetAmount); err != nil {
+			return Transaction{}, err
+		}
+		if remainingTarget, err = remainingTarget.Sub(r.Amount); err != nil {
+			return Transaction{}, err
+		}
+	}
+	if !remaining.IsPositive() {
+		return Transaction{}, ErrReversalExceedsOriginal
+	}
+
+	reversed := remaining
+	if amount != "" {
+		if reversed, err = money.Parse(amount, original.SourceCurrency); err != nil ||
+			!reversed.IsPositive() {
+			return Transaction{}, ErrInvalidReversalAmount
+		}
+	}
+	exceeds, err := reversed.Cmp(remaining)
+	if err != nil {
+		return Transaction{}, err
+	}
+
+	// The target leg is taken back in proportion of the original amounts, and
+	// in full by the last reversal so that rounding never leaves a remainder
+	reversedTarget := remainingTarget
+	if reversed != remaining {
+		reversedTarget, err = original.TargetAmount.Prorate(reversed, original.Amount)
+		if err != nil {
+			return Transaction{}, err
+		}
+	}
+
+	// The reversal flows from the target account back to the source account.
+	// From now on a refused reversal is kept as a failed transaction.
+	reversal := Transaction{
+		ID:              nextTransactionID(),
+		SourceAccountID: original.TargetAccountID,
+		TargetAccountID: original.SourceAccountID,
+		Amount:          reversedTarget,
+		Currency:        original.TargetCurrency,
+		SourceCurrency:  original.TargetCurrency,
+		TargetCurrency:  original.SourceCurrency,
+		TargetAmount:    reversed,
+		ReversalOf:      original.ID,
+		Status:          StatusPending,
+		CreatedAt:       time.Now().UTC(),
+	}
+	if key, ok := auth.FromContext(ctx); ok {
+		reversal.InitiatedBy, reversal.InitiatorCustomerID = key.ID, key.CustomerID
+	}
+	if exceeds > 0 {
+		s.fail(ctx, reversal, ErrReversalExceedsOriginal)
+		return Transaction{}, ErrReversalExceedsOriginal
+	}
+
+	// Reversals above the approval threshold wait for an approval like any
+	// transfer. The repository checks again, under lock, that the reversal
+	// does not take back more than the original amount.
+	booked := reversal
+	s.approvals.Book(&booked)
+	transaction, err := s.transactions.Transfer(ctx, &booked)
+	if err != nil {
+		s.fail(ctx, reversal, err)
+		return Transaction{}, err
+	}
 
 	return *transaction, nil
 }
 
-func (s *service) LoadAll(ctx context.Context) []Transaction {
-	var transactions []Transaction
-	for _, t := range s.transactions.FindAll(ctx) {
-		transactions = append(transactions, *t)
+func (s *service) Approve(ctx context.Context, id string) (Transaction, error) {
+	return s.review(ctx, id, StatusCompleted)
+}
+
+func (s *service) Reject(ctx context.Context, id string) (Transaction, error) {
+	return s.review(ctx, id, StatusRejected)
+}
+
+// review approves or rejects a transfer waiting for an approval on behalf of
+// the caller, who must be another principal than the initiator and may
+// approve the transfers from the source account
+func (s *service) review(
+	ctx context.Context, id string, status string,
+) (Transaction, error) {
+	txn, err := s.transactions.Find(ctx, id)
+	if err != nil {
+		return Transaction{}, err
 	}
-	return transactions
+
+	sourceAccount, err := s.accounts.Find(ctx, txn.SourceAccountID)
+	if err != nil {
+		return Transaction{}, err
+	}
+	if err := account.CheckRole(ctx, s.roles, sourceAccount, account.RoleApprove); err != nil {
+		return Transaction{}, err
+	}
+
+	// Reject early what the repository refuses again under lock
+	now := time.Now().UTC()
+	if txn.Status != StatusPendingApproval {
+		return Transaction{}, ErrNotPendingApproval
+	}
+	if txn.ExpiresAt != nil && !txn.ExpiresAt.After(now) {
+		return Transaction{}, ErrApprovalExpired
+	}
+
+	key, _ := auth.FromContext(ctx)
+	if samePrincipal(key, txn) {
+		return Transaction{}, ErrSelfApproval
+	}
+
+	reviewed, err := s.transactions.Review(ctx, id, Review{
+		Status:     status,
+		ReviewedBy: key.ID,
+		ReviewedAt: now,
+	})
+	if err != nil {
+		return Transaction{}, err
+	}
+
+	return *reviewed, nil
 }
 
-func (s *service) Clean(ctx context.Context, id string) error {
-	if err := s.transactions.Delete(ctx, id); err != nil {
-		return err
+// samePrincipal returns true if the key acts for the principal who initiated
+// the transaction: the same customer for the keys issued for customers, and
+// the same key otherwise
+func samePrincipal(key auth.Key, txn *Transaction) bool {
+	if key.CustomerID != "" || txn.InitiatorCustomerID != "" {
+		return key.CustomerID == txn.InitiatorCustomerID
 	}
-	return nil
+	return key.ID == txn.InitiatedBy
+}
+
+func (s *service) ExpireApprovals(ctx context.Context, now time.Time) (int64, error) {
+	return s.transactions.Expire(ctx, now)
 }
 
 type service struct {
 	accounts     account.AccountRepository
+	roles        account.RoleRepository
 	transactions TransactionRepository
+	rates        fx.RateProvider
+	quotes       fx.QuoteRepository
+	approvals    ApprovalPolicy
 }
 
 // NewService creates a transaction service with necessary dependencies
 func NewService(
 	accounts account.AccountRepository,
+	roles account.RoleRepository,
 	transactions TransactionRepository,
+	rates fx.RateProvider,
+	quotes fx.QuoteRepository,
+	approvals ApprovalPolicy,
 ) Service {
 	return &service{
 		accounts:     accounts,
+		roles:        roles,
 		transactions: transactions,
+		rates:        rates,
+		quotes:       quotes,
+		approvals:    approvals,
 	}
 }
 
@@ -121,12 +645,3 @@ func NewService(
 func nextTransactionID() string {
 	return uuid.NewV4().String()
 }
-
-// errInsufficientBalance is used when a transaction could not be performed
-// because of insufficient balance
-func errInsufficientBalance(bal, amt float64, id string) error {
-	b := fmt.Sprintf("%8.2f", bal)
-	a := fmt.Sprintf("%8.2f", amt)
-	return errors.New("the source amount is insufficient: " + b + " < " +
-		a + " for the account " + string(id))
-}
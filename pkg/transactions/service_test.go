@@ -2,8 +2,16 @@ package transactions
 
 import (
 	"context"
+	"errors"
 	"financial-app/pkg/accounts"
+	"financial-app/pkg/auth"
+	"financial-app/pkg/errs"
+	"financial-app/pkg/fx"
+	"financial-app/pkg/money"
+	"financial-app/pkg/pagination"
+	"sort"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -27,12 +35,22 @@ func (m *mockAccountRepository) FindByIDs(
 	accounts := make(map[string]*accounts.Account)
 	for _, id := range ids {
 		if acct, ok := m.Accounts[id]; ok {
-			accounts[id] = acct
+			accounts[id] = available(acct)
 		}
 	}
 	return accounts, nil
 }
 
+// available returns a copy of an account whose available balance defaults to
+// its balance, as if nothing was held on it
+func available(acct *accounts.Account) *accounts.Account {
+	found := *acct
+	if found.AvailableBalance.Currency() == "" {
+		found.AvailableBalance = found.Balance
+	}
+	return &found
+}
+
 func (m *mockAccountRepository) Store(
 	ctx context.Context, acct *accounts.Account,
 ) (*accounts.Account, error) {
@@ -40,39 +58,182 @@ func (m *mockAccountRepository) Store(
 	return acct, nil
 }
 
-func (m *mockAccountRepository) FindAll(
-	ctx context.Context,
-) []*accounts.Account {
-	accounts := make([]*accounts.Account, 0, len(m.Accounts))
-	for _, txn := range m.Accounts {
-		accounts = append(accounts, txn)
+func (m *mockAccountRepository) Query(
+	ctx context.Context, q accounts.Query,
+) ([]*accounts.Account, error) {
+	accts := make([]*accounts.Account, 0, len(m.Accounts))
+	for _, acct := range m.Accounts {
+		accts = append(accts, acct)
 	}
-	return accounts
+	return accts, nil
 }
 
-func (m *mockAccountRepository) Delete(
-	ctx context.Context, id string,
-) error {
-	if _, ok := m.Accounts[id]; ok {
-		delete(m.Accounts, id)
-		return nil
+func (m *mockAccountRepository) UpdateStatus(
+	ctx context.Context, id string, change accounts.StatusChange,
+) (*accounts.Account, error) {
+	acct, ok := m.Accounts[id]
+	if !ok {
+		return nil, accounts.ErrFetchingAccount(id)
 	}
-	return accounts.ErrDeletingAccount(id)
+	acct.Status = change.Status
+	return acct, nil
+}
+
+func (m *mockAccountRepository) BalanceAsOf(
+	ctx context.Context, id string, asOf time.Time,
+) (money.Money, error) {
+	if acct, ok := m.Accounts[id]; ok {
+		return acct.Balance, nil
+	}
+	return money.Money{}, accounts.ErrFetchingAccount(id)
+}
+
+func (m *mockAccountRepository) SnapshotBalances(
+	ctx context.Context, asOf time.Time,
+) (int64, error) {
+	return int64(len(m.Accounts)), nil
 }
 
 type mockTransactionRepository struct {
 	Transactions map[string]*Transaction
+	Accounts     map[string]*accounts.Account
+	// Held are the amounts held on the accounts by the pending approvals
+	Held map[string]money.Money
 }
 
 func (m *mockTransactionRepository) Transfer(
-	ctx context.Context, txn *Transaction, sacc *accounts.Account, tacc *accounts.Account,
+	ctx context.Context, txn *Transaction,
 ) (*Transaction, error) {
-	if sacc.Balance >= txn.Amount {
+	sacc := m.Accounts[txn.SourceAccountID]
+
+	// The amounts held by the pending approvals are not available
+	available, err := sacc.Balance.Sub(m.held(sacc.ID, sacc.Currency))
+	if err != nil {
+		return nil, err
+	}
+	remaining, err := available.Sub(txn.Amount)
+	if err != nil {
+		return nil, err
+	}
+	if remaining.IsNegative() {
+		return nil, &InsufficientFundsError{
+			AccountID: sacc.ID,
+			Balance:   available,
+			Amount:    txn.Amount,
+		}
+	}
+
+	txn.BookedAt = time.Now().UTC()
+	if txn.Status == StatusPendingApproval {
+		if m.Held == nil {
+			m.Held = make(map[string]money.Money)
+		}
+		m.Held[sacc.ID], _ = m.held(sacc.ID, sacc.Currency).Add(txn.Amount)
 		m.Transactions[txn.ID] = txn
 		return txn, nil
 	}
-	return nil,
-		errInsufficientBalance(sacc.Balance, txn.Amount, sacc.ID)
+
+	if err := m.move(txn); err != nil {
+		return nil, err
+	}
+	m.Transactions[txn.ID] = txn
+	m.markReversed(txn)
+	return txn, nil
+}
+
+// markReversed marks the transaction reversed by txn reversed once its
+// reversals took back all of it
+func (m *mockTransactionRepository) markReversed(txn *Transaction) {
+	original, ok := m.Transactions[txn.ReversalOf]
+	if !ok {
+		return
+	}
+	reversed := money.Zero(original.SourceCurrency)
+	for _, r := range m.Transactions {
+		if r.ReversalOf == original.ID && r.Status == StatusCompleted {
+			reversed, _ = reversed.Add(r.TargetAmount)
+		}
+	}
+	if cmp, err := reversed.Cmp(original.Amount); err == nil && cmp >= 0 {
+		original.Status = StatusReversed
+	}
+}
+
+func (m *mockTransactionRepository) Fail(
+	ctx context.Context, txn *Transaction,
+) (*Transaction, error) {
+	txn.BookedAt = time.Now().UTC()
+	m.Transactions[txn.ID] = txn
+	return txn, nil
+}
+
+// held returns the amount held on an account
+func (m *mockTransactionRepository) held(accountID, currency string) money.Money {
+	if held, ok := m.Held[accountID]; ok {
+		return held
+	}
+	return money.Zero(currency)
+}
+
+// move debits the source and credits the target account of a transaction
+func (m *mockTransactionRepository) move(txn *Transaction) error {
+	sacc := m.Accounts[txn.SourceAccountID]
+	tacc := m.Accounts[txn.TargetAccountID]
+
+	debited, err := sacc.Balance.Sub(txn.Amount)
+	if err != nil {
+		return err
+	}
+	credited, err := tacc.Balance.Add(txn.TargetAmount)
+	if err != nil {
+		return err
+	}
+
+	sacc.Balance = debited
+	tacc.Balance = credited
+	return nil
+}
+
+func (m *mockTransactionRepository) Review(
+	ctx context.Context, id string, review Review,
+) (*Transaction, error) {
+	txn, ok := m.Transactions[id]
+	if !ok {
+		return nil, ErrFetchingTransaction(id)
+	}
+	if txn.Status != StatusPendingApproval {
+		return nil, ErrNotPendingApproval
+	}
+	if !txn.ExpiresAt.After(review.ReviewedAt) {
+		return nil, ErrApprovalExpired
+	}
+
+	m.Held[txn.SourceAccountID], _ = m.Held[txn.SourceAccountID].Sub(txn.Amount)
+	if review.Status == StatusCompleted {
+		if err := m.move(txn); err != nil {
+			return nil, err
+		}
+		txn.BookedAt = review.ReviewedAt
+	}
+	txn.Status = review.Status
+	m.markReversed(txn)
+	txn.ReviewedBy = review.ReviewedBy
+	txn.ReviewedAt = &review.ReviewedAt
+	return txn, nil
+}
+
+func (m *mockTransactionRepository) Expire(
+	ctx context.Context, now time.Time,
+) (int64, error) {
+	var expired int64
+	for _, txn := range m.Transactions {
+		if txn.Status == StatusPendingApproval && !txn.ExpiresAt.After(now) {
+			m.Held[txn.SourceAccountID], _ = m.Held[txn.SourceAccountID].Sub(txn.Amount)
+			txn.Status = StatusExpired
+			expired++
+		}
+	}
+	return expired, nil
 }
 
 func (m *mockTransactionRepository) Find(
@@ -84,24 +245,151 @@ func (m *mockTransactionRepository) Find(
 	return nil, ErrFetchingTransaction(id)
 }
 
-func (m *mockTransactionRepository) FindAll(
-	ctx context.Context,
-) []*Transaction {
+func (m *mockTransactionRepository) Query(
+	ctx context.Context, q Query,
+) ([]*Transaction, error) {
 	transactions := make([]*Transaction, 0, len(m.Transactions))
 	for _, txn := range m.Transactions {
+		if q.AccountID != "" &&
+			txn.SourceAccountID != q.AccountID && txn.TargetAccountID != q.AccountID {
+			continue
+		}
+		if q.Currency != "" && txn.Currency != q.Currency {
+			continue
+		}
+		if q.Status != "" && txn.Status != q.Status {
+			continue
+		}
+		if q.AccessibleTo != "" && !m.owns(q.AccessibleTo, txn) {
+			continue
+		}
+		if q.After != nil {
+			after, _ := time.Parse(time.RFC3339Nano, q.After.Key)
+			if txn.BookedAt.After(after) ||
+				txn.BookedAt.Equal(after) && txn.ID >= q.After.ID {
+				continue
+			}
+		}
 		transactions = append(transactions, txn)
 	}
-	return transactions
+
+	// The most recently booked first
+	sort.Slice(transactions, func(i, j int) bool {
+		if !transactions[i].BookedAt.Equal(transactions[j].BookedAt) {
+			return transactions[i].BookedAt.After(transactions[j].BookedAt)
+		}
+		return transactions[i].ID > transactions[j].ID
+	})
+	if len(transactions) > q.Limit {
+		transactions = transactions[:q.Limit]
+	}
+	return transactions, nil
 }
 
-func (m *mockTransactionRepository) Delete(
-	ctx context.Context, id string,
+// owns returns true if the customer owns either account of the transaction
+func (m *mockTransactionRepository) owns(customerID string, txn *Transaction) bool {
+	for _, id := range []string{txn.SourceAccountID, txn.TargetAccountID} {
+		if acct, ok := m.Accounts[id]; ok && acct.CustomerID == customerID {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *mockTransactionRepository) History(
+	ctx context.Context, q HistoryQuery,
+) ([]*HistoryEntry, error) {
+	all, _ := m.Query(ctx, Query{AccountID: q.AccountID, Limit: len(m.Transactions)})
+
+	// Only the booked transactions moved the balances
+	txns := make([]*Transaction, 0, len(all))
+	for _, txn := range all {
+		if txn.Status == StatusCompleted || txn.Status == StatusReversed || txn.Status == "" {
+			txns = append(txns, txn)
+		}
+	}
+
+	// The accounts have no opening balance in these tests, so the running
+	// balance is summed up from the oldest transaction
+	entries := make([]*HistoryEntry, len(txns))
+	var balance money.Money
+	for i := len(txns) - 1; i >= 0; i-- {
+		amount := txns[i].TargetAmount
+		if txns[i].SourceAccountID == q.AccountID {
+			amount = txns[i].Amount.Neg()
+		}
+		if balance.Currency() == "" {
+			balance = money.Zero(amount.Currency())
+		}
+		balance, _ = balance.Add(amount)
+		entry := NewHistoryEntry(q.AccountID, txns[i], amount, balance)
+		entries[i] = &entry
+	}
+
+	for q.After != nil && len(entries) > 0 {
+		id := entries[0].TransactionID
+		entries = entries[1:]
+		if id == q.After.ID {
+			break
+		}
+	}
+	if len(entries) > q.Limit {
+		entries = entries[:q.Limit]
+	}
+	return entries, nil
+}
+
+func (m *mockTransactionRepository) StreamHistory(
+	ctx context.Context, accountID string, from, to time.Time,
+	begin func(opening, closing money.Money) error,
+	fn func(*HistoryEntry) error,
 ) error {
-	if _, ok := m.Transactions[id]; ok {
-		delete(m.Transactions, id)
-		return nil
+	entries, _ := m.History(ctx, HistoryQuery{AccountID: accountID, Limit: len(m.Transactions)})
+	// The entries are listed the most recent first, with the balance after each
+	balance := func(asOf time.Time) money.Money {
+		for _, e := range entries {
+			if !e.BookedAt.After(asOf) {
+				return e.BalanceAfter
+			}
+		}
+		return money.Money{}
+	}
+	if err := begin(balance(from), balance(to)); err != nil {
+		return err
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].BookedAt.After(from) && !entries[i].BookedAt.After(to) {
+			if err := fn(entries[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (m *mockTransactionRepository) FindReversals(
+	ctx context.Context, id string,
+) ([]*Transaction, error) {
+	reversals := make([]*Transaction, 0)
+	for _, txn := range m.Transactions {
+		if txn.ReversalOf == id {
+			reversals = append(reversals, txn)
+		}
+	}
+	return reversals, nil
+}
+
+// assertFailed asserts that the only transaction stored is a failed one
+// refused with the given reason
+func assertFailed(t *testing.T, m *mockTransactionRepository, reason string) {
+	t.Helper()
+	if !assert.Len(t, m.Transactions, 1, "The failed transaction should be stored") {
+		return
+	}
+	for _, txn := range m.Transactions {
+		assert.Equal(t, StatusFailed, txn.Status)
+		assert.Equal(t, reason, txn.FailureReason)
 	}
-	return ErrDeletingTransaction(id)
 }
 
 func TestService_LoadTransaction(t *testing.T) {
@@ -113,7 +401,7 @@ func TestService_LoadTransaction(t *testing.T) {
 		ID:              transactionID,
 		SourceAccountID: sourceAccountID,
 		TargetAccountID: targetAccountID,
-		Amount:          100.0,
+		Amount:          money.New(10000, "USD"),
 		Currency:        "USD",
 	}
 
@@ -123,7 +411,7 @@ func TestService_LoadTransaction(t *testing.T) {
 		},
 	}
 
-	service := NewService(nil, mockTransactionRepository)
+	service := NewService(nil, nil, mockTransactionRepository, nil, nil, ApprovalPolicy{})
 
 	loadedTransaction, err := service.Load(context.Background(), transactionID)
 
@@ -136,20 +424,84 @@ func TestService_LoadTransaction(t *testing.T) {
 	)
 }
 
+func TestService_LoadOwnership(t *testing.T) {
+	ownerID := "5d7e6e6c-52b4-4a3e-9d1c-3f7b1d2c9a10"
+	delegateID := "a7c6a8f5-3b8e-4a55-8b5b-7a2d0f0b9e21"
+	testCases := []struct {
+		Name          string
+		Key           auth.Key
+		Role          accounts.Role
+		ExpectedError error
+	}{
+		{
+			Name: "Operator Key",
+			Key:  auth.Key{ID: "key"},
+		},
+		{
+			Name: "Owner Of The Target Account",
+			Key:  auth.Key{ID: "key", CustomerID: ownerID},
+		},
+		{
+			Name: "Viewer On The Source Account",
+			Key:  auth.Key{ID: "key", CustomerID: delegateID},
+			Role: accounts.RoleView,
+		},
+		{
+			Name:          "Other Customer",
+			Key:           auth.Key{ID: "key", CustomerID: delegateID},
+			ExpectedError: accounts.ErrNotAccountOwner,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			txn := Transaction{ID: "1111", SourceAccountID: "2222", TargetAccountID: "3333"}
+			mockAccountRepository := &mockAccountRepository{
+				Accounts: map[string]*accounts.Account{
+					"2222": {ID: "2222", Currency: "EUR"},
+					"3333": {ID: "3333", Currency: "EUR", CustomerID: ownerID},
+				},
+			}
+			mockTransactionRepository := &mockTransactionRepository{
+				Transactions: map[string]*Transaction{txn.ID: &txn},
+			}
+			mockRoleRepository := &mockRoleRepository{Roles: make(map[string]accounts.Role)}
+			if tc.Role != "" {
+				mockRoleRepository.Roles["2222/"+delegateID] = tc.Role
+			}
+			service := NewService(
+				mockAccountRepository, mockRoleRepository, mockTransactionRepository, nil, nil,
+				ApprovalPolicy{},
+			)
+
+			loaded, err := service.Load(auth.NewContext(context.Background(), tc.Key), txn.ID)
+
+			if tc.ExpectedError == nil {
+				assert.NoError(t, err)
+				assert.Equal(t, txn, loaded)
+				return
+			}
+			assert.True(t, errors.Is(err, tc.ExpectedError))
+		})
+	}
+}
+
 func TestService_TransferHappyPath(t *testing.T) {
 	sourceAccountID := "2222"
 	targetAccountID := "3333"
 
 	mockSourceAccount := accounts.Account{
 		ID:       sourceAccountID,
-		Balance:  200.0,
+		Balance:  money.New(20000, "USD"),
 		Currency: "USD",
+		Status:   accounts.StatusActive,
 	}
 
 	mockTargetAccount := accounts.Account{
 		ID:       targetAccountID,
-		Balance:  0.0,
+		Balance:  money.New(0, "USD"),
 		Currency: "USD",
+		Status:   accounts.StatusActive,
 	}
 
 	mockAccounts := map[string]*accounts.Account{
@@ -165,19 +517,35 @@ func TestService_TransferHappyPath(t *testing.T) {
 		ID:              "1111",
 		SourceAccountID: sourceAccountID,
 		TargetAccountID: targetAccountID,
-		Amount:          100.0,
+		Amount:          money.New(10000, "USD"),
 		Currency:        "USD",
 	}
 
 	mockTransactionRepository := &mockTransactionRepository{
 		Transactions: make(map[string]*Transaction),
+		Accounts:     mockAccountRepository.Accounts,
 	}
 
-	service := NewService(mockAccountRepository, mockTransactionRepository)
+	service := NewService(
+		mockAccountRepository, nil, mockTransactionRepository, nil, nil, ApprovalPolicy{},
+	)
 
 	transferedTransaction, err := service.Transfer(context.Background(), expectedTransaction)
 
+	expectedTransaction.SourceCurrency = "USD"
+	expectedTransaction.TargetCurrency = "USD"
+	expectedTransaction.TargetAmount = expectedTransaction.Amount
+	expectedTransaction.Status = StatusCompleted
+
 	assert.NoError(t, err, "Error should be nil")
+	assert.False(t, transferedTransaction.CreatedAt.IsZero(), "Creation time should be set")
+	assert.False(
+		t,
+		transferedTransaction.BookedAt.Before(transferedTransaction.CreatedAt),
+		"Transaction should be booked after its creation",
+	)
+	expectedTransaction.CreatedAt = transferedTransaction.CreatedAt
+	expectedTransaction.BookedAt = transferedTransaction.BookedAt
 	assert.Equal(
 		t,
 		expectedTransaction,
@@ -186,13 +554,13 @@ func TestService_TransferHappyPath(t *testing.T) {
 	)
 	assert.Equal(
 		t,
-		100.0,
+		money.New(10000, "USD"),
 		mockSourceAccount.Balance,
 		"Source account balance should be decreased by the transaction amount",
 	)
 	assert.Equal(
 		t,
-		100.0,
+		money.New(10000, "USD"),
 		mockTargetAccount.Balance,
 		"Target account balance should be increased by the transaction amount",
 	)
@@ -204,14 +572,16 @@ func TestService_TransferInsufficientBalance(t *testing.T) {
 
 	mockSourceAccount := accounts.Account{
 		ID:       sourceAccountID,
-		Balance:  100.0,
+		Balance:  money.New(10000, "USD"),
 		Currency: "USD",
+		Status:   accounts.StatusActive,
 	}
 
 	mockTargetAccount := accounts.Account{
 		ID:       targetAccountID,
-		Balance:  0.0,
+		Balance:  money.New(0, "USD"),
 		Currency: "USD",
+		Status:   accounts.StatusActive,
 	}
 
 	mockAccounts := map[string]*accounts.Account{
@@ -227,100 +597,978 @@ func TestService_TransferInsufficientBalance(t *testing.T) {
 		ID:              "1111",
 		SourceAccountID: sourceAccountID,
 		TargetAccountID: targetAccountID,
-		Amount:          200.0,
+		Amount:          money.New(20000, "USD"),
 		Currency:        "USD",
 	}
 
 	mockTransactionRepository := &mockTransactionRepository{
 		Transactions: make(map[string]*Transaction),
+		Accounts:     mockAccountRepository.Accounts,
 	}
 
-	service := NewService(mockAccountRepository, mockTransactionRepository)
+	service := NewService(
+		mockAccountRepository, nil, mockTransactionRepository, nil, nil, ApprovalPolicy{},
+	)
 
 	_, err := service.Transfer(context.Background(), mockTransaction)
 
 	assert.Error(t, err, "Error should not be nil")
+	assert.ErrorIs(t, err, ErrInsufficientFunds, "Error should be an insufficient funds error")
 	assert.Equal(
 		t,
-		errInsufficientBalance(
-			mockSourceAccount.Balance,
-			mockTransaction.Amount,
-			mockSourceAccount.ID,
-		),
+		&InsufficientFundsError{
+			AccountID: mockSourceAccount.ID,
+			Balance:   mockSourceAccount.Balance,
+			Amount:    mockTransaction.Amount,
+		},
 		err,
 		"Error should match the expected error",
 	)
 	assert.Equal(
 		t,
-		100.0,
+		money.New(10000, "USD"),
 		mockSourceAccount.Balance,
 		"Source account balance should not be changed",
 	)
 	assert.Equal(
 		t,
-		0.0,
+		money.New(0, "USD"),
 		mockTargetAccount.Balance,
 		"Target account balance should not be changed",
 	)
 }
 
+func TestService_TransferHeldBalance(t *testing.T) {
+	sourceAccountID := "2222"
+	targetAccountID := "3333"
+
+	// Only the balance which is not held can be transfered
+	mockSourceAccount := accounts.Account{
+		ID:               sourceAccountID,
+		Balance:          money.New(10000, "USD"),
+		AvailableBalance: money.New(3000, "USD"),
+		Currency:         "USD",
+		Status:           accounts.StatusActive,
+	}
+	mockTargetAccount := accounts.Account{
+		ID:       targetAccountID,
+		Balance:  money.New(0, "USD"),
+		Currency: "USD",
+		Status:   accounts.StatusActive,
+	}
+	mockAccountRepository := &mockAccountRepository{
+		Accounts: map[string]*accounts.Account{
+			sourceAccountID: &mockSourceAccount,
+			targetAccountID: &mockTargetAccount,
+		},
+	}
+	mockTransactionRepository := &mockTransactionRepository{
+		Transactions: make(map[string]*Transaction),
+		Accounts:     mockAccountRepository.Accounts,
+	}
+
+	service := NewService(
+		mockAccountRepository, nil, mockTransactionRepository, nil, nil, ApprovalPolicy{},
+	)
+
+	_, err := service.Transfer(context.Background(), Transaction{
+		ID:              "1111",
+		SourceAccountID: sourceAccountID,
+		TargetAccountID: targetAccountID,
+		Amount:          money.New(5000, "USD"),
+		Currency:        "USD",
+	})
+
+	assert.Equal(
+		t,
+		&InsufficientFundsError{
+			AccountID: sourceAccountID,
+			Balance:   money.New(3000, "USD"),
+			Amount:    money.New(5000, "USD"),
+		},
+		err,
+		"The available balance should be checked rather than the balance",
+	)
+	assert.Equal(t, money.New(10000, "USD"), mockSourceAccount.Balance)
+	assert.Equal(t, money.New(0, "USD"), mockTargetAccount.Balance)
+}
+
+func TestService_TransferCurrencyMismatch(t *testing.T) {
+	sourceAccountID := "2222"
+	targetAccountID := "3333"
+
+	testCases := []struct {
+		Name           string
+		SourceCurrency string
+		TargetCurrency string
+		ExpectedError  error
+	}{
+		{
+			Name:           "Source Account Mismatch",
+			SourceCurrency: "EUR",
+			TargetCurrency: "USD",
+			ExpectedError: &CurrencyMismatchError{
+				AccountID:       sourceAccountID,
+				AccountCurrency: "EUR",
+				Currency:        "USD",
+			},
+		},
+		{
+			Name:           "Target Account Mismatch",
+			SourceCurrency: "USD",
+			TargetCurrency: "EUR",
+			ExpectedError: &CurrencyMismatchError{
+				AccountID:       targetAccountID,
+				AccountCurrency: "EUR",
+				Currency:        "USD",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			mockSourceAccount := accounts.Account{
+				ID:       sourceAccountID,
+				Balance:  money.New(20000, tc.SourceCurrency),
+				Currency: tc.SourceCurrency,
+				Status:   accounts.StatusActive,
+			}
+
+			mockTargetAccount := accounts.Account{
+				ID:       targetAccountID,
+				Balance:  money.Zero(tc.TargetCurrency),
+				Currency: tc.TargetCurrency,
+				Status:   accounts.StatusActive,
+			}
+
+			mockAccountRepository := &mockAccountRepository{
+				Accounts: map[string]*accounts.Account{
+					sourceAccountID: &mockSourceAccount,
+					targetAccountID: &mockTargetAccount,
+				},
+			}
+
+			mockTransactionRepository := &mockTransactionRepository{
+				Transactions: make(map[string]*Transaction),
+				Accounts:     mockAccountRepository.Accounts,
+			}
+
+			service := NewService(
+				mockAccountRepository, nil, mockTransactionRepository, nil, nil, ApprovalPolicy{},
+			)
+
+			_, err := service.Transfer(context.Background(), Transaction{
+				ID:              "1111",
+				SourceAccountID: sourceAccountID,
+				TargetAccountID: targetAccountID,
+				Amount:          money.New(10000, "USD"),
+				Currency:        "USD",
+			})
+
+			assert.ErrorIs(t, err, ErrCurrencyMismatch, "Error should be a currency mismatch")
+			assert.Equal(t, tc.ExpectedError, err, "Error should match the expected error")
+			assertFailed(t, mockTransactionRepository, ErrCurrencyMismatch.Code)
+			assert.Equal(
+				t,
+				money.New(20000, tc.SourceCurrency),
+				mockSourceAccount.Balance,
+				"Source account balance should not be changed",
+			)
+		})
+	}
+}
+
+func TestService_TransferCrossCurrency(t *testing.T) {
+	sourceAccountID := "2222"
+	targetAccountID := "3333"
+	rateTimestamp := time.Date(2023, 9, 7, 0, 0, 0, 0, time.UTC)
+
+	mockSourceAccount := accounts.Account{
+		ID:       sourceAccountID,
+		Balance:  money.MustParse("200.00", "EUR"),
+		Currency: "EUR",
+		Status:   accounts.StatusActive,
+	}
+
+	mockTargetAccount := accounts.Account{
+		ID:       targetAccountID,
+		Balance:  money.Zero("USD"),
+		Currency: "USD",
+		Status:   accounts.StatusActive,
+	}
+
+	mockAccountRepository := &mockAccountRepository{
+		Accounts: map[string]*accounts.Account{
+			sourceAccountID: &mockSourceAccount,
+			targetAccountID: &mockTargetAccount,
+		},
+	}
+
+	mockTransactionRepository := &mockTransactionRepository{
+		Transactions: make(map[string]*Transaction),
+		Accounts:     mockAccountRepository.Accounts,
+	}
+
+	rates, err := fx.NewStaticProvider(map[string]string{"EUR/USD": "1.0842"}, rateTimestamp)
+	assert.NoError(t, err)
+
+	service := NewService(
+		mockAccountRepository, nil, mockTransactionRepository, rates, nil, ApprovalPolicy{},
+	)
+
+	txn, err := service.Transfer(context.Background(), Transaction{
+		ID:              "1111",
+		SourceAccountID: sourceAccountID,
+		TargetAccountID: targetAccountID,
+		Amount:          money.MustParse("100.00", "EUR"),
+		Currency:        "EUR",
+		TargetCurrency:  "USD",
+	})
+
+	assert.NoError(t, err, "Error should be nil")
+	assert.Equal(t, "EUR", txn.SourceCurrency)
+	assert.Equal(t, "USD", txn.TargetCurrency)
+	assert.Equal(t, money.MustParse("108.42", "USD"), txn.TargetAmount)
+	assert.Equal(t, "1.0842", txn.Rate)
+	assert.Equal(t, &rateTimestamp, txn.RateTimestamp)
+	assert.Equal(
+		t,
+		money.MustParse("100.00", "EUR"),
+		mockSourceAccount.Balance,
+		"Source account should be debited in its currency",
+	)
+	assert.Equal(
+		t,
+		money.MustParse("108.42", "USD"),
+		mockTargetAccount.Balance,
+		"Target account should be credited in its currency",
+	)
+}
+
+func TestService_TransferInactiveAccount(t *testing.T) {
+	for _, status := range []string{accounts.StatusFrozen, accounts.StatusClosed} {
+		t.Run(status, func(t *testing.T) {
+			mockAccountRepository := &mockAccountRepository{
+				Accounts: map[string]*accounts.Account{
+					"2222": {ID: "2222", Balance: money.MustParse("200.00", "EUR"), Currency: "EUR",
+						Status: accounts.StatusActive},
+					"3333": {ID: "3333", Balance: money.Zero("EUR"), Currency: "EUR",
+						Status: status},
+				},
+			}
+
+			mockTransactionRepository := &mockTransactionRepository{
+				Transactions: make(map[string]*Transaction),
+				Accounts:     mockAccountRepository.Accounts,
+			}
+
+			service := NewService(
+				mockAccountRepository, nil, mockTransactionRepository, nil, nil, ApprovalPolicy{},
+			)
+
+			// The inactive account can neither receive nor send
+			for _, accts := range [][2]string{{"2222", "3333"}, {"3333", "2222"}} {
+				_, err := service.Transfer(context.Background(), Transaction{
+					ID:              "1111",
+					SourceAccountID: accts[0],
+					TargetAccountID: accts[1],
+					Amount:          money.MustParse("100.00", "EUR"),
+					Currency:        "EUR",
+				})
+				assert.Equal(t, &accounts.InactiveAccountError{AccountID: "3333", Status: status}, err)
+			}
+			assertFailed(t, mockTransactionRepository, accounts.ErrAccountInactive.Code)
+		})
+	}
+}
+
+// mockRoleRepository only finds the roles of the customers on the accounts
+type mockRoleRepository struct {
+	accounts.RoleRepository
+	// Roles are keyed by account and customer IDs
+	Roles map[string]accounts.Role
+}
+
+func (m *mockRoleRepository) Find(
+	ctx context.Context, accountID, customerID string,
+) (*accounts.Grant, error) {
+	role, ok := m.Roles[accountID+"/"+customerID]
+	if !ok {
+		return nil, accounts.ErrFetchingRole(accountID, customerID)
+	}
+	return &accounts.Grant{AccountID: accountID, CustomerID: customerID, Role: role}, nil
+}
+
+func TestService_TransferOwnership(t *testing.T) {
+	customerID := "5d7e6e6c-52b4-4a3e-9d1c-3f7b1d2c9a10"
+	delegateID := "a7c6a8f5-3b8e-4a55-8b5b-7a2d0f0b9e21"
+	testCases := []struct {
+		Name          string
+		Key           *auth.Key
+		Owner         string
+		Role          accounts.Role
+		ExpectedError error
+	}{
+		{
+			Name:  "Operator Key",
+			Key:   &auth.Key{ID: "key"},
+			Owner: customerID,
+		},
+		{
+			Name:  "Without Key",
+			Owner: customerID,
+		},
+		{
+			Name:  "Owner Key",
+			Key:   &auth.Key{ID: "key", CustomerID: customerID},
+			Owner: customerID,
+		},
+		{
+			Name:          "Other Customer Key",
+			Key:           &auth.Key{ID: "key", CustomerID: delegateID},
+			Owner:         customerID,
+			ExpectedError: accounts.ErrNotAccountOwner,
+		},
+		{
+			Name:  "Initiator Key",
+			Key:   &auth.Key{ID: "key", CustomerID: delegateID},
+			Owner: customerID,
+			Role:  accounts.RoleInitiate,
+		},
+		{
+			Name:  "Approver Key",
+			Key:   &auth.Key{ID: "key", CustomerID: delegateID},
+			Owner: customerID,
+			Role:  accounts.RoleApprove,
+		},
+		{
+			Name:          "Viewer Key",
+			Key:           &auth.Key{ID: "key", CustomerID: delegateID},
+			Owner:         customerID,
+			Role:          accounts.RoleView,
+			ExpectedError: accounts.ErrInsufficientRole,
+		},
+		{
+			Name:          "Account Without Owner",
+			Key:           &auth.Key{ID: "key", CustomerID: customerID},
+			ExpectedError: accounts.ErrNotAccountOwner,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			mockAccountRepository := &mockAccountRepository{
+				Accounts: map[string]*accounts.Account{
+					"2222": {ID: "2222", Balance: money.MustParse("200.00", "EUR"), Currency: "EUR",
+						Status: accounts.StatusActive, CustomerID: tc.Owner},
+					"3333": {ID: "3333", Balance: money.Zero("EUR"), Currency: "EUR",
+						Status: accounts.StatusActive},
+				},
+			}
+			mockTransactionRepository := &mockTransactionRepository{
+				Transactions: make(map[string]*Transaction),
+				Accounts:     mockAccountRepository.Accounts,
+			}
+			mockRoleRepository := &mockRoleRepository{Roles: make(map[string]accounts.Role)}
+			if tc.Role != "" {
+				mockRoleRepository.Roles["2222/"+delegateID] = tc.Role
+			}
+			service := NewService(
+				mockAccountRepository, mockRoleRepository, mockTransactionRepository, nil, nil,
+				ApprovalPolicy{},
+			)
+
+			ctx := context.Background()
+			if tc.Key != nil {
+				ctx = auth.NewContext(ctx, *tc.Key)
+			}
+			_, err := service.Transfer(ctx, Transaction{
+				ID:              "1111",
+				SourceAccountID: "2222",
+				TargetAccountID: "3333",
+				Amount:          money.MustParse("100.00", "EUR"),
+				Currency:        "EUR",
+			})
+
+			if tc.ExpectedError == nil {
+				assert.NoError(t, err)
+				assert.Len(t, mockTransactionRepository.Transactions, 1)
+				return
+			}
+			assert.True(t, errors.Is(err, tc.ExpectedError))
+			assert.Empty(t, mockTransactionRepository.Transactions)
+		})
+	}
+}
+
+func TestService_TransferCrossCurrencyNoRate(t *testing.T) {
+	mockAccountRepository := &mockAccountRepository{
+		Accounts: map[string]*accounts.Account{
+			"2222": {ID: "2222", Balance: money.MustParse("200.00", "EUR"), Currency: "EUR", Status: accounts.StatusActive},
+			"3333": {ID: "3333", Balance: money.Zero("USD"), Currency: "USD", Status: accounts.StatusActive},
+		},
+	}
+
+	mockTransactionRepository := &mockTransactionRepository{
+		Transactions: make(map[string]*Transaction),
+		Accounts:     mockAccountRepository.Accounts,
+	}
+
+	service := NewService(
+		mockAccountRepository, nil, mockTransactionRepository, nil, nil, ApprovalPolicy{},
+	)
+
+	_, err := service.Transfer(context.Background(), Transaction{
+		ID:              "1111",
+		SourceAccountID: "2222",
+		TargetAccountID: "3333",
+		Amount:          money.MustParse("100.00", "EUR"),
+		Currency:        "EUR",
+		TargetCurrency:  "USD",
+	})
+
+	assert.Equal(t, fx.ErrRateNotFound, err, "Error should be a missing rate")
+	assertFailed(t, mockTransactionRepository, fx.ErrRateNotFound.Code)
+}
+
 func TestService_Transactions(t *testing.T) {
-	expectedTransaction1 := Transaction{
+	bookedAt := time.Date(2023, 9, 12, 9, 0, 0, 0, time.UTC)
+
+	transaction1 := Transaction{
 		ID:              "1111",
 		SourceAccountID: "2222",
 		TargetAccountID: "3333",
-		Amount:          100.0,
+		Amount:          money.New(10000, "USD"),
 		Currency:        "USD",
+		BookedAt:        bookedAt,
 	}
 
-	expectedTransaction2 := Transaction{
+	transaction2 := Transaction{
 		ID:              "4444",
 		SourceAccountID: "5555",
+		TargetAccountID: "2222",
+		Amount:          money.New(20000, "USD"),
+		Currency:        "USD",
+		BookedAt:        bookedAt.Add(time.Minute),
+	}
+
+	transaction3 := Transaction{
+		ID:              "7777",
+		SourceAccountID: "5555",
 		TargetAccountID: "6666",
-		Amount:          200.0,
+		Amount:          money.New(30000, "USD"),
 		Currency:        "USD",
+		BookedAt:        bookedAt.Add(2 * time.Minute),
 	}
 
 	mockTransactionRepository := &mockTransactionRepository{
 		Transactions: map[string]*Transaction{
-			expectedTransaction1.ID: &expectedTransaction1,
-			expectedTransaction2.ID: &expectedTransaction2,
+			transaction1.ID: &transaction1,
+			transaction2.ID: &transaction2,
+			transaction3.ID: &transaction3,
 		},
 	}
 
-	service := NewService(nil, mockTransactionRepository)
+	service := NewService(nil, nil, mockTransactionRepository, nil, nil, ApprovalPolicy{})
 
-	transactions := service.LoadAll(context.Background())
+	// First page
+	page, err := service.LoadAll(context.Background(), Query{Limit: 2})
 
-	assert.Len(t, transactions, 2, "Number of returned transactions should be 2")
-	assert.Contains(
-		t,
-		transactions,
-		expectedTransaction1,
-		"Transaction 1 should be present",
+	assert.NoError(t, err)
+	assert.Equal(t, []Transaction{transaction3, transaction2}, page.Transactions,
+		"First page should hold the most recent transactions")
+	assert.NotEmpty(t, page.NextCursor, "First page should have a next cursor")
+
+	// Last page
+	cursor, err := pagination.Decode(page.NextCursor)
+	assert.NoError(t, err)
+	page, err = service.LoadAll(context.Background(), Query{Limit: 2, After: &cursor})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []Transaction{transaction1}, page.Transactions)
+	assert.Empty(t, page.NextCursor, "Last page should not have a next cursor")
+
+	// Filtered by account on either side
+	page, err = service.LoadAll(context.Background(), Query{AccountID: "2222", Limit: 10})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []Transaction{transaction2, transaction1}, page.Transactions)
+	assert.Empty(t, page.NextCursor)
+
+	// Customers only see the transactions of their accounts
+	mockTransactionRepository.Accounts = map[string]*accounts.Account{
+		"2222": {ID: "2222", CustomerID: "customer"},
+	}
+	ctx := auth.NewContext(context.Background(), auth.Key{ID: "key", CustomerID: "customer"})
+	page, err = service.LoadAll(ctx, Query{Limit: 10})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []Transaction{transaction2, transaction1}, page.Transactions)
+}
+
+func TestService_History(t *testing.T) {
+	bookedAt := time.Date(2023, 9, 15, 9, 0, 0, 0, time.UTC)
+
+	// 100.00 EUR in, 30.00 EUR out and 50.00 USD converted to 45.00 EUR in
+	transaction1 := Transaction{
+		ID:              "1111",
+		SourceAccountID: "3333",
+		TargetAccountID: "2222",
+		Amount:          money.New(10000, "EUR"),
+		TargetAmount:    money.New(10000, "EUR"),
+		BookedAt:        bookedAt,
+	}
+	transaction2 := Transaction{
+		ID:              "4444",
+		SourceAccountID: "2222",
+		TargetAccountID: "3333",
+		Amount:          money.New(3000, "EUR"),
+		TargetAmount:    money.New(3000, "EUR"),
+		BookedAt:        bookedAt.Add(time.Minute),
+	}
+	transaction3 := Transaction{
+		ID:              "5555",
+		SourceAccountID: "6666",
+		TargetAccountID: "2222",
+		Amount:          money.New(5000, "USD"),
+		TargetAmount:    money.New(4500, "EUR"),
+		BookedAt:        bookedAt.Add(2 * time.Minute),
+	}
+
+	mockAccountRepository := &mockAccountRepository{
+		Accounts: map[string]*accounts.Account{
+			"2222": {ID: "2222", Balance: money.New(11500, "EUR"), Currency: "EUR", Status: accounts.StatusActive},
+		},
+	}
+	mockTransactionRepository := &mockTransactionRepository{
+		Transactions: map[string]*Transaction{
+			transaction1.ID: &transaction1,
+			transaction2.ID: &transaction2,
+			transaction3.ID: &transaction3,
+		},
+	}
+
+	service := NewService(
+		mockAccountRepository, nil, mockTransactionRepository, nil, nil, ApprovalPolicy{},
 	)
-	assert.Contains(
-		t,
-		transactions,
-		expectedTransaction2,
-		"Transaction 2 should be present",
+
+	// First page
+	page, err := service.History(context.Background(), HistoryQuery{AccountID: "2222", Limit: 2})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []HistoryEntry{
+		{TransactionID: "5555", Direction: DirectionIncoming, CounterpartyID: "6666",
+			Amount: money.New(4500, "EUR"), Currency: "EUR",
+			BalanceAfter: money.New(11500, "EUR"), BookedAt: transaction3.BookedAt},
+		{TransactionID: "4444", Direction: DirectionOutgoing, CounterpartyID: "3333",
+			Amount: money.New(-3000, "EUR"), Currency: "EUR",
+			BalanceAfter: money.New(7000, "EUR"), BookedAt: transaction2.BookedAt},
+	}, page.Entries)
+	assert.NotEmpty(t, page.NextCursor, "First page should have a next cursor")
+
+	// Last page
+	cursor, err := pagination.Decode(page.NextCursor)
+	assert.NoError(t, err)
+	page, err = service.History(context.Background(),
+		HistoryQuery{AccountID: "2222", After: &cursor, Limit: 2})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []HistoryEntry{
+		{TransactionID: "1111", Direction: DirectionIncoming, CounterpartyID: "3333",
+			Amount: money.New(10000, "EUR"), Currency: "EUR",
+			BalanceAfter: money.New(10000, "EUR"), BookedAt: transaction1.BookedAt},
+	}, page.Entries)
+	assert.Empty(t, page.NextCursor, "Last page should not have a next cursor")
+
+	// Unknown account
+	_, err = service.History(context.Background(), HistoryQuery{AccountID: "7777", Limit: 2})
+	assert.Error(t, err)
+}
+
+func TestService_Reverse(t *testing.T) {
+	// 100.00 EUR were sent from 2222 and 108.42 USD received by 3333
+	original := Transaction{
+		ID:              "1111",
+		SourceAccountID: "2222",
+		TargetAccountID: "3333",
+		Amount:          money.MustParse("100.00", "EUR"),
+		Currency:        "EUR",
+		SourceCurrency:  "EUR",
+		TargetCurrency:  "USD",
+		TargetAmount:    money.MustParse("108.42", "USD"),
+		Status:          StatusCompleted,
+	}
+	mockSourceAccount := accounts.Account{
+		ID: "2222", Balance: money.MustParse("100.00", "EUR"), Currency: "EUR",
+		Status: accounts.StatusActive,
+	}
+	mockTargetAccount := accounts.Account{
+		ID: "3333", Balance: money.MustParse("108.42", "USD"), Currency: "USD",
+		Status: accounts.StatusActive,
+	}
+
+	mockTransactionRepository := &mockTransactionRepository{
+		Transactions: map[string]*Transaction{original.ID: &original},
+		Accounts: map[string]*accounts.Account{
+			mockSourceAccount.ID: &mockSourceAccount,
+			mockTargetAccount.ID: &mockTargetAccount,
+		},
+	}
+
+	mockAccountRepository := &mockAccountRepository{Accounts: mockTransactionRepository.Accounts}
+	service := NewService(
+		mockAccountRepository, nil, mockTransactionRepository, nil, nil, ApprovalPolicy{},
 	)
+
+	// A third is taken back at the rate of the original transfer
+	reversal, err := service.Reverse(context.Background(), original.ID, "33.33")
+	assert.NoError(t, err)
+	assert.Equal(t, original.ID, reversal.ReversalOf)
+	assert.Equal(t, "3333", reversal.SourceAccountID)
+	assert.Equal(t, "2222", reversal.TargetAccountID)
+	assert.Equal(t, money.MustParse("36.14", "USD"), reversal.Amount)
+	assert.Equal(t, money.MustParse("33.33", "EUR"), reversal.TargetAmount)
+	assert.Equal(t, money.MustParse("133.33", "EUR"), mockSourceAccount.Balance)
+	assert.Equal(t, money.MustParse("72.28", "USD"), mockTargetAccount.Balance)
+	assert.Equal(t, StatusCompleted, original.Status, "A partial reversal should not reverse")
+
+	// failures returns the failure reasons of the refused reversals
+	failures := func() []string {
+		reasons := make([]string, 0)
+		for _, txn := range mockTransactionRepository.Transactions {
+			if txn.ReversalOf == original.ID && txn.Status == StatusFailed {
+				reasons = append(reasons, txn.FailureReason)
+			}
+		}
+		return reasons
+	}
+
+	// More than what remains is refused and kept as a failed reversal
+	_, err = service.Reverse(context.Background(), original.ID, "66.68")
+	assert.Equal(t, ErrReversalExceedsOriginal, err)
+	assert.Equal(t, []string{"reversal_exceeds_original"}, failures())
+
+	// So is a reversal the target account can no longer pay for
+	mockTargetAccount.Balance = money.MustParse("10.00", "USD")
+	_, err = service.Reverse(context.Background(), original.ID, "33.33")
+	assert.True(t, errors.Is(err, ErrInsufficientFunds))
+	assert.ElementsMatch(t, []string{"reversal_exceeds_original", "insufficient_funds"}, failures())
+	mockTargetAccount.Balance = money.MustParse("72.28", "USD")
+
+	// Invalid amounts
+	_, err = service.Reverse(context.Background(), original.ID, "-1.00")
+	assert.Equal(t, ErrInvalidReversalAmount, err)
+	_, err = service.Reverse(context.Background(), original.ID, "1.001")
+	assert.Equal(t, ErrInvalidReversalAmount, err)
+
+	// A reversal cannot be reversed
+	_, err = service.Reverse(context.Background(), reversal.ID, "")
+	assert.Equal(t, ErrReversingReversal, err)
+
+	// The rest is taken back exactly, whatever the rounding of the first part
+	rest, err := service.Reverse(context.Background(), original.ID, "")
+	assert.NoError(t, err)
+	assert.Equal(t, money.MustParse("72.28", "USD"), rest.Amount)
+	assert.Equal(t, money.MustParse("66.67", "EUR"), rest.TargetAmount)
+	assert.Equal(t, money.MustParse("200.00", "EUR"), mockSourceAccount.Balance)
+	assert.True(t, mockTargetAccount.Balance.IsZero())
+	assert.Equal(t, StatusReversed, original.Status)
+
+	// Nothing is left to reverse
+	_, err = service.Reverse(context.Background(), original.ID, "")
+	assert.Equal(t, ErrReversalExceedsOriginal, err)
+
+	// Unknown transaction
+	_, err = service.Reverse(context.Background(), "9999", "")
+	assert.Equal(t, ErrFetchingTransaction("9999"), err)
+}
+
+func TestService_ReverseOwnership(t *testing.T) {
+	ownerID := "5d7e6e6c-52b4-4a3e-9d1c-3f7b1d2c9a10"
+	delegateID := "a7c6a8f5-3b8e-4a55-8b5b-7a2d0f0b9e21"
+	testCases := []struct {
+		Name          string
+		Key           auth.Key
+		Role          accounts.Role
+		ExpectedError error
+	}{
+		{
+			Name: "Operator Key",
+			Key:  auth.Key{ID: "key"},
+		},
+		{
+			Name: "Owner Of The Target Account",
+			Key:  auth.Key{ID: "key", CustomerID: ownerID},
+		},
+		{
+			Name: "Initiator On The Target Account",
+			Key:  auth.Key{ID: "key", CustomerID: delegateID},
+			Role: accounts.RoleInitiate,
+		},
+		{
+			Name:          "Viewer On The Target Account",
+			Key:           auth.Key{ID: "key", CustomerID: delegateID},
+			Role:          accounts.RoleView,
+			ExpectedError: accounts.ErrInsufficientRole,
+		},
+		{
+			Name:          "Other Customer",
+			Key:           auth.Key{ID: "key", CustomerID: delegateID},
+			ExpectedError: accounts.ErrNotAccountOwner,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			original := Transaction{
+				ID:              "1111",
+				SourceAccountID: "2222",
+				TargetAccountID: "3333",
+				Amount:          money.MustParse("100.00", "EUR"),
+				Currency:        "EUR",
+				SourceCurrency:  "EUR",
+				TargetCurrency:  "EUR",
+				TargetAmount:    money.MustParse("100.00", "EUR"),
+				Status:          StatusCompleted,
+			}
+			mockAccountRepository := &mockAccountRepository{
+				Accounts: map[string]*accounts.Account{
+					"2222": {ID: "2222", Balance: money.Zero("EUR"), Currency: "EUR",
+						Status: accounts.StatusActive},
+					"3333": {ID: "3333", Balance: money.MustParse("100.00", "EUR"),
+						Currency: "EUR", Status: accounts.StatusActive, CustomerID: ownerID},
+				},
+			}
+			mockTransactionRepository := &mockTransactionRepository{
+				Transactions: map[string]*Transaction{original.ID: &original},
+				Accounts:     mockAccountRepository.Accounts,
+			}
+			mockRoleRepository := &mockRoleRepository{Roles: make(map[string]accounts.Role)}
+			if tc.Role != "" {
+				mockRoleRepository.Roles["3333/"+delegateID] = tc.Role
+			}
+			service := NewService(
+				mockAccountRepository, mockRoleRepository, mockTransactionRepository, nil, nil,
+				ApprovalPolicy{},
+			)
+
+			_, err := service.Reverse(auth.NewContext(context.Background(), tc.Key), "1111", "")
+
+			if tc.ExpectedError == nil {
+				assert.NoError(t, err)
+				assert.Len(t, mockTransactionRepository.Transactions, 2)
+				return
+			}
+			assert.True(t, errors.Is(err, tc.ExpectedError))
+			assert.Len(t, mockTransactionRepository.Transactions, 1)
+			assert.Equal(t, money.MustParse("100.00", "EUR"),
+				mockAccountRepository.Accounts["3333"].Balance, "No balance should move")
+		})
+	}
 }
 
-func TestService_Clean(t *testing.T) {
-	mockTransactionID := "transaction-123"
+func TestService_TransferExactAmounts(t *testing.T) {
+	sourceAccountID := "2222"
+	targetAccountID := "3333"
+
+	mockSourceAccount := accounts.Account{
+		ID:       sourceAccountID,
+		Balance:  money.MustParse("0.30", "USD"),
+		Currency: "USD",
+		Status:   accounts.StatusActive,
+	}
+
+	mockTargetAccount := accounts.Account{
+		ID:       targetAccountID,
+		Balance:  money.Zero("USD"),
+		Currency: "USD",
+		Status:   accounts.StatusActive,
+	}
+
+	mockAccountRepository := &mockAccountRepository{
+		Accounts: map[string]*accounts.Account{
+			sourceAccountID: &mockSourceAccount,
+			targetAccountID: &mockTargetAccount,
+		},
+	}
 
 	mockTransactionRepository := &mockTransactionRepository{
-		Transactions: map[string]*Transaction{
-			mockTransactionID: {},
+		Transactions: make(map[string]*Transaction),
+		Accounts:     mockAccountRepository.Accounts,
+	}
+
+	service := NewService(
+		mockAccountRepository, nil, mockTransactionRepository, nil, nil, ApprovalPolicy{},
+	)
+
+	// Three transfers of 0.10 drift in binary floating point but not in minor units
+	for _, id := range []string{"1111", "4444", "5555"} {
+		_, err := service.Transfer(context.Background(), Transaction{
+			ID:              id,
+			SourceAccountID: sourceAccountID,
+			TargetAccountID: targetAccountID,
+			Amount:          money.MustParse("0.10", "USD"),
+			Currency:        "USD",
+		})
+		assert.NoError(t, err, "Error should be nil")
+	}
+
+	assert.True(t, mockSourceAccount.Balance.IsZero(), "Source account should be emptied exactly")
+	assert.Equal(
+		t,
+		money.MustParse("0.30", "USD"),
+		mockTargetAccount.Balance,
+		"Target account should be credited exactly",
+	)
+}
+
+type mockQuoteRepository struct {
+	Quotes map[string]*fx.Quote
+}
+
+func (m *mockQuoteRepository) Store(
+	ctx context.Context, q *fx.Quote,
+) (*fx.Quote, error) {
+	m.Quotes[q.ID] = q
+	return q, nil
+}
+
+func (m *mockQuoteRepository) Find(
+	ctx context.Context, id string,
+) (*fx.Quote, error) {
+	if q, ok := m.Quotes[id]; ok {
+		return q, nil
+	}
+	return nil, fx.ErrQuoteNotFound
+}
+
+func TestService_TransferWithQuote(t *testing.T) {
+	rateTimestamp := time.Date(2023, 9, 7, 0, 0, 0, 0, time.UTC)
+	usedAt := time.Now().Add(-time.Second)
+
+	newQuote := func() *fx.Quote {
+		return &fx.Quote{
+			ID:             "9999",
+			SourceCurrency: "EUR",
+			TargetCurrency: "USD",
+			SourceAmount:   money.MustParse("100.00", "EUR"),
+			TargetAmount:   money.MustParse("110.00", "USD"),
+			Rate:           "1.1",
+			RateTimestamp:  rateTimestamp,
+			ExpiresAt:      time.Now().Add(time.Minute),
+		}
+	}
+
+	testCases := []struct {
+		Name          string
+		Quote         func(q *fx.Quote)
+		Amount        string
+		ExpectedError error
+	}{
+		{
+			Name:   "Locked Rate",
+			Amount: "100.00",
+		},
+		{
+			Name:          "Expired",
+			Quote:         func(q *fx.Quote) { q.ExpiresAt = time.Now().Add(-time.Second) },
+			Amount:        "100.00",
+			ExpectedError: fx.ErrQuoteExpired,
+		},
+		{
+			Name:          "Used",
+			Quote:         func(q *fx.Quote) { q.UsedAt = &usedAt },
+			Amount:        "100.00",
+			ExpectedError: fx.ErrQuoteUsed,
+		},
+		{
+			Name:          "Amount Mismatch",
+			Amount:        "50.00",
+			ExpectedError: fx.ErrQuoteMismatch,
 		},
 	}
 
-	service := NewService(nil, mockTransactionRepository)
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			mockAccountRepository := &mockAccountRepository{
+				Accounts: map[string]*accounts.Account{
+					"2222": {ID: "2222", Balance: money.MustParse("200.00", "EUR"), Currency: "EUR", Status: accounts.StatusActive},
+					"3333": {ID: "3333", Balance: money.Zero("USD"), Currency: "USD", Status: accounts.StatusActive},
+				},
+			}
 
-	err := service.Clean(context.Background(), mockTransactionID)
+			mockTransactionRepository := &mockTransactionRepository{
+				Transactions: make(map[string]*Transaction),
+				Accounts:     mockAccountRepository.Accounts,
+			}
 
-	assert.NoError(t, err, "Error should be nil")
-	_, exists := mockTransactionRepository.Transactions[mockTransactionID]
-	assert.False(t, exists, "Transaction should be deleted")
+			quote := newQuote()
+			if tc.Quote != nil {
+				tc.Quote(quote)
+			}
+			mockQuoteRepository := &mockQuoteRepository{
+				Quotes: map[string]*fx.Quote{quote.ID: quote},
+			}
+
+			service := NewService(
+				mockAccountRepository, nil, mockTransactionRepository, nil, mockQuoteRepository,
+				ApprovalPolicy{},
+			)
+
+			txn, err := service.Transfer(context.Background(), Transaction{
+				ID:              "1111",
+				SourceAccountID: "2222",
+				TargetAccountID: "3333",
+				Amount:          money.MustParse(tc.Amount, "EUR"),
+				Currency:        "EUR",
+				QuoteID:         quote.ID,
+			})
+
+			assert.Equal(t, tc.ExpectedError, err)
+			if tc.ExpectedError != nil {
+				assertFailed(t, mockTransactionRepository, tc.ExpectedError.(*errs.Error).Code)
+				return
+			}
+
+			assert.Equal(t, "USD", txn.TargetCurrency,
+				"Target currency should default to the one of the quote")
+			assert.Equal(t, money.MustParse("110.00", "USD"), txn.TargetAmount)
+			assert.Equal(t, "1.1", txn.Rate)
+			assert.Equal(t, &rateTimestamp, txn.RateTimestamp)
+			assert.Equal(t, money.MustParse("110.00", "USD"),
+				mockAccountRepository.Accounts["3333"].Balance)
+		})
+	}
+}
+
+func TestService_TransferUnknownQuote(t *testing.T) {
+	mockAccountRepository := &mockAccountRepository{
+		Accounts: map[string]*accounts.Account{
+			"2222": {ID: "2222", Balance: money.MustParse("200.00", "EUR"), Currency: "EUR", Status: accounts.StatusActive},
+			"3333": {ID: "3333", Balance: money.Zero("USD"), Currency: "USD", Status: accounts.StatusActive},
+		},
+	}
+
+	mockTransactionRepository := &mockTransactionRepository{
+		Transactions: make(map[string]*Transaction),
+		Accounts:     mockAccountRepository.Accounts,
+	}
+
+	mockQuoteRepository := &mockQuoteRepository{Quotes: make(map[string]*fx.Quote)}
+
+	service := NewService(
+		mockAccountRepository, nil, mockTransactionRepository, nil, mockQuoteRepository,
+		ApprovalPolicy{},
+	)
+
+	_, err := service.Transfer(context.Background(), Transaction{
+		ID:              "1111",
+		SourceAccountID: "2222",
+		TargetAccountID: "3333",
+		Amount:          money.MustParse("100.00", "EUR"),
+		Currency:        "EUR",
+		QuoteID:         "9999",
+	})
+
+	assert.Equal(t, fx.ErrQuoteNotFound, err)
 }
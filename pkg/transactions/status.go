@@ -0,0 +1,46 @@
+package transactions
+
+// Statuses of a transaction. A transfer is pending until it is either booked
+// and completed, or refused and failed. A transfer above the approval
+// threshold of its currency waits for an approval instead, with its amount
+// held on the source account, until it is approved and completed, rejected
+// or expired. A completed transaction is reversed once its reversals have
+// taken back all of it.
+const (
+	StatusPending         = "pending"
+	StatusPendingApproval = "pending_approval"
+	StatusCompleted       = "completed"
+	StatusFailed          = "failed"
+	StatusRejected        = "rejected"
+	StatusExpired         = "expired"
+	StatusReversed        = "reversed"
+)
+
+// transitions are the statuses a transaction may move to from each status.
+// The other statuses are final.
+var transitions = map[string][]string{
+	StatusPending:         {StatusPendingApproval, StatusCompleted, StatusFailed},
+	StatusPendingApproval: {StatusCompleted, StatusRejected, StatusExpired},
+	StatusCompleted:       {StatusReversed},
+}
+
+// ValidStatus returns true if the given status is a known transaction status
+func ValidStatus(status string) bool {
+	switch status {
+	case StatusPending, StatusPendingApproval, StatusCompleted, StatusFailed,
+		StatusRejected, StatusExpired, StatusReversed:
+		return true
+	}
+	return false
+}
+
+// CheckTransition returns an error unless the transaction may move to the
+// given status
+func CheckTransition(txn *Transaction, status string) error {
+	for _, to := range transitions[txn.Status] {
+		if to == status {
+			return nil
+		}
+	}
+	return &TransitionError{TransactionID: txn.ID, From: txn.Status, To: status}
+}
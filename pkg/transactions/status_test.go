@@ -0,0 +1,150 @@
+package transactions
+
+import (
+	"context"
+	"errors"
+	"financial-app/pkg/accounts"
+	"financial-app/pkg/money"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckTransition(t *testing.T) {
+	testCases := []struct {
+		From    string
+		To      string
+		Allowed bool
+	}{
+		{From: StatusPending, To: StatusCompleted, Allowed: true},
+		{From: StatusPending, To: StatusPendingApproval, Allowed: true},
+		{From: StatusPending, To: StatusFailed, Allowed: true},
+		{From: StatusPendingApproval, To: StatusCompleted, Allowed: true},
+		{From: StatusPendingApproval, To: StatusRejected, Allowed: true},
+		{From: StatusPendingApproval, To: StatusExpired, Allowed: true},
+		{From: StatusCompleted, To: StatusReversed, Allowed: true},
+		{From: StatusPending, To: StatusReversed},
+		{From: StatusPendingApproval, To: StatusFailed},
+		{From: StatusCompleted, To: StatusFailed},
+		{From: StatusFailed, To: StatusCompleted},
+		{From: StatusRejected, To: StatusCompleted},
+		{From: StatusReversed, To: StatusCompleted},
+		{From: StatusCompleted, To: StatusCompleted},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.From+" to "+tc.To, func(t *testing.T) {
+			err := CheckTransition(&Transaction{ID: "1111", Status: tc.From}, tc.To)
+			if tc.Allowed {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Equal(t, &TransitionError{TransactionID: "1111", From: tc.From, To: tc.To}, err)
+			assert.True(t, errors.Is(err, ErrInvalidTransition))
+		})
+	}
+
+	assert.True(t, ValidStatus(StatusReversed))
+	assert.False(t, ValidStatus("booked"))
+}
+
+// failingTransactionRepository refuses every transfer with its error
+type failingTransactionRepository struct {
+	*mockTransactionRepository
+	Error error
+}
+
+func (m *failingTransactionRepository) Transfer(
+	ctx context.Context, txn *Transaction,
+) (*Transaction, error) {
+	return nil, m.Error
+}
+
+func TestService_TransferFailure(t *testing.T) {
+	testCases := []struct {
+		Name           string
+		Amount         string
+		RepoError      error
+		ExpectedError  error
+		ExpectedReason string
+	}{
+		{
+			Name:           "Insufficient Funds",
+			Amount:         "300.00",
+			ExpectedError:  ErrInsufficientFunds,
+			ExpectedReason: "insufficient_funds",
+		},
+		{
+			Name:           "Refused Under Lock",
+			Amount:         "100.00",
+			RepoError:      &InsufficientFundsError{AccountID: "2222"},
+			ExpectedError:  ErrInsufficientFunds,
+			ExpectedReason: "insufficient_funds",
+		},
+		{
+			Name:          "Server Error",
+			Amount:        "100.00",
+			RepoError:     ErrPostingTransaction("1111"),
+			ExpectedError: ErrPostingTransaction("1111"),
+		},
+		{
+			Name:          "Duplicate",
+			Amount:        "100.00",
+			RepoError:     ErrDuplicateTransaction,
+			ExpectedError: ErrDuplicateTransaction,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			mockAccountRepository := &mockAccountRepository{
+				Accounts: map[string]*accounts.Account{
+					"2222": {ID: "2222", Balance: money.MustParse("200.00", "EUR"),
+						Currency: "EUR", Status: accounts.StatusActive},
+					"3333": {ID: "3333", Balance: money.Zero("EUR"),
+						Currency: "EUR", Status: accounts.StatusActive},
+				},
+			}
+			mockTransactionRepository := &mockTransactionRepository{
+				Transactions: make(map[string]*Transaction),
+				Accounts:     mockAccountRepository.Accounts,
+			}
+			var transactions TransactionRepository = mockTransactionRepository
+			if tc.RepoError != nil {
+				transactions = &failingTransactionRepository{
+					mockTransactionRepository: mockTransactionRepository,
+					Error:                     tc.RepoError,
+				}
+			}
+			service := NewService(
+				mockAccountRepository, nil, transactions, nil, nil, ApprovalPolicy{},
+			)
+
+			_, err := service.Transfer(context.Background(), Transaction{
+				ID:              "1111",
+				SourceAccountID: "2222",
+				TargetAccountID: "3333",
+				Amount:          money.MustParse(tc.Amount, "EUR"),
+				Currency:        "EUR",
+			})
+
+			assert.True(t, errors.Is(err, tc.ExpectedError))
+			assert.Equal(t, money.MustParse("200.00", "EUR"),
+				mockAccountRepository.Accounts["2222"].Balance, "No balance should move")
+			if tc.ExpectedReason == "" {
+				assert.Empty(t, mockTransactionRepository.Transactions,
+					"No transaction should be stored")
+				return
+			}
+
+			assertFailed(t, mockTransactionRepository, tc.ExpectedReason)
+			failed := mockTransactionRepository.Transactions["1111"]
+			assert.Equal(t, money.MustParse(tc.Amount, "EUR"), failed.Amount)
+			assert.False(t, failed.BookedAt.IsZero())
+
+			// A failed transaction moved no money to take back
+			_, err = service.Reverse(context.Background(), "1111", "")
+			assert.Equal(t, ErrReversingIncomplete, err)
+		})
+	}
+}
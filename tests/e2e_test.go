@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"os"
 	"strings"
 	"testing"
 
@@ -21,6 +22,18 @@ const (
 	BASE_URL = "http://localhost:8080"
 )
 
+// newAPIRequest returns a request authenticated with the API key set by the
+// E2E_API_KEY environment variable, which needs the accounts:write and
+// transactions:write scopes
+func newAPIRequest(method, url string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+os.Getenv("E2E_API_KEY"))
+	return req, nil
+}
+
 func createAccount(amount float64) (string, error) {
 	client := &http.Client{}
 
@@ -28,7 +41,7 @@ func createAccount(amount float64) (string, error) {
 		"balance": ` + fmt.Sprintf("%v", amount) + `,
 		"currency": "EUR"}`
 
-	req, err := http.NewRequest("POST", BASE_URL+"/api/v1/accounts", strings.NewReader(acctBody))
+	req, err := newAPIRequest("POST", BASE_URL+"/api/v1/accounts", strings.NewReader(acctBody))
 	if err != nil {
 		return "", err
 	}
@@ -57,36 +70,14 @@ func createAccount(amount float64) (string, error) {
 	return a.ID, nil
 }
 
-func cleanAccount(id string) error {
-	client := &http.Client{}
-
-	req, err := http.NewRequest("DELETE", BASE_URL+"/api/v1/accounts/"+id, nil)
-	if err != nil {
-		return err
-	}
-
-	req.Close = true
-	req.Header.Add("Connection", "close")
-
-	rsp, err := client.Do(req)
-	if err != nil {
-		return err
-	}
-
-	if rsp.StatusCode != http.StatusOK {
-		err, _ := io.ReadAll(rsp.Body)
-		return errors.New("failed to delete account: " + string(err))
-	}
-
-	rsp.Body.Close()
-
-	return nil
-}
-
-func cleanTransaction(id string) error {
+// retireAccount freezes a test account. Accounts with a balance cannot be
+// closed and transactions cannot be deleted, so the test data is kept but
+// cannot be used anymore.
+func retireAccount(id string) error {
 	client := &http.Client{}
 
-	req, err := http.NewRequest("DELETE", BASE_URL+"/api/v1/transactions/"+id, nil)
+	req, err := newAPIRequest("POST", BASE_URL+"/api/v1/accounts/"+id+"/freeze",
+		strings.NewReader(`{"reason": "end of the e2e test"}`))
 	if err != nil {
 		return err
 	}
@@ -101,7 +92,7 @@ func cleanTransaction(id string) error {
 
 	if rsp.StatusCode != http.StatusOK {
 		err, _ := io.ReadAll(rsp.Body)
-		return errors.New("failed to delete account: " + string(err))
+		return errors.New("failed to freeze account: " + string(err))
 	}
 
 	rsp.Body.Close()
@@ -127,7 +118,7 @@ func TestE2E_TransactionHappyPath(t *testing.T) {
 		"amount": 11.50,
 		"currency": "EUR"}`
 
-	txnReq, err := http.NewRequest("POST", BASE_URL+"/api/v1/transactions",
+	txnReq, err := newAPIRequest("POST", BASE_URL+"/api/v1/transactions",
 		strings.NewReader(txnBody))
 	assert.NoError(t, err)
 
@@ -145,14 +136,13 @@ func TestE2E_TransactionHappyPath(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, txnRsp.StatusCode)
 
-	// Cleanups
-	err = cleanAccount(saID)
-	assert.NoError(t, err)
+	assert.NotEmpty(t, txn.ID)
 
-	err = cleanAccount(taID)
+	// Cleanups
+	err = retireAccount(saID)
 	assert.NoError(t, err)
 
-	err = cleanTransaction(txn.ID)
+	err = retireAccount(taID)
 	assert.NoError(t, err)
 }
 
@@ -174,7 +164,7 @@ func TestE2E_TransactionInsufficientBalance(t *testing.T) {
 		"amount": 11.50,
 		"currency": "EUR"}`
 
-	txnReq, err := http.NewRequest("POST", BASE_URL+"/api/v1/transactions",
+	txnReq, err := newAPIRequest("POST", BASE_URL+"/api/v1/transactions",
 		strings.NewReader(txnBody))
 	assert.NoError(t, err)
 
@@ -189,10 +179,10 @@ func TestE2E_TransactionInsufficientBalance(t *testing.T) {
 	assert.Equal(t, http.StatusConflict, txnRsp.StatusCode)
 
 	// Cleanups
-	err = cleanAccount(saID)
+	err = retireAccount(saID)
 	assert.NoError(t, err)
 
-	err = cleanAccount(taID)
+	err = retireAccount(taID)
 	assert.NoError(t, err)
 }
 
@@ -210,7 +200,7 @@ func TestE2E_TransactionSameAccount(t *testing.T) {
 		"amount": 11.50,
 		"currency": "EUR"}`
 
-	txnReq, err := http.NewRequest("POST", BASE_URL+"/api/v1/transactions",
+	txnReq, err := newAPIRequest("POST", BASE_URL+"/api/v1/transactions",
 		strings.NewReader(txnBody))
 	assert.NoError(t, err)
 
@@ -225,7 +215,7 @@ func TestE2E_TransactionSameAccount(t *testing.T) {
 	assert.Equal(t, http.StatusConflict, txnRsp.StatusCode)
 
 	// Cleanups
-	err = cleanAccount(saID)
+	err = retireAccount(saID)
 	assert.NoError(t, err)
 }
 
@@ -243,7 +233,7 @@ func TestE2E_TransactionAccountNotFound(t *testing.T) {
 		"amount": 11.50,
 		"currency": "EUR"}`
 
-	txnReq, err := http.NewRequest("POST", BASE_URL+"/api/v1/transactions",
+	txnReq, err := newAPIRequest("POST", BASE_URL+"/api/v1/transactions",
 		strings.NewReader(txnBody))
 	assert.NoError(t, err)
 
@@ -258,10 +248,35 @@ func TestE2E_TransactionAccountNotFound(t *testing.T) {
 	assert.Equal(t, http.StatusNotFound, txnRsp.StatusCode)
 
 	// Cleanups
-	err = cleanAccount(saID)
+	err = retireAccount(saID)
 	assert.NoError(t, err)
 }
 
+func TestE2E_MalformedID(t *testing.T) {
+	client := &http.Client{}
+
+	for _, path := range []string{
+		"/api/v1/accounts/foo",
+		"/api/v1/accounts/foo/balance",
+		"/api/v1/accounts/foo/transactions",
+		"/api/v1/accounts/foo/statement?from=2024-01-01T00:00:00Z&to=2024-02-01T00:00:00Z",
+		"/api/v1/transactions/foo",
+	} {
+		req, err := newAPIRequest("GET", BASE_URL+path, nil)
+		assert.NoError(t, err)
+
+		req.Close = true
+		req.Header.Add("Connection", "close")
+
+		resp, err := client.Do(req)
+		assert.NoError(t, err)
+
+		resp.Body.Close()
+
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode, path)
+	}
+}
+
 func TestE2E_TransactionEmptyAccount(t *testing.T) {
 	client := &http.Client{}
 
@@ -276,7 +291,7 @@ func TestE2E_TransactionEmptyAccount(t *testing.T) {
 		"amount": 11.50,
 		"currency": "EUR"}`
 
-	txnReq, err := http.NewRequest("POST", BASE_URL+"/api/v1/transactions",
+	txnReq, err := newAPIRequest("POST", BASE_URL+"/api/v1/transactions",
 		strings.NewReader(txnBody))
 	assert.NoError(t, err)
 
@@ -291,7 +306,7 @@ func TestE2E_TransactionEmptyAccount(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, txnRsp.StatusCode)
 
 	// Cleanups
-	err = cleanAccount(saID)
+	err = retireAccount(saID)
 	assert.NoError(t, err)
 }
 
@@ -313,7 +328,7 @@ func TestE2E_TransactionZeroAmount(t *testing.T) {
 		"amount": 0,
 		"currency": "EUR"}`
 
-	txnReq, err := http.NewRequest("POST", BASE_URL+"/api/v1/transactions",
+	txnReq, err := newAPIRequest("POST", BASE_URL+"/api/v1/transactions",
 		strings.NewReader(txnBody))
 	assert.NoError(t, err)
 
@@ -328,10 +343,50 @@ func TestE2E_TransactionZeroAmount(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, txnRsp.StatusCode)
 
 	// Cleanups
-	err = cleanAccount(saID)
+	err = retireAccount(saID)
 	assert.NoError(t, err)
 
-	err = cleanAccount(taID)
+	err = retireAccount(taID)
+	assert.NoError(t, err)
+}
+
+func TestE2E_TransactionFrozenAccount(t *testing.T) {
+	client := &http.Client{}
+
+	// Create the source account
+	saID, err := createAccount(11.50)
+	assert.NoError(t, err)
+
+	// Create and freeze the target account
+	taID, err := createAccount(10.50)
+	assert.NoError(t, err)
+
+	err = retireAccount(taID)
+	assert.NoError(t, err)
+
+	// Perform a transaction
+	txnBody := `{
+		"source_account_id": "` + saID + `", 
+		"target_account_id": "` + taID + `", 
+		"amount": 1.50,
+		"currency": "EUR"}`
+
+	txnReq, err := newAPIRequest("POST", BASE_URL+"/api/v1/transactions",
+		strings.NewReader(txnBody))
+	assert.NoError(t, err)
+
+	txnReq.Close = true
+	txnReq.Header.Add("Connection", "close")
+
+	txnRsp, err := client.Do(txnReq)
+	assert.NoError(t, err)
+
+	defer txnRsp.Body.Close()
+
+	assert.Equal(t, http.StatusConflict, txnRsp.StatusCode)
+
+	// Cleanups
+	err = retireAccount(saID)
 	assert.NoError(t, err)
 }
 
@@ -351,3 +406,21 @@ func TestE2E_HealthEndpoint(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, resp.StatusCode)
 }
+
+func TestE2E_Unauthorized(t *testing.T) {
+	client := &http.Client{}
+
+	req, err := http.NewRequest("GET", BASE_URL+"/api/v1/accounts", nil)
+	assert.NoError(t, err)
+
+	req.Close = true
+	req.Header.Add("Connection", "close")
+
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, "Bearer", resp.Header.Get("WWW-Authenticate"))
+}